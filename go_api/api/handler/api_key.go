@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type ApiKeyHandler struct {
+	ApiKeyUseCase usecase.ApiKeyUseCase
+}
+
+func NewApiKeyHandler(apiKeyUseCase usecase.ApiKeyUseCase) *ApiKeyHandler {
+	return &ApiKeyHandler{ApiKeyUseCase: apiKeyUseCase}
+}
+
+// CreateApiKey 	Create API key.
+// @Summary		Create API key.
+// @Description	Create an API key for the authenticated user. The key is only shown once.
+// @Tags		API Keys
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		input	body		dto.ApiKeyInputDTO	true	"API key name, scopes and optional expiration"
+// @Success		201		{object}	dto.ApiKeyCreatedOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/me/api-keys [post]
+func (kh *ApiKeyHandler) CreateApiKey(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	kh.createApiKey(w, r, u.OrganizationID, u.ID)
+}
+
+// ListApiKeys 	List API keys.
+// @Summary		List API keys.
+// @Description	List the API keys of the authenticated user.
+// @Tags		API Keys
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Success		200	{array}		dto.ApiKeyOutputDTO
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Failure		401	{object}	dto.ProblemOutputDTO
+// @Router		/me/api-keys [get]
+func (kh *ApiKeyHandler) ListApiKeys(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	kh.listApiKeys(w, r, u.OrganizationID, u.ID)
+}
+
+// RevokeApiKey 	Revoke API key.
+// @Summary		Revoke API key.
+// @Description	Revoke one of the API keys of the authenticated user.
+// @Tags		API Keys
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		keyId	path		int	true	"API key ID"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/me/api-keys/{keyId} [delete]
+func (kh *ApiKeyHandler) RevokeApiKey(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	kh.revokeApiKey(w, r, u.OrganizationID, u.ID)
+}
+
+// CreateUserApiKey 	Create API key for a user.
+// @Summary		Create API key for a user.
+// @Description	Create an API key on behalf of a user. The key is only shown once. Requires admin role.
+// @Tags		API Keys
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int					true	"User ID"
+// @Param		input	body		dto.ApiKeyInputDTO	true	"API key name, scopes and optional expiration"
+// @Success		201		{object}	dto.ApiKeyCreatedOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/api-keys [post]
+func (kh *ApiKeyHandler) CreateUserApiKey(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	kh.createApiKey(w, r, u.OrganizationID, userId)
+}
+
+// ListUserApiKeys 	List API keys of a user.
+// @Summary		List API keys of a user.
+// @Description	List the API keys of a user. Requires admin role.
+// @Tags		API Keys
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int	true	"User ID"
+// @Success		200		{array}		dto.ApiKeyOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/api-keys [get]
+func (kh *ApiKeyHandler) ListUserApiKeys(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	kh.listApiKeys(w, r, u.OrganizationID, userId)
+}
+
+// RevokeUserApiKey 	Revoke API key of a user.
+// @Summary		Revoke API key of a user.
+// @Description	Revoke one of the API keys of a user. Requires admin role.
+// @Tags		API Keys
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int	true	"User ID"
+// @Param		keyId	path		int	true	"API key ID"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/api-keys/{keyId} [delete]
+func (kh *ApiKeyHandler) RevokeUserApiKey(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	kh.revokeApiKey(w, r, u.OrganizationID, userId)
+}
+
+func (kh *ApiKeyHandler) createApiKey(w http.ResponseWriter, r *http.Request, tenantId uint, userId uint) {
+	var input dto.ApiKeyInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	output, err := kh.ApiKeyUseCase.CreateApiKey(r.Context(), tenantId, userId, &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusCreated)
+}
+
+func (kh *ApiKeyHandler) listApiKeys(w http.ResponseWriter, r *http.Request, tenantId uint, userId uint) {
+	output, err := kh.ApiKeyUseCase.ListApiKeys(r.Context(), tenantId, userId)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+func (kh *ApiKeyHandler) revokeApiKey(w http.ResponseWriter, r *http.Request, tenantId uint, userId uint) {
+	keyId, err := strconv.ParseUint(r.PathValue("keyId"), 10, 32)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, pathParamError("keyId"))
+		return
+	}
+
+	err = kh.ApiKeyUseCase.RevokeApiKey(r.Context(), tenantId, userId, uint(keyId))
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "api key revoked", http.StatusOK)
+}
+
+// pathUserId reads the "userId" path value, answering 400 when it is invalid.
+func pathUserId(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	userId, err := strconv.ParseUint(r.PathValue("userId"), 10, 32)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, pathParamError("userId"))
+		return 0, false
+	}
+
+	return uint(userId), true
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockApiKeyUseCase struct {
+	mock.Mock
+}
+
+// CreateApiKey implements usecase.ApiKeyUseCase.
+func (m *mockApiKeyUseCase) CreateApiKey(ctx context.Context, tenantId uint, userId uint, input *dto.ApiKeyInputDTO) (*dto.ApiKeyCreatedOutputDTO, error) {
+	args := m.Called(ctx, tenantId, userId, input)
+	return args.Get(0).(*dto.ApiKeyCreatedOutputDTO), args.Error(1)
+}
+
+// ListApiKeys implements usecase.ApiKeyUseCase.
+func (m *mockApiKeyUseCase) ListApiKeys(ctx context.Context, tenantId uint, userId uint) ([]*dto.ApiKeyOutputDTO, error) {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Get(0).([]*dto.ApiKeyOutputDTO), args.Error(1)
+}
+
+// RevokeApiKey implements usecase.ApiKeyUseCase.
+func (m *mockApiKeyUseCase) RevokeApiKey(ctx context.Context, tenantId uint, userId uint, keyId uint) error {
+	args := m.Called(ctx, tenantId, userId, keyId)
+	return args.Error(0)
+}
+
+// AuthenticateApiKey implements usecase.ApiKeyUseCase.
+func (m *mockApiKeyUseCase) AuthenticateApiKey(ctx context.Context, key string) (*domain.User, []string, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(*domain.User), args.Get(1).([]string), args.Error(2)
+}
+
+func TestCreateApiKey(t *testing.T) {
+
+	mockApiKeyUseCase := new(mockApiKeyUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2, Email: "user1@example.com"}
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockInput      *dto.ApiKeyInputDTO
+		mockReturn     *dto.ApiKeyCreatedOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:      "Success",
+			body:      `{"name": "CI", "scopes": ["users:read"]}`,
+			mockInput: &dto.ApiKeyInputDTO{Name: "CI", Scopes: []string{domain.ScopeUsersRead}},
+			mockReturn: &dto.ApiKeyCreatedOutputDTO{
+				ApiKeyOutputDTO: dto.ApiKeyOutputDTO{ID: 7, Name: "CI", Prefix: "gsk_abcdefgh", Scopes: []string{domain.ScopeUsersRead}},
+				Key:             "gsk_abcdefghijklmnop",
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"name": "CI"`,
+			mockInput:      nil,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid scope",
+			body:           `{"name": "CI", "scopes": ["orders:delete"]}`,
+			mockInput:      &dto.ApiKeyInputDTO{Name: "CI", Scopes: []string{"orders:delete"}},
+			mockReturn:     nil,
+			mockError:      domain.ErrApiKeyScopeInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiKeyUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockApiKeyUseCase.On("CreateApiKey", mock.Anything, user.OrganizationID, user.ID, tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			}
+
+			apiKeyHandler := NewApiKeyHandler(mockApiKeyUseCase)
+			req, err := http.NewRequest(http.MethodPost, "/me/api-keys", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			apiKeyHandler.CreateApiKey(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			if rr.Code == http.StatusCreated {
+				var output dto.ApiKeyCreatedOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.mockReturn, &output)
+			}
+
+			mockApiKeyUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListUserApiKeys(t *testing.T) {
+
+	mockApiKeyUseCase := new(mockApiKeyUseCase)
+	admin := &domain.User{ID: 1, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		userId         string
+		mockUserId     uint
+		mockReturn     []*dto.ApiKeyOutputDTO
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			userId:         "2",
+			mockUserId:     2,
+			mockReturn:     []*dto.ApiKeyOutputDTO{{ID: 7, Name: "CI", Prefix: "gsk_abcdefgh", Scopes: []string{domain.ScopeUsersRead}}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid path parameter userId",
+			userId:         "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiKeyUseCase.ExpectedCalls = nil
+			if tc.mockUserId != 0 {
+				mockApiKeyUseCase.On("ListApiKeys", mock.Anything, admin.OrganizationID, tc.mockUserId).Return(tc.mockReturn, nil)
+			}
+
+			apiKeyHandler := NewApiKeyHandler(mockApiKeyUseCase)
+			req, err := http.NewRequest(http.MethodGet, "/admin/users/"+tc.userId+"/api-keys", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", tc.userId)
+			rr := httptest.NewRecorder()
+			apiKeyHandler.ListUserApiKeys(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			if rr.Code == http.StatusOK {
+				var output []*dto.ApiKeyOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.mockReturn, output)
+			}
+
+			mockApiKeyUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+
+	mockApiKeyUseCase := new(mockApiKeyUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2, Email: "user1@example.com"}
+
+	testCases := []struct {
+		name           string
+		keyId          string
+		mockKeyId      uint
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			keyId:          "7",
+			mockKeyId:      7,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Not found",
+			keyId:          "8",
+			mockKeyId:      8,
+			mockError:      domain.ErrApiKeyNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid key id",
+			keyId:          "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiKeyUseCase.ExpectedCalls = nil
+			if tc.mockKeyId != 0 {
+				mockApiKeyUseCase.On("RevokeApiKey", mock.Anything, user.OrganizationID, user.ID, tc.mockKeyId).Return(tc.mockError)
+			}
+
+			apiKeyHandler := NewApiKeyHandler(mockApiKeyUseCase)
+			req, err := http.NewRequest(http.MethodDelete, "/me/api-keys/"+tc.keyId, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("keyId", tc.keyId)
+			rr := httptest.NewRecorder()
+			apiKeyHandler.RevokeApiKey(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			mockApiKeyUseCase.AssertExpectations(t)
+		})
+	}
+}
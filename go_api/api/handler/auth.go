@@ -1,10 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 
+	"github.com/Daffc/GO-Sales/domain"
 	"github.com/Daffc/GO-Sales/domain/dto"
 	"github.com/Daffc/GO-Sales/internal/util"
 	"github.com/Daffc/GO-Sales/usecase"
@@ -26,22 +27,99 @@ func NewAuthHandler(authUseCase usecase.AuthUseCase) *AuthHandler {
 // @Produce		json
 // @Param		input	body	dto.LoginInputDTO	true	"User credentials"
 // @Success		200	{object}	dto.LoginOutputDTO
-// @Failure		500	{object}	string
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Failure		401	{object}	dto.ProblemOutputDTO
+// @Failure		403	{object}	dto.ProblemOutputDTO	"Account suspended"
+// @Failure		422	{object}	dto.ProblemOutputDTO
+// @Failure		423	{object}	dto.ProblemOutputDTO	"Account temporarily locked"
+// @Failure		429	{object}	dto.ProblemOutputDTO	"Too many login attempts"
+// @Failure		500	{object}	dto.ProblemOutputDTO
 // @Router		/login [post]
 func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var input dto.LoginInputDTO
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := decodeJSON(w, r, &input); err != nil {
 		log.Println(err)
-		util.JSONResponse(w, err.Error(), http.StatusBadRequest)
+		util.ProblemResponse(w, r, err)
 		return
 	}
 
-	output, err := ah.AuthUseCase.Login(&input)
+	input.IP = util.ClientIP(r)
+	input.UserAgent = r.UserAgent()
+
+	output, err := ah.AuthUseCase.Login(r.Context(), &input)
 	if err != nil {
 		log.Println(err)
-		util.JSONResponse(w, err.Error(), http.StatusBadRequest)
+		util.ProblemResponse(w, r, err)
 		return
 	}
 
 	util.JSONResponse(w, output, http.StatusOK)
 }
+
+// LoginMfa 	Complete two-step login.
+// @Summary		Complete two-step login.
+// @Description	Exchange the MFA challenge returned by /login and a TOTP or recovery code for an access token.
+// @Tags		Auth
+// @Accept		json
+// @Produce		json
+// @Param		input	body		dto.MfaLoginInputDTO	true	"MFA challenge and code"
+// @Success		200		{object}	dto.LoginOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO	"Account suspended"
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Failure		423		{object}	dto.ProblemOutputDTO	"Account temporarily locked"
+// @Failure		429		{object}	dto.ProblemOutputDTO	"Too many login attempts"
+// @Router		/login/mfa [post]
+func (ah *AuthHandler) LoginMfa(w http.ResponseWriter, r *http.Request) {
+	var input dto.MfaLoginInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	input.IP = util.ClientIP(r)
+	input.UserAgent = r.UserAgent()
+
+	output, err := ah.AuthUseCase.LoginMfa(r.Context(), &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// UnlockUser 	Unlock user login.
+// @Summary		Unlock user login.
+// @Description	Clear failed login attempts and lift a temporary lockout. Requires admin role.
+// @Tags		Auth
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int	true	"User ID"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/lockout [delete]
+func (ah *AuthHandler) UnlockUser(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, err := strconv.ParseUint(r.PathValue("userId"), 10, 32)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, pathParamError("userId"))
+		return
+	}
+
+	err = ah.AuthUseCase.UnlockUser(r.Context(), u.OrganizationID, uint(userId))
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "user unlocked", http.StatusOK)
+}
@@ -2,12 +2,14 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/Daffc/GO-Sales/domain"
 	"github.com/Daffc/GO-Sales/domain/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,11 +20,40 @@ type mockAuthUseCase struct {
 }
 
 // CreateUser implements usecase.UserRepository.
-func (m *mockAuthUseCase) Login(input *dto.LoginInputDTO) (*dto.LoginOutputDTO, error) {
-	args := m.Called(input)
+func (m *mockAuthUseCase) Login(ctx context.Context, input *dto.LoginInputDTO) (*dto.LoginOutputDTO, error) {
+	args := m.Called(ctx, input)
 	return args.Get(0).(*dto.LoginOutputDTO), args.Error(1)
 }
 
+// LoginMfa implements usecase.AuthUseCase.
+func (m *mockAuthUseCase) LoginMfa(ctx context.Context, input *dto.MfaLoginInputDTO) (*dto.LoginOutputDTO, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*dto.LoginOutputDTO), args.Error(1)
+}
+
+// LoginExternalUser implements usecase.AuthUseCase.
+func (m *mockAuthUseCase) LoginExternalUser(ctx context.Context, user *domain.User, ip string, userAgent string) (*dto.LoginOutputDTO, error) {
+	args := m.Called(ctx, user, ip, userAgent)
+	return args.Get(0).(*dto.LoginOutputDTO), args.Error(1)
+}
+
+// UnlockUser implements usecase.AuthUseCase.
+func (m *mockAuthUseCase) UnlockUser(ctx context.Context, tenantId uint, userId uint) error {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Error(0)
+}
+
+// CheckUserStatus implements usecase.AuthUseCase.
+func (m *mockAuthUseCase) CheckUserStatus(ctx context.Context, userId uint) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *mockAuthUseCase) CheckImpersonatorStatus(ctx context.Context, actorId uint) error {
+	args := m.Called(ctx, actorId)
+	return args.Error(0)
+}
+
 func TestLogin(t *testing.T) {
 
 	mockAuthUseCase := new(mockAuthUseCase)
@@ -35,13 +66,15 @@ func TestLogin(t *testing.T) {
 		mockError      error
 		expectedStatus int
 		expectedBody   interface{}
+		expectedRetry  string
 	}{
 		{
 			name: "Success",
 			body: `{"email": "user1@example.com", "password": "Password@1"}`,
 			mockInput: &dto.LoginInputDTO{
-				Email:    "user1@example.com",
-				Password: "Password@1",
+				Email:     "user1@example.com",
+				Password:  "Password@1",
+				UserAgent: "Mozilla/5.0",
 			},
 			mockReturn: &dto.LoginOutputDTO{
 				ID:    1,
@@ -65,20 +98,62 @@ func TestLogin(t *testing.T) {
 			mockReturn:     nil,
 			mockError:      nil,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "unexpected EOF",
+			expectedBody:   "invalid request body: unexpected EOF",
 		},
 		{
 			name: "Wrong Credentials",
 			body: `{"email": "user1@example.com", "password": "Password@1"}`,
 			mockInput: &dto.LoginInputDTO{
-				Email:    "user1@example.com",
-				Password: "Password@1",
+				Email:     "user1@example.com",
+				Password:  "Password@1",
+				UserAgent: "Mozilla/5.0",
 			},
 			mockReturn:     nil,
-			mockError:      errors.New("wrong credentials"),
-			expectedStatus: http.StatusBadRequest,
+			mockError:      domain.ErrUserCredentialsInvalid,
+			expectedStatus: http.StatusUnauthorized,
 			expectedBody:   "wrong credentials",
 		},
+		{
+			name: "Account Locked",
+			body: `{"email": "user1@example.com", "password": "Password@1"}`,
+			mockInput: &dto.LoginInputDTO{
+				Email:     "user1@example.com",
+				Password:  "Password@1",
+				UserAgent: "Mozilla/5.0",
+			},
+			mockReturn:     nil,
+			mockError:      &domain.LoginBlockedError{Reason: domain.ErrAccountLocked, RetryAfter: 90 * time.Second},
+			expectedStatus: http.StatusLocked,
+			expectedBody:   domain.ErrAccountLocked.Error(),
+			expectedRetry:  "90",
+		},
+		{
+			name: "Too Many Attempts",
+			body: `{"email": "user1@example.com", "password": "Password@1"}`,
+			mockInput: &dto.LoginInputDTO{
+				Email:     "user1@example.com",
+				Password:  "Password@1",
+				UserAgent: "Mozilla/5.0",
+			},
+			mockReturn:     nil,
+			mockError:      &domain.LoginBlockedError{Reason: domain.ErrTooManyLoginAttempts, RetryAfter: 1500 * time.Millisecond},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   domain.ErrTooManyLoginAttempts.Error(),
+			expectedRetry:  "2",
+		},
+		{
+			name: "Suspended",
+			body: `{"email": "user1@example.com", "password": "Password@1"}`,
+			mockInput: &dto.LoginInputDTO{
+				Email:     "user1@example.com",
+				Password:  "Password@1",
+				UserAgent: "Mozilla/5.0",
+			},
+			mockReturn:     nil,
+			mockError:      domain.ErrUserSuspended,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   domain.ErrUserSuspended.Error(),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -88,7 +163,7 @@ func TestLogin(t *testing.T) {
 
 			// Set up the mock only if mockInput is not nil (error before calling mocked function)
 			if tc.mockInput != nil {
-				mockAuthUseCase.On("Login", tc.mockInput).Return(tc.mockReturn, tc.mockError)
+				mockAuthUseCase.On("Login", mock.Anything, tc.mockInput).Return(tc.mockReturn, tc.mockError)
 			}
 
 			authHandler := NewAuthHandler(mockAuthUseCase)
@@ -96,10 +171,12 @@ func TestLogin(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
+			req.Header.Set("User-Agent", "Mozilla/5.0")
 			rr := httptest.NewRecorder()
 			authHandler.Login(rr, req)
 
 			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+			assert.Equal(t, tc.expectedRetry, rr.Header().Get("Retry-After"), "Expected Retry-After header to match.")
 
 			switch rr.Code {
 			case http.StatusOK:
@@ -110,14 +187,14 @@ func TestLogin(t *testing.T) {
 				}
 
 				assert.Equal(t, tc.expectedBody, lu)
-			case http.StatusBadRequest:
-				var r string
+			case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusLocked, http.StatusTooManyRequests:
+				var r dto.ProblemOutputDTO
 				err := json.NewDecoder(rr.Body).Decode(&r)
 				if err != nil {
 					t.Fatal(err)
 				}
 
-				assert.Equal(t, tc.expectedBody, r)
+				assert.Equal(t, tc.expectedBody, r.Detail)
 			default:
 				t.Fatalf("Unexpected status code: %d", rr.Code)
 			}
@@ -127,3 +204,154 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestUnlockUser(t *testing.T) {
+
+	mockAuthUseCase := new(mockAuthUseCase)
+
+	admin := &domain.User{ID: 1, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		userId         string
+		mockInput      uint
+		mockError      error
+		expectMock     bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Success",
+			userId:         "2",
+			mockInput:      2,
+			mockError:      nil,
+			expectMock:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "user unlocked",
+		},
+		{
+			name:           "invalid path parameter userId",
+			userId:         "X",
+			expectMock:     false,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid path parameter userId",
+		},
+		{
+			name:           "User not found",
+			userId:         "100",
+			mockInput:      100,
+			mockError:      domain.ErrUserNotFound,
+			expectMock:     true,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "user not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAuthUseCase.ExpectedCalls = nil
+			if tc.expectMock {
+				mockAuthUseCase.On("UnlockUser", mock.Anything, admin.OrganizationID, tc.mockInput).Return(tc.mockError)
+			}
+
+			authHandler := NewAuthHandler(mockAuthUseCase)
+			req, err := http.NewRequest(http.MethodDelete, "/admin/users/"+tc.userId+"/lockout", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", tc.userId)
+			rr := httptest.NewRecorder()
+			authHandler.UnlockUser(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			assert.Equal(t, tc.expectedBody, responseMessage(t, rr))
+
+			mockAuthUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoginMfa(t *testing.T) {
+
+	mockAuthUseCase := new(mockAuthUseCase)
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockInput      *dto.MfaLoginInputDTO
+		mockReturn     *dto.LoginOutputDTO
+		mockError      error
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:           "Success",
+			body:           `{"mfa_token": "challenge", "code": "123456"}`,
+			mockInput:      &dto.MfaLoginInputDTO{MfaToken: "challenge", Code: "123456"},
+			mockReturn:     &dto.LoginOutputDTO{ID: 1, Name: "User1", Email: "user1@example.com", Token: "token"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &dto.LoginOutputDTO{ID: 1, Name: "User1", Email: "user1@example.com", Token: "token"},
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"mfa_token": "challenge"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid request body: unexpected EOF",
+		},
+		{
+			name:           "Wrong code",
+			body:           `{"mfa_token": "challenge", "code": "000000"}`,
+			mockInput:      &dto.MfaLoginInputDTO{MfaToken: "challenge", Code: "000000"},
+			mockError:      domain.ErrMfaCodeInvalid,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   domain.ErrMfaCodeInvalid.Error(),
+		},
+		{
+			name:           "Account Locked",
+			body:           `{"mfa_token": "challenge", "recovery_code": "abcd-efgh-ijkl-mnop"}`,
+			mockInput:      &dto.MfaLoginInputDTO{MfaToken: "challenge", RecoveryCode: "abcd-efgh-ijkl-mnop"},
+			mockError:      &domain.LoginBlockedError{Reason: domain.ErrAccountLocked, RetryAfter: time.Minute},
+			expectedStatus: http.StatusLocked,
+			expectedBody:   domain.ErrAccountLocked.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAuthUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockAuthUseCase.On("LoginMfa", mock.Anything, tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			}
+
+			authHandler := NewAuthHandler(mockAuthUseCase)
+			req, err := http.NewRequest(http.MethodPost, "/login/mfa", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			authHandler.LoginMfa(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			switch rr.Code {
+			case http.StatusOK:
+				lu := &dto.LoginOutputDTO{}
+				err := json.NewDecoder(rr.Body).Decode(lu)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.expectedBody, lu)
+			default:
+				var r dto.ProblemOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.expectedBody, r.Detail)
+			}
+
+			mockAuthUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type EmailChangeHandler struct {
+	EmailChangeUseCase usecase.EmailChangeUseCase
+}
+
+func NewEmailChangeHandler(emailChangeUseCase usecase.EmailChangeUseCase) *EmailChangeHandler {
+	return &EmailChangeHandler{EmailChangeUseCase: emailChangeUseCase}
+}
+
+// ConfirmEmailChange 	Confirm a new email address.
+// @Summary		Confirm a new email address.
+// @Description	Replace the email of the account with the address the token was sent to.
+// @Tags		Users
+// @Produce		json
+// @Param		token	query		string	true	"Email change token"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		409		{object}	dto.ProblemOutputDTO
+// @Router		/email-change/confirm [get]
+func (eh *EmailChangeHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	err := eh.EmailChangeUseCase.ConfirmEmailChange(r.Context(), token)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "email changed", http.StatusOK)
+}
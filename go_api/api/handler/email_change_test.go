@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockEmailChangeUseCase struct {
+	mock.Mock
+}
+
+// RequestEmailChange implements usecase.EmailChangeUseCase.
+func (m *mockEmailChangeUseCase) CheckNewEmail(ctx context.Context, newEmail string) (string, error) {
+	args := m.Called(ctx, newEmail)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockEmailChangeUseCase) RequestEmailChange(ctx context.Context, user *domain.User, newEmail string) error {
+	args := m.Called(ctx, user, newEmail)
+	return args.Error(0)
+}
+
+// ConfirmEmailChange implements usecase.EmailChangeUseCase.
+func (m *mockEmailChangeUseCase) ConfirmEmailChange(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+
+	mockEmailChangeUseCase := new(mockEmailChangeUseCase)
+
+	testCases := []struct {
+		name           string
+		url            string
+		mockInput      string
+		mockError      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Success",
+			url:            "/email-change/confirm?token=valid-token",
+			mockInput:      "valid-token",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "email changed",
+		},
+		{
+			name:           "Invalid token",
+			url:            "/email-change/confirm?token=invalid-token",
+			mockInput:      "invalid-token",
+			mockError:      domain.ErrEmailChangeTokenInvalid,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   domain.ErrEmailChangeTokenInvalid.Error(),
+		},
+		{
+			name:           "Email taken",
+			url:            "/email-change/confirm?token=valid-token",
+			mockInput:      "valid-token",
+			mockError:      domain.ErrUserEmailTaken,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   domain.ErrUserEmailTaken.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEmailChangeUseCase.ExpectedCalls = nil
+			mockEmailChangeUseCase.On("ConfirmEmailChange", mock.Anything, tc.mockInput).Return(tc.mockError)
+
+			emailChangeHandler := NewEmailChangeHandler(mockEmailChangeUseCase)
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			emailChangeHandler.ConfirmEmailChange(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			assert.Equal(t, tc.expectedBody, responseMessage(t, rr), "Expected message to match")
+
+			mockEmailChangeUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type EmailVerificationHandler struct {
+	EmailVerificationUseCase usecase.EmailVerificationUseCase
+}
+
+func NewEmailVerificationHandler(emailVerificationUseCase usecase.EmailVerificationUseCase) *EmailVerificationHandler {
+	return &EmailVerificationHandler{EmailVerificationUseCase: emailVerificationUseCase}
+}
+
+// VerifyEmail 	Confirm user email address.
+// @Summary		Confirm user email address.
+// @Description	Confirm user email address using the token sent by email on signup.
+// @Tags		Auth
+// @Produce		json
+// @Param		token	query		string	true	"Verification token"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Router		/verify-email [get]
+func (eh *EmailVerificationHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	err := eh.EmailVerificationUseCase.VerifyEmail(r.Context(), token)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "email verified", http.StatusOK)
+}
+
+// ResendVerificationEmail 	Resend email verification.
+// @Summary		Resend email verification.
+// @Description	Send a new verification link to the given address if it belongs to an unverified account.
+// @Tags		Auth
+// @Accept		json
+// @Produce		json
+// @Param		input	body		dto.ResendVerificationEmailInputDTO	true	"Account email"
+// @Success		202		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/verify-email/resend [post]
+func (eh *EmailVerificationHandler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	var input dto.ResendVerificationEmailInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	err := eh.EmailVerificationUseCase.ResendVerificationEmail(r.Context(), &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "if the account exists and is not verified, a new verification email was sent", http.StatusAccepted)
+}
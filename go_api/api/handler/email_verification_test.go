@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockEmailVerificationUseCase struct {
+	mock.Mock
+}
+
+// SendVerificationEmail implements usecase.EmailVerificationUseCase.
+func (m *mockEmailVerificationUseCase) SendVerificationEmail(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+// VerifyEmail implements usecase.EmailVerificationUseCase.
+func (m *mockEmailVerificationUseCase) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+// ResendVerificationEmail implements usecase.EmailVerificationUseCase.
+func (m *mockEmailVerificationUseCase) ResendVerificationEmail(ctx context.Context, input *dto.ResendVerificationEmailInputDTO) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+func TestVerifyEmail(t *testing.T) {
+
+	mockEmailVerificationUseCase := new(mockEmailVerificationUseCase)
+
+	testCases := []struct {
+		name           string
+		url            string
+		mockInput      string
+		mockError      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Success",
+			url:            "/verify-email?token=valid-token",
+			mockInput:      "valid-token",
+			mockError:      nil,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "email verified",
+		},
+		{
+			name:           "Invalid token",
+			url:            "/verify-email?token=invalid-token",
+			mockInput:      "invalid-token",
+			mockError:      domain.ErrEmailVerificationTokenInvalid,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   domain.ErrEmailVerificationTokenInvalid.Error(),
+		},
+		{
+			name:           "Missing token",
+			url:            "/verify-email",
+			mockInput:      "",
+			mockError:      domain.ErrEmailVerificationTokenInvalid,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   domain.ErrEmailVerificationTokenInvalid.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEmailVerificationUseCase.ExpectedCalls = nil
+			mockEmailVerificationUseCase.On("VerifyEmail", mock.Anything, tc.mockInput).Return(tc.mockError)
+
+			emailVerificationHandler := NewEmailVerificationHandler(mockEmailVerificationUseCase)
+
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			emailVerificationHandler.VerifyEmail(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			assert.Equal(t, tc.expectedBody, responseMessage(t, rr), "Expected message to match")
+
+			mockEmailVerificationUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+
+	mockEmailVerificationUseCase := new(mockEmailVerificationUseCase)
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockInput      *dto.ResendVerificationEmailInputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			requestBody:    `{"email": "user1@example.com"}`,
+			mockInput:      &dto.ResendVerificationEmailInputDTO{Email: "user1@example.com"},
+			mockError:      nil,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Invalid JSON",
+			requestBody:    `{"email": "user1@example.com"`,
+			mockInput:      nil,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Use case error",
+			requestBody:    `{"email": "user1@example.com"}`,
+			mockInput:      &dto.ResendVerificationEmailInputDTO{Email: "user1@example.com"},
+			mockError:      assert.AnError,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEmailVerificationUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockEmailVerificationUseCase.On("ResendVerificationEmail", mock.Anything, tc.mockInput).Return(tc.mockError)
+			}
+
+			emailVerificationHandler := NewEmailVerificationHandler(mockEmailVerificationUseCase)
+
+			req, err := http.NewRequest(http.MethodPost, "/verify-email/resend", bytes.NewBufferString(tc.requestBody))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			emailVerificationHandler.ResendVerificationEmail(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+
+			mockEmailVerificationUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/Daffc/GO-Sales/domain"
+)
+
+// requestBodyError reports a request body that could not be decoded.
+func requestBodyError(err error) error {
+	return fmt.Errorf("%w: %v", domain.ErrRequestBodyInvalid, err)
+}
+
+// pathParamError reports an invalid path parameter by name.
+func pathParamError(name string) error {
+	return fmt.Errorf("%w %s", domain.ErrPathParamInvalid, name)
+}
+
+// queryParamError reports an invalid query parameter by name.
+func queryParamError(name string) error {
+	return fmt.Errorf("%w %s", domain.ErrQueryParamInvalid, name)
+}
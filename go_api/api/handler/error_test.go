@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+// responseMessage decodes the message of rr, the plain JSON string of a
+// success or the detail of a problem.
+func responseMessage(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	if rr.Code < 400 {
+		var message string
+		if err := json.NewDecoder(rr.Body).Decode(&message); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return message
+	}
+
+	var p dto.ProblemOutputDTO
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return p.Detail
+}
+
+func TestRequestErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		err         error
+		expectedErr error
+		expectedMsg string
+	}{
+		{
+			name:        "Request body",
+			err:         requestBodyError(errors.New("unexpected EOF")),
+			expectedErr: domain.ErrRequestBodyInvalid,
+			expectedMsg: "invalid request body: unexpected EOF",
+		},
+		{
+			name:        "Path parameter",
+			err:         pathParamError("userId"),
+			expectedErr: domain.ErrPathParamInvalid,
+			expectedMsg: "invalid path parameter userId",
+		},
+		{
+			name:        "Query parameter",
+			err:         queryParamError("limit"),
+			expectedErr: domain.ErrQueryParamInvalid,
+			expectedMsg: "invalid query parameter limit",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, tc.err, tc.expectedErr)
+			assert.Equal(t, tc.expectedMsg, tc.err.Error())
+			assert.Equal(t, domain.ErrorKindValidation, domain.ErrorOf(tc.err).Kind)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type ImpersonationHandler struct {
+	ImpersonationUseCase usecase.ImpersonationUseCase
+}
+
+func NewImpersonationHandler(impersonationUseCase usecase.ImpersonationUseCase) *ImpersonationHandler {
+	return &ImpersonationHandler{ImpersonationUseCase: impersonationUseCase}
+}
+
+// Impersonate 	Impersonate user.
+// @Summary		Impersonate user.
+// @Description	Issue a short-lived token to act as a user without their password. Destructive operations are refused with it and every request is logged with both identities. Requires admin role.
+// @Tags		Auth
+// @Produce		json
+// @Security	BearerAuth
+// @Param		userId	path		int	true	"User ID"
+// @Success		200		{object}	dto.ImpersonationOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/impersonate [post]
+func (ih *ImpersonationHandler) Impersonate(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	output, err := ih.ImpersonationUseCase.Impersonate(r.Context(), u, userId, util.ClientIP(r), r.UserAgent())
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
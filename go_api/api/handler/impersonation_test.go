@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockImpersonationUseCase struct {
+	mock.Mock
+}
+
+// Impersonate implements usecase.ImpersonationUseCase.
+func (m *mockImpersonationUseCase) Impersonate(ctx context.Context, actor *domain.User, userId uint, ip string, userAgent string) (*dto.ImpersonationOutputDTO, error) {
+	args := m.Called(ctx, actor, userId, ip, userAgent)
+	return args.Get(0).(*dto.ImpersonationOutputDTO), args.Error(1)
+}
+
+func TestImpersonate(t *testing.T) {
+
+	mockImpersonationUseCase := new(mockImpersonationUseCase)
+	admin := &domain.User{ID: 2, Email: "admin@example.com", Role: domain.RoleAdmin}
+	expiresAt := time.Date(2024, time.January, 1, 12, 15, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		userId         string
+		mockUserId     uint
+		mockReturn     *dto.ImpersonationOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			userId:         "1",
+			mockUserId:     1,
+			mockReturn:     &dto.ImpersonationOutputDTO{ID: 1, Name: "User1", Email: "user1@example.com", ActorID: admin.ID, Token: "token", ExpiresAt: expiresAt},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Admin target",
+			userId:         "3",
+			mockUserId:     3,
+			mockReturn:     nil,
+			mockError:      domain.ErrImpersonationAdmin,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "invalid path parameter userId",
+			userId:         "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockImpersonationUseCase.ExpectedCalls = nil
+			if tc.mockUserId != 0 {
+				mockImpersonationUseCase.On("Impersonate", mock.Anything, admin, tc.mockUserId, "192.0.2.1", "Mozilla/5.0").Return(tc.mockReturn, tc.mockError)
+			}
+
+			impersonationHandler := NewImpersonationHandler(mockImpersonationUseCase)
+			req, err := http.NewRequest(http.MethodPost, "/admin/users/"+tc.userId+"/impersonate", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", tc.userId)
+			req.RemoteAddr = "192.0.2.1:51234"
+			req.Header.Set("User-Agent", "Mozilla/5.0")
+			rr := httptest.NewRecorder()
+			impersonationHandler.Impersonate(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			if rr.Code == http.StatusOK {
+				var output dto.ImpersonationOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.mockReturn, &output)
+			}
+
+			mockImpersonationUseCase.AssertExpectations(t)
+		})
+	}
+}
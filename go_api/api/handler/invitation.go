@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type InvitationHandler struct {
+	InvitationUseCase usecase.InvitationUseCase
+}
+
+func NewInvitationHandler(invitationUseCase usecase.InvitationUseCase) *InvitationHandler {
+	return &InvitationHandler{InvitationUseCase: invitationUseCase}
+}
+
+// CreateInvitation 	Invite a user.
+// @Summary		Invite a user.
+// @Description	Invite a person by email into the organization of the authenticated admin, with a preassigned role. An expiring invitation link is mailed to them. Requires admin role.
+// @Tags		Invitations
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		input	body		dto.InvitationInputDTO	true	"Invitee email and role"
+// @Success		201		{object}	dto.InvitationOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		409		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/admin/invitations [post]
+func (ih *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	var input dto.InvitationInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	output, err := ih.InvitationUseCase.CreateInvitation(r.Context(), u, &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusCreated)
+}
+
+// ListInvitations 	List pending invitations.
+// @Summary		List pending invitations.
+// @Description	List the invitations of the organization that were neither accepted nor expired. Requires admin role.
+// @Tags		Invitations
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Success		200		{array}		dto.InvitationOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Router		/admin/invitations [get]
+func (ih *InvitationHandler) ListInvitations(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	output, err := ih.InvitationUseCase.ListInvitations(r.Context(), u.OrganizationID)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// RevokeInvitation 	Revoke invitation.
+// @Summary		Revoke invitation.
+// @Description	Revoke a pending invitation, its link stops working. Requires admin role.
+// @Tags		Invitations
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		invitationId	path		int	true	"Invitation ID"
+// @Success		200				{object}	string
+// @Failure		400				{object}	dto.ProblemOutputDTO
+// @Failure		401				{object}	dto.ProblemOutputDTO
+// @Failure		403				{object}	dto.ProblemOutputDTO
+// @Failure		404				{object}	dto.ProblemOutputDTO
+// @Router		/admin/invitations/{invitationId} [delete]
+func (ih *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	invitationId, err := strconv.ParseUint(r.PathValue("invitationId"), 10, 32)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, pathParamError("invitationId"))
+		return
+	}
+
+	err = ih.InvitationUseCase.RevokeInvitation(r.Context(), u.OrganizationID, uint(invitationId))
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "invitation revoked", http.StatusOK)
+}
+
+// AcceptInvitation 	Accept invitation.
+// @Summary		Accept invitation.
+// @Description	Create the invited account with the token of the invitation link, and the name and password chosen by the invitee.
+// @Tags		Invitations
+// @Accept		json
+// @Produce		json
+// @Param		input	body		dto.AcceptInvitationInputDTO	true	"Invitation token, name and password"
+// @Success		201		{object}	dto.UserOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/invitations/accept [post]
+func (ih *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var input dto.AcceptInvitationInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	output, err := ih.InvitationUseCase.AcceptInvitation(r.Context(), &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusCreated)
+}
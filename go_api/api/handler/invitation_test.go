@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockInvitationUseCase struct {
+	mock.Mock
+}
+
+// CreateInvitation implements usecase.InvitationUseCase.
+func (m *mockInvitationUseCase) CreateInvitation(ctx context.Context, inviter *domain.User, input *dto.InvitationInputDTO) (*dto.InvitationOutputDTO, error) {
+	args := m.Called(ctx, inviter, input)
+	return args.Get(0).(*dto.InvitationOutputDTO), args.Error(1)
+}
+
+// ListInvitations implements usecase.InvitationUseCase.
+func (m *mockInvitationUseCase) ListInvitations(ctx context.Context, tenantId uint) ([]*dto.InvitationOutputDTO, error) {
+	args := m.Called(ctx, tenantId)
+	return args.Get(0).([]*dto.InvitationOutputDTO), args.Error(1)
+}
+
+// RevokeInvitation implements usecase.InvitationUseCase.
+func (m *mockInvitationUseCase) RevokeInvitation(ctx context.Context, tenantId uint, invitationId uint) error {
+	args := m.Called(ctx, tenantId, invitationId)
+	return args.Error(0)
+}
+
+// AcceptInvitation implements usecase.InvitationUseCase.
+func (m *mockInvitationUseCase) AcceptInvitation(ctx context.Context, input *dto.AcceptInvitationInputDTO) (*dto.UserOutputDTO, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*dto.UserOutputDTO), args.Error(1)
+}
+
+func TestCreateInvitation(t *testing.T) {
+
+	mockInvitationUseCase := new(mockInvitationUseCase)
+	admin := &domain.User{ID: 1, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockInput      *dto.InvitationInputDTO
+		mockReturn     *dto.InvitationOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			body:           `{"email": "user1@example.com", "role": "admin"}`,
+			mockInput:      &dto.InvitationInputDTO{Email: "user1@example.com", Role: domain.RoleAdmin},
+			mockReturn:     &dto.InvitationOutputDTO{ID: 7, Email: "user1@example.com", Role: domain.RoleAdmin, InvitedByID: 1},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"email": "user1@example.com"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Email taken",
+			body:           `{"email": "user1@example.com", "role": "user"}`,
+			mockInput:      &dto.InvitationInputDTO{Email: "user1@example.com", Role: domain.RoleUser},
+			mockError:      domain.ErrUserEmailTaken,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockInvitationUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockInvitationUseCase.On("CreateInvitation", mock.Anything, admin, tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			}
+
+			invitationHandler := NewInvitationHandler(mockInvitationUseCase)
+			req, err := http.NewRequest(http.MethodPost, "/admin/invitations", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			invitationHandler.CreateInvitation(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			if rr.Code == http.StatusCreated {
+				var output dto.InvitationOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.mockReturn, &output)
+			}
+
+			mockInvitationUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeInvitation(t *testing.T) {
+
+	mockInvitationUseCase := new(mockInvitationUseCase)
+	admin := &domain.User{ID: 1, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name             string
+		invitationId     string
+		mockInvitationId uint
+		mockError        error
+		expectedStatus   int
+	}{
+		{
+			name:             "Success",
+			invitationId:     "7",
+			mockInvitationId: 7,
+			expectedStatus:   http.StatusOK,
+		},
+		{
+			name:             "Not found",
+			invitationId:     "8",
+			mockInvitationId: 8,
+			mockError:        domain.ErrInvitationNotFound,
+			expectedStatus:   http.StatusNotFound,
+		},
+		{
+			name:           "Invalid invitation id",
+			invitationId:   "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockInvitationUseCase.ExpectedCalls = nil
+			if tc.mockInvitationId != 0 {
+				mockInvitationUseCase.On("RevokeInvitation", mock.Anything, admin.OrganizationID, tc.mockInvitationId).Return(tc.mockError)
+			}
+
+			invitationHandler := NewInvitationHandler(mockInvitationUseCase)
+			req, err := http.NewRequest(http.MethodDelete, "/admin/invitations/"+tc.invitationId, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("invitationId", tc.invitationId)
+			rr := httptest.NewRecorder()
+			invitationHandler.RevokeInvitation(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			mockInvitationUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+
+	mockInvitationUseCase := new(mockInvitationUseCase)
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockInput      *dto.AcceptInvitationInputDTO
+		mockReturn     *dto.UserOutputDTO
+		mockError      error
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:           "Success",
+			body:           `{"token": "token", "name": "User1", "password": "Password@1"}`,
+			mockInput:      &dto.AcceptInvitationInputDTO{Token: "token", Name: "User1", Password: "Password@1"},
+			mockReturn:     &dto.UserOutputDTO{ID: 9, OrganizationID: 2, Name: "User1", Email: "user1@example.com", Role: domain.RoleUser, EmailVerified: true},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   &dto.UserOutputDTO{ID: 9, OrganizationID: 2, Name: "User1", Email: "user1@example.com", Role: domain.RoleUser, EmailVerified: true},
+		},
+		{
+			name:           "Invalid invitation",
+			body:           `{"token": "token", "name": "User1", "password": "Password@1"}`,
+			mockInput:      &dto.AcceptInvitationInputDTO{Token: "token", Name: "User1", Password: "Password@1"},
+			mockError:      domain.ErrInvitationInvalid,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   domain.ErrInvitationInvalid.Error(),
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"token": "token"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid request body: unexpected EOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockInvitationUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockInvitationUseCase.On("AcceptInvitation", mock.Anything, tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			}
+
+			invitationHandler := NewInvitationHandler(mockInvitationUseCase)
+			req, err := http.NewRequest(http.MethodPost, "/invitations/accept", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			invitationHandler.AcceptInvitation(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			switch rr.Code {
+			case http.StatusCreated:
+				var output dto.UserOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.expectedBody, &output, "Expected user to match.")
+			case http.StatusBadRequest:
+				var response dto.ProblemOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&response)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.expectedBody, response.Detail, "Expected error message to match.")
+			}
+
+			mockInvitationUseCase.AssertExpectations(t)
+		})
+	}
+}
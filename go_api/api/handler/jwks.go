@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+type JwksHandler struct {
+	JwtKeySet *util.JwtKeySet
+}
+
+func NewJwksHandler(jwtKeySet *util.JwtKeySet) *JwksHandler {
+	return &JwksHandler{JwtKeySet: jwtKeySet}
+}
+
+// Jwks 		List token verification keys.
+// @Summary		List token verification keys.
+// @Description	Public keys that verify the access tokens issued by this API, as a JSON Web Key Set. Empty while tokens are signed with a shared secret.
+// @Tags		Auth
+// @Produce		json
+// @Success		200	{object}	dto.JwksOutputDTO
+// @Router		/.well-known/jwks.json [get]
+func (jh *JwksHandler) Jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	util.JSONResponse(w, jh.JwtKeySet.JWKS(), http.StatusOK)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJwks(t *testing.T) {
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtKeySet, err := util.NewJwtKeySet(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwksHandler := NewJwksHandler(jwtKeySet)
+	req, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	jwksHandler.Jwks(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Expected status code to match.")
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"), "Expected Cache-Control header to match.")
+
+	var output dto.JwksOutputDTO
+	err = json.NewDecoder(rr.Body).Decode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jwtKeySet.JWKS(), &output, "Expected key set to match.")
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type MfaHandler struct {
+	MfaUseCase usecase.MfaUseCase
+}
+
+func NewMfaHandler(mfaUseCase usecase.MfaUseCase) *MfaHandler {
+	return &MfaHandler{MfaUseCase: mfaUseCase}
+}
+
+// EnrollTotp 	Start TOTP enrollment.
+// @Summary		Start TOTP enrollment.
+// @Description	Generate a TOTP secret for the authenticated user. The QR code is a base64 encoded PNG of the otpauth URI.
+// @Tags		MFA
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200	{object}	dto.TotpEnrollmentOutputDTO
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Failure		401	{object}	dto.ProblemOutputDTO
+// @Router		/me/mfa/totp [post]
+func (mh *MfaHandler) EnrollTotp(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	output, err := mh.MfaUseCase.EnrollTotp(r.Context(), u)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// ConfirmTotp 	Confirm TOTP enrollment.
+// @Summary		Confirm TOTP enrollment.
+// @Description	Enable two-factor authentication with a code from the authenticator app. Recovery codes are only shown once.
+// @Tags		MFA
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		input	body		dto.TotpConfirmInputDTO	true	"Current TOTP code"
+// @Success		200		{object}	dto.RecoveryCodesOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/me/mfa/totp/confirm [post]
+func (mh *MfaHandler) ConfirmTotp(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	var input dto.TotpConfirmInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	output, err := mh.MfaUseCase.ConfirmTotp(r.Context(), u, &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
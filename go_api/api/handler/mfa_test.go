@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockMfaUseCase struct {
+	mock.Mock
+}
+
+// EnrollTotp implements usecase.MfaUseCase.
+func (m *mockMfaUseCase) EnrollTotp(ctx context.Context, user *domain.User) (*dto.TotpEnrollmentOutputDTO, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(*dto.TotpEnrollmentOutputDTO), args.Error(1)
+}
+
+// ConfirmTotp implements usecase.MfaUseCase.
+func (m *mockMfaUseCase) ConfirmTotp(ctx context.Context, user *domain.User, input *dto.TotpConfirmInputDTO) (*dto.RecoveryCodesOutputDTO, error) {
+	args := m.Called(ctx, user, input)
+	return args.Get(0).(*dto.RecoveryCodesOutputDTO), args.Error(1)
+}
+
+// IsEnabled implements usecase.MfaUseCase.
+func (m *mockMfaUseCase) IsEnabled(ctx context.Context, userId uint) (bool, error) {
+	args := m.Called(ctx, userId)
+	return args.Bool(0), args.Error(1)
+}
+
+// Verify implements usecase.MfaUseCase.
+func (m *mockMfaUseCase) Verify(ctx context.Context, userId uint, code string, recoveryCode string) error {
+	args := m.Called(ctx, userId, code, recoveryCode)
+	return args.Error(0)
+}
+
+func TestEnrollTotp(t *testing.T) {
+
+	mockMfaUseCase := new(mockMfaUseCase)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+
+	testCases := []struct {
+		name           string
+		mockReturn     *dto.TotpEnrollmentOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockReturn: &dto.TotpEnrollmentOutputDTO{
+				Secret:     "SECRET",
+				OtpauthURI: "otpauth://totp/GO-Sales:user1@example.com?secret=SECRET",
+				QRCodePNG:  []byte("\x89PNG"),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Already enabled",
+			mockReturn:     nil,
+			mockError:      domain.ErrMfaAlreadyEnabled,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMfaUseCase.ExpectedCalls = nil
+			mockMfaUseCase.On("EnrollTotp", mock.Anything, user).Return(tc.mockReturn, tc.mockError)
+
+			mfaHandler := NewMfaHandler(mockMfaUseCase)
+			req, err := http.NewRequest(http.MethodPost, "/me/mfa/totp", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			mfaHandler.EnrollTotp(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			switch rr.Code {
+			case http.StatusOK:
+				var output dto.TotpEnrollmentOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.mockReturn, &output)
+			default:
+				var r dto.ProblemOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.mockError.Error(), r.Detail)
+			}
+
+			mockMfaUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConfirmTotp(t *testing.T) {
+
+	mockMfaUseCase := new(mockMfaUseCase)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockInput      *dto.TotpConfirmInputDTO
+		mockReturn     *dto.RecoveryCodesOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			body:           `{"code": "123456"}`,
+			mockInput:      &dto.TotpConfirmInputDTO{Code: "123456"},
+			mockReturn:     &dto.RecoveryCodesOutputDTO{RecoveryCodes: []string{"abcd-efgh-ijkl-mnop"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid JSON",
+			body:           `{"code": "123456"`,
+			mockInput:      nil,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Wrong code",
+			body:           `{"code": "000000"}`,
+			mockInput:      &dto.TotpConfirmInputDTO{Code: "000000"},
+			mockReturn:     nil,
+			mockError:      domain.ErrMfaCodeInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMfaUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockMfaUseCase.On("ConfirmTotp", mock.Anything, user, tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			}
+
+			mfaHandler := NewMfaHandler(mockMfaUseCase)
+			req, err := http.NewRequest(http.MethodPost, "/me/mfa/totp/confirm", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			mfaHandler.ConfirmTotp(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			if rr.Code == http.StatusOK {
+				var output dto.RecoveryCodesOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.mockReturn, &output)
+			}
+
+			mockMfaUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+// oidcBindingCookie holds the binding of a login state in the browser that
+// started the flow. Lax lets it through the redirect back from the provider.
+const (
+	oidcBindingCookie     = "oidc_binding"
+	oidcBindingCookiePath = "/login/oidc/callback"
+)
+
+type OidcHandler struct {
+	OidcUseCase usecase.OidcUseCase
+}
+
+func NewOidcHandler(oidcUseCase usecase.OidcUseCase) *OidcHandler {
+	return &OidcHandler{OidcUseCase: oidcUseCase}
+}
+
+// StartLogin 	Login with the identity provider.
+// @Summary		Login with the identity provider.
+// @Description	Redirect to the OpenID Connect provider to start an authorization code flow with PKCE.
+// @Tags		Auth
+// @Success		302
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Router		/login/oidc [get]
+func (oh *OidcHandler) StartLogin(w http.ResponseWriter, r *http.Request) {
+	output, err := oh.OidcUseCase.StartLogin(r.Context())
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	setOidcBindingCookie(w, output)
+	http.Redirect(w, r, output.AuthorizationURL, http.StatusFound)
+}
+
+// Callback 	Finish login with the identity provider.
+// @Summary		Finish login with the identity provider.
+// @Description	Redirect target of the OpenID Connect provider. Answers like /login, including the MFA challenge when enabled. Only the browser holding the cookie set when the flow started can finish it.
+// @Tags		Auth
+// @Produce		json
+// @Param		code	query		string	true	"Authorization code"
+// @Param		state	query		string	true	"State issued by /login/oidc"
+// @Success		200		{object}	dto.LoginOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Router		/login/oidc/callback [get]
+func (oh *OidcHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// A state is only ever good for one callback, whatever its outcome.
+	clearOidcBindingCookie(w)
+
+	// The provider reports refused or failed logins through the error
+	// parameter instead of a code.
+	if providerError := query.Get("error"); len(providerError) > 0 {
+		log.Println(providerError, query.Get("error_description"))
+		util.ProblemResponse(w, r, domain.ErrOidcLoginFailed)
+		return
+	}
+
+	input := dto.OidcCallbackInputDTO{
+		Code:      query.Get("code"),
+		State:     query.Get("state"),
+		IP:        util.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	if c, err := r.Cookie(oidcBindingCookie); err == nil {
+		input.Binding = c.Value
+	}
+
+	output, err := oh.OidcUseCase.Callback(r.Context(), &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// StartLink 	Link an identity provider account.
+// @Summary		Link an identity provider account.
+// @Description	Start an authorization code flow that links the account used at the OpenID Connect provider to the authenticated user.
+// @Tags		Auth
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200	{object}	dto.OidcAuthorizationOutputDTO
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Failure		401	{object}	dto.ProblemOutputDTO
+// @Router		/me/oidc/link [post]
+func (oh *OidcHandler) StartLink(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	output, err := oh.OidcUseCase.StartLink(r.Context(), u)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	setOidcBindingCookie(w, output)
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+func setOidcBindingCookie(w http.ResponseWriter, output *dto.OidcAuthorizationOutputDTO) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcBindingCookie,
+		Value:    output.Binding,
+		Path:     oidcBindingCookiePath,
+		Expires:  output.ExpiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearOidcBindingCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcBindingCookie,
+		Path:     oidcBindingCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
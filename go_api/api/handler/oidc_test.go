@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockOidcUseCase struct {
+	mock.Mock
+}
+
+// StartLogin implements usecase.OidcUseCase.
+func (m *mockOidcUseCase) StartLogin(ctx context.Context) (*dto.OidcAuthorizationOutputDTO, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*dto.OidcAuthorizationOutputDTO), args.Error(1)
+}
+
+// StartLink implements usecase.OidcUseCase.
+func (m *mockOidcUseCase) StartLink(ctx context.Context, user *domain.User) (*dto.OidcAuthorizationOutputDTO, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(*dto.OidcAuthorizationOutputDTO), args.Error(1)
+}
+
+// Callback implements usecase.OidcUseCase.
+func (m *mockOidcUseCase) Callback(ctx context.Context, input *dto.OidcCallbackInputDTO) (*dto.LoginOutputDTO, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*dto.LoginOutputDTO), args.Error(1)
+}
+
+func TestStartOidcLogin(t *testing.T) {
+
+	mockOidcUseCase := new(mockOidcUseCase)
+	expiresAt := time.Now().Add(10 * time.Minute).UTC().Truncate(time.Second)
+	mockOidcUseCase.On("StartLogin", mock.Anything).Return(&dto.OidcAuthorizationOutputDTO{AuthorizationURL: "https://sso.example.com/authorize?state=abc", Binding: "binding", ExpiresAt: expiresAt}, nil)
+
+	oidcHandler := NewOidcHandler(mockOidcUseCase)
+	req, err := http.NewRequest(http.MethodGet, "/login/oidc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	oidcHandler.StartLogin(rr, req)
+
+	assert.Equal(t, http.StatusFound, rr.Code, "Expected status code to match.")
+	assert.Equal(t, "https://sso.example.com/authorize?state=abc", rr.Header().Get("Location"), "Expected redirect location to match.")
+
+	cookies := rr.Result().Cookies()
+	if assert.Len(t, cookies, 1, "Expected the binding cookie to be set") {
+		c := cookies[0]
+		assert.Equal(t, "oidc_binding", c.Name, "Expected cookie name to match.")
+		assert.Equal(t, "binding", c.Value, "Expected the binding to be stored in the browser.")
+		assert.Equal(t, "/login/oidc/callback", c.Path, "Expected the cookie to be sent to the callback only.")
+		assert.True(t, c.Expires.Equal(expiresAt), "Expected the cookie to expire with the state.")
+		assert.True(t, c.HttpOnly, "Expected the cookie to be hidden from scripts.")
+		assert.True(t, c.Secure, "Expected the cookie to be sent over HTTPS only.")
+		assert.Equal(t, http.SameSiteLaxMode, c.SameSite, "Expected the cookie to survive the redirect from the provider.")
+	}
+
+	mockOidcUseCase.AssertExpectations(t)
+}
+
+func TestOidcCallback(t *testing.T) {
+
+	mockOidcUseCase := new(mockOidcUseCase)
+
+	testCases := []struct {
+		name           string
+		query          string
+		cookie         string
+		mockInput      *dto.OidcCallbackInputDTO
+		mockReturn     *dto.LoginOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			query:          "?code=code&state=state",
+			cookie:         "binding",
+			mockInput:      &dto.OidcCallbackInputDTO{Code: "code", State: "state", Binding: "binding"},
+			mockReturn:     &dto.LoginOutputDTO{ID: 1, Name: "User1", Email: "user1@example.com", Token: "token"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Account not linked",
+			query:          "?code=code&state=state",
+			mockInput:      &dto.OidcCallbackInputDTO{Code: "code", State: "state"},
+			mockReturn:     nil,
+			mockError:      domain.ErrOidcAccountNotLinked,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Missing cookie",
+			query:          "?code=code&state=state",
+			mockInput:      &dto.OidcCallbackInputDTO{Code: "code", State: "state"},
+			mockReturn:     nil,
+			mockError:      domain.ErrOidcStateInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Provider error",
+			query:          "?error=access_denied&state=state",
+			mockInput:      nil,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOidcUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockOidcUseCase.On("Callback", mock.Anything, tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			}
+
+			oidcHandler := NewOidcHandler(mockOidcUseCase)
+			req, err := http.NewRequest(http.MethodGet, "/login/oidc/callback"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tc.cookie) > 0 {
+				req.AddCookie(&http.Cookie{Name: "oidc_binding", Value: tc.cookie})
+			}
+			rr := httptest.NewRecorder()
+			oidcHandler.Callback(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			cookies := rr.Result().Cookies()
+			if assert.Len(t, cookies, 1, "Expected the binding cookie to be cleared") {
+				assert.Equal(t, "oidc_binding", cookies[0].Name, "Expected cookie name to match.")
+				assert.Less(t, cookies[0].MaxAge, 0, "Expected the cookie to be removed.")
+			}
+
+			if rr.Code == http.StatusOK {
+				var output dto.LoginOutputDTO
+				err := json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.mockReturn, &output)
+			}
+
+			mockOidcUseCase.AssertExpectations(t)
+		})
+	}
+}
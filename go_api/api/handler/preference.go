@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type PreferenceHandler struct {
+	PreferenceUseCase usecase.PreferenceUseCase
+}
+
+func NewPreferenceHandler(preferenceUseCase usecase.PreferenceUseCase) *PreferenceHandler {
+	return &PreferenceHandler{PreferenceUseCase: preferenceUseCase}
+}
+
+// GetPreferences 	Get own preferences.
+// @Summary		Get own preferences.
+// @Description	Get the display preferences of the authenticated user. Timestamps of authenticated responses are rendered in their timezone.
+// @Tags		Users
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Success		200	{object}	dto.PreferencesOutputDTO
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Failure		401	{object}	dto.ProblemOutputDTO
+// @Router		/me/preferences [get]
+func (ph *PreferenceHandler) GetPreferences(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	output, err := ph.PreferenceUseCase.GetPreferences(r.Context(), u.ID)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// UpdatePreferences 	Update own preferences.
+// @Summary		Update own preferences.
+// @Description	Change the display preferences of the authenticated user: a language tag as locale, an IANA timezone, an ISO 4217 currency and the default page size of listings. Absent fields are left untouched.
+// @Tags		Users
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		input	body		dto.PreferencesInputDTO	true	"Preferences to change"
+// @Success		200		{object}	dto.PreferencesOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Router		/me/preferences [put]
+func (ph *PreferenceHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	var input dto.PreferencesInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	output, err := ph.PreferenceUseCase.UpdatePreferences(r.Context(), u.ID, &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
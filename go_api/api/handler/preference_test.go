@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockPreferenceUseCase struct {
+	mock.Mock
+}
+
+// LoadPreferences implements usecase.PreferenceUseCase.
+func (m *mockPreferenceUseCase) LoadPreferences(ctx context.Context, userId uint) (*domain.UserPreferences, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).(*domain.UserPreferences), args.Error(1)
+}
+
+// GetPreferences implements usecase.PreferenceUseCase.
+func (m *mockPreferenceUseCase) GetPreferences(ctx context.Context, userId uint) (*dto.PreferencesOutputDTO, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).(*dto.PreferencesOutputDTO), args.Error(1)
+}
+
+// UpdatePreferences implements usecase.PreferenceUseCase.
+func (m *mockPreferenceUseCase) UpdatePreferences(ctx context.Context, userId uint, input *dto.PreferencesInputDTO) (*dto.PreferencesOutputDTO, error) {
+	args := m.Called(ctx, userId, input)
+	return args.Get(0).(*dto.PreferencesOutputDTO), args.Error(1)
+}
+
+func TestGetPreferences(t *testing.T) {
+
+	mockPreferenceUseCase := new(mockPreferenceUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2}
+
+	mockPreferenceUseCase.On("GetPreferences", mock.Anything, user.ID).Return(&dto.PreferencesOutputDTO{Locale: "pt-BR", Timezone: "America/Sao_Paulo", Currency: "BRL", PageSize: 50}, nil)
+	preferenceHandler := NewPreferenceHandler(mockPreferenceUseCase)
+
+	req, err := http.NewRequest(http.MethodGet, "/me/preferences", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	preferenceHandler.GetPreferences(rr, req, user)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Expected status code to match")
+	assert.Contains(t, rr.Body.String(), `"timezone":"America/Sao_Paulo"`, "Expected the preferences")
+}
+
+func TestUpdatePreferences(t *testing.T) {
+
+	mockPreferenceUseCase := new(mockPreferenceUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2}
+	timezone := "America/Sao_Paulo"
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedInput  *dto.PreferencesInputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			body:           `{"timezone":"America/Sao_Paulo"}`,
+			expectedInput:  &dto.PreferencesInputDTO{Timezone: &timezone},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid preference",
+			body:           `{"timezone":"America/Sao_Paulo"}`,
+			expectedInput:  &dto.PreferencesInputDTO{Timezone: &timezone},
+			mockError:      domain.ErrPreferenceTimezoneInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid body",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPreferenceUseCase.ExpectedCalls = nil
+			if tc.expectedInput != nil {
+				mockPreferenceUseCase.On("UpdatePreferences", mock.Anything, user.ID, tc.expectedInput).Return(&dto.PreferencesOutputDTO{Timezone: timezone}, tc.mockError)
+			}
+			preferenceHandler := NewPreferenceHandler(mockPreferenceUseCase)
+
+			req, err := http.NewRequest(http.MethodPut, "/me/preferences", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			preferenceHandler.UpdatePreferences(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			mockPreferenceUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type PrivacyHandler struct {
+	PrivacyUseCase usecase.PrivacyUseCase
+}
+
+func NewPrivacyHandler(privacyUseCase usecase.PrivacyUseCase) *PrivacyHandler {
+	return &PrivacyHandler{PrivacyUseCase: privacyUseCase}
+}
+
+// ExportMyData 	Export own data.
+// @Summary		Export own data.
+// @Description	Export everything held about the authenticated user, as a JSON document or as a ZIP bundle with a JSON file per kind of data.
+// @Tags		Privacy
+// @Produce		json
+// @Produce		application/zip
+// @Security	BearerAuth
+// @Param		format	query		string	false	"json (default) or zip"
+// @Success		200		{object}	dto.UserDataExportDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Router		/me/data-export [get]
+func (ph *PrivacyHandler) ExportMyData(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	ph.exportUserData(w, r, u.OrganizationID, u.ID)
+}
+
+// ExportUserData 	Export the data of a user.
+// @Summary		Export the data of a user.
+// @Description	Export everything held about a user of the organization, deleted or erased users included, as a JSON document or as a ZIP bundle. Requires admin role.
+// @Tags		Privacy
+// @Produce		json
+// @Produce		application/zip
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int		true	"User ID"
+// @Param		format	query		string	false	"json (default) or zip"
+// @Success		200		{object}	dto.UserDataExportDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/data-export [get]
+func (ph *PrivacyHandler) ExportUserData(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	ph.exportUserData(w, r, u.OrganizationID, userId)
+}
+
+func (ph *PrivacyHandler) exportUserData(w http.ResponseWriter, r *http.Request, tenantId uint, userId uint) {
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		output, err := ph.PrivacyUseCase.ExportUserData(r.Context(), tenantId, userId)
+		if err != nil {
+			log.Println(err)
+			util.ProblemResponse(w, r, err)
+			return
+		}
+
+		util.JSONResponse(w, output, http.StatusOK)
+	case "zip":
+		// The bundle is built in memory so a failure can still be reported
+		// with a proper status.
+		buf := &bytes.Buffer{}
+		err := ph.PrivacyUseCase.WriteUserDataBundle(r.Context(), tenantId, userId, buf)
+		if err != nil {
+			log.Println(err)
+			util.ProblemResponse(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"user-%d-data.zip\"", userId))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	default:
+		util.ProblemResponse(w, r, queryParamError("format"))
+	}
+}
+
+// EraseUser 	Erase a user.
+// @Summary		Erase a user.
+// @Description	Anonymize a user of the organization in place. Their name, email and password are overwritten, their sessions, keys and linked identities are dropped and every consent still granted is withdrawn. The account is left deleted and cannot be restored. Requires admin role.
+// @Tags		Privacy
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int	true	"User ID"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/erase [post]
+func (ph *PrivacyHandler) EraseUser(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	err := ph.PrivacyUseCase.EraseUser(r.Context(), u.OrganizationID, userId)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "user erased", http.StatusOK)
+}
+
+// ListConsents 	List own consents.
+// @Summary		List own consents.
+// @Description	List the consent history of the authenticated user, oldest first. The latest decision for a purpose is the one in force.
+// @Tags		Privacy
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200	{array}		dto.ConsentOutputDTO
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Failure		401	{object}	dto.ProblemOutputDTO
+// @Router		/me/consents [get]
+func (ph *PrivacyHandler) ListConsents(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	output, err := ph.PrivacyUseCase.ListConsents(r.Context(), u.ID)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// RecordConsent 	Grant or withdraw consent.
+// @Summary		Grant or withdraw consent.
+// @Description	Record a consent decision of the authenticated user for one of the purposes "terms_of_service", "privacy_policy" or "marketing".
+// @Tags		Privacy
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		input	body		dto.ConsentInputDTO	true	"Purpose and decision"
+// @Success		201		{object}	dto.ConsentOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/me/consents [post]
+func (ph *PrivacyHandler) RecordConsent(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	var input dto.ConsentInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	output, err := ph.PrivacyUseCase.RecordConsent(r.Context(), u.ID, &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusCreated)
+}
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type mockPrivacyUseCase struct {
+	mock.Mock
+}
+
+// ExportUserData implements usecase.PrivacyUseCase.
+func (m *mockPrivacyUseCase) ExportUserData(ctx context.Context, tenantId uint, userId uint) (*dto.UserDataExportDTO, error) {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Get(0).(*dto.UserDataExportDTO), args.Error(1)
+}
+
+// WriteUserDataBundle implements usecase.PrivacyUseCase.
+func (m *mockPrivacyUseCase) WriteUserDataBundle(ctx context.Context, tenantId uint, userId uint, w io.Writer) error {
+	args := m.Called(ctx, tenantId, userId, w)
+	if args.Error(0) == nil {
+		w.Write([]byte("PK"))
+	}
+	return args.Error(0)
+}
+
+// EraseUser implements usecase.PrivacyUseCase.
+func (m *mockPrivacyUseCase) EraseUser(ctx context.Context, tenantId uint, userId uint) error {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Error(0)
+}
+
+// RecordConsent implements usecase.PrivacyUseCase.
+func (m *mockPrivacyUseCase) RecordConsent(ctx context.Context, userId uint, input *dto.ConsentInputDTO) (*dto.ConsentOutputDTO, error) {
+	args := m.Called(ctx, userId, input)
+	return args.Get(0).(*dto.ConsentOutputDTO), args.Error(1)
+}
+
+// ListConsents implements usecase.PrivacyUseCase.
+func (m *mockPrivacyUseCase) ListConsents(ctx context.Context, userId uint) ([]*dto.ConsentOutputDTO, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]*dto.ConsentOutputDTO), args.Error(1)
+}
+
+func TestExportMyData(t *testing.T) {
+
+	mockPrivacyUseCase := new(mockPrivacyUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2}
+
+	testCases := []struct {
+		name                string
+		format              string
+		mockError           error
+		expectJSON          bool
+		expectZip           bool
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{
+			name:                "JSON",
+			expectJSON:          true,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "ZIP",
+			format:              "zip",
+			expectZip:           true,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/zip",
+		},
+		{
+			name:                "ZIP error",
+			format:              "zip",
+			mockError:           gorm.ErrInvalidDB,
+			expectZip:           true,
+			expectedStatus:      http.StatusInternalServerError,
+			expectedContentType: util.ProblemContentType,
+		},
+		{
+			name:                "Invalid format",
+			format:              "xml",
+			expectedStatus:      http.StatusBadRequest,
+			expectedContentType: util.ProblemContentType,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPrivacyUseCase.ExpectedCalls = nil
+			if tc.expectJSON {
+				mockPrivacyUseCase.On("ExportUserData", mock.Anything, user.OrganizationID, user.ID).Return(&dto.UserDataExportDTO{User: dto.UserOutputDTO{ID: 1}}, tc.mockError)
+			}
+			if tc.expectZip {
+				mockPrivacyUseCase.On("WriteUserDataBundle", mock.Anything, user.OrganizationID, user.ID, mock.Anything).Return(tc.mockError)
+			}
+			privacyHandler := NewPrivacyHandler(mockPrivacyUseCase)
+
+			req, err := http.NewRequest(http.MethodGet, "/me/data-export?format="+tc.format, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			privacyHandler.ExportMyData(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"), "Expected content type to match")
+			if tc.expectZip && tc.mockError == nil {
+				assert.Equal(t, "attachment; filename=\"user-1-data.zip\"", rr.Header().Get("Content-Disposition"), "Expected the bundle as an attachment")
+				assert.Equal(t, "PK", rr.Body.String(), "Expected the bundle as body")
+			}
+			mockPrivacyUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+
+	mockPrivacyUseCase := new(mockPrivacyUseCase)
+	admin := &domain.User{ID: 3, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		mockReturn     *dto.UserDataExportDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			mockReturn:     &dto.UserDataExportDTO{User: dto.UserOutputDTO{ID: 1}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "User not found",
+			mockReturn:     (*dto.UserDataExportDTO)(nil),
+			mockError:      domain.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPrivacyUseCase.ExpectedCalls = nil
+			mockPrivacyUseCase.On("ExportUserData", mock.Anything, admin.OrganizationID, uint(1)).Return(tc.mockReturn, tc.mockError)
+			privacyHandler := NewPrivacyHandler(mockPrivacyUseCase)
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/users/1/data-export", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", "1")
+			rr := httptest.NewRecorder()
+			privacyHandler.ExportUserData(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			mockPrivacyUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEraseUser(t *testing.T) {
+
+	mockPrivacyUseCase := new(mockPrivacyUseCase)
+	admin := &domain.User{ID: 3, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "User not found",
+			mockError:      domain.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPrivacyUseCase.ExpectedCalls = nil
+			mockPrivacyUseCase.On("EraseUser", mock.Anything, admin.OrganizationID, uint(1)).Return(tc.mockError)
+			privacyHandler := NewPrivacyHandler(mockPrivacyUseCase)
+
+			req, err := http.NewRequest(http.MethodPost, "/admin/users/1/erase", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", "1")
+			rr := httptest.NewRecorder()
+			privacyHandler.EraseUser(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			mockPrivacyUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecordConsent(t *testing.T) {
+
+	mockPrivacyUseCase := new(mockPrivacyUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2}
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockError      error
+		expectCall     bool
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			body:           `{"purpose":"marketing","granted":true}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Invalid purpose",
+			body:           `{"purpose":"profiling","granted":true}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Invalid body",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPrivacyUseCase.ExpectedCalls = nil
+			if tc.expectCall {
+				mockPrivacyUseCase.On("RecordConsent", mock.Anything, user.ID, mock.Anything).Return(&dto.ConsentOutputDTO{ID: 3}, tc.mockError)
+			}
+			privacyHandler := NewPrivacyHandler(mockPrivacyUseCase)
+
+			req, err := http.NewRequest(http.MethodPost, "/me/consents", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			privacyHandler.RecordConsent(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			mockPrivacyUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListConsents(t *testing.T) {
+
+	mockPrivacyUseCase := new(mockPrivacyUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2}
+
+	mockPrivacyUseCase.On("ListConsents", mock.Anything, user.ID).Return([]*dto.ConsentOutputDTO{{ID: 3, Purpose: domain.ConsentPurposeMarketing}}, nil)
+	privacyHandler := NewPrivacyHandler(mockPrivacyUseCase)
+
+	req, err := http.NewRequest(http.MethodGet, "/me/consents", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	privacyHandler.ListConsents(rr, req, user)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Expected status code to match")
+	assert.Contains(t, rr.Body.String(), `"purpose":"marketing"`, "Expected the consent history")
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+)
+
+// requestBodyMaxSize bounds JSON request bodies, no input of the API comes
+// close to it.
+const requestBodyMaxSize = 64 << 10
+
+// validator is implemented by the input DTOs that check their own fields.
+type validator interface {
+	Validate() error
+}
+
+// decodeJSON decodes the body of r into v, refusing bodies over
+// requestBodyMaxSize, unknown fields and anything after the JSON value. v is
+// then validated when it knows how to.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, requestBodyMaxSize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil || !isMaxBytesError(err) {
+			err = errTrailingData
+		}
+		return decodeError(err)
+	}
+
+	if input, ok := v.(validator); ok {
+		return input.Validate()
+	}
+
+	return nil
+}
+
+var errTrailingData = errors.New("unexpected data after the JSON value")
+
+func decodeError(err error) error {
+	if isMaxBytesError(err) {
+		return fmt.Errorf("%w, it must be at most %d bytes", domain.ErrRequestBodyTooLarge, requestBodyMaxSize)
+	}
+
+	return requestBodyError(err)
+}
+
+func isMaxBytesError(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
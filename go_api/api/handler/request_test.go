@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedInput  *dto.LoginInputDTO
+		expectedErr    error
+		expectedErrMsg string
+	}{
+		{
+			name:          "Success",
+			body:          `{"email": "user1@example.com", "password": "Password@1"}` + "\n",
+			expectedInput: &dto.LoginInputDTO{Email: "user1@example.com", Password: "Password@1"},
+		},
+		{
+			name:           "Unknown field",
+			body:           `{"email": "user1@example.com", "password": "Password@1", "role": "admin"}`,
+			expectedErr:    domain.ErrRequestBodyInvalid,
+			expectedErrMsg: `invalid request body: json: unknown field "role"`,
+		},
+		{
+			name:           "Trailing value",
+			body:           `{"email": "user1@example.com", "password": "Password@1"} {"email": "user2@example.com"}`,
+			expectedErr:    domain.ErrRequestBodyInvalid,
+			expectedErrMsg: "invalid request body: unexpected data after the JSON value",
+		},
+		{
+			name:           "Trailing garbage",
+			body:           `{"email": "user1@example.com", "password": "Password@1"}}`,
+			expectedErr:    domain.ErrRequestBodyInvalid,
+			expectedErrMsg: "invalid request body: unexpected data after the JSON value",
+		},
+		{
+			name:           "Too large",
+			body:           `{"email": "` + strings.Repeat("a", requestBodyMaxSize) + `", "password": "Password@1"}`,
+			expectedErr:    domain.ErrRequestBodyTooLarge,
+			expectedErrMsg: "request body too large, it must be at most 65536 bytes",
+		},
+		{
+			name:           "Every field invalid",
+			body:           `{"email": "", "password": " "}`,
+			expectedErr:    domain.ErrFieldRequired,
+			expectedErrMsg: "email: the field is required; password: the field is required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(tc.body))
+			rr := httptest.NewRecorder()
+
+			var input dto.LoginInputDTO
+			err := decodeJSON(rr, req, &input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.EqualError(t, err, tc.expectedErrMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedInput, &input, "Expected input to match")
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Daffc/GO-Sales/api/middleware"
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+type SessionHandler struct {
+	SessionUseCase usecase.SessionUseCase
+}
+
+func NewSessionHandler(sessionUseCase usecase.SessionUseCase) *SessionHandler {
+	return &SessionHandler{SessionUseCase: sessionUseCase}
+}
+
+// ListSessions 	List active sessions.
+// @Summary		List active sessions.
+// @Description	List the devices the authenticated user is logged in from. The session of the request is flagged as current.
+// @Tags		Sessions
+// @Produce		json
+// @Security	BearerAuth
+// @Success		200	{array}		dto.SessionOutputDTO
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Failure		401	{object}	dto.ProblemOutputDTO
+// @Router		/me/sessions [get]
+func (sh *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	currentSessionId, _ := middleware.SessionIdFromContext(r.Context())
+
+	output, err := sh.SessionUseCase.ListSessions(r.Context(), u.ID, currentSessionId)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// RevokeSession 	Revoke session.
+// @Summary		Revoke session.
+// @Description	Log the authenticated user out of one of their sessions. Its access token is refused from then on.
+// @Tags		Sessions
+// @Produce		json
+// @Security	BearerAuth
+// @Param		sessionId	path		int	true	"Session ID"
+// @Success		200			{object}	string
+// @Failure		400			{object}	dto.ProblemOutputDTO
+// @Failure		401			{object}	dto.ProblemOutputDTO
+// @Failure		404			{object}	dto.ProblemOutputDTO
+// @Router		/me/sessions/{sessionId} [delete]
+func (sh *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	sessionId, err := strconv.ParseUint(r.PathValue("sessionId"), 10, 32)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, pathParamError("sessionId"))
+		return
+	}
+
+	err = sh.SessionUseCase.RevokeSession(r.Context(), u.ID, uint(sessionId))
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "session revoked", http.StatusOK)
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/api/middleware"
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockSessionUseCase struct {
+	mock.Mock
+}
+
+// CreateSession implements usecase.SessionUseCase.
+func (m *mockSessionUseCase) CreateSession(ctx context.Context, userId uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error) {
+	args := m.Called(ctx, userId, ip, userAgent, duration)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+// CreateImpersonationSession implements usecase.SessionUseCase.
+func (m *mockSessionUseCase) CreateImpersonationSession(ctx context.Context, userId uint, actorId uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error) {
+	args := m.Called(ctx, userId, actorId, ip, userAgent, duration)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+// ListSessions implements usecase.SessionUseCase.
+func (m *mockSessionUseCase) ListSessions(ctx context.Context, userId uint, currentSessionId uint) ([]*dto.SessionOutputDTO, error) {
+	args := m.Called(ctx, userId, currentSessionId)
+	return args.Get(0).([]*dto.SessionOutputDTO), args.Error(1)
+}
+
+// RevokeSession implements usecase.SessionUseCase.
+func (m *mockSessionUseCase) RevokeSession(ctx context.Context, userId uint, sessionId uint) error {
+	args := m.Called(ctx, userId, sessionId)
+	return args.Error(0)
+}
+
+// RevokeOtherSessions implements usecase.SessionUseCase.
+func (m *mockSessionUseCase) RevokeOtherSessions(ctx context.Context, userId uint, currentSessionId uint) error {
+	args := m.Called(ctx, userId, currentSessionId)
+	return args.Error(0)
+}
+
+func (m *mockSessionUseCase) RevokeImpersonationSessions(ctx context.Context, actorId uint) error {
+	args := m.Called(ctx, actorId)
+	return args.Error(0)
+}
+
+// ValidateSession implements usecase.SessionUseCase.
+func (m *mockSessionUseCase) ValidateSession(ctx context.Context, userId uint, tokenId string) (*domain.Session, error) {
+	args := m.Called(ctx, userId, tokenId)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func TestListSessions(t *testing.T) {
+
+	mockSessionUseCase := new(mockSessionUseCase)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	sessions := []*dto.SessionOutputDTO{
+		{ID: 7, IP: "192.0.2.1", UserAgent: "Mozilla/5.0", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour), Current: true},
+	}
+	mockSessionUseCase.On("ListSessions", mock.Anything, user.ID, uint(7)).Return(sessions, nil)
+
+	sessionHandler := NewSessionHandler(mockSessionUseCase)
+	req, err := http.NewRequest(http.MethodGet, "/me/sessions", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(middleware.ContextWithSessionId(req.Context(), 7))
+	rr := httptest.NewRecorder()
+	sessionHandler.ListSessions(rr, req, user)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Expected status code to match.")
+
+	var output []*dto.SessionOutputDTO
+	err = json.NewDecoder(rr.Body).Decode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sessions, output, "Expected sessions to match.")
+
+	mockSessionUseCase.AssertExpectations(t)
+}
+
+func TestRevokeSession(t *testing.T) {
+
+	mockSessionUseCase := new(mockSessionUseCase)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+
+	testCases := []struct {
+		name           string
+		sessionId      string
+		mockSessionId  uint
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			sessionId:      "7",
+			mockSessionId:  7,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Not found",
+			sessionId:      "8",
+			mockSessionId:  8,
+			mockError:      domain.ErrSessionNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid session id",
+			sessionId:      "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSessionUseCase.ExpectedCalls = nil
+			if tc.mockSessionId != 0 {
+				mockSessionUseCase.On("RevokeSession", mock.Anything, user.ID, tc.mockSessionId).Return(tc.mockError)
+			}
+
+			sessionHandler := NewSessionHandler(mockSessionUseCase)
+			req, err := http.NewRequest(http.MethodDelete, "/me/sessions/"+tc.sessionId, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("sessionId", tc.sessionId)
+			rr := httptest.NewRecorder()
+			sessionHandler.RevokeSession(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match.")
+
+			mockSessionUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
-	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Daffc/GO-Sales/api/middleware"
+	"github.com/Daffc/GO-Sales/domain"
 	"github.com/Daffc/GO-Sales/domain/dto"
 	"github.com/Daffc/GO-Sales/internal/util"
 	"github.com/Daffc/GO-Sales/usecase"
@@ -14,92 +18,461 @@ import (
 
 type UserHandler struct {
 	UserUseCase usecase.UserUseCase
+	// OpenSignup lets anyone create an account through CreateUser. When
+	// disabled, accounts are only created by accepting an invitation.
+	OpenSignup bool
 }
 
-func NewUserHandler(userUseCase usecase.UserUseCase) *UserHandler {
-	return &UserHandler{UserUseCase: userUseCase}
+func NewUserHandler(userUseCase usecase.UserUseCase, openSignup bool) *UserHandler {
+	return &UserHandler{UserUseCase: userUseCase, OpenSignup: openSignup}
 }
 
 // CreateUser 	Create a new user.
 // @Summary		Create a new user.
-// @Description	Create a new user.
+// @Description	Create a new user. Refused when open signup is disabled, accounts are then created through invitations.
 // @Tags		Users
 // @Accept		json
 // @Produce		json
 // @Param		input	body	dto.UserInputDTO true	"User input data"
 // @Success		200
-// @Failure		500	{object}	string
+// @Failure		400	{object}	dto.ProblemOutputDTO
+// @Failure		403	{object}	dto.ProblemOutputDTO
+// @Failure		409	{object}	dto.ProblemOutputDTO
+// @Failure		422	{object}	dto.ProblemOutputDTO
+// @Failure		429	{object}	dto.ProblemOutputDTO	"Too many requests"
+// @Failure		500	{object}	dto.ProblemOutputDTO
 // @Router		/users [post]
 func (uh *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	if !uh.OpenSignup {
+		util.ProblemResponse(w, r, domain.ErrUserOpenSignupDisabled)
+		return
+	}
+
 	var input dto.UserInputDTO
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+	if err := decodeJSON(w, r, &input); err != nil {
 		log.Println(err)
-		util.JSONResponse(w, err.Error(), http.StatusBadRequest)
+		util.ProblemResponse(w, r, err)
 		return
 	}
 
-	output, err := uh.UserUseCase.CreateUser(&input)
+	output, err := uh.UserUseCase.CreateUser(r.Context(), &input)
 	if err != nil {
 		log.Println(err)
-		util.JSONResponse(w, err.Error(), http.StatusBadRequest)
+		util.ProblemResponse(w, r, err)
 		return
 	}
 
 	util.JSONResponse(w, output, http.StatusOK)
 }
 
-// ListUsers 	List all non deleted users.
-// @Summary		List all non deleted users.
-// @Description	List all non deleted users.
+// ListUsers 	List non deleted users.
+// @Summary		List non deleted users.
+// @Description	List a page of the non deleted users of the organization of the authenticated user. Pass the returned next_cursor, or follow the "next" Link header, to get the following page along with the same filters and sort.
 // @Tags		Users
 // @Accept		json
 // @Produce		json
-// @Success		200	{object}	[]dto.UserOutputDTO
-// @Failure		500	{object}	string
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		limit			query		int		false	"Page size, 1 to 100, the page size preference of the user by default"
+// @Param		cursor			query		string	false	"Cursor of the page to get, as returned in next_cursor"
+// @Param		name			query		string	false	"Part of the name"
+// @Param		email			query		string	false	"Exact email"
+// @Param		status			query		string	false	"active or suspended"
+// @Param		created_from	query		string	false	"Created at or after, RFC 3339"
+// @Param		created_to		query		string	false	"Created at or before, RFC 3339"
+// @Param		sort			query		string	false	"id, name, email or created_at, prefixed with - for descending order"	default(id)
+// @Success		200				{object}	dto.UserListOutputDTO
+// @Header		200				{string}	Link	"Link to the next page, absent on the last one"
+// @Failure		400				{object}	dto.ProblemOutputDTO
+// @Failure		401				{object}	dto.ProblemOutputDTO
+// @Failure		403				{object}	dto.ProblemOutputDTO	"API key without the users:read scope"
+// @Failure		429				{object}	dto.ProblemOutputDTO	"Too many requests"
 // @Router		/users [get]
-func (uh *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+func (uh *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	query := r.URL.Query()
+
+	input := dto.ListUsersInputDTO{
+		Cursor: query.Get("cursor"),
+		Name:   query.Get("name"),
+		Email:  query.Get("email"),
+		Status: query.Get("status"),
+		Sort:   query.Get("sort"),
+	}
 
-	output, err := uh.UserUseCase.ListUsers()
+	if limit := query.Get("limit"); len(limit) > 0 {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			util.ProblemResponse(w, r, domain.ErrUserListLimitInvalid)
+			return
+		}
+		input.Limit = n
+	} else if p, ok := middleware.PreferencesFromContext(r.Context()); ok {
+		input.Limit = p.PageSize
+	}
+
+	var err error
+	if input.CreatedFrom, err = queryTime(query, "created_from"); err != nil {
+		util.ProblemResponse(w, r, err)
+		return
+	}
+	if input.CreatedTo, err = queryTime(query, "created_to"); err != nil {
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	output, err := uh.UserUseCase.ListUsers(r.Context(), u.OrganizationID, &input)
 	if err != nil {
 		log.Println(err)
-		util.JSONResponse(w, err.Error(), http.StatusBadRequest)
+		util.ProblemResponse(w, r, err)
 		return
 	}
 
+	if len(output.NextCursor) > 0 {
+		query.Set("cursor", output.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+	}
+
 	util.JSONResponse(w, output, http.StatusOK)
 }
 
+// userImportMaxSize bounds the size of an imported CSV file.
+const userImportMaxSize = 1 << 20
+
+// ImportUsers 	Import users from CSV.
+// @Summary		Import users from CSV.
+// @Description	Create users in the organization from a CSV file with name, email and password columns. Every row is reported as created, skipped when its email is taken or repeated, or failed with the reason. The valid rows are created together or not at all. With dry_run nothing is created and valid rows are reported as such. Requires admin role.
+// @Tags		Users
+// @Accept		multipart/form-data
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		file	formData	file	true	"CSV file, at most 1000 rows"
+// @Param		dry_run	query		bool	false	"Only validate the rows"
+// @Success		200		{object}	dto.UserImportOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		409		{object}	dto.ProblemOutputDTO
+// @Router		/users/import [post]
+func (uh *UserHandler) ImportUsers(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); len(value) > 0 {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			util.ProblemResponse(w, r, queryParamError("dry_run"))
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, userImportMaxSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, fmt.Errorf("%w: a CSV file of at most 1MB is expected in the file field", domain.ErrRequestBodyInvalid))
+		return
+	}
+	defer file.Close()
+
+	output, err := uh.UserUseCase.ImportUsers(r.Context(), u, file, dryRun)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// queryTime parses an optional RFC 3339 query parameter.
+func queryTime(query url.Values, param string) (*time.Time, error) {
+	value := query.Get(param)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, queryParamError(param)
+	}
+
+	return &t, nil
+}
+
 // FindUserById Recover user by userId.
 // @Summary		Recover user by userId.
-// @Description	Recover user by userId.
+// @Description	Recover user by userId, within the organization of the authenticated user.
 // @Tags		Users
 // @Accept		json
 // @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
 // @Param		userId	path		int	true	"User ID"
 // @Success		200		{object}	dto.UserOutputDTO
-// @Failure		500		{object}	string
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO	"API key without the users:read scope"
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Failure		500		{object}	dto.ProblemOutputDTO
 // @Router		/users/{userId} [get]
-func (uh *UserHandler) FindUserById(w http.ResponseWriter, r *http.Request) {
+func (uh *UserHandler) FindUserById(w http.ResponseWriter, r *http.Request, u *domain.User) {
 	// Extract userId directly from the URL path
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) < 2 {
-		util.JSONResponse(w, "Invalid URL path", http.StatusBadRequest)
+		util.ProblemResponse(w, r, pathParamError("userId"))
 		return
 	}
 
 	userId, err := strconv.ParseUint(pathParts[len(pathParts)-1], 10, 32)
 	if err != nil {
 		log.Println(err)
-		util.JSONResponse(w, "Invalid user id", http.StatusBadRequest)
+		util.ProblemResponse(w, r, pathParamError("userId"))
+		return
+	}
+
+	output, err := uh.UserUseCase.FindUserById(r.Context(), u.OrganizationID, uint(userId))
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// UpdateMe 	Update own profile.
+// @Summary		Update own profile.
+// @Description	Update the name and email of the authenticated user. A new email is only applied once confirmed through the link sent to it, until then it is returned as pending_email.
+// @Tags		Users
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		input	body		dto.UpdateUserInputDTO	true	"Profile fields to change"
+// @Success		200		{object}	dto.UpdateUserOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		409		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/me [patch]
+func (uh *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	uh.updateUser(w, r, u, u.ID)
+}
+
+// UpdateUser 	Update a user profile.
+// @Summary		Update a user profile.
+// @Description	Update the name and email of a user of the organization. Users can only update themselves unless they are admins. A new email is only applied once confirmed through the link sent to it.
+// @Tags		Users
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int						true	"User ID"
+// @Param		input	body		dto.UpdateUserInputDTO	true	"Profile fields to change"
+// @Success		200		{object}	dto.UpdateUserOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		409		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/users/{userId} [patch]
+func (uh *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	if userId != u.ID && !u.IsAdmin() {
+		util.ProblemResponse(w, r, domain.ErrRoleForbidden)
+		return
+	}
+
+	uh.updateUser(w, r, u, userId)
+}
+
+func (uh *UserHandler) updateUser(w http.ResponseWriter, r *http.Request, u *domain.User, userId uint) {
+	var input dto.UpdateUserInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
 		return
 	}
 
-	output, err := uh.UserUseCase.FindUserById(uint(userId))
+	output, err := uh.UserUseCase.UpdateUser(r.Context(), u.OrganizationID, userId, &input)
 	if err != nil {
 		log.Println(err)
-		util.JSONResponse(w, err.Error(), http.StatusBadRequest)
+		util.ProblemResponse(w, r, err)
 		return
 	}
 
 	util.JSONResponse(w, output, http.StatusOK)
 }
+
+// UpdatePassword 	Change password.
+// @Summary		Change password.
+// @Description	Change the password of the authenticated user. The current password is required, recently used passwords are refused and every other session is logged out.
+// @Tags		Users
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Param		input	body		dto.UpdateUserPasswordInputDTO	true	"Current and new password"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Router		/me/password [put]
+func (uh *UserHandler) UpdatePassword(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	var input dto.UpdateUserPasswordInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	currentSessionId, _ := middleware.SessionIdFromContext(r.Context())
+
+	err := uh.UserUseCase.UpdateUserPassword(r.Context(), u.ID, currentSessionId, &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "password updated", http.StatusOK)
+}
+
+// DeleteUser 	Delete a user.
+// @Summary		Delete a user.
+// @Description	Soft delete a user of the organization. The account stops authenticating and its email can be used again, an admin can restore it until it is purged. Users can only delete themselves unless they are admins.
+// @Tags		Users
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int	true	"User ID"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/users/{userId} [delete]
+func (uh *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	if userId != u.ID && !u.IsAdmin() {
+		util.ProblemResponse(w, r, domain.ErrRoleForbidden)
+		return
+	}
+
+	err := uh.UserUseCase.DeleteUser(r.Context(), u.OrganizationID, userId)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "user deleted", http.StatusOK)
+}
+
+// UpdateUserStatus 	Suspend or reactivate a user.
+// @Summary		Suspend or reactivate a user.
+// @Description	Set the status of a user of the organization to "active" or "suspended". Suspended users cannot log in and their tokens and API keys are refused. Requires admin role.
+// @Tags		Users
+// @Accept		json
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int								true	"User ID"
+// @Param		input	body		dto.UpdateUserStatusInputDTO	true	"New status"
+// @Success		200		{object}	dto.UserOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		422		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/status [put]
+func (uh *UserHandler) UpdateUserStatus(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	var input dto.UpdateUserStatusInputDTO
+	if err := decodeJSON(w, r, &input); err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	output, err := uh.UserUseCase.UpdateUserStatus(r.Context(), u.OrganizationID, userId, &input)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// RestoreUser 	Restore a deleted user.
+// @Summary		Restore a deleted user.
+// @Description	Bring back a deleted user of the organization as active. Refused with 409 when another account took their email meanwhile, or when the user was erased. Requires admin role.
+// @Tags		Users
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int	true	"User ID"
+// @Success		200		{object}	dto.UserOutputDTO
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Failure		409		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId}/restore [post]
+func (uh *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	output, err := uh.UserUseCase.RestoreUser(r.Context(), u.OrganizationID, userId)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, output, http.StatusOK)
+}
+
+// PurgeUser 	Permanently remove a deleted user.
+// @Summary		Permanently remove a deleted user.
+// @Description	Remove a deleted user of the organization and everything attached to them. This cannot be undone, live users have to be deleted first. Refused with 409 for erased users, whose consent history is kept. Requires admin role.
+// @Tags		Users
+// @Produce		json
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Param		userId	path		int	true	"User ID"
+// @Success		200		{object}	string
+// @Failure		400		{object}	dto.ProblemOutputDTO
+// @Failure		401		{object}	dto.ProblemOutputDTO
+// @Failure		403		{object}	dto.ProblemOutputDTO
+// @Failure		404		{object}	dto.ProblemOutputDTO
+// @Failure		409		{object}	dto.ProblemOutputDTO
+// @Router		/admin/users/{userId} [delete]
+func (uh *UserHandler) PurgeUser(w http.ResponseWriter, r *http.Request, u *domain.User) {
+	userId, ok := pathUserId(w, r)
+	if !ok {
+		return
+	}
+
+	err := uh.UserUseCase.PurgeUser(r.Context(), u.OrganizationID, userId)
+	if err != nil {
+		log.Println(err)
+		util.ProblemResponse(w, r, err)
+		return
+	}
+
+	util.JSONResponse(w, "user purged", http.StatusOK)
+}
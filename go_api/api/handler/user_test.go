@@ -2,13 +2,19 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"encoding/json"
 
+	"github.com/Daffc/GO-Sales/api/middleware"
+	"github.com/Daffc/GO-Sales/domain"
 	"github.com/Daffc/GO-Sales/domain/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,26 +25,67 @@ type mockUserUseCase struct {
 }
 
 // CreateUser implements usecase.UserUseCase.
-func (m *mockUserUseCase) CreateUser(input *dto.UserInputDTO) (*dto.UserOutputDTO, error) {
-	args := m.Called(input)
+func (m *mockUserUseCase) CreateUser(ctx context.Context, input *dto.UserInputDTO) (*dto.UserOutputDTO, error) {
+	args := m.Called(ctx, input)
 	return args.Get(0).(*dto.UserOutputDTO), args.Error(1)
 }
 
 // FindUserById implements usecase.UserUseCase.
-func (m *mockUserUseCase) FindUserById(input uint) (*dto.UserOutputDTO, error) {
-	args := m.Called(input)
+func (m *mockUserUseCase) FindUserById(ctx context.Context, tenantId uint, input uint) (*dto.UserOutputDTO, error) {
+	args := m.Called(ctx, tenantId, input)
 	return args.Get(0).(*dto.UserOutputDTO), args.Error(1)
 }
 
+// UpdateUser implements usecase.UserUseCase.
+func (m *mockUserUseCase) UpdateUser(ctx context.Context, tenantId uint, userId uint, input *dto.UpdateUserInputDTO) (*dto.UpdateUserOutputDTO, error) {
+	args := m.Called(ctx, tenantId, userId, input)
+	return args.Get(0).(*dto.UpdateUserOutputDTO), args.Error(1)
+}
+
+// UpdateUserStatus implements usecase.UserUseCase.
+func (m *mockUserUseCase) UpdateUserStatus(ctx context.Context, tenantId uint, userId uint, input *dto.UpdateUserStatusInputDTO) (*dto.UserOutputDTO, error) {
+	args := m.Called(ctx, tenantId, userId, input)
+	return args.Get(0).(*dto.UserOutputDTO), args.Error(1)
+}
+
+// DeleteUser implements usecase.UserUseCase.
+func (m *mockUserUseCase) DeleteUser(ctx context.Context, tenantId uint, userId uint) error {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Error(0)
+}
+
+// RestoreUser implements usecase.UserUseCase.
+func (m *mockUserUseCase) RestoreUser(ctx context.Context, tenantId uint, userId uint) (*dto.UserOutputDTO, error) {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Get(0).(*dto.UserOutputDTO), args.Error(1)
+}
+
+// PurgeUser implements usecase.UserUseCase.
+func (m *mockUserUseCase) PurgeUser(ctx context.Context, tenantId uint, userId uint) error {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Error(0)
+}
+
 // UpdateUserPassword implements usecase.UserUseCase.
-func (m *mockUserUseCase) UpdateUserPassword(input dto.UpdateUserPasswordInputDTO) error {
-	args := m.Called()
+func (m *mockUserUseCase) UpdateUserPassword(ctx context.Context, userId uint, currentSessionId uint, input *dto.UpdateUserPasswordInputDTO) error {
+	args := m.Called(ctx, userId, currentSessionId, input)
 	return args.Error(0)
 }
 
-func (m *mockUserUseCase) ListUsers() ([]*dto.UserOutputDTO, error) {
-	args := m.Called()
-	return args.Get(0).([]*dto.UserOutputDTO), args.Error(1)
+// ListUsers implements usecase.UserUseCase.
+func (m *mockUserUseCase) ListUsers(ctx context.Context, tenantId uint, input *dto.ListUsersInputDTO) (*dto.UserListOutputDTO, error) {
+	args := m.Called(ctx, tenantId, input)
+	return args.Get(0).(*dto.UserListOutputDTO), args.Error(1)
+}
+
+// ImportUsers implements usecase.UserUseCase.
+func (m *mockUserUseCase) ImportUsers(ctx context.Context, importer *domain.User, file io.Reader, dryRun bool) (*dto.UserImportOutputDTO, error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	args := m.Called(ctx, importer, string(content), dryRun)
+	return args.Get(0).(*dto.UserImportOutputDTO), args.Error(1)
 }
 
 func TestCreateUser(t *testing.T) {
@@ -84,20 +131,15 @@ func TestCreateUser(t *testing.T) {
 			mockReturn:     nil, // The mock should not return anything
 			mockError:      nil, // The mock should not be called
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "unexpected EOF", // Match the error message returned by the handler
+			expectedBody:   "invalid request body: unexpected EOF", // Match the error message returned by the handler
 		},
 		{
-			name:        "Invalid User Name",
-			requestBody: "{\n\t\"name\": \"\",\n\t\"email\": \"user1@example.com\",\n\t\"password\": \"Password@1\"\n}",
-			mockInput: &dto.UserInputDTO{
-				Name:     "",
-				Password: "Password@1",
-				Email:    "user1@example.com",
-			},
+			name:           "Invalid User Name",
+			requestBody:    "{\n\t\"name\": \"\",\n\t\"email\": \"user1@example.com\",\n\t\"password\": \"Password@1\"\n}",
+			mockInput:      nil,
 			mockReturn:     nil,
-			mockError:      fmt.Errorf("invalid user name"),
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "invalid user name",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "the request has invalid fields",
 		},
 	}
 
@@ -109,10 +151,10 @@ func TestCreateUser(t *testing.T) {
 
 			// Set up the mock only if mockInput is not nil (error before calling mocked function)
 			if tc.mockInput != nil {
-				mockUserUseCase.On("CreateUser", tc.mockInput).Return(tc.mockReturn, tc.mockError)
+				mockUserUseCase.On("CreateUser", mock.Anything, tc.mockInput).Return(tc.mockReturn, tc.mockError)
 			}
 
-			userHandler := NewUserHandler(mockUserUseCase)
+			userHandler := NewUserHandler(mockUserUseCase, true)
 
 			req, err := http.NewRequest("POST", "/users", bytes.NewBufferString(tc.requestBody))
 			if err != nil {
@@ -135,8 +177,8 @@ func TestCreateUser(t *testing.T) {
 				// Check the response body
 				assert.Equal(t, tc.expectedBody, uo, "Expected user to match")
 
-			case http.StatusBadRequest:
-				var response string
+			case http.StatusBadRequest, http.StatusUnprocessableEntity:
+				var response dto.ProblemOutputDTO
 
 				err = json.NewDecoder(rr.Body).Decode(&response)
 				if err != nil {
@@ -144,7 +186,7 @@ func TestCreateUser(t *testing.T) {
 				}
 
 				// Check the response error message
-				assert.Equal(t, tc.expectedBody, response, "Expected error message to match")
+				assert.Equal(t, tc.expectedBody, response.Detail, "Expected error message to match")
 			default:
 				t.Fatalf("Unexpected status code: %d", rr.Code)
 			}
@@ -155,80 +197,165 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func TestCreateUserOpenSignupDisabled(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	userHandler := NewUserHandler(mockUserUseCase, false)
+
+	req, err := http.NewRequest("POST", "/users", bytes.NewBufferString(`{"name": "User1", "email": "user1@example.com", "password": "Password@1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	userHandler.CreateUser(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code, "Expected status code to match")
+
+	var response dto.ProblemOutputDTO
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	assert.Equal(t, domain.ErrUserOpenSignupDisabled.Error(), response.Detail, "Expected error message to match")
+
+	// The use case is never reached.
+	mockUserUseCase.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
 // TestListUsers tests the ListUsers function of the UserHandler.
 func TestListUsers(t *testing.T) {
 	// Create a new mock user use case
 	mockUserUseCase := new(mockUserUseCase)
 
+	createdFrom := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 	// Defining test cases mocks and expected results
 	testCases := []struct {
 		name           string
-		mockReturn     interface{}
+		url            string
+		preferences    *domain.UserPreferences
+		expectedInput  *dto.ListUsersInputDTO
+		mockReturn     *dto.UserListOutputDTO
 		mockError      error
 		expectedStatus int
 		expectedBody   interface{}
+		expectedLink   string
 	}{
 		{
-			name: "Success",
-			mockReturn: []*dto.UserOutputDTO{
+			name:          "Success",
+			url:           "/users",
+			expectedInput: &dto.ListUsersInputDTO{},
+			mockReturn: &dto.UserListOutputDTO{Data: []*dto.UserOutputDTO{
 				{ID: 1, Name: "User1", Email: "user1@example.com"},
 				{ID: 2, Name: "User2", Email: "user2@example.com"},
-			},
-			mockError:      nil,
+			}},
 			expectedStatus: http.StatusOK,
-			expectedBody: []*dto.UserOutputDTO{
+			expectedBody: &dto.UserListOutputDTO{Data: []*dto.UserOutputDTO{
 				{ID: 1, Name: "User1", Email: "user1@example.com"},
 				{ID: 2, Name: "User2", Email: "user2@example.com"},
+			}},
+		},
+		{
+			name:          "Next page",
+			url:           "/users?limit=1&name=User&status=active&created_from=2024-01-01T00:00:00Z&sort=-name",
+			expectedInput: &dto.ListUsersInputDTO{Limit: 1, Name: "User", Status: "active", CreatedFrom: &createdFrom, Sort: "-name"},
+			mockReturn: &dto.UserListOutputDTO{
+				Data:       []*dto.UserOutputDTO{{ID: 2, Name: "User2", Email: "user2@example.com"}},
+				NextCursor: "abc",
 			},
+			expectedStatus: http.StatusOK,
+			expectedBody: &dto.UserListOutputDTO{
+				Data:       []*dto.UserOutputDTO{{ID: 2, Name: "User2", Email: "user2@example.com"}},
+				NextCursor: "abc",
+			},
+			expectedLink: `</users?created_from=2024-01-01T00%3A00%3A00Z&cursor=abc&limit=1&name=User&sort=-name&status=active>; rel="next"`,
+		},
+		{
+			name:           "Preferred page size",
+			url:            "/users",
+			preferences:    &domain.UserPreferences{UserID: 1, PageSize: 50},
+			expectedInput:  &dto.ListUsersInputDTO{Limit: 50},
+			mockReturn:     &dto.UserListOutputDTO{Data: []*dto.UserOutputDTO{}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &dto.UserListOutputDTO{Data: []*dto.UserOutputDTO{}},
+		},
+		{
+			name:           "Explicit limit over preference",
+			url:            "/users?limit=5",
+			preferences:    &domain.UserPreferences{UserID: 1, PageSize: 50},
+			expectedInput:  &dto.ListUsersInputDTO{Limit: 5},
+			mockReturn:     &dto.UserListOutputDTO{Data: []*dto.UserOutputDTO{}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &dto.UserListOutputDTO{Data: []*dto.UserOutputDTO{}},
+		},
+		{
+			name:           "Invalid limit",
+			url:            "/users?limit=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   domain.ErrUserListLimitInvalid.Error(),
+		},
+		{
+			name:           "Invalid created_to",
+			url:            "/users?created_to=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid query parameter created_to",
 		},
 		{
 			name:           "Bad Request",
-			mockReturn:     []*dto.UserOutputDTO{},
-			mockError:      fmt.Errorf("Bad Request"),
+			url:            "/users?sort=password",
+			expectedInput:  &dto.ListUsersInputDTO{Sort: "password"},
+			mockReturn:     (*dto.UserListOutputDTO)(nil),
+			mockError:      domain.ErrUserListSortInvalid,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "Bad Request",
+			expectedBody:   domain.ErrUserListSortInvalid.Error(),
 		},
 	}
 
 	// Running tests
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Set up the mock to return the user when ListUsers is called
+			// Set up the mock to return the users when ListUsers is called
 			mockUserUseCase.ExpectedCalls = nil
-			mockUserUseCase.On("ListUsers").Return(tc.mockReturn, tc.mockError)
-			userHandler := NewUserHandler(mockUserUseCase)
+			if tc.expectedInput != nil {
+				mockUserUseCase.On("ListUsers", mock.Anything, uint(2), tc.expectedInput).Return(tc.mockReturn, tc.mockError)
+			}
+			userHandler := NewUserHandler(mockUserUseCase, true)
 
 			// Create a new HTTP request and response recorder
-			req, err := http.NewRequest("GET", "/users", nil)
+			req, err := http.NewRequest("GET", tc.url, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
+			if tc.preferences != nil {
+				req = req.WithContext(middleware.ContextWithPreferences(req.Context(), tc.preferences))
+			}
 			rr := httptest.NewRecorder()
-			userHandler.ListUsers(rr, req)
+			userHandler.ListUsers(rr, req, &domain.User{ID: 1, OrganizationID: 2})
 
 			// Asserting results
 			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
 			switch rr.Code {
 			case http.StatusOK:
 				// Check the response body
-				var uos []*dto.UserOutputDTO
-				err = json.NewDecoder(rr.Body).Decode(&uos)
+				var output *dto.UserListOutputDTO
+				err = json.NewDecoder(rr.Body).Decode(&output)
 				if err != nil {
 					t.Fatalf("Failed to unmarshal response: %v", err)
 				}
 
 				// Check the response body
-				assert.Equal(t, tc.expectedBody, uos, "Expected list of users to match")
+				assert.Equal(t, tc.expectedBody, output, "Expected page of users to match")
+				assert.Equal(t, tc.expectedLink, rr.Header().Get("Link"), "Expected Link header to match")
 
 			case http.StatusBadRequest:
-				var response string
+				var response dto.ProblemOutputDTO
 				err = json.NewDecoder(rr.Body).Decode(&response)
 				if err != nil {
 					t.Fatalf("Failed to unmarshal response: %v", err)
 				}
 
 				// Check the response error message
-				assert.Equal(t, tc.expectedBody, response, "Expected error message to match")
+				assert.Equal(t, tc.expectedBody, response.Detail, "Expected error message to match")
 			default:
 				t.Fatalf("Unexpected status code: %d", rr.Code)
 			}
@@ -276,27 +403,27 @@ func TestFindUserById(t *testing.T) {
 			url:            "/users/X",
 			mockInput:      1,
 			mockReturn:     nil,
-			mockError:      fmt.Errorf("Invalid user id"),
+			mockError:      fmt.Errorf("invalid path parameter userId"),
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "Invalid user id",
+			expectedBody:   "invalid path parameter userId",
 		},
 		{
-			name:           "Invalid URL path",
+			name:           "invalid path parameter userId",
 			url:            "/users",
 			mockInput:      1,
 			mockReturn:     nil,
-			mockError:      fmt.Errorf("Invalid URL path"),
+			mockError:      fmt.Errorf("invalid path parameter userId"),
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "Invalid URL path",
+			expectedBody:   "invalid path parameter userId",
 		},
 		{
 			name:           "User not found",
 			url:            "/users/1",
 			mockInput:      1,
 			mockReturn:     nil,
-			mockError:      fmt.Errorf("record not found"),
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   "record not found",
+			mockError:      domain.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   domain.ErrUserNotFound.Error(),
 		},
 	}
 
@@ -306,8 +433,8 @@ func TestFindUserById(t *testing.T) {
 
 			// Mocking UserUseCase according to test.
 			mockUserUseCase.ExpectedCalls = nil
-			mockUserUseCase.On("FindUserById", tc.mockInput).Return(tc.mockReturn, tc.mockError)
-			userHandler := NewUserHandler(mockUserUseCase)
+			mockUserUseCase.On("FindUserById", mock.Anything, uint(2), tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			userHandler := NewUserHandler(mockUserUseCase, true)
 
 			// Create a new HTTP request and response recorder
 			req, err := http.NewRequest("GET", tc.url, nil)
@@ -315,7 +442,7 @@ func TestFindUserById(t *testing.T) {
 				t.Fatal(err)
 			}
 			rr := httptest.NewRecorder()
-			userHandler.FindUserById(rr, req)
+			userHandler.FindUserById(rr, req, &domain.User{ID: 1, OrganizationID: 2})
 
 			// Assert the response status code
 			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
@@ -329,15 +456,15 @@ func TestFindUserById(t *testing.T) {
 					t.Fatalf("Failed to unmarshal response: %v", err)
 				}
 				assert.Equal(t, tc.expectedBody, &uo, "Expected user to match")
-			case http.StatusBadRequest:
-				var response string
+			case http.StatusBadRequest, http.StatusNotFound:
+				var response dto.ProblemOutputDTO
 				err = json.NewDecoder(rr.Body).Decode(&response)
 				if err != nil {
 					t.Fatalf("Failed to unmarshal response: %v", err)
 				}
 
 				// Check the response error message
-				assert.Equal(t, tc.expectedBody, response, "Expected error message to match")
+				assert.Equal(t, tc.expectedBody, response.Detail, "Expected error message to match")
 			default:
 				t.Fatalf("Unexpected status code: %d", rr.Code)
 			}
@@ -348,3 +475,542 @@ func TestFindUserById(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdatePassword(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockInput      *dto.UpdateUserPasswordInputDTO
+		mockError      error
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:           "Success",
+			body:           `{"current_password":"Password@1","new_password":"Password@2"}`,
+			mockInput:      &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@1", NewPassword: "Password@2"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "password updated",
+		},
+		{
+			name:           "Wrong current password",
+			body:           `{"current_password":"Password@0","new_password":"Password@2"}`,
+			mockInput:      &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@0", NewPassword: "Password@2"},
+			mockError:      domain.ErrUserPasswordIncorrect,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   domain.ErrUserPasswordIncorrect.Error(),
+		},
+		{
+			name:      "Password policy",
+			body:      `{"current_password":"Password@1","new_password":"abc"}`,
+			mockInput: &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@1", NewPassword: "abc"},
+			mockError: domain.NewFieldError("new_password", &domain.PasswordPolicyError{Violations: []error{
+				domain.ErrUserPasswordFormatUpperCase,
+				domain.ErrUserPasswordFormatNumber,
+			}}),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody: []dto.ProblemViolationOutputDTO{
+				{Field: "new_password", Code: "password_uppercase_missing", Detail: domain.ErrUserPasswordFormatUpperCase.Error()},
+				{Field: "new_password", Code: "password_digit_missing", Detail: domain.ErrUserPasswordFormatNumber.Error()},
+			},
+		},
+		{
+			name:           "Password reused",
+			body:           `{"current_password":"Password@1","new_password":"Password@1"}`,
+			mockInput:      &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@1", NewPassword: "Password@1"},
+			mockError:      domain.NewFieldError("new_password", domain.ErrUserPasswordReused),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody: []dto.ProblemViolationOutputDTO{
+				{Field: "new_password", Code: "password_reused", Detail: domain.ErrUserPasswordReused.Error()},
+			},
+		},
+		{
+			name:           "Invalid body",
+			body:           `{"current_password":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid request body: unexpected EOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockUserUseCase.On("UpdateUserPassword", mock.Anything, user.ID, uint(7), tc.mockInput).Return(tc.mockError)
+			}
+			userHandler := NewUserHandler(mockUserUseCase, true)
+
+			req, err := http.NewRequest(http.MethodPut, "/me/password", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req = req.WithContext(middleware.ContextWithSessionId(req.Context(), 7))
+			rr := httptest.NewRecorder()
+			userHandler.UpdatePassword(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+
+			switch expected := tc.expectedBody.(type) {
+			case []dto.ProblemViolationOutputDTO:
+				var body dto.ProblemOutputDTO
+				err = json.NewDecoder(rr.Body).Decode(&body)
+				if err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				assert.Equal(t, "validation_failed", body.Code, "Expected problem code to match")
+				assert.Equal(t, expected, body.Violations, "Expected violations to match")
+			default:
+				assert.Equal(t, expected, responseMessage(t, rr), "Expected response body to match")
+			}
+
+			mockUserUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2, Role: domain.RoleUser}
+	admin := &domain.User{ID: 3, OrganizationID: 2, Role: domain.RoleAdmin}
+	name := "User1"
+	email := "user1@example.com"
+
+	testCases := []struct {
+		name           string
+		actor          *domain.User
+		userId         string
+		body           string
+		mockUserId     uint
+		mockInput      *dto.UpdateUserInputDTO
+		mockReturn     *dto.UpdateUserOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Own profile",
+			actor:          user,
+			userId:         "1",
+			body:           `{"name": "User1"}`,
+			mockUserId:     1,
+			mockInput:      &dto.UpdateUserInputDTO{Name: &name},
+			mockReturn:     &dto.UpdateUserOutputDTO{UserOutputDTO: dto.UserOutputDTO{ID: 1, OrganizationID: 2, Name: "User1"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Admin on another user",
+			actor:          admin,
+			userId:         "1",
+			body:           `{"email": "user1@example.com"}`,
+			mockUserId:     1,
+			mockInput:      &dto.UpdateUserInputDTO{Email: &email},
+			mockReturn:     &dto.UpdateUserOutputDTO{UserOutputDTO: dto.UserOutputDTO{ID: 1, OrganizationID: 2}, PendingEmail: "user1@example.com"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Another user",
+			actor:          user,
+			userId:         "3",
+			body:           `{"name": "User1"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Email taken",
+			actor:          user,
+			userId:         "1",
+			body:           `{"email": "user1@example.com"}`,
+			mockUserId:     1,
+			mockInput:      &dto.UpdateUserInputDTO{Email: &email},
+			mockError:      domain.ErrUserEmailTaken,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "invalid path parameter userId",
+			actor:          user,
+			userId:         "abc",
+			body:           `{"name": "User1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid body",
+			actor:          user,
+			userId:         "1",
+			body:           `{"name":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockUserUseCase.On("UpdateUser", mock.Anything, tc.actor.OrganizationID, tc.mockUserId, tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			}
+			userHandler := NewUserHandler(mockUserUseCase, true)
+
+			req, err := http.NewRequest(http.MethodPatch, "/users/"+tc.userId, bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", tc.userId)
+			rr := httptest.NewRecorder()
+			userHandler.UpdateUser(rr, req, tc.actor)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+
+			if rr.Code == http.StatusOK {
+				var output dto.UpdateUserOutputDTO
+				err = json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				assert.Equal(t, tc.mockReturn, &output, "Expected response body to match")
+			}
+
+			mockUserUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateMe(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2}
+	name := "User1"
+	output := &dto.UpdateUserOutputDTO{UserOutputDTO: dto.UserOutputDTO{ID: 1, OrganizationID: 2, Name: "User1"}}
+
+	mockUserUseCase.On("UpdateUser", mock.Anything, uint(2), uint(1), &dto.UpdateUserInputDTO{Name: &name}).Return(output, nil)
+	userHandler := NewUserHandler(mockUserUseCase, true)
+
+	req, err := http.NewRequest(http.MethodPatch, "/me", bytes.NewBufferString(`{"name": "User1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	userHandler.UpdateMe(rr, req, user)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "Expected status code to match")
+	mockUserUseCase.AssertExpectations(t)
+}
+
+func TestDeleteUser(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	user := &domain.User{ID: 1, OrganizationID: 2, Role: domain.RoleUser}
+	admin := &domain.User{ID: 3, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		actor          *domain.User
+		userId         string
+		mockUserId     uint
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Own account",
+			actor:          user,
+			userId:         "1",
+			mockUserId:     1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Admin on another user",
+			actor:          admin,
+			userId:         "1",
+			mockUserId:     1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Another user",
+			actor:          user,
+			userId:         "3",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Not found",
+			actor:          admin,
+			userId:         "4",
+			mockUserId:     4,
+			mockError:      domain.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserUseCase.ExpectedCalls = nil
+			if tc.mockUserId != 0 {
+				mockUserUseCase.On("DeleteUser", mock.Anything, tc.actor.OrganizationID, tc.mockUserId).Return(tc.mockError)
+			}
+			userHandler := NewUserHandler(mockUserUseCase, true)
+
+			req, err := http.NewRequest(http.MethodDelete, "/users/"+tc.userId, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", tc.userId)
+			rr := httptest.NewRecorder()
+			userHandler.DeleteUser(rr, req, tc.actor)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			mockUserUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	admin := &domain.User{ID: 3, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockInput      *dto.UpdateUserStatusInputDTO
+		mockReturn     *dto.UserOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Suspend",
+			body:           `{"status": "suspended"}`,
+			mockInput:      &dto.UpdateUserStatusInputDTO{Status: domain.UserStatusSuspended},
+			mockReturn:     &dto.UserOutputDTO{ID: 1, OrganizationID: 2, Status: domain.UserStatusSuspended},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid status",
+			body:           `{"status": "deleted"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Invalid body",
+			body:           `{"status":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserUseCase.ExpectedCalls = nil
+			if tc.mockInput != nil {
+				mockUserUseCase.On("UpdateUserStatus", mock.Anything, admin.OrganizationID, uint(1), tc.mockInput).Return(tc.mockReturn, tc.mockError)
+			}
+			userHandler := NewUserHandler(mockUserUseCase, true)
+
+			req, err := http.NewRequest(http.MethodPut, "/admin/users/1/status", bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", "1")
+			rr := httptest.NewRecorder()
+			userHandler.UpdateUserStatus(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+
+			if rr.Code == http.StatusOK {
+				var output dto.UserOutputDTO
+				err = json.NewDecoder(rr.Body).Decode(&output)
+				if err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				assert.Equal(t, tc.mockReturn, &output, "Expected response body to match")
+			}
+
+			mockUserUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	admin := &domain.User{ID: 3, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		mockReturn     *dto.UserOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			mockReturn:     &dto.UserOutputDTO{ID: 1, OrganizationID: 2, Status: domain.UserStatusActive},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Email taken",
+			mockError:      domain.ErrUserEmailTaken,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Not deleted",
+			mockError:      domain.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserUseCase.ExpectedCalls = nil
+			mockUserUseCase.On("RestoreUser", mock.Anything, admin.OrganizationID, uint(1)).Return(tc.mockReturn, tc.mockError)
+			userHandler := NewUserHandler(mockUserUseCase, true)
+
+			req, err := http.NewRequest(http.MethodPost, "/admin/users/1/restore", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", "1")
+			rr := httptest.NewRecorder()
+			userHandler.RestoreUser(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			mockUserUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeUser(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	admin := &domain.User{ID: 3, OrganizationID: 2, Role: domain.RoleAdmin}
+
+	testCases := []struct {
+		name           string
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Not deleted",
+			mockError:      domain.ErrUserNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserUseCase.ExpectedCalls = nil
+			mockUserUseCase.On("PurgeUser", mock.Anything, admin.OrganizationID, uint(1)).Return(tc.mockError)
+			userHandler := NewUserHandler(mockUserUseCase, true)
+
+			req, err := http.NewRequest(http.MethodDelete, "/admin/users/1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetPathValue("userId", "1")
+			rr := httptest.NewRecorder()
+			userHandler.PurgeUser(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			mockUserUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportUsers(t *testing.T) {
+
+	mockUserUseCase := new(mockUserUseCase)
+	admin := &domain.User{ID: 3, OrganizationID: 2, Role: domain.RoleAdmin}
+	file := "name,email,password\nUser1,user1@example.com,Password@1\n"
+	report := &dto.UserImportOutputDTO{
+		Created: 1,
+		Rows:    []*dto.UserImportRowDTO{{Row: 2, Email: "user1@example.com", Status: domain.UserImportRowCreated, UserID: 1}},
+	}
+
+	testCases := []struct {
+		name           string
+		query          string
+		field          string
+		expectedDryRun *bool
+		mockReturn     *dto.UserImportOutputDTO
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Success",
+			field:          "file",
+			expectedDryRun: new(bool),
+			mockReturn:     report,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Dry run",
+			query:          "?dry_run=true",
+			field:          "file",
+			expectedDryRun: func() *bool { b := true; return &b }(),
+			mockReturn:     &dto.UserImportOutputDTO{DryRun: true, Valid: 1},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Invalid dry run",
+			query:          "?dry_run=maybe",
+			field:          "file",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing file",
+			field:          "upload",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid file",
+			field:          "file",
+			expectedDryRun: new(bool),
+			mockError:      domain.ErrUserImportHeaderInvalid,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Email taken while importing",
+			field:          "file",
+			expectedDryRun: new(bool),
+			mockError:      domain.ErrUserEmailTaken,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserUseCase.ExpectedCalls = nil
+			mockUserUseCase.Calls = nil
+			if tc.expectedDryRun != nil {
+				mockUserUseCase.On("ImportUsers", mock.Anything, admin, file, *tc.expectedDryRun).Return(tc.mockReturn, tc.mockError)
+			}
+			userHandler := NewUserHandler(mockUserUseCase, true)
+
+			body := new(bytes.Buffer)
+			mw := multipart.NewWriter(body)
+			part, err := mw.CreateFormFile(tc.field, "users.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write([]byte(file))
+			mw.Close()
+
+			req, err := http.NewRequest(http.MethodPost, "/users/import"+tc.query, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			rr := httptest.NewRecorder()
+			userHandler.ImportUsers(rr, req, admin)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			if tc.mockReturn != nil {
+				var output dto.UserImportOutputDTO
+				err = json.Unmarshal(rr.Body.Bytes(), &output)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.mockReturn, &output, "Expected import report to match")
+			}
+			mockUserUseCase.AssertExpectations(t)
+		})
+	}
+}
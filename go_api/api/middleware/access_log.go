@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// responseRecorder remembers the status and size of the response written
+// through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// accessLogEntry collects what is only known deeper in the chain, such as the
+// authenticated user, for the access log line of the request.
+type accessLogEntry struct {
+	route  string
+	userId uint
+}
+
+// setAccessLogUser records the authenticated user of the request in its
+// access log line, if it is being logged.
+func setAccessLogUser(ctx context.Context, userId uint) {
+	if entry, ok := ctx.Value(accessLogContextKey).(*accessLogEntry); ok {
+		entry.userId = userId
+	}
+}
+
+// AccessLogRoute records in the access log line the route pattern the ServeMux
+// matched. It has to be the innermost middleware, wrapping the mux itself: the
+// mux sets the pattern on the request it is given, which is not the one seen
+// by AccessLog as soon as a middleware in between replaces it, as Timeout
+// does to give it a deadline.
+func AccessLogRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Deferred, so requests that panic are logged with their route too.
+		defer func() {
+			if entry, ok := r.Context().Value(accessLogContextKey).(*accessLogEntry); ok {
+				entry.route = r.Pattern
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AccessLog logs a line per request once it is served, with its method, the
+// route pattern it matched, the status and size of the response, how long it
+// took and, when known, the request ID and the authenticated user.
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &accessLogEntry{}
+			rec := &responseRecorder{ResponseWriter: w}
+
+			r = r.WithContext(context.WithValue(r.Context(), accessLogContextKey, entry))
+			next.ServeHTTP(rec, r)
+
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			// The pattern is set by the ServeMux further down the chain, it
+			// is empty when no route matched.
+			route := entry.route
+			if len(route) == 0 {
+				route = r.Pattern
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", rec.bytes),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("remote_ip", util.ClientIP(r)),
+			}
+			if requestId, ok := util.RequestIdFromContext(r.Context()); ok {
+				attrs = append(attrs, slog.String("request_id", requestId))
+			}
+			if entry.userId != 0 {
+				attrs = append(attrs, slog.Uint64("user_id", uint64(entry.userId)))
+			}
+
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+		})
+	}
+}
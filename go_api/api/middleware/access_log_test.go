@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	sm := http.NewServeMux()
+	sm.HandleFunc("GET /users/{userId}", func(w http.ResponseWriter, r *http.Request) {
+		setAccessLogUser(r.Context(), 7)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+	handler := Chain(sm, RequestId, AccessLog(logger))
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected map[string]interface{}
+	}{
+		{
+			name: "Matched route",
+			path: "/users/7",
+			expected: map[string]interface{}{
+				"msg":        "request",
+				"method":     "GET",
+				"route":      "GET /users/{userId}",
+				"path":       "/users/7",
+				"status":     float64(http.StatusCreated),
+				"bytes":      float64(5),
+				"request_id": "abc",
+				"user_id":    float64(7),
+			},
+		},
+		{
+			name: "No route",
+			path: "/missing",
+			expected: map[string]interface{}{
+				"route":      "",
+				"path":       "/missing",
+				"status":     float64(http.StatusNotFound),
+				"request_id": "abc",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set(RequestIdHeader, "abc")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			line := map[string]interface{}{}
+			err := json.Unmarshal(buf.Bytes(), &line)
+			if !assert.NoError(t, err, "Expected a JSON log line") {
+				return
+			}
+			for key, value := range tc.expected {
+				assert.Equal(t, value, line[key], "Expected %s to match", key)
+			}
+			assert.Contains(t, line, "latency", "Expected the latency to be logged")
+			if _, ok := tc.expected["user_id"]; !ok {
+				assert.NotContains(t, line, "user_id", "Expected no user for anonymous requests")
+			}
+		})
+	}
+}
+
+func TestAccessLogStack(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	sm := http.NewServeMux()
+	sm.HandleFunc("GET /users/{userId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	sm.HandleFunc("GET /panics", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	// Timeout hands the mux a request of its own, the route has to make it
+	// back to the log line all the same.
+	handler := Stack(sm, logger, nil, CorsPolicy{}, time.Second, NewRateLimits(nil, nil))
+
+	testCases := []struct {
+		name           string
+		path           string
+		expectedRoute  string
+		expectedStatus int
+	}{
+		{
+			name:           "Matched route",
+			path:           "/users/7",
+			expectedRoute:  "GET /users/{userId}",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Panicking route",
+			path:           "/panics",
+			expectedRoute:  "GET /panics",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "No route",
+			path:           "/missing",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			line := map[string]interface{}{}
+			err := json.Unmarshal(buf.Bytes(), &line)
+			if !assert.NoError(t, err, "Expected a JSON log line") {
+				return
+			}
+			assert.Equal(t, tc.expectedRoute, line["route"], "Expected route to match")
+			assert.Equal(t, float64(tc.expectedStatus), line["status"], "Expected status to match")
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+// Middleware wraps a handler with behaviour shared by many routes.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps handler with middlewares, the first of them being the outermost
+// one, so it sees the request first and the response last.
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// Stack wraps the routes of sm with the middlewares every request goes
+// through. Every route gets a request ID, the IP address of its client as seen
+// through trustedProxies, an access log line, a JSON 500 when it panics and a
+// deadline of timeout. CORS preflights are answered before the
+// deadline, and refusals of the rate limits keyed by IP come last, so browsers
+// can read them.
+func Stack(sm *http.ServeMux, accessLogger *slog.Logger, trustedProxies []netip.Prefix, corsPolicy CorsPolicy, timeout time.Duration, rateLimits *RateLimits) http.Handler {
+	return Chain(sm, RequestId, ClientIP(trustedProxies), AccessLog(accessLogger), Recover, Cors(corsPolicy), Timeout(timeout), rateLimits.ByIP(sm), AccessLogRoute)
+}
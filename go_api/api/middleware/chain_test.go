@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	order := []string{}
+	named := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	Chain(handler, named("first"), named("second")).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []string{"first", "second", "handler"}, order, "Expected the first middleware to be the outermost")
+}
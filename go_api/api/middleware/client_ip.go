@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// ClientIP resolves the IP address of the client once per request, available
+// through util.ClientIP. The X-Forwarded-For header is only believed when the
+// peer is one of trustedProxies, anyone else could forge it.
+func ClientIP(trustedProxies []netip.Prefix) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := util.ForwardedClientIP(r, trustedProxies)
+
+			next.ServeHTTP(w, r.WithContext(util.ContextWithClientIP(r.Context(), ip)))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		trustedProxies []netip.Prefix
+		expectedIP     string
+	}{
+		{
+			name:           "Behind a trusted proxy",
+			trustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+			expectedIP:     "198.51.100.7",
+		},
+		{
+			name:           "No trusted proxy",
+			trustedProxies: nil,
+			expectedIP:     "192.0.2.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen string
+			handler := ClientIP(tc.trustedProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = util.ClientIP(r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set(util.ForwardedForHeader, "198.51.100.7")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.expectedIP, seen, "Expected client IP to match.")
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CorsPolicy tells which other origins browsers may call the API from.
+// AllowedOrigins holds exact origins, such as "https://admin.example.com",
+// patterns matching any subdomain, such as "https://*.example.com", or "*" for
+// any origin. MaxAge is how long, in seconds, browsers may cache a preflight.
+type CorsPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           uint
+}
+
+// Cors answers preflight requests from allowed origins and lets browsers read
+// the responses to the others. Preflights are answered before routing, as no
+// route accepts OPTIONS. A policy with no allowed origin changes nothing.
+func Cors(policy CorsPolicy) Middleware {
+	matches := originMatcher(policy.AllowedOrigins)
+
+	return func(next http.Handler) http.Handler {
+		if len(policy.AllowedOrigins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if len(origin) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			preflight := r.Method == http.MethodOptions && len(r.Header.Get("Access-Control-Request-Method")) > 0
+
+			h := w.Header()
+			// The answer depends on the origin, caches must not share it.
+			h.Add("Vary", "Origin")
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if matches(origin) {
+				// The origin is echoed rather than "*", which browsers
+				// refuse along with credentials.
+				h.Set("Access-Control-Allow-Origin", origin)
+				if policy.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+
+				if preflight {
+					h.Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+					h.Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+					h.Set("Access-Control-Max-Age", strconv.FormatUint(uint64(policy.MaxAge), 10))
+				} else if len(policy.ExposedHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+				}
+			}
+
+			// Preflights of origins that are not allowed are answered all
+			// the same, without the headers the browser needs to go on.
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// originMatcher returns whether an origin is one of allowed. Origins are
+// compared case insensitively, without trailing slashes.
+func originMatcher(allowed []string) func(origin string) bool {
+	type wildcard struct{ prefix, suffix string }
+
+	var exact []string
+	var wildcards []wildcard
+	anyOrigin := false
+
+	for _, o := range allowed {
+		o = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(o)), "/")
+		scheme, host, ok := strings.Cut(o, "://*.")
+		switch {
+		case o == "*":
+			anyOrigin = true
+		case ok:
+			wildcards = append(wildcards, wildcard{prefix: scheme + "://", suffix: "." + host})
+		default:
+			exact = append(exact, o)
+		}
+	}
+
+	return func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+
+		origin = strings.ToLower(origin)
+		if slices.Contains(exact, origin) {
+			return true
+		}
+
+		for _, w := range wildcards {
+			if len(origin) > len(w.prefix)+len(w.suffix) && strings.HasPrefix(origin, w.prefix) && strings.HasSuffix(origin, w.suffix) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCors(t *testing.T) {
+
+	policy := CorsPolicy{
+		AllowedOrigins:   []string{"https://admin.example.com/", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+
+	testCases := []struct {
+		name            string
+		policy          CorsPolicy
+		method          string
+		origin          string
+		requestMethod   string
+		expectNext      bool
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "Same origin",
+			policy:         policy,
+			method:         http.MethodPost,
+			expectNext:     true,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "",
+			},
+		},
+		{
+			name:           "Allowed origin",
+			policy:         policy,
+			method:         http.MethodPost,
+			origin:         "https://Admin.example.com",
+			expectNext:     true,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://Admin.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Request-ID",
+				"Access-Control-Allow-Methods":     "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			name:           "Allowed subdomain",
+			policy:         policy,
+			method:         http.MethodGet,
+			origin:         "https://back.office.example.org",
+			expectNext:     true,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "https://back.office.example.org",
+			},
+		},
+		{
+			name:           "Wildcard does not match the domain itself",
+			policy:         policy,
+			method:         http.MethodGet,
+			origin:         "https://example.org",
+			expectNext:     true,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+				"Vary":                        "Origin",
+			},
+		},
+		{
+			name:           "Wildcard does not match another scheme",
+			policy:         policy,
+			method:         http.MethodGet,
+			origin:         "http://admin.example.org",
+			expectNext:     true,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:           "Preflight",
+			policy:         policy,
+			method:         http.MethodOptions,
+			origin:         "https://admin.example.com",
+			requestMethod:  http.MethodPost,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://admin.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+				"Access-Control-Max-Age":           "600",
+				"Access-Control-Expose-Headers":    "",
+			},
+		},
+		{
+			name:           "Preflight from another origin",
+			policy:         policy,
+			method:         http.MethodOptions,
+			origin:         "https://evil.example.net",
+			requestMethod:  http.MethodPost,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:           "OPTIONS without requested method",
+			policy:         policy,
+			method:         http.MethodOptions,
+			origin:         "https://admin.example.com",
+			expectNext:     true,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:           "Any origin",
+			policy:         CorsPolicy{AllowedOrigins: []string{"*"}},
+			method:         http.MethodGet,
+			origin:         "https://app.example.net",
+			expectNext:     true,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.net",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:           "No allowed origin",
+			policy:         CorsPolicy{},
+			method:         http.MethodOptions,
+			origin:         "https://admin.example.com",
+			requestMethod:  http.MethodPost,
+			expectNext:     true,
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Vary": "",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			nextCalled := false
+			handler := Cors(tc.policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tc.method, "/users", nil)
+			if len(tc.origin) > 0 {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if len(tc.requestMethod) > 0 {
+				req.Header.Set("Access-Control-Request-Method", tc.requestMethod)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectNext, nextCalled, "Expected the next handler to be called or not")
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			for name, value := range tc.expectedHeaders {
+				assert.Equal(t, value, rr.Header().Get(name), "Expected header %s to match", name)
+			}
+		})
+	}
+}
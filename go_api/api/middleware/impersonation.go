@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// ContextWithImpersonator marks ctx as authenticated by an impersonation token
+// of actor.
+func ContextWithImpersonator(ctx context.Context, actor *domain.ActorClaims) context.Context {
+	return context.WithValue(ctx, impersonatorContextKey, actor)
+}
+
+// ImpersonatorFromContext returns the administrator acting as the
+// authenticated user. The second value is false outside of impersonation.
+func ImpersonatorFromContext(ctx context.Context) (*domain.ActorClaims, bool) {
+	actor, ok := ctx.Value(impersonatorContextKey).(*domain.ActorClaims)
+	return actor, ok
+}
+
+// ForbidImpersonation wraps an authenticated handler performing a destructive
+// operation so it cannot be reached with an impersonation token.
+func ForbidImpersonation(handler AuthenticationHandler) AuthenticationHandler {
+	return func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		if _, ok := ImpersonatorFromContext(r.Context()); ok {
+			util.ProblemResponse(w, r, domain.ErrImpersonationForbidden)
+			return
+		}
+
+		handler(w, r, u)
+	}
+}
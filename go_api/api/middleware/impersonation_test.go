@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForbidImpersonation(t *testing.T) {
+
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	testCases := []struct {
+		name           string
+		actor          *domain.ActorClaims
+		expectedStatus int
+	}{
+		{
+			name:           "Regular token",
+			actor:          nil,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Impersonation token",
+			actor:          &domain.ActorClaims{ID: 2, Email: "admin@example.com"},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodDelete, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.actor != nil {
+				req = req.WithContext(ContextWithImpersonator(req.Context(), tc.actor))
+			}
+			rr := httptest.NewRecorder()
+
+			ForbidImpersonation(mockAuthenticationHandler)(rr, req, &domain.User{ID: 1})
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+		})
+	}
+}
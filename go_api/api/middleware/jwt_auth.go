@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strings"
 
@@ -10,35 +12,172 @@ import (
 
 type AuthenticationHandler func(http.ResponseWriter, *http.Request, *domain.User)
 
+// ApiKeyAuthenticator resolves a raw API key to its owner and granted scopes.
+type ApiKeyAuthenticator interface {
+	AuthenticateApiKey(ctx context.Context, key string) (*domain.User, []string, error)
+}
+
+// SessionValidator checks that the session linked to an access token through
+// its "jti" claim has not been revoked.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, userId uint, tokenId string) (*domain.Session, error)
+}
+
+// UserStatusChecker refuses users that can no longer authenticate, such as
+// suspended or deleted ones, even though their token is still valid. The actor
+// of an impersonation must in addition still be an admin.
+type UserStatusChecker interface {
+	CheckUserStatus(ctx context.Context, userId uint) error
+	CheckImpersonatorStatus(ctx context.Context, actorId uint) error
+}
+
+type contextKey string
+
+const (
+	apiKeyScopesContextKey contextKey = "apiKeyScopes"
+	apiKeyHashContextKey   contextKey = "apiKeyHash"
+	sessionIdContextKey    contextKey = "sessionId"
+	impersonatorContextKey contextKey = "impersonator"
+	preferencesContextKey  contextKey = "preferences"
+	accessLogContextKey    contextKey = "accessLog"
+)
+
 type JwtAuthenticator struct {
-	handler       AuthenticationHandler
-	JwtSigningKey []byte
+	handler             AuthenticationHandler
+	JwtKeySet           *util.JwtKeySet
+	SessionValidator    SessionValidator
+	ApiKeyAuthenticator ApiKeyAuthenticator
+	UserStatusChecker   UserStatusChecker
 }
 
 func (ja *JwtAuthenticator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if apiKey, ok := ja.apiKey(r); ok {
+		ja.serveApiKey(w, r, apiKey)
+		return
+	}
+
 	authHeader := r.Header.Get("Authorization")
 	splitToken := strings.Split(authHeader, " ")
 	if len(splitToken) != 2 {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		util.ProblemResponse(w, r, domain.ErrUnauthenticated)
 		return
 	}
 
-	if splitToken[0] != "bearer" {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	if !strings.EqualFold(splitToken[0], "bearer") {
+		util.ProblemResponse(w, r, domain.ErrUnauthenticated)
 		return
 	}
 
 	authToken := splitToken[1]
 
-	user, err := util.RecoverUserFromToken(authToken, ja.JwtSigningKey)
+	claims, err := util.RecoverClaimsFromToken(authToken, ja.JwtKeySet)
 	if err != nil {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		util.ProblemResponse(w, r, domain.ErrUnauthenticated)
 		return
 	}
 
-	ja.handler(w, r, user)
+	if ja.SessionValidator != nil {
+		session, err := ja.SessionValidator.ValidateSession(r.Context(), claims.ID, claims.Id)
+		if err != nil {
+			util.ProblemResponse(w, r, domain.ErrUnauthenticated)
+			return
+		}
+
+		r = r.WithContext(ContextWithSessionId(r.Context(), session.ID))
+	}
+
+	if ja.UserStatusChecker != nil {
+		err = ja.UserStatusChecker.CheckUserStatus(r.Context(), claims.ID)
+		if err != nil {
+			util.ProblemResponse(w, r, domain.ErrUnauthenticated)
+			return
+		}
+
+		if claims.Actor != nil {
+			err = ja.UserStatusChecker.CheckImpersonatorStatus(r.Context(), claims.Actor.ID)
+			if err != nil {
+				util.ProblemResponse(w, r, domain.ErrUnauthenticated)
+				return
+			}
+		}
+	}
+
+	// Every request made while impersonating is logged with both identities.
+	if claims.Actor != nil {
+		log.Printf("impersonation: actor %d <%s> as user %d <%s>: %s %s", claims.Actor.ID, claims.Actor.Email, claims.ID, claims.Email, r.Method, r.URL.RequestURI())
+		r = r.WithContext(ContextWithImpersonator(r.Context(), claims.Actor))
+	}
+
+	setAccessLogUser(r.Context(), claims.ID)
+	ja.handler(w, r, claims.User())
+}
+
+// apiKey extracts an API key from either the "X-API-Key" header or an
+// "Authorization: ApiKey <key>" header. Keys are ignored when no
+// ApiKeyAuthenticator is configured.
+func (ja *JwtAuthenticator) apiKey(r *http.Request) (string, bool) {
+	if ja.ApiKeyAuthenticator == nil {
+		return "", false
+	}
+
+	if key := r.Header.Get("X-API-Key"); len(key) > 0 {
+		return key, true
+	}
+
+	splitToken := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(splitToken) == 2 && strings.EqualFold(splitToken[0], "apikey") {
+		return splitToken[1], true
+	}
+
+	return "", false
+}
+
+func (ja *JwtAuthenticator) serveApiKey(w http.ResponseWriter, r *http.Request, apiKey string) {
+	user, scopes, err := ja.ApiKeyAuthenticator.AuthenticateApiKey(r.Context(), apiKey)
+	if err != nil {
+		util.ProblemResponse(w, r, domain.ErrUnauthenticated)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), apiKeyScopesContextKey, scopes)
+	ctx = context.WithValue(ctx, apiKeyHashContextKey, util.HashOpaqueToken(apiKey))
+
+	setAccessLogUser(ctx, user.ID)
+	ja.handler(w, r.WithContext(ctx), user)
+}
+
+// ApiKeyScopesFromContext returns the scopes of the API key that authenticated
+// the request. The second value is false for requests authenticated otherwise.
+func ApiKeyScopesFromContext(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(apiKeyScopesContextKey).([]string)
+	return scopes, ok
+}
+
+// apiKeyHashFromContext returns the hash of the API key that authenticated the
+// request, which tells it apart from the other keys of its user.
+func apiKeyHashFromContext(ctx context.Context) (string, bool) {
+	keyHash, ok := ctx.Value(apiKeyHashContextKey).(string)
+	return keyHash, ok
+}
+
+// ContextWithSessionId marks ctx as authenticated by the access token of
+// session sessionId.
+func ContextWithSessionId(ctx context.Context, sessionId uint) context.Context {
+	return context.WithValue(ctx, sessionIdContextKey, sessionId)
+}
+
+// SessionIdFromContext returns the session of the access token that
+// authenticated the request. The second value is false for requests
+// authenticated otherwise.
+func SessionIdFromContext(ctx context.Context) (uint, bool) {
+	sessionId, ok := ctx.Value(sessionIdContextKey).(uint)
+	return sessionId, ok
 }
 
-func NewJwtAuthenticator(handlerToWrap AuthenticationHandler, jwtSigningKey []byte) *JwtAuthenticator {
-	return &JwtAuthenticator{handlerToWrap, jwtSigningKey}
+// NewJwtAuthenticator wraps handlerToWrap so it only runs for authenticated
+// requests. A nil sessionValidator skips the revocation check, a nil
+// apiKeyAuthenticator refuses API keys and a nil userStatusChecker trusts the
+// token alone. API keys are checked by their authenticator.
+func NewJwtAuthenticator(handlerToWrap AuthenticationHandler, jwtKeySet *util.JwtKeySet, sessionValidator SessionValidator, apiKeyAuthenticator ApiKeyAuthenticator, userStatusChecker UserStatusChecker) *JwtAuthenticator {
+	return &JwtAuthenticator{handlerToWrap, jwtKeySet, sessionValidator, apiKeyAuthenticator, userStatusChecker}
 }
@@ -1,15 +1,52 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Daffc/GO-Sales/domain"
 	"github.com/Daffc/GO-Sales/internal/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// mockApiKeyAuthenticator implements ApiKeyAuthenticator.
+type mockApiKeyAuthenticator struct {
+	mock.Mock
+}
+
+func (m *mockApiKeyAuthenticator) AuthenticateApiKey(ctx context.Context, key string) (*domain.User, []string, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(*domain.User), args.Get(1).([]string), args.Error(2)
+}
+
+// mockSessionValidator implements SessionValidator.
+type mockSessionValidator struct {
+	mock.Mock
+}
+
+func (m *mockSessionValidator) ValidateSession(ctx context.Context, userId uint, tokenId string) (*domain.Session, error) {
+	args := m.Called(ctx, userId, tokenId)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+type mockUserStatusChecker struct {
+	mock.Mock
+}
+
+func (m *mockUserStatusChecker) CheckUserStatus(ctx context.Context, userId uint) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *mockUserStatusChecker) CheckImpersonatorStatus(ctx context.Context, actorId uint) error {
+	args := m.Called(ctx, actorId)
+	return args.Error(0)
+}
+
 func TestServeHTTP(t *testing.T) {
 
 	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
@@ -17,8 +54,11 @@ func TestServeHTTP(t *testing.T) {
 		w.Write([]byte("success"))
 	}
 
-	mockJwtSigningKey := []byte("test-signing-key")
-	mockAuthenticator := NewJwtAuthenticator(mockAuthenticationHandler, mockJwtSigningKey)
+	mockJwtKeySet, err := util.NewHmacJwtKeySet([]byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockAuthenticator := NewJwtAuthenticator(mockAuthenticationHandler, mockJwtKeySet, nil, nil, nil)
 
 	validUser := &domain.User{
 		ID:    1,
@@ -26,7 +66,7 @@ func TestServeHTTP(t *testing.T) {
 		Email: "user1.example.com",
 	}
 
-	validToken, _ := util.NewAccessToken(validUser, mockJwtSigningKey, 1)
+	validToken, _ := util.NewAccessToken(validUser, mockJwtKeySet, 1, "token-id")
 
 	testCases := []struct {
 		name            string
@@ -82,3 +122,360 @@ func TestServeHTTP(t *testing.T) {
 		})
 	}
 }
+
+func TestServeHTTPApiKey(t *testing.T) {
+
+	var receivedScopes []string
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		receivedScopes, _ = ApiKeyScopesFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}
+
+	mockJwtKeySet, err := util.NewHmacJwtKeySet([]byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockApiKeyAuthenticator := new(mockApiKeyAuthenticator)
+	mockAuthenticator := NewJwtAuthenticator(mockAuthenticationHandler, mockJwtKeySet, nil, mockApiKeyAuthenticator, nil)
+
+	validUser := &domain.User{
+		ID:    1,
+		Name:  "User1",
+		Email: "user1.example.com",
+	}
+	validScopes := []string{domain.ScopeUsersRead}
+
+	validToken, _ := util.NewAccessToken(validUser, mockJwtKeySet, 1, "token-id")
+
+	testCases := []struct {
+		name             string
+		header           string
+		value            string
+		mockKey          string
+		mockUserReturn   *domain.User
+		mockScopesReturn []string
+		mockError        error
+		expectedStatus   int
+		expectedScopes   []string
+	}{
+		{
+			name:             "Valid X-API-Key header",
+			header:           "X-API-Key",
+			value:            "gsk_valid",
+			mockKey:          "gsk_valid",
+			mockUserReturn:   validUser,
+			mockScopesReturn: validScopes,
+			expectedStatus:   http.StatusOK,
+			expectedScopes:   validScopes,
+		},
+		{
+			name:             "Valid ApiKey authorization header",
+			header:           "Authorization",
+			value:            "ApiKey gsk_valid",
+			mockKey:          "gsk_valid",
+			mockUserReturn:   validUser,
+			mockScopesReturn: validScopes,
+			expectedStatus:   http.StatusOK,
+			expectedScopes:   validScopes,
+		},
+		{
+			name:             "Invalid API key",
+			header:           "X-API-Key",
+			value:            "gsk_invalid",
+			mockKey:          "gsk_invalid",
+			mockUserReturn:   (*domain.User)(nil),
+			mockScopesReturn: []string(nil),
+			mockError:        domain.ErrApiKeyInvalid,
+			expectedStatus:   http.StatusUnauthorized,
+		},
+		{
+			name:           "Bearer token still accepted",
+			header:         "Authorization",
+			value:          "Bearer " + validToken,
+			expectedStatus: http.StatusOK,
+			expectedScopes: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiKeyAuthenticator.ExpectedCalls = nil
+			receivedScopes = nil
+
+			if len(tc.mockKey) > 0 {
+				mockApiKeyAuthenticator.On("AuthenticateApiKey", mock.Anything, tc.mockKey).Return(tc.mockUserReturn, tc.mockScopesReturn, tc.mockError)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add(tc.header, tc.value)
+			rr := httptest.NewRecorder()
+
+			mockAuthenticator.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedScopes, receivedScopes, "Expected scopes to match.")
+		})
+	}
+}
+
+func TestServeHTTPSession(t *testing.T) {
+
+	var receivedSessionId uint
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		receivedSessionId, _ = SessionIdFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}
+
+	mockJwtKeySet, err := util.NewHmacJwtKeySet([]byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockSessionValidator := new(mockSessionValidator)
+	mockAuthenticator := NewJwtAuthenticator(mockAuthenticationHandler, mockJwtKeySet, mockSessionValidator, nil, nil)
+
+	validUser := &domain.User{
+		ID:    1,
+		Name:  "User1",
+		Email: "user1.example.com",
+	}
+
+	validToken, _ := util.NewAccessToken(validUser, mockJwtKeySet, 1, "token-id")
+	revokedToken, _ := util.NewAccessToken(validUser, mockJwtKeySet, 1, "revoked-token-id")
+
+	testCases := []struct {
+		name              string
+		token             string
+		mockTokenId       string
+		mockReturn        *domain.Session
+		mockError         error
+		expectedStatus    int
+		expectedSessionId uint
+	}{
+		{
+			name:              "Active session",
+			token:             validToken,
+			mockTokenId:       "token-id",
+			mockReturn:        &domain.Session{ID: 7, UserID: 1, TokenID: "token-id"},
+			expectedStatus:    http.StatusOK,
+			expectedSessionId: 7,
+		},
+		{
+			name:           "Revoked session",
+			token:          revokedToken,
+			mockTokenId:    "revoked-token-id",
+			mockReturn:     nil,
+			mockError:      domain.ErrSessionInvalid,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSessionValidator.ExpectedCalls = nil
+			receivedSessionId = 0
+
+			mockSessionValidator.On("ValidateSession", mock.Anything, validUser.ID, tc.mockTokenId).Return(tc.mockReturn, tc.mockError)
+
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			mockAuthenticator.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedSessionId, receivedSessionId, "Expected session id to match.")
+			mockSessionValidator.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServeHTTPUserStatus(t *testing.T) {
+
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	mockJwtKeySet, err := util.NewHmacJwtKeySet([]byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockUserStatusChecker := new(mockUserStatusChecker)
+	mockAuthenticator := NewJwtAuthenticator(mockAuthenticationHandler, mockJwtKeySet, nil, nil, mockUserStatusChecker)
+
+	user := &domain.User{ID: 1, Name: "User1", Email: "user1@example.com"}
+	token, _ := util.NewAccessToken(user, mockJwtKeySet, 1, "token-id")
+
+	testCases := []struct {
+		name           string
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Active user",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Suspended user",
+			mockError:      domain.ErrUserSuspended,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Deleted user",
+			mockError:      domain.ErrUserNotFound,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserStatusChecker.ExpectedCalls = nil
+			mockUserStatusChecker.On("CheckUserStatus", mock.Anything, user.ID).Return(tc.mockError)
+
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+
+			mockAuthenticator.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			mockUserStatusChecker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServeHTTPImpersonation(t *testing.T) {
+
+	var receivedUser *domain.User
+	var receivedActor *domain.ActorClaims
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		receivedUser = u
+		receivedActor, _ = ImpersonatorFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}
+
+	mockJwtKeySet, err := util.NewHmacJwtKeySet([]byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockAuthenticator := NewJwtAuthenticator(mockAuthenticationHandler, mockJwtKeySet, nil, nil, nil)
+
+	user := &domain.User{ID: 1, Name: "User1", Email: "user1@example.com", Role: domain.RoleUser}
+	admin := &domain.User{ID: 2, Name: "Admin", Email: "admin@example.com", Role: domain.RoleAdmin}
+
+	impersonationToken, err := util.NewImpersonationToken(user, admin, mockJwtKeySet, time.Minute, "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, err := util.NewAccessToken(user, mockJwtKeySet, 1, "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		token         string
+		expectedActor *domain.ActorClaims
+	}{
+		{
+			name:          "Impersonation token",
+			token:         impersonationToken,
+			expectedActor: &domain.ActorClaims{ID: admin.ID, Email: admin.Email},
+		},
+		{
+			name:          "Access token",
+			token:         accessToken,
+			expectedActor: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			receivedUser, receivedActor = nil, nil
+
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			mockAuthenticator.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, user, receivedUser, "Expected the impersonated user to be authenticated.")
+			assert.Equal(t, tc.expectedActor, receivedActor, "Expected actor to match.")
+		})
+	}
+}
+
+func TestServeHTTPImpersonatorStatus(t *testing.T) {
+
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	mockJwtKeySet, err := util.NewHmacJwtKeySet([]byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockUserStatusChecker := new(mockUserStatusChecker)
+	mockAuthenticator := NewJwtAuthenticator(mockAuthenticationHandler, mockJwtKeySet, nil, nil, mockUserStatusChecker)
+
+	user := &domain.User{ID: 1, Name: "User1", Email: "user1@example.com", Role: domain.RoleUser}
+	admin := &domain.User{ID: 2, Name: "Admin", Email: "admin@example.com", Role: domain.RoleAdmin}
+
+	token, err := util.NewImpersonationToken(user, admin, mockJwtKeySet, time.Minute, "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "Active admin",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Suspended admin",
+			mockError:      domain.ErrUserSuspended,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "No longer admin",
+			mockError:      domain.ErrRoleForbidden,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserStatusChecker.ExpectedCalls = nil
+			mockUserStatusChecker.On("CheckUserStatus", mock.Anything, user.ID).Return(nil)
+			mockUserStatusChecker.On("CheckImpersonatorStatus", mock.Anything, admin.ID).Return(tc.mockError)
+
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Add("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+
+			mockAuthenticator.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			mockUserStatusChecker.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// PreferenceLoader returns the preferences of a user, defaults included.
+type PreferenceLoader interface {
+	LoadPreferences(ctx context.Context, userId uint) (*domain.UserPreferences, error)
+}
+
+// ContextWithPreferences attaches the preferences of the authenticated user to
+// ctx.
+func ContextWithPreferences(ctx context.Context, p *domain.UserPreferences) context.Context {
+	return context.WithValue(ctx, preferencesContextKey, p)
+}
+
+// PreferencesFromContext returns the preferences of the authenticated user.
+// The second value is false when they were not loaded.
+func PreferencesFromContext(ctx context.Context) (*domain.UserPreferences, bool) {
+	p, ok := ctx.Value(preferencesContextKey).(*domain.UserPreferences)
+	return p, ok
+}
+
+// WithPreferences wraps an authenticated handler so it runs with the
+// preferences of the user in the request context, and so the timestamps of its
+// JSON responses are rendered in the timezone of the user. A failure to load
+// them is not worth refusing the request, it is served in UTC instead.
+func WithPreferences(loader PreferenceLoader, handler AuthenticationHandler) AuthenticationHandler {
+	return func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		p, err := loader.LoadPreferences(r.Context(), u.ID)
+		if err != nil {
+			log.Println(err)
+			handler(w, r, u)
+			return
+		}
+
+		handler(util.NewLocalizedResponseWriter(w, p.Location()), r.WithContext(ContextWithPreferences(r.Context(), p)), u)
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockPreferenceLoader struct {
+	mock.Mock
+}
+
+func (m *mockPreferenceLoader) LoadPreferences(ctx context.Context, userId uint) (*domain.UserPreferences, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).(*domain.UserPreferences), args.Error(1)
+}
+
+func TestWithPreferences(t *testing.T) {
+
+	mockPreferenceLoader := new(mockPreferenceLoader)
+	createdAt := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		util.JSONResponse(w, &struct {
+			CreatedAt time.Time `json:"created_at"`
+		}{CreatedAt: createdAt}, http.StatusOK)
+	}
+
+	testCases := []struct {
+		name              string
+		mockReturn        *domain.UserPreferences
+		mockError         error
+		expectPreferences bool
+		expectedBody      string
+	}{
+		{
+			name:              "User timezone",
+			mockReturn:        &domain.UserPreferences{UserID: 1, Timezone: "America/Sao_Paulo", PageSize: 50},
+			expectPreferences: true,
+			expectedBody:      "{\"created_at\":\"2024-01-01T09:00:00-03:00\"}\n",
+		},
+		{
+			name:         "Load error",
+			mockReturn:   (*domain.UserPreferences)(nil),
+			mockError:    errors.New("database down"),
+			expectedBody: "{\"created_at\":\"2024-01-01T12:00:00Z\"}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPreferenceLoader.ExpectedCalls = nil
+			mockPreferenceLoader.On("LoadPreferences", mock.Anything, uint(1)).Return(tc.mockReturn, tc.mockError)
+
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+
+			var preferences *domain.UserPreferences
+			var found bool
+			WithPreferences(mockPreferenceLoader, func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+				preferences, found = PreferencesFromContext(r.Context())
+				mockAuthenticationHandler(w, r, u)
+			})(rr, req, &domain.User{ID: 1})
+
+			assert.Equal(t, http.StatusOK, rr.Code, "Expected the request to be served")
+			assert.Equal(t, tc.expectedBody, rr.Body.String(), "Expected timestamps in the timezone of the user")
+			assert.Equal(t, tc.expectPreferences, found, "Expected preferences in the context to match")
+			if tc.expectPreferences {
+				assert.Equal(t, tc.mockReturn, preferences, "Expected the loaded preferences")
+			}
+		})
+	}
+}
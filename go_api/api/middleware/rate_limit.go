@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// RateLimiter takes a token from the bucket of key, filled according to
+// policy.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, policy domain.RateLimitPolicy) (*domain.RateLimitResult, error)
+}
+
+// RouteMatcher tells the pattern of the route serving r, as *http.ServeMux
+// does.
+type RouteMatcher interface {
+	Handler(r *http.Request) (http.Handler, string)
+}
+
+// RateLimits limits the requests to the routes it has a policy for, by the
+// pattern they were registered with. Each route and client get a bucket of
+// their own.
+type RateLimits struct {
+	limiter  RateLimiter
+	policies map[string]domain.RateLimitPolicy
+}
+
+func NewRateLimits(limiter RateLimiter, policies map[string]domain.RateLimitPolicy) *RateLimits {
+	return &RateLimits{limiter: limiter, policies: policies}
+}
+
+// ByIP enforces the policies keyed by IP. It runs before routing, so it finds
+// the route of requests through routes, and refuses them before any work is
+// done for them.
+func (rl *RateLimits) ByIP(routes RouteMatcher) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, pattern := routes.Handler(r)
+
+			policy, ok := rl.policies[pattern]
+			if ok && policy.Key == domain.RateLimitKeyIP && !rl.allow(w, r, pattern, "ip:"+util.ClientIP(r), policy) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ByCaller wraps an authenticated handler to enforce the policies keyed by
+// user or API key. Requests authenticated by access token have no API key,
+// they count against their user either way. Policies keyed this way are not
+// enforced on routes open to anonymous clients.
+func (rl *RateLimits) ByCaller(handler AuthenticationHandler) AuthenticationHandler {
+	return func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		policy, ok := rl.policies[r.Pattern]
+		if !ok || policy.Key == domain.RateLimitKeyIP {
+			handler(w, r, u)
+			return
+		}
+
+		subject := fmt.Sprintf("user:%d", u.ID)
+		if keyHash, ok := apiKeyHashFromContext(r.Context()); ok && policy.Key == domain.RateLimitKeyApiKey {
+			subject = "api_key:" + keyHash
+		}
+
+		if rl.allow(w, r, r.Pattern, subject, policy) {
+			handler(w, r, u)
+		}
+	}
+}
+
+// allow takes a token for subject on the route and tells the client how many
+// it has left. When there was none, it answers with a 429 and returns false.
+// A failure of the limiter is not worth refusing the request over.
+func (rl *RateLimits) allow(w http.ResponseWriter, r *http.Request, pattern string, subject string, policy domain.RateLimitPolicy) bool {
+	res, err := rl.limiter.Allow(r.Context(), pattern+"|"+subject, policy)
+	if err != nil {
+		log.Println(err)
+		return true
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", policy.Limit, ceilSeconds(policy.Period)))
+	h.Set("RateLimit-Limit", strconv.FormatUint(uint64(res.Limit), 10))
+	h.Set("RateLimit-Remaining", strconv.FormatUint(uint64(res.Remaining), 10))
+	h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+	if !res.Allowed {
+		h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+		util.ProblemResponse(w, r, domain.ErrRateLimited)
+		return false
+	}
+
+	return true
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockRateLimiter struct {
+	mock.Mock
+}
+
+func (m *mockRateLimiter) Allow(ctx context.Context, key string, policy domain.RateLimitPolicy) (*domain.RateLimitResult, error) {
+	args := m.Called(ctx, key, policy)
+	return args.Get(0).(*domain.RateLimitResult), args.Error(1)
+}
+
+var testRateLimitPolicies = map[string]domain.RateLimitPolicy{
+	"POST /login":      {Limit: 5, Period: time.Minute, Key: domain.RateLimitKeyIP},
+	"/users":           {Limit: 100, Period: time.Minute, Key: domain.RateLimitKeyUser},
+	"GET /me/api-keys": {Limit: 10, Period: time.Second, Key: domain.RateLimitKeyApiKey},
+}
+
+func TestRateLimitsByIP(t *testing.T) {
+
+	testCases := []struct {
+		name            string
+		path            string
+		mockReturn      *domain.RateLimitResult
+		mockError       error
+		expectAllow     bool
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		{
+			name:           "Allowed",
+			path:           "/login",
+			mockReturn:     &domain.RateLimitResult{Allowed: true, Limit: 5, Remaining: 4, Reset: 12 * time.Second},
+			expectAllow:    true,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"RateLimit-Policy":    "5;w=60",
+				"RateLimit-Limit":     "5",
+				"RateLimit-Remaining": "4",
+				"RateLimit-Reset":     "12",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:           "Refused",
+			path:           "/login",
+			mockReturn:     &domain.RateLimitResult{Allowed: false, Limit: 5, Remaining: 0, Reset: 59500 * time.Millisecond, RetryAfter: 1500 * time.Millisecond},
+			expectAllow:    true,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeaders: map[string]string{
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "2",
+				"Content-Type":        util.ProblemContentType,
+			},
+		},
+		{
+			name:           "Limiter failure",
+			path:           "/login",
+			mockReturn:     (*domain.RateLimitResult)(nil),
+			mockError:      errors.New("database error"),
+			expectAllow:    true,
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+			},
+		},
+		{
+			name:           "Route keyed by user",
+			path:           "/users",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Route without policy",
+			path:           "/verify-email",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			mockRateLimiter := new(mockRateLimiter)
+			if tc.expectAllow {
+				mockRateLimiter.On("Allow", mock.Anything, "POST /login|ip:192.0.2.1", testRateLimitPolicies["POST /login"]).Return(tc.mockReturn, tc.mockError)
+			}
+
+			sm := http.NewServeMux()
+			noContent := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+			sm.HandleFunc("POST /login", noContent)
+			sm.HandleFunc("/users", noContent)
+			sm.HandleFunc("/verify-email", noContent)
+
+			handler := NewRateLimits(mockRateLimiter, testRateLimitPolicies).ByIP(sm)(sm)
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			for name, value := range tc.expectedHeaders {
+				assert.Equal(t, value, rr.Header().Get(name), "Expected header %s to match", name)
+			}
+			mockRateLimiter.AssertExpectations(t)
+			if !tc.expectAllow {
+				mockRateLimiter.AssertNotCalled(t, "Allow", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRateLimitsByCaller(t *testing.T) {
+
+	user := &domain.User{ID: 7}
+	apiKeyHash := util.HashOpaqueToken("gsk_key")
+
+	testCases := []struct {
+		name           string
+		pattern        string
+		apiKey         bool
+		expectedKey    string
+		mockReturn     *domain.RateLimitResult
+		expectedStatus int
+	}{
+		{
+			name:           "Keyed by user",
+			pattern:        "/users",
+			apiKey:         true,
+			expectedKey:    "/users|user:7",
+			mockReturn:     &domain.RateLimitResult{Allowed: true, Limit: 100, Remaining: 99},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Keyed by API key",
+			pattern:        "GET /me/api-keys",
+			apiKey:         true,
+			expectedKey:    "GET /me/api-keys|api_key:" + apiKeyHash,
+			mockReturn:     &domain.RateLimitResult{Allowed: true, Limit: 10, Remaining: 9},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Keyed by API key, authenticated by access token",
+			pattern:        "GET /me/api-keys",
+			expectedKey:    "GET /me/api-keys|user:7",
+			mockReturn:     &domain.RateLimitResult{Allowed: false, Limit: 10, RetryAfter: 100 * time.Millisecond},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "Route keyed by IP",
+			pattern:        "POST /login",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Route without policy",
+			pattern:        "GET /me",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			mockRateLimiter := new(mockRateLimiter)
+			if len(tc.expectedKey) > 0 {
+				mockRateLimiter.On("Allow", mock.Anything, tc.expectedKey, testRateLimitPolicies[tc.pattern]).Return(tc.mockReturn, nil)
+			}
+
+			handler := NewRateLimits(mockRateLimiter, testRateLimitPolicies).ByCaller(func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Pattern = tc.pattern
+			if tc.apiKey {
+				req = req.WithContext(context.WithValue(req.Context(), apiKeyHashContextKey, apiKeyHash))
+			}
+			rr := httptest.NewRecorder()
+
+			handler(rr, req, user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			mockRateLimiter.AssertExpectations(t)
+			if len(tc.expectedKey) == 0 {
+				mockRateLimiter.AssertNotCalled(t, "Allow", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// Recover answers with a 500 problem when a handler panics, instead of dropping
+// the connection, and logs the panic with its stack and the request ID. A
+// response already under way is left as it is.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &responseRecorder{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// Handlers abort responses on purpose with this one, the server
+			// knows not to log it.
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			requestId, _ := util.RequestIdFromContext(r.Context())
+			log.Printf("panic: %s %s (request %q): %v\n%s", r.Method, r.URL.RequestURI(), requestId, v, debug.Stack())
+
+			if rec.status == 0 {
+				util.ProblemResponse(w, r, domain.ErrInternal)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "No panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/","code":"internal"}` + "\n",
+		},
+		{
+			name: "Panic after the response started",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("partial"))
+				panic("boom")
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "partial",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rr := httptest.NewRecorder()
+
+			assert.NotPanics(t, func() {
+				Recover(tc.handler).ServeHTTP(rr, req)
+			})
+
+			assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match")
+			assert.Equal(t, tc.expectedBody, rr.Body.String(), "Expected body to match")
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), req)
+	}, "Expected aborted responses to be left to the server")
+}
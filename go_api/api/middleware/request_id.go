@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// RequestIdHeader carries the ID of a request, both ways.
+const RequestIdHeader = "X-Request-ID"
+
+const requestIdSize = 16
+
+// requestIdPattern bounds the IDs taken from clients, as they end up in logs.
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestId gives every request an ID, echoed in the X-Request-ID response
+// header and available through util.RequestIdFromContext. The ID a client or proxy
+// sent in the same header is kept when it looks like one, so log lines can be
+// followed across services.
+func RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(RequestIdHeader)
+		if !requestIdPattern.MatchString(requestId) {
+			var err error
+			requestId, err = util.NewOpaqueToken(requestIdSize)
+			if err != nil {
+				log.Println(err)
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		w.Header().Set(RequestIdHeader, requestId)
+		next.ServeHTTP(w, r.WithContext(util.ContextWithRequestId(r.Context(), requestId)))
+	})
+}
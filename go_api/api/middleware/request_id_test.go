@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestId(t *testing.T) {
+
+	testCases := []struct {
+		name       string
+		incoming   string
+		expectKept bool
+	}{
+		{
+			name:       "Incoming ID",
+			incoming:   "3f2b9c1e-6a4d-4f3e-9b1a-2c5d8e7f0a11",
+			expectKept: true,
+		},
+		{
+			name:     "No incoming ID",
+			incoming: "",
+		},
+		{
+			name:     "Incoming ID with spaces",
+			incoming: "id\nforged log line",
+		},
+		{
+			name:     "Incoming ID too long",
+			incoming: strings.Repeat("a", 129),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen string
+			handler := RequestId(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen, _ = util.RequestIdFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if len(tc.incoming) > 0 {
+				req.Header.Set(RequestIdHeader, tc.incoming)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.NotEmpty(t, seen, "Expected the request to carry an ID")
+			assert.Equal(t, seen, rr.Header().Get(RequestIdHeader), "Expected the ID to be echoed")
+			if tc.expectKept {
+				assert.Equal(t, tc.incoming, seen, "Expected the incoming ID to be kept")
+			} else {
+				assert.NotEqual(t, tc.incoming, seen, "Expected a new ID")
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// RequireRole wraps an authenticated handler so it is only reached by users
+// holding the given role.
+func RequireRole(role string, handler AuthenticationHandler) AuthenticationHandler {
+	return func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		if u == nil || u.Role != role {
+			util.ProblemResponse(w, r, domain.ErrRoleForbidden)
+			return
+		}
+
+		handler(w, r, u)
+	}
+}
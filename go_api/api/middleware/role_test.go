@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRole(t *testing.T) {
+
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	testCases := []struct {
+		name           string
+		user           *domain.User
+		expectedStatus int
+	}{
+		{
+			name:           "Admin",
+			user:           &domain.User{ID: 1, Role: domain.RoleAdmin},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Regular user",
+			user:           &domain.User{ID: 2, Role: domain.RoleUser},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Missing role",
+			user:           &domain.User{ID: 3},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Nil user",
+			user:           nil,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+
+			RequireRole(domain.RoleAdmin, mockAuthenticationHandler)(rr, req, tc.user)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+)
+
+// RequireScope wraps an authenticated handler so requests made with an API key
+// are only let through when the key was granted the given scope. Requests
+// authenticated with an access token carry every scope of their user.
+func RequireScope(scope string, handler AuthenticationHandler) AuthenticationHandler {
+	return func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		scopes, ok := ApiKeyScopesFromContext(r.Context())
+		if ok && !slices.Contains(scopes, scope) {
+			util.ProblemResponse(w, r, domain.ErrScopeForbidden)
+			return
+		}
+
+		handler(w, r, u)
+	}
+}
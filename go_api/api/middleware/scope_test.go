@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireScope(t *testing.T) {
+
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	testCases := []struct {
+		name           string
+		scopes         []string
+		apiKey         bool
+		expectedStatus int
+	}{
+		{
+			name:           "Access token",
+			apiKey:         false,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "API key with scope",
+			scopes:         []string{domain.ScopeUsersRead, domain.ScopeUsersWrite},
+			apiKey:         true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "API key without scope",
+			scopes:         []string{domain.ScopeUsersWrite},
+			apiKey:         true,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.apiKey {
+				req = req.WithContext(context.WithValue(req.Context(), apiKeyScopesContextKey, tc.scopes))
+			}
+			rr := httptest.NewRecorder()
+
+			RequireScope(domain.ScopeUsersRead, mockAuthenticationHandler)(rr, req, &domain.User{ID: 1})
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+		})
+	}
+}
+
+// TestRequireScopeReadRoutes wraps handlers the way the user read routes are,
+// an API key only granted write access must not list or read users.
+func TestRequireScopeReadRoutes(t *testing.T) {
+
+	mockAuthenticationHandler := func(w http.ResponseWriter, r *http.Request, u *domain.User) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	mockJwtKeySet, err := util.NewHmacJwtKeySet([]byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		scopes         []string
+		expectedStatus int
+	}{
+		{
+			name:           "API key with read scope",
+			scopes:         []string{domain.ScopeUsersRead},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "API key with write scope only",
+			scopes:         []string{domain.ScopeUsersWrite},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiKeyAuthenticator := new(mockApiKeyAuthenticator)
+			mockApiKeyAuthenticator.On("AuthenticateApiKey", mock.Anything, "gsk_key").Return(&domain.User{ID: 1}, tc.scopes, nil)
+
+			handler := NewJwtAuthenticator(RequireScope(domain.ScopeUsersRead, mockAuthenticationHandler), mockJwtKeySet, nil, mockApiKeyAuthenticator, nil)
+
+			for _, path := range []string{"/users", "/users/2"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.Header.Set("X-API-Key", "gsk_key")
+				rr := httptest.NewRecorder()
+
+				handler.ServeHTTP(rr, req)
+
+				assert.Equal(t, tc.expectedStatus, rr.Code, "Expected status code to match for %s", path)
+			}
+			mockApiKeyAuthenticator.AssertExpectations(t)
+		})
+	}
+}
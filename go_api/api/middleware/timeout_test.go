@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+
+	var ctx context.Context
+	handler := Timeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok, "Expected the request to have a deadline")
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second, "Expected the deadline to match")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code, "Expected status code to match")
+	assert.ErrorIs(t, ctx.Err(), context.Canceled, "Expected the context to be released with the request")
+
+	handler = Timeout(time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		assert.ErrorIs(t, r.Context().Err(), context.DeadlineExceeded, "Expected the deadline to cancel the request")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
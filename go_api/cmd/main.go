@@ -2,16 +2,27 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"time"
+	// User timezones are IANA names, embedded so they load on hosts without
+	// a zoneinfo database.
+	_ "time/tzdata"
 
 	"github.com/Daffc/GO-Sales/api/handler"
+	"github.com/Daffc/GO-Sales/api/middleware"
 	_ "github.com/Daffc/GO-Sales/docs"
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/breached"
 	"github.com/Daffc/GO-Sales/internal/config"
 	"github.com/Daffc/GO-Sales/internal/database/mariadb"
+	"github.com/Daffc/GO-Sales/internal/mailer"
+	"github.com/Daffc/GO-Sales/internal/oidc"
+	"github.com/Daffc/GO-Sales/internal/util"
 	"github.com/Daffc/GO-Sales/repository"
 	"github.com/Daffc/GO-Sales/usecase"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -25,6 +36,16 @@ import (
 // @license.nameGNU	GPL
 // @license.url		https://www.gnu.org/licenses/lgpl-3.0.html
 // @host			localhost:8080
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				Type "bearer" followed by a space and the JWT token.
+//
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						X-API-Key
+// @description				API key created through /me/api-keys.
 func main() {
 
 	config, err := config.NewConfigParser(".env")
@@ -32,6 +53,11 @@ func main() {
 		panic(err)
 	}
 
+	jwtKeySet, err := util.LoadJwtKeySet(config.Server.JwtPrivateKeyFile, config.Server.JwtPublicKeyFiles, config.Server.JwtSigningKey)
+	if err != nil {
+		panic(err)
+	}
+
 	db, err := mariadb.NewDatabaseConnection(&config.Database)
 	if err != nil {
 		panic(err)
@@ -42,32 +68,246 @@ func main() {
 		panic(err)
 	}
 
+	err = repository.RegisterTenantScope(db)
+	if err != nil {
+		panic(err)
+	}
+
 	userRepository, err := repository.NewMysqlUserRepository(db)
 	if err != nil {
 		panic(err)
 	}
 
-	userUseCase := usecase.NewUserUseCase(userRepository)
-	authUseCase := usecase.NewAuthUseCase(userRepository, config.Server.JwtSigningKey, config.Server.JwtSessionDuration)
+	emailVerificationRepository, err := repository.NewMysqlEmailVerificationRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	loginThrottleRepository, err := repository.NewMysqlLoginThrottleRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	mfaRepository, err := repository.NewMysqlMfaRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	apiKeyRepository, err := repository.NewMysqlApiKeyRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	oidcRepository, err := repository.NewMysqlOidcRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	sessionRepository, err := repository.NewMysqlSessionRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	invitationRepository, err := repository.NewMysqlInvitationRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	emailChangeRepository, err := repository.NewMysqlEmailChangeRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	consentRepository, err := repository.NewMysqlConsentRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	preferenceRepository, err := repository.NewMysqlPreferenceRepository(db)
+	if err != nil {
+		panic(err)
+	}
+
+	// Buckets kept in memory limit each instance on its own, the database
+	// shares them between every instance.
+	var rateLimitRepository repository.RateLimitRepository
+	switch config.Server.RateLimitStore {
+	case "memory":
+		rateLimitRepository = repository.NewMemoryRateLimitRepository()
+	case "mariadb":
+		rateLimitRepository, err = repository.NewMysqlRateLimitRepository(db)
+		if err != nil {
+			panic(err)
+		}
+	default:
+		panic(fmt.Errorf("unknown rate limit store %q", config.Server.RateLimitStore))
+	}
+
+	mailer := mailer.NewMailer(&config.Mail)
+
+	loginThrottlePolicy := usecase.LoginThrottlePolicy{
+		MaxAccountFailures: config.Auth.LoginMaxAttempts,
+		MaxIPFailures:      config.Auth.LoginMaxAttemptsPerIP,
+		LockoutDuration:    time.Minute * time.Duration(config.Auth.LoginLockoutDuration),
+		BackoffBase:        time.Second * time.Duration(config.Auth.LoginBackoffBase),
+		BackoffMax:         time.Second * time.Duration(config.Auth.LoginBackoffMax),
+	}
+
+	passwordHasher, err := util.NewPasswordHasher(config.Auth.PasswordHashAlgorithm, config.Auth.PasswordBcryptCost, util.Argon2idParams{
+		Memory:      config.Auth.PasswordArgon2Memory,
+		Iterations:  config.Auth.PasswordArgon2Iterations,
+		Parallelism: config.Auth.PasswordArgon2Parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	passwordPolicy := &domain.PasswordPolicy{
+		MinLength:          config.Auth.PasswordMinLength,
+		MaxLength:          config.Auth.PasswordMaxLength,
+		RequireLowercase:   config.Auth.PasswordRequireLowercase,
+		RequireUppercase:   config.Auth.PasswordRequireUppercase,
+		RequireDigit:       config.Auth.PasswordRequireDigit,
+		RequireSpecial:     config.Auth.PasswordRequireSpecial,
+		ForbidPersonalInfo: config.Auth.PasswordForbidPersonalInfo,
+	}
+	if len(config.Auth.PasswordBreachedListFile) > 0 {
+		breachedPasswords, err := breached.Open(config.Auth.PasswordBreachedListFile)
+		if err != nil {
+			panic(err)
+		}
+		defer breachedPasswords.Close()
+		passwordPolicy.BreachedPasswords = breachedPasswords
+	}
+
+	emailVerificationUseCase := usecase.NewEmailVerificationUseCase(userRepository, emailVerificationRepository, mailer, config.Server.PublicURL, config.Auth.EmailVerificationTTL)
+	loginThrottleUseCase := usecase.NewLoginThrottleUseCase(userRepository, loginThrottleRepository, loginThrottlePolicy)
+	mfaUseCase := usecase.NewMfaUseCase(mfaRepository, config.Auth.MfaIssuer)
+	sessionUseCase := usecase.NewSessionUseCase(sessionRepository)
+	emailChangeUseCase := usecase.NewEmailChangeUseCase(userRepository, emailChangeRepository, mailer, config.Server.PublicURL, config.Auth.EmailChangeTTL)
+	userUseCase := usecase.NewUserUseCase(userRepository, emailVerificationUseCase, emailChangeUseCase, sessionUseCase, passwordHasher, passwordPolicy, config.Auth.PasswordHistorySize)
+	authUseCase := usecase.NewAuthUseCase(userRepository, loginThrottleUseCase, mfaUseCase, sessionUseCase, passwordHasher, jwtKeySet, config.Server.JwtSessionDuration, config.Auth.MfaChallengeTTL, config.Auth.RequireVerifiedEmail)
+	apiKeyUseCase := usecase.NewApiKeyUseCase(apiKeyRepository, userRepository)
+	impersonationUseCase := usecase.NewImpersonationUseCase(userRepository, sessionUseCase, jwtKeySet, config.Auth.ImpersonationTTL)
+	preferenceUseCase := usecase.NewPreferenceUseCase(preferenceRepository)
+	privacyUseCase := usecase.NewPrivacyUseCase(userRepository, consentRepository, loginThrottleUseCase)
+	rateLimitUseCase := usecase.NewRateLimitUseCase(rateLimitRepository)
+	invitationUseCase := usecase.NewInvitationUseCase(invitationRepository, userRepository, mailer, passwordHasher, passwordPolicy, jwtKeySet, config.Server.FrontendURL, config.Auth.InvitationTTL)
 
-	userHandler := handler.NewUserHandler(userUseCase)
+	userHandler := handler.NewUserHandler(userUseCase, config.Auth.OpenSignup)
 	authHandler := handler.NewAuthHandler(authUseCase)
+	emailVerificationHandler := handler.NewEmailVerificationHandler(emailVerificationUseCase)
+	emailChangeHandler := handler.NewEmailChangeHandler(emailChangeUseCase)
+	mfaHandler := handler.NewMfaHandler(mfaUseCase)
+	apiKeyHandler := handler.NewApiKeyHandler(apiKeyUseCase)
+	jwksHandler := handler.NewJwksHandler(jwtKeySet)
+	sessionHandler := handler.NewSessionHandler(sessionUseCase)
+	impersonationHandler := handler.NewImpersonationHandler(impersonationUseCase)
+	invitationHandler := handler.NewInvitationHandler(invitationUseCase)
+	privacyHandler := handler.NewPrivacyHandler(privacyUseCase)
+	preferenceHandler := handler.NewPreferenceHandler(preferenceUseCase)
+
+	rateLimits := middleware.NewRateLimits(rateLimitUseCase, config.Server.RateLimits)
+
+	// authenticated accepts both access tokens and API keys, while
+	// interactive only routes go through sessionAuthenticated instead. Both
+	// enforce the rate limits keyed by user or API key, and render the
+	// timestamps of their responses in the timezone of the user.
+	authenticated := func(h middleware.AuthenticationHandler) http.Handler {
+		return middleware.NewJwtAuthenticator(rateLimits.ByCaller(middleware.WithPreferences(preferenceUseCase, h)), jwtKeySet, sessionUseCase, apiKeyUseCase, authUseCase)
+	}
+	sessionAuthenticated := func(h middleware.AuthenticationHandler) http.Handler {
+		return middleware.NewJwtAuthenticator(rateLimits.ByCaller(middleware.WithPreferences(preferenceUseCase, h)), jwtKeySet, sessionUseCase, nil, authUseCase)
+	}
 
 	sm := http.NewServeMux()
 
+	sm.HandleFunc("GET /.well-known/jwks.json", jwksHandler.Jwks)
 	sm.HandleFunc("POST /login", authHandler.Login)
+	sm.HandleFunc("POST /login/mfa", authHandler.LoginMfa)
+	sm.HandleFunc("GET /verify-email", emailVerificationHandler.VerifyEmail)
+	sm.HandleFunc("POST /verify-email/resend", emailVerificationHandler.ResendVerificationEmail)
+	sm.HandleFunc("GET /email-change/confirm", emailChangeHandler.ConfirmEmailChange)
 	sm.HandleFunc("POST /users", userHandler.CreateUser)
-	sm.HandleFunc("/users", userHandler.ListUsers)
-	sm.HandleFunc("/users/{userId}", userHandler.FindUserById)
+	sm.HandleFunc("POST /invitations/accept", invitationHandler.AcceptInvitation)
+	sm.Handle("/users", authenticated(middleware.RequireScope(domain.ScopeUsersRead, userHandler.ListUsers)))
+	sm.Handle("POST /users/import", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, userHandler.ImportUsers)))))
+	sm.Handle("/users/{userId}", authenticated(middleware.RequireScope(domain.ScopeUsersRead, userHandler.FindUserById)))
+	sm.Handle("PATCH /users/{userId}", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeUsersWrite, userHandler.UpdateUser))))
+	sm.Handle("PATCH /me", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeUsersWrite, userHandler.UpdateMe))))
+	sm.Handle("GET /me/preferences", authenticated(middleware.RequireScope(domain.ScopeUsersRead, preferenceHandler.GetPreferences)))
+	sm.Handle("PUT /me/preferences", authenticated(middleware.RequireScope(domain.ScopeUsersWrite, preferenceHandler.UpdatePreferences)))
+	sm.Handle("DELETE /users/{userId}", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeUsersWrite, userHandler.DeleteUser))))
+
+	// Destructive operations are wrapped with ForbidImpersonation so support
+	// staff acting as a user cannot perform them.
+	sm.Handle("POST /me/mfa/totp", sessionAuthenticated(middleware.ForbidImpersonation(mfaHandler.EnrollTotp)))
+	sm.Handle("POST /me/mfa/totp/confirm", sessionAuthenticated(middleware.ForbidImpersonation(mfaHandler.ConfirmTotp)))
+	sm.Handle("PUT /me/password", sessionAuthenticated(middleware.ForbidImpersonation(userHandler.UpdatePassword)))
+	sm.Handle("GET /me/sessions", sessionAuthenticated(sessionHandler.ListSessions))
+	sm.Handle("DELETE /me/sessions/{sessionId}", sessionAuthenticated(middleware.ForbidImpersonation(sessionHandler.RevokeSession)))
+	sm.Handle("GET /me/data-export", sessionAuthenticated(middleware.ForbidImpersonation(privacyHandler.ExportMyData)))
+	sm.Handle("GET /me/consents", sessionAuthenticated(privacyHandler.ListConsents))
+	sm.Handle("POST /me/consents", sessionAuthenticated(middleware.ForbidImpersonation(privacyHandler.RecordConsent)))
+	sm.Handle("POST /me/api-keys", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeApiKeys, apiKeyHandler.CreateApiKey))))
+	sm.Handle("GET /me/api-keys", authenticated(middleware.RequireScope(domain.ScopeApiKeys, apiKeyHandler.ListApiKeys)))
+	sm.Handle("DELETE /me/api-keys/{keyId}", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeApiKeys, apiKeyHandler.RevokeApiKey))))
+	sm.Handle("PUT /admin/users/{userId}/status", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, userHandler.UpdateUserStatus)))))
+	sm.Handle("POST /admin/users/{userId}/restore", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, userHandler.RestoreUser)))))
+	sm.Handle("DELETE /admin/users/{userId}", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, userHandler.PurgeUser)))))
+	sm.Handle("GET /admin/users/{userId}/data-export", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, privacyHandler.ExportUserData)))))
+	sm.Handle("POST /admin/users/{userId}/erase", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, privacyHandler.EraseUser)))))
+	sm.Handle("DELETE /admin/users/{userId}/lockout", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, authHandler.UnlockUser)))))
+	sm.Handle("POST /admin/users/{userId}/api-keys", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, apiKeyHandler.CreateUserApiKey)))))
+	sm.Handle("GET /admin/users/{userId}/api-keys", authenticated(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, apiKeyHandler.ListUserApiKeys))))
+	sm.Handle("DELETE /admin/users/{userId}/api-keys/{keyId}", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, apiKeyHandler.RevokeUserApiKey)))))
+	sm.Handle("POST /admin/invitations", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, invitationHandler.CreateInvitation)))))
+	sm.Handle("GET /admin/invitations", authenticated(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, invitationHandler.ListInvitations))))
+	sm.Handle("DELETE /admin/invitations/{invitationId}", authenticated(middleware.ForbidImpersonation(middleware.RequireScope(domain.ScopeAdmin, middleware.RequireRole(domain.RoleAdmin, invitationHandler.RevokeInvitation)))))
+	sm.Handle("POST /admin/users/{userId}/impersonate", sessionAuthenticated(middleware.ForbidImpersonation(middleware.RequireRole(domain.RoleAdmin, impersonationHandler.Impersonate))))
+
+	// Login through an external identity provider is only offered when one
+	// is configured.
+	if len(config.Oidc.IssuerURL) > 0 {
+		if len(config.Oidc.RedirectURL) == 0 {
+			config.Oidc.RedirectURL = config.Server.PublicURL + "/login/oidc/callback"
+		}
+
+		oidcProvider, err := oidc.NewProvider(context.Background(), &config.Oidc)
+		if err != nil {
+			panic(err)
+		}
+
+		oidcUseCase := usecase.NewOidcUseCase(oidcRepository, userRepository, authUseCase, oidcProvider, config.Oidc.StateTTL)
+		oidcHandler := handler.NewOidcHandler(oidcUseCase)
+
+		sm.HandleFunc("GET /login/oidc", oidcHandler.StartLogin)
+		sm.HandleFunc("GET /login/oidc/callback", oidcHandler.Callback)
+		sm.Handle("POST /me/oidc/link", sessionAuthenticated(middleware.ForbidImpersonation(oidcHandler.StartLink)))
+	}
 
 	sm.HandleFunc("/swagger/", httpSwagger.WrapHandler)
 
+	accessLogger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	requestTimeout := time.Second * time.Duration(config.Server.RequestTimeout)
+	corsPolicy := middleware.CorsPolicy{
+		AllowedOrigins:   config.Server.CorsAllowedOrigins,
+		AllowedMethods:   config.Server.CorsAllowedMethods,
+		AllowedHeaders:   config.Server.CorsAllowedHeaders,
+		ExposedHeaders:   config.Server.CorsExposedHeaders,
+		AllowCredentials: config.Server.CorsAllowCredentials,
+		MaxAge:           config.Server.CorsMaxAge,
+	}
+	routes := middleware.Stack(sm, accessLogger, config.Server.TrustedProxies, corsPolicy, requestTimeout, rateLimits)
+
 	srv := &http.Server{
 		Addr:         config.Server.Port,
 		WriteTimeout: time.Second * time.Duration(config.Server.WriteTimeout),
 		ReadTimeout:  time.Second * time.Duration(config.Server.ReadTimeout),
 		IdleTimeout:  time.Second * time.Duration(config.Server.IdleTimeout),
-		Handler:      sm,
+		Handler:      routes,
 	}
 
 	go func() {
@@ -0,0 +1,116 @@
+// Command privacy answers data subject requests from the command line.
+//
+//	privacy export -org 1 -user 42 [-out user-42.zip]
+//	privacy erase -org 1 -user 42
+//
+// export writes the ZIP bundle of everything held about a user, to standard
+// output unless -out is given. erase anonymizes the user in place.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Daffc/GO-Sales/internal/config"
+	"github.com/Daffc/GO-Sales/internal/database/mariadb"
+	"github.com/Daffc/GO-Sales/repository"
+	"github.com/Daffc/GO-Sales/usecase"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: privacy export -org ID -user ID [-out FILE]")
+	fmt.Fprintln(os.Stderr, "       privacy erase -org ID -user ID")
+	os.Exit(2)
+}
+
+func main() {
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	orgId := fs.Uint("org", 0, "organization of the user")
+	userId := fs.Uint("user", 0, "user to act on")
+	out := fs.String("out", "", "file the export is written to, standard output by default")
+	fs.Parse(os.Args[2:])
+
+	if *orgId == 0 || *userId == 0 {
+		usage()
+	}
+
+	privacyUseCase, err := newPrivacyUseCase()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "export":
+		var w io.Writer = os.Stdout
+		if len(*out) > 0 {
+			f, err := os.Create(*out)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		err = privacyUseCase.WriteUserDataBundle(ctx, uint(*orgId), uint(*userId), w)
+	case "erase":
+		err = privacyUseCase.EraseUser(ctx, uint(*orgId), uint(*userId))
+		if err == nil {
+			log.Printf("user %d erased", *userId)
+		}
+	default:
+		usage()
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func newPrivacyUseCase() (usecase.PrivacyUseCase, error) {
+
+	config, err := config.NewConfigParser(".env")
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := mariadb.NewDatabaseConnection(&config.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.RegisterTenantScope(db)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepository, err := repository.NewMysqlUserRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	consentRepository, err := repository.NewMysqlConsentRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	loginThrottleRepository, err := repository.NewMysqlLoginThrottleRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Erasure only clears lockouts, the policy is irrelevant.
+	loginThrottleUseCase := usecase.NewLoginThrottleUseCase(userRepository, loginThrottleRepository, usecase.LoginThrottlePolicy{})
+
+	return usecase.NewPrivacyUseCase(userRepository, consentRepository, loginThrottleUseCase), nil
+}
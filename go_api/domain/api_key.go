@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ApiKeyPrefix = "gsk_"
+
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopeApiKeys    = "api_keys"
+	ScopeAdmin      = "admin"
+)
+
+// ApiKeyScopes lists every scope that can be granted to an API key.
+var ApiKeyScopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeApiKeys, ScopeAdmin}
+
+type ApiKey struct {
+	ID         uint `gorm:"primaryKey"`
+	UserID     uint
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+var (
+	ErrApiKeyNameRequired  = NewError(ErrorKindValidation, "api_key_name_required", "invalid api key name")
+	ErrApiKeyScopeInvalid  = NewError(ErrorKindValidation, "api_key_scope_invalid", "invalid api key scope")
+	ErrApiKeyExpiresAtPast = NewError(ErrorKindValidation, "api_key_expires_at_past", "api key expiration must be in the future")
+	ErrApiKeyInvalid       = NewError(ErrorKindUnauthorized, "api_key_invalid", "invalid or expired api key")
+	ErrApiKeyNotFound      = NewError(ErrorKindNotFound, "api_key_not_found", "api key not found")
+)
+
+func (k *ApiKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+func (k *ApiKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+func (k *ApiKey) ValidateName() error {
+	if len(strings.TrimSpace(k.Name)) == 0 {
+		return ErrApiKeyNameRequired
+	}
+
+	return nil
+}
+
+func (k *ApiKey) ValidateScopes() error {
+	scopes := k.ScopeList()
+	if len(scopes) == 0 {
+		return ErrApiKeyScopeInvalid
+	}
+
+	for _, s := range scopes {
+		if !slices.Contains(ApiKeyScopes, s) {
+			return ErrApiKeyScopeInvalid
+		}
+	}
+
+	return nil
+}
+
+func (k *ApiKey) ValidateExpiresAt(now time.Time) error {
+	if k.IsExpired(now) {
+		return ErrApiKeyExpiresAtPast
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// Purposes a user can consent to the processing of their data for.
+const (
+	ConsentPurposeTermsOfService = "terms_of_service"
+	ConsentPurposePrivacyPolicy  = "privacy_policy"
+	ConsentPurposeMarketing      = "marketing"
+)
+
+// Where a consent decision came from. Erasure records the withdrawals made
+// when the data of a user is erased.
+const (
+	ConsentSourceUser    = "user"
+	ConsentSourceErasure = "erasure"
+)
+
+// Consent is a single decision of a user about a purpose. Consents are only
+// ever appended, so the latest one for a purpose is the one in force and the
+// earlier ones are its history.
+type Consent struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint
+	Purpose   string
+	Granted   bool
+	Source    string
+	CreatedAt time.Time
+}
+
+var ErrConsentPurposeInvalid = NewError(ErrorKindValidation, "consent_purpose_invalid", "invalid consent purpose")
+
+func (c *Consent) ValidatePurpose() error {
+	switch c.Purpose {
+	case ConsentPurposeTermsOfService, ConsentPurposePrivacyPolicy, ConsentPurposeMarketing:
+		return nil
+	default:
+		return ErrConsentPurposeInvalid
+	}
+}
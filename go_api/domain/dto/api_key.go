@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Daffc/GO-Sales/internal/validate"
+)
+
+type ApiKeyInputDTO struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Validate checks the name only, scopes and expiry are up to the domain.
+func (i *ApiKeyInputDTO) Validate() error {
+	return validate.New().
+		String("name", i.Name, validate.Required, validate.MaxLength(nameMaxLength)).
+		Err()
+}
+
+type ApiKeyOutputDTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ApiKeyCreatedOutputDTO struct {
+	ApiKeyOutputDTO
+	Key string `json:"key"`
+}
@@ -1,17 +1,32 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/Daffc/GO-Sales/internal/validate"
+)
 
 type LoginInputDTO struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	IP        string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+func (i *LoginInputDTO) Validate() error {
+	return validate.New().
+		String("email", i.Email, validate.Required, validate.MaxLength(emailMaxLength)).
+		String("password", i.Password, validate.Required).
+		Err()
 }
 
 type LoginOutputDTO struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Token     string    `json:"token"`
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Token       string    `json:"token"`
+	MfaRequired bool      `json:"mfa_required,omitempty"`
+	MfaToken    string    `json:"mfa_token,omitempty"`
 }
@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/Daffc/GO-Sales/internal/validate"
+
+type ResendVerificationEmailInputDTO struct {
+	Email string `json:"email"`
+}
+
+func (i *ResendVerificationEmailInputDTO) Validate() error {
+	return validate.New().
+		String("email", i.Email, validate.Required, validate.MaxLength(emailMaxLength), validate.Email).
+		Err()
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type ImpersonationOutputDTO struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	ActorID   uint      `json:"actor_id"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
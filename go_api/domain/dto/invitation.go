@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/validate"
+)
+
+type InvitationInputDTO struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+func (i *InvitationInputDTO) Validate() error {
+	return validate.New().
+		String("email", i.Email, validate.Required, validate.MaxLength(emailMaxLength), validate.Email).
+		String("role", i.Role, validate.Required, validate.OneOf(domain.RoleUser, domain.RoleAdmin)).
+		Err()
+}
+
+type InvitationOutputDTO struct {
+	ID          uint      `json:"id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	InvitedByID uint      `json:"invited_by_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AcceptInvitationInputDTO struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+func (i *AcceptInvitationInputDTO) Validate() error {
+	return validate.New().
+		String("token", i.Token, validate.Required).
+		String("name", i.Name, validate.Required, validate.MaxLength(nameMaxLength)).
+		String("password", i.Password, validate.Required).
+		Err()
+}
@@ -0,0 +1,16 @@
+package dto
+
+type JwkDTO struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JwksOutputDTO struct {
+	Keys []JwkDTO `json:"keys"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "github.com/Daffc/GO-Sales/internal/validate"
+
+type TotpEnrollmentOutputDTO struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+	QRCodePNG  []byte `json:"qr_code_png" swaggertype:"string" format:"base64"`
+}
+
+type TotpConfirmInputDTO struct {
+	Code string `json:"code"`
+}
+
+func (i *TotpConfirmInputDTO) Validate() error {
+	return validate.New().
+		String("code", i.Code, validate.Required).
+		Err()
+}
+
+type RecoveryCodesOutputDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MfaLoginInputDTO struct {
+	MfaToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+	IP           string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+// Validate only requires the token, the usecase tells which of the code and
+// the recovery code is expected.
+func (i *MfaLoginInputDTO) Validate() error {
+	return validate.New().
+		String("mfa_token", i.MfaToken, validate.Required).
+		Err()
+}
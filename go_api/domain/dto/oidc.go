@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+type OidcAuthorizationOutputDTO struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	Binding          string    `json:"-"`
+	ExpiresAt        time.Time `json:"-"`
+}
+
+type OidcCallbackInputDTO struct {
+	Code      string
+	State     string
+	Binding   string
+	IP        string
+	UserAgent string
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/validate"
+)
+
+// UserDataExportDTO is everything held about a user. Secrets such as password
+// hashes, API key hashes and TOTP seeds are left out, only when they were set
+// is exported.
+type UserDataExportDTO struct {
+	User            UserOutputDTO           `json:"user"`
+	Organization    OrganizationOutputDTO   `json:"organization"`
+	Sessions        []*SessionOutputDTO     `json:"sessions"`
+	ApiKeys         []*ApiKeyOutputDTO      `json:"api_keys"`
+	Identities      []*IdentityOutputDTO    `json:"identities"`
+	Preferences     *PreferencesOutputDTO   `json:"preferences"`
+	TotpEnabledAt   *time.Time              `json:"totp_enabled_at"`
+	PasswordChanges []time.Time             `json:"password_changes"`
+	InvitationsSent []*InvitationOutputDTO  `json:"invitations_sent"`
+	EmailChanges    []*EmailChangeOutputDTO `json:"email_changes"`
+	Consents        []*ConsentOutputDTO     `json:"consents"`
+	ExportedAt      time.Time               `json:"exported_at"`
+}
+
+type OrganizationOutputDTO struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type IdentityOutputDTO struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EmailChangeOutputDTO struct {
+	NewEmail  string    `json:"new_email"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ConsentInputDTO struct {
+	Purpose string `json:"purpose"`
+	Granted bool   `json:"granted"`
+}
+
+func (i *ConsentInputDTO) Validate() error {
+	return validate.New().
+		String("purpose", i.Purpose, validate.Required, validate.OneOf(domain.ConsentPurposeTermsOfService, domain.ConsentPurposePrivacyPolicy, domain.ConsentPurposeMarketing)).
+		Err()
+}
+
+type ConsentOutputDTO struct {
+	ID        uint      `json:"id"`
+	Purpose   string    `json:"purpose"`
+	Granted   bool      `json:"granted"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+// ProblemOutputDTO describes why a request failed, as RFC 7807 problem
+// details. Code is stable and meant for clients to match on, Detail is meant
+// for people.
+type ProblemOutputDTO struct {
+	Type       string                      `json:"type"`
+	Title      string                      `json:"title"`
+	Status     int                         `json:"status"`
+	Detail     string                      `json:"detail"`
+	Instance   string                      `json:"instance,omitempty"`
+	Code       string                      `json:"code"`
+	RequestId  string                      `json:"request_id,omitempty"`
+	Violations []ProblemViolationOutputDTO `json:"violations,omitempty"`
+}
+
+// ProblemViolationOutputDTO is one of several rules a request failed. Field
+// is set when the rule is about a field of the request body.
+type ProblemViolationOutputDTO struct {
+	Field  string `json:"field,omitempty"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
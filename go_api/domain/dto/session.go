@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+type SessionOutputDTO struct {
+	ID         uint      `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+	// Impersonated flags sessions opened by an administrator acting as the
+	// user.
+	Impersonated bool `json:"impersonated"`
+}
@@ -1,13 +1,48 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/validate"
+)
+
+// Longest names and emails accepted, as long as the columns they are stored
+// in.
+const (
+	nameMaxLength  = 255
+	emailMaxLength = 255
+)
 
 type UserOutputDTO struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             uint      `json:"id"`
+	OrganizationID uint      `json:"organization_id"`
+	Name           string    `json:"name"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	Status         string    `json:"status"`
+	EmailVerified  bool      `json:"email_verified"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ListUsersInputDTO holds the query parameters of a user listing. Sort is a
+// field name, prefixed with "-" for descending order.
+type ListUsersInputDTO struct {
+	Limit       int
+	Cursor      string
+	Name        string
+	Email       string
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        string
+}
+
+// UserListOutputDTO is a page of users. NextCursor is absent on the last page.
+type UserListOutputDTO struct {
+	Data       []*UserOutputDTO `json:"data"`
+	NextCursor string           `json:"next_cursor,omitempty"`
 }
 
 type UserInputDTO struct {
@@ -17,7 +52,91 @@ type UserInputDTO struct {
 	Password string `json:"password"`
 }
 
+func (i *UserInputDTO) Validate() error {
+	return validate.New().
+		String("name", i.Name, validate.Required, validate.MaxLength(nameMaxLength)).
+		String("email", i.Email, validate.Required, validate.MaxLength(emailMaxLength), validate.Email).
+		String("password", i.Password, validate.Required).
+		Err()
+}
+
+// UpdateUserInputDTO holds the profile fields to change, absent ones are left
+// untouched.
+type UpdateUserInputDTO struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+}
+
+func (i *UpdateUserInputDTO) Validate() error {
+	return validate.New().
+		OptionalString("name", i.Name, validate.Required, validate.MaxLength(nameMaxLength)).
+		OptionalString("email", i.Email, validate.Required, validate.MaxLength(emailMaxLength), validate.Email).
+		Err()
+}
+
+// UpdateUserOutputDTO is the updated user. PendingEmail is set while a new
+// address waits for confirmation.
+type UpdateUserOutputDTO struct {
+	UserOutputDTO
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+// UpdateUserStatusInputDTO is either "active" or "suspended".
+type UpdateUserStatusInputDTO struct {
+	Status string `json:"status"`
+}
+
+func (i *UpdateUserStatusInputDTO) Validate() error {
+	return validate.New().
+		String("status", i.Status, validate.Required, validate.OneOf(domain.UserStatusActive, domain.UserStatusSuspended)).
+		Err()
+}
+
+// UserImportRowDTO is the outcome of a row of a user import. Row is its line
+// in the CSV file and UserID is set once the user is created.
+type UserImportRowDTO struct {
+	Row    int    `json:"row"`
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	UserID uint   `json:"user_id,omitempty"`
+}
+
+// UserImportOutputDTO reports every row of a user import. Rows that would be
+// created are counted as valid on a dry run.
+type UserImportOutputDTO struct {
+	DryRun  bool                `json:"dry_run"`
+	Created int                 `json:"created"`
+	Valid   int                 `json:"valid"`
+	Skipped int                 `json:"skipped"`
+	Failed  int                 `json:"failed"`
+	Rows    []*UserImportRowDTO `json:"rows"`
+}
+
 type UpdateUserPasswordInputDTO struct {
-	ID       uint   `json:"id"`
-	Password string `json:"password"`
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (i *UpdateUserPasswordInputDTO) Validate() error {
+	return validate.New().
+		String("current_password", i.CurrentPassword, validate.Required).
+		String("new_password", i.NewPassword, validate.Required).
+		Err()
+}
+
+// PreferencesInputDTO holds the preferences to change, absent ones are left
+// untouched.
+type PreferencesInputDTO struct {
+	Locale   *string `json:"locale"`
+	Timezone *string `json:"timezone"`
+	Currency *string `json:"currency"`
+	PageSize *int    `json:"page_size"`
+}
+
+type PreferencesOutputDTO struct {
+	Locale   string `json:"locale"`
+	Timezone string `json:"timezone"`
+	Currency string `json:"currency"`
+	PageSize int    `json:"page_size"`
 }
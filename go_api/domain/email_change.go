@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// EmailChangeToken holds a requested email address until its owner confirms
+// it through the link sent to that address.
+type EmailChangeToken struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint
+	NewEmail  string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+var ErrEmailChangeTokenInvalid = NewError(ErrorKindValidation, "email_change_token_invalid", "invalid or expired email change token")
+
+func (t *EmailChangeToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+type EmailVerificationToken struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+var (
+	ErrEmailVerificationTokenInvalid = NewError(ErrorKindValidation, "email_verification_token_invalid", "invalid or expired verification token")
+	ErrEmailAlreadyVerified          = NewError(ErrorKindConflict, "email_already_verified", "email already verified")
+)
+
+func (t *EmailVerificationToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,66 @@
+package domain
+
+import "errors"
+
+// ErrorKind tells what went wrong with an operation, independently of how the
+// failure is reported to a client.
+type ErrorKind string
+
+const (
+	ErrorKindValidation      ErrorKind = "validation"
+	ErrorKindUnauthorized    ErrorKind = "unauthorized"
+	ErrorKindForbidden       ErrorKind = "forbidden"
+	ErrorKindNotFound        ErrorKind = "not_found"
+	ErrorKindConflict        ErrorKind = "conflict"
+	ErrorKindTooLarge        ErrorKind = "too_large"
+	ErrorKindLocked          ErrorKind = "locked"
+	ErrorKindTooManyRequests ErrorKind = "too_many_requests"
+	ErrorKindUnavailable     ErrorKind = "unavailable"
+	ErrorKindInternal        ErrorKind = "internal"
+)
+
+// Error is a failure of the domain. Its code is stable and meant for clients
+// to match on, unlike its message. Errors of any other type are internal.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+// NewError returns an error of the given kind. Domain errors are created once
+// as package variables, so errors.Is can tell them apart.
+func NewError(kind ErrorKind, code string, message string) error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var errInternal = &Error{Kind: ErrorKindInternal, Code: "internal", Message: "internal server error"}
+
+// ErrInternal stands for any failure that is not the client's doing.
+var ErrInternal error = errInternal
+
+// ErrorOf returns the domain error err is or wraps, or the one of ErrInternal
+// when there is none.
+func ErrorOf(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	return errInternal
+}
+
+// Errors about the request itself rather than what it asks for.
+var (
+	ErrRequestBodyInvalid  = NewError(ErrorKindValidation, "request_body_invalid", "invalid request body")
+	ErrRequestBodyTooLarge = NewError(ErrorKindTooLarge, "request_body_too_large", "request body too large")
+	ErrPathParamInvalid    = NewError(ErrorKindValidation, "path_parameter_invalid", "invalid path parameter")
+	ErrQueryParamInvalid   = NewError(ErrorKindValidation, "query_parameter_invalid", "invalid query parameter")
+	ErrUnauthenticated     = NewError(ErrorKindUnauthorized, "unauthenticated", "missing, invalid or expired credentials")
+	ErrRoleForbidden       = NewError(ErrorKindForbidden, "role_forbidden", "your role does not allow this operation")
+	ErrScopeForbidden      = NewError(ErrorKindForbidden, "scope_forbidden", "the api key was not granted the scope of this operation")
+	ErrRequestTimeout      = NewError(ErrorKindUnavailable, "request_timeout", "the request took too long, try again later")
+)
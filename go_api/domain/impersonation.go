@@ -0,0 +1,7 @@
+package domain
+
+var (
+	ErrImpersonationSelf      = NewError(ErrorKindValidation, "impersonation_self", "cannot impersonate yourself")
+	ErrImpersonationAdmin     = NewError(ErrorKindForbidden, "impersonation_admin", "administrators cannot be impersonated")
+	ErrImpersonationForbidden = NewError(ErrorKindForbidden, "impersonation_forbidden", "operation not allowed while impersonating")
+)
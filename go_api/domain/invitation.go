@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Invitation lets an admin onboard a teammate into their organization with a
+// preassigned role. The invitee picks their own name and password when
+// accepting it.
+type Invitation struct {
+	ID             uint `gorm:"primaryKey"`
+	OrganizationID uint
+	Email          string
+	Role           string
+	InvitedByID    uint
+	ExpiresAt      time.Time
+	AcceptedAt     *time.Time
+	CreatedAt      time.Time
+}
+
+var (
+	ErrInvitationRoleInvalid = NewError(ErrorKindValidation, "invitation_role_invalid", "invalid invitation role")
+	ErrInvitationInvalid     = NewError(ErrorKindValidation, "invitation_invalid", "invalid or expired invitation")
+	ErrInvitationNotFound    = NewError(ErrorKindNotFound, "invitation_not_found", "invitation not found")
+)
+
+func (i *Invitation) IsExpired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
+
+func (i *Invitation) IsAccepted() bool {
+	return i.AcceptedAt != nil
+}
+
+func (i *Invitation) ValidateEmail() error {
+	u := User{Email: i.Email}
+	return u.ValidateEmail()
+}
+
+func (i *Invitation) ValidateRole() error {
+	switch i.Role {
+	case RoleUser, RoleAdmin:
+		return nil
+	default:
+		return ErrInvitationRoleInvalid
+	}
+}
@@ -6,5 +6,27 @@ type UserClaims struct {
 	ID    uint
 	Name  string
 	Email string
+	Role  string
+	// OrganizationID is the tenant every request made with the token is
+	// scoped to.
+	OrganizationID uint
+	// Actor is only set on impersonation tokens and names the administrator
+	// acting as the user, following the RFC 8693 "act" claim.
+	Actor *ActorClaims `json:"act,omitempty"`
 	jwt.StandardClaims
 }
+
+type ActorClaims struct {
+	ID    uint
+	Email string
+}
+
+func (c *UserClaims) User() *User {
+	return &User{
+		ID:             c.ID,
+		OrganizationID: c.OrganizationID,
+		Name:           c.Name,
+		Email:          c.Email,
+		Role:           c.Role,
+	}
+}
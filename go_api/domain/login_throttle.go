@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+const (
+	LoginThrottleScopeAccount = "account"
+	LoginThrottleScopeIP      = "ip"
+)
+
+type LoginThrottle struct {
+	ID           uint `gorm:"primaryKey"`
+	Scope        string
+	Subject      string
+	Failures     uint
+	LastFailedAt time.Time
+	LockedUntil  *time.Time
+}
+
+// LoginFailure is a failed login to count against the throttle of Subject.
+// Failures before ForgetBefore no longer count, and reaching MaxFailures,
+// unless zero, locks the throttle until LockUntil.
+type LoginFailure struct {
+	Scope        string
+	Subject      string
+	FailedAt     time.Time
+	ForgetBefore time.Time
+	MaxFailures  uint
+	LockUntil    time.Time
+}
+
+var (
+	ErrAccountLocked        = NewError(ErrorKindLocked, "account_locked", "account temporarily locked due to too many failed login attempts")
+	ErrTooManyLoginAttempts = NewError(ErrorKindTooManyRequests, "too_many_login_attempts", "too many login attempts, try again later")
+)
+
+// LoginBlockedError is returned when a login is refused before checking the
+// credentials. Reason is either ErrAccountLocked or ErrTooManyLoginAttempts.
+type LoginBlockedError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return e.Reason.Error()
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	return e.Reason
+}
+
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+type TotpCredential struct {
+	UserID       uint `gorm:"primaryKey;autoIncrement:false"`
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type MfaRecoveryCode struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+var (
+	ErrMfaAlreadyEnabled   = NewError(ErrorKindConflict, "mfa_already_enabled", "two-factor authentication already enabled")
+	ErrMfaNotEnrolled      = NewError(ErrorKindConflict, "mfa_not_enrolled", "two-factor authentication enrollment not started")
+	ErrMfaCodeInvalid      = NewError(ErrorKindValidation, "mfa_code_invalid", "invalid two-factor authentication code")
+	ErrMfaChallengeInvalid = NewError(ErrorKindUnauthorized, "mfa_challenge_invalid", "invalid or expired two-factor authentication challenge")
+)
+
+func (c *TotpCredential) IsConfirmed() bool {
+	return c.ConfirmedAt != nil
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// UserIdentity links an account at an external OpenID Connect provider to a
+// user.
+type UserIdentity struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint
+	Issuer    string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// OidcLoginState keeps what is needed to finish an authorization code flow
+// between the redirect to the provider and its callback. UserID is set when
+// the flow links an identity to an already authenticated user. BindingHash
+// ties the flow to the browser that started it, which holds the binding in a
+// cookie.
+type OidcLoginState struct {
+	ID           uint `gorm:"primaryKey"`
+	StateHash    string
+	BindingHash  string
+	Nonce        string
+	CodeVerifier string
+	UserID       *uint
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+var (
+	ErrOidcStateInvalid     = NewError(ErrorKindValidation, "oidc_state_invalid", "invalid or expired login state")
+	ErrOidcLoginFailed      = NewError(ErrorKindUnauthorized, "oidc_login_failed", "external login failed")
+	ErrOidcAccountNotLinked = NewError(ErrorKindUnauthorized, "oidc_account_not_linked", "no account is linked to this identity")
+	ErrOidcIdentityLinked   = NewError(ErrorKindConflict, "oidc_identity_linked", "identity already linked to another account")
+)
+
+func (s *OidcLoginState) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
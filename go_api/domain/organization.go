@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Organization is a client company sharing the deployment. Every user is a
+// member of exactly one organization, and rows owned by an organization are
+// only visible to its members.
+type Organization struct {
+	ID        uint `gorm:"primaryKey"`
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DefaultOrganizationID is the organization created by the migration that
+// introduced tenants, existing users were moved into it.
+const DefaultOrganizationID = 1
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// PasswordHistory keeps a password hash a user replaced, so recently used
+// passwords can be refused.
+type PasswordHistory struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint
+	Password  string
+	CreatedAt time.Time
+}
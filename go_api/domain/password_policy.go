@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// BreachedPasswordChecker tells whether a password is known to have leaked.
+type BreachedPasswordChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// PasswordPolicy holds the rules a new password has to follow.
+type PasswordPolicy struct {
+	// MinLength and MaxLength are counted in characters, zero disables the
+	// limit.
+	MinLength          int
+	MaxLength          int
+	RequireLowercase   bool
+	RequireUppercase   bool
+	RequireDigit       bool
+	RequireSpecial     bool
+	ForbidPersonalInfo bool
+	// BreachedPasswords is optional, no lookup is made when nil.
+	BreachedPasswords BreachedPasswordChecker
+}
+
+// PasswordPolicyError lists every rule a password failed.
+type PasswordPolicyError struct {
+	Violations []error
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap lets errors.Is match any of the violations.
+func (e *PasswordPolicyError) Unwrap() []error {
+	return e.Violations
+}
+
+var (
+	passwordLowercaseRegexp = regexp.MustCompile(`[a-z]`)
+	passwordUppercaseRegexp = regexp.MustCompile(`[A-Z]`)
+	passwordDigitRegexp     = regexp.MustCompile(`[0-9]`)
+	passwordSpecialRegexp   = regexp.MustCompile(`[-._!"\x60´'#%&,:;<>=@{}~\$\(\)\*\+\/\\\?\[\]\^\|]`)
+	personalInfoSeparators  = regexp.MustCompile(`[\s._+@-]+`)
+)
+
+// personalInfoMinLength keeps short name parts such as "da" or "li" from
+// rejecting unrelated passwords.
+const personalInfoMinLength = 3
+
+// Validate checks password against every rule of the policy. Unmet rules are
+// returned together as a *PasswordPolicyError.
+func (p *PasswordPolicy) Validate(password string, name string, email string) error {
+	var violations []error
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, fmt.Errorf("%w, it must be at least %d characters long", ErrUserPasswordTooShort, p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Errorf("%w, it must be at most %d characters long", ErrUserPasswordTooLong, p.MaxLength))
+	}
+
+	if p.RequireLowercase && !passwordLowercaseRegexp.MatchString(password) {
+		violations = append(violations, ErrUserPasswordFormatLowCase)
+	}
+	if p.RequireUppercase && !passwordUppercaseRegexp.MatchString(password) {
+		violations = append(violations, ErrUserPasswordFormatUpperCase)
+	}
+	if p.RequireDigit && !passwordDigitRegexp.MatchString(password) {
+		violations = append(violations, ErrUserPasswordFormatNumber)
+	}
+	if p.RequireSpecial && !passwordSpecialRegexp.MatchString(password) {
+		violations = append(violations, ErrUserPasswordFormatSpecialCharacter)
+	}
+
+	if p.ForbidPersonalInfo && containsPersonalInfo(password, name, email) {
+		violations = append(violations, ErrUserPasswordPersonalInfo)
+	}
+
+	if p.BreachedPasswords != nil {
+		breached, err := p.BreachedPasswords.IsBreached(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, ErrUserPasswordBreached)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// containsPersonalInfo reports whether the password contains, ignoring case,
+// a part of the name or of the email local part.
+func containsPersonalInfo(password string, name string, email string) bool {
+	password = strings.ToLower(password)
+
+	localPart, _, _ := strings.Cut(email, "@")
+	parts := personalInfoSeparators.Split(strings.ToLower(name+" "+localPart), -1)
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= personalInfoMinLength && strings.Contains(password, part) {
+			return true
+		}
+	}
+
+	return false
+}
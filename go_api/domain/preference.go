@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+// UserPreferences are the display settings of a user. Timestamps are stored in
+// UTC and only rendered in Timezone.
+type UserPreferences struct {
+	UserID    uint `gorm:"primaryKey;autoIncrement:false"`
+	Locale    string
+	Timezone  string
+	Currency  string
+	PageSize  int
+	UpdatedAt time.Time
+}
+
+const (
+	DefaultLocale   = "en-US"
+	DefaultTimezone = "UTC"
+	DefaultCurrency = "USD"
+)
+
+var (
+	ErrPreferenceLocaleInvalid   = NewError(ErrorKindValidation, "preference_locale_invalid", "invalid locale, expected a language tag such as pt-BR")
+	ErrPreferenceTimezoneInvalid = NewError(ErrorKindValidation, "preference_timezone_invalid", "invalid timezone, expected an IANA name such as America/Sao_Paulo")
+	ErrPreferenceCurrencyInvalid = NewError(ErrorKindValidation, "preference_currency_invalid", "invalid currency, expected an ISO 4217 code such as BRL")
+	ErrPreferencePageSizeInvalid = NewError(ErrorKindValidation, "preference_page_size_invalid", "page size must be between 1 and 100")
+)
+
+var (
+	localeRegex   = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+	currencyRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// DefaultUserPreferences are the preferences of a user that never set any.
+func DefaultUserPreferences(userId uint) *UserPreferences {
+	return &UserPreferences{
+		UserID:   userId,
+		Locale:   DefaultLocale,
+		Timezone: DefaultTimezone,
+		Currency: DefaultCurrency,
+		PageSize: UserListDefaultLimit,
+	}
+}
+
+// Location returns the timezone of the user, UTC when it cannot be loaded.
+func (p *UserPreferences) Location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+func (p *UserPreferences) Validate() error {
+	if !localeRegex.MatchString(p.Locale) {
+		return ErrPreferenceLocaleInvalid
+	}
+	// An empty name and "Local" load the server timezone, which is exactly
+	// what preferences are meant to get away from.
+	if p.Timezone == "" || p.Timezone == "Local" {
+		return ErrPreferenceTimezoneInvalid
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return ErrPreferenceTimezoneInvalid
+	}
+	if !currencyRegex.MatchString(p.Currency) {
+		return ErrPreferenceCurrencyInvalid
+	}
+	if p.PageSize < 1 || p.PageSize > UserListMaxLimit {
+		return ErrPreferencePageSizeInvalid
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// Rate limit policies tell clients apart by one of these.
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyUser   = "user"
+	RateLimitKeyApiKey = "api_key"
+)
+
+// RateLimitKeys lists every key a rate limit policy can be set on.
+var RateLimitKeys = []string{RateLimitKeyIP, RateLimitKeyUser, RateLimitKeyApiKey}
+
+// RateLimitPolicy lets each client make Limit requests every Period. Requests
+// can come in bursts of up to Limit, the allowance then refills evenly over
+// the period.
+type RateLimitPolicy struct {
+	Limit  uint
+	Period time.Duration
+	Key    string
+}
+
+// RateLimitBucket is the token bucket of one client. It holds up to the limit
+// of its policy in tokens, each request taking one. ExpiresAt is when it is
+// full again, and so no different from a missing bucket.
+type RateLimitBucket struct {
+	BucketKey  string `gorm:"primaryKey"`
+	Tokens     float64
+	RefilledAt time.Time
+	ExpiresAt  time.Time
+}
+
+// RateLimitResult is the outcome of a request against a bucket. Reset is the
+// time left until the bucket is full, RetryAfter the one until a refused
+// request would be let through.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      uint
+	Remaining  uint
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+var ErrRateLimited = NewError(ErrorKindTooManyRequests, "rate_limited", "too many requests, try again later")
+
+// Take refills the bucket for the time elapsed since it last was, then takes
+// a token from it when there is one. A bucket never refilled starts full.
+func (b *RateLimitBucket) Take(p RateLimitPolicy, now time.Time) RateLimitResult {
+	limit := float64(p.Limit)
+	perSecond := limit / p.Period.Seconds()
+
+	if b.RefilledAt.IsZero() {
+		b.Tokens = limit
+	} else if elapsed := now.Sub(b.RefilledAt); elapsed > 0 {
+		b.Tokens = math.Min(limit, b.Tokens+elapsed.Seconds()*perSecond)
+	}
+	b.RefilledAt = now
+
+	res := RateLimitResult{Limit: p.Limit}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsDuration((1 - b.Tokens) / perSecond)
+	}
+
+	res.Remaining = uint(b.Tokens)
+	res.Reset = secondsDuration((limit - b.Tokens) / perSecond)
+	b.ExpiresAt = now.Add(res.Reset)
+
+	return res
+}
+
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Session is a single login of a user. Its TokenID is carried by the access
+// token as the "jti" claim, so revoking the session invalidates the token.
+type Session struct {
+	ID         uint `gorm:"primaryKey"`
+	UserID     uint
+	TokenID    string
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	// ActorID is the administrator that opened the session through
+	// impersonation, nil for regular logins.
+	ActorID *uint
+}
+
+var (
+	ErrSessionNotFound = NewError(ErrorKindNotFound, "session_not_found", "session not found")
+	ErrSessionInvalid  = NewError(ErrorKindUnauthorized, "session_invalid", "session revoked or expired")
+)
+
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+func (s *Session) IsActive(now time.Time) bool {
+	return !s.IsRevoked() && now.Before(s.ExpiresAt)
+}
@@ -1,7 +1,6 @@
 package domain
 
 import (
-	"errors"
 	"regexp"
 	"time"
 
@@ -10,53 +9,85 @@ import (
 
 type User struct {
 	gorm.Model
-	ID        uint `gorm:"primaryKey;default:auto_random()"`
-	Name      string
-	Email     string
-	Password  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID              uint `gorm:"primaryKey;default:auto_random()"`
+	OrganizationID  uint
+	Name            string
+	Email           string
+	Password        string
+	Role            string
+	Status          string
+	EmailVerifiedAt *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
-var (
-	ErrUserNameRequired                   = errors.New("invalid user name")
-	ErrUserEmailRequired                  = errors.New("invalid user email")
-	ErrUserPasswordFormat                 = errors.New("password must have at least one uppercase letter, one lowercase letter, one numeric character, and one special character")
-	ErrUserPasswordLenght                 = errors.New("password be at least 6 characters long")
-	ErrUserPasswordFormatLowCase          = errors.New("the password must have at least one lowercase character")
-	ErrUserPasswordFormatUpperCase        = errors.New("the password must have at least one uppercase character ")
-	ErrUserPasswordFormatNumber           = errors.New("the password must have at least one numeric character")
-	ErrUserPasswordFormatSpecialCharacter = errors.New("the password must have at least one special character")
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
 )
 
-func (u *User) ValidatePassword() error {
-	if len(u.Password) < 6 {
-		return ErrUserPasswordLenght
-	}
+// Account statuses. Suspended accounts keep their data but cannot log in or
+// use their tokens. Deleted accounts are soft deleted and can be restored
+// until purged. Erased accounts were anonymized, their row is kept for the
+// records referencing it and can neither be restored nor purged.
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusDeleted   = "deleted"
+	UserStatusErased    = "erased"
+)
 
-	re := regexp.MustCompile(`(.*[a-z])+`)
-	if !re.MatchString(u.Password) {
-		return ErrUserPasswordFormatLowCase
-	}
+var (
+	ErrUserNameRequired                   = NewError(ErrorKindValidation, "user_name_invalid", "invalid user name")
+	ErrUserEmailRequired                  = NewError(ErrorKindValidation, "user_email_invalid", "invalid user email")
+	ErrUserPasswordTooShort               = NewError(ErrorKindValidation, "password_too_short", "the password is too short")
+	ErrUserPasswordTooLong                = NewError(ErrorKindValidation, "password_too_long", "the password is too long")
+	ErrUserPasswordFormatLowCase          = NewError(ErrorKindValidation, "password_lowercase_missing", "the password must have at least one lowercase character")
+	ErrUserPasswordFormatUpperCase        = NewError(ErrorKindValidation, "password_uppercase_missing", "the password must have at least one uppercase character ")
+	ErrUserPasswordFormatNumber           = NewError(ErrorKindValidation, "password_digit_missing", "the password must have at least one numeric character")
+	ErrUserPasswordFormatSpecialCharacter = NewError(ErrorKindValidation, "password_special_missing", "the password must have at least one special character")
+	ErrUserEmailNotVerified               = NewError(ErrorKindForbidden, "email_not_verified", "email not verified")
+	ErrUserPasswordIncorrect              = NewError(ErrorKindValidation, "password_incorrect", "current password is incorrect")
+	ErrUserPasswordReused                 = NewError(ErrorKindValidation, "password_reused", "the password was used recently, choose a different one")
+	ErrUserPasswordPersonalInfo           = NewError(ErrorKindValidation, "password_personal_info", "the password must not contain the user name or email")
+	ErrUserPasswordBreached               = NewError(ErrorKindValidation, "password_breached", "the password appears in a list of breached passwords")
+	ErrUserOpenSignupDisabled             = NewError(ErrorKindForbidden, "open_signup_disabled", "signup is by invitation only")
+	ErrUserEmailTaken                     = NewError(ErrorKindConflict, "email_taken", "a user with this email already exists")
+	ErrUserNotFound                       = NewError(ErrorKindNotFound, "user_not_found", "user not found")
+	ErrUserSuspended                      = NewError(ErrorKindForbidden, "account_suspended", "account suspended")
+	ErrUserErased                         = NewError(ErrorKindConflict, "account_erased", "the account was erased and can no longer be restored or purged")
+	ErrUserStatusInvalid                  = NewError(ErrorKindValidation, "user_status_invalid", "invalid user status")
+	ErrUserCredentialsInvalid             = NewError(ErrorKindUnauthorized, "credentials_invalid", "wrong credentials")
+)
 
-	re = regexp.MustCompile(`(.*[A-Z])+`)
-	if !re.MatchString(u.Password) {
-		return ErrUserPasswordFormatUpperCase
-	}
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
 
-	re = regexp.MustCompile(`(.*[1-9])+`)
-	if !re.MatchString(u.Password) {
-		return ErrUserPasswordFormatNumber
-	}
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+func (u *User) IsSuspended() bool {
+	return u.Status == UserStatusSuspended
+}
 
-	re = regexp.MustCompile(`(.*[-._!"\x60´'#%&,:;<>=@{}~\$\(\)\*\+\/\\\?\[\]\^\|])+`)
-	if !re.MatchString(u.Password) {
-		return ErrUserPasswordFormatSpecialCharacter
+// ValidateStatus accepts the statuses that can be set directly, deletion goes
+// through its own operation.
+func (u *User) ValidateStatus() error {
+	if u.Status != UserStatusActive && u.Status != UserStatusSuspended {
+		return ErrUserStatusInvalid
 	}
 
 	return nil
 }
 
+// ValidatePassword checks the password against the policy, the name and
+// email of the user are used to refuse passwords built from them.
+func (u *User) ValidatePassword(policy *PasswordPolicy) error {
+	return policy.Validate(u.Password, u.Name, u.Email)
+}
+
 func (u *User) ValidateName() error {
 	if len(u.Name) == 0 {
 		return ErrUserNameRequired
@@ -74,17 +105,24 @@ func (u *User) ValidateEmail() error {
 	return nil
 }
 
-func (u *User) ValidateAll() error {
+// ValidateAll checks the name, email and password of the user, every broken
+// rule is returned together as a *ValidationError.
+func (u *User) ValidateAll(policy *PasswordPolicy) error {
+	var violations []FieldViolation
 
 	if err := u.ValidateName(); err != nil {
-		return err
+		violations = append(violations, FieldViolation{Field: "name", Err: err})
 	}
-
 	if err := u.ValidateEmail(); err != nil {
-		return err
+		violations = append(violations, FieldViolation{Field: "email", Err: err})
+	}
+
+	if err := u.ValidatePassword(policy); err != nil {
+		violations = append(violations, fieldViolations("password", err)...)
 	}
-	if err := u.ValidatePassword(); err != nil {
-		return err
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
 	}
 
 	return nil
@@ -0,0 +1,17 @@
+package domain
+
+// UserData is everything held about a user, as gathered to answer a data
+// subject access request.
+type UserData struct {
+	User            *User
+	Organization    *Organization
+	Sessions        []*Session
+	ApiKeys         []*ApiKey
+	Identities      []*UserIdentity
+	TotpCredential  *TotpCredential
+	Preferences     *UserPreferences
+	PasswordHistory []*PasswordHistory
+	InvitationsSent []*Invitation
+	EmailChanges    []*EmailChangeToken
+	Consents        []*Consent
+}
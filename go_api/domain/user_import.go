@@ -0,0 +1,23 @@
+package domain
+
+// Outcomes of a row of a user import. Rows are valid instead of created on a
+// dry run.
+const (
+	UserImportRowCreated = "created"
+	UserImportRowValid   = "valid"
+	UserImportRowSkipped = "skipped"
+	UserImportRowFailed  = "failed"
+)
+
+const (
+	UserImportMaxRows   = 1000
+	UserImportBatchSize = 100
+)
+
+var (
+	ErrUserImportHeaderInvalid = NewError(ErrorKindValidation, "user_import_header_invalid", "the CSV header must have name, email and password columns")
+	ErrUserImportMalformed     = NewError(ErrorKindValidation, "user_import_malformed", "malformed CSV file")
+	ErrUserImportEmpty         = NewError(ErrorKindValidation, "user_import_empty", "the CSV file has no rows")
+	ErrUserImportTooLarge      = NewError(ErrorKindValidation, "user_import_too_large", "the CSV file has more than 1000 rows")
+	ErrUserImportDuplicateRow  = NewError(ErrorKindConflict, "user_import_duplicate_row", "the email appears on an earlier row")
+)
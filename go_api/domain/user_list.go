@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Fields users can be listed by. Ties are broken by id, so every order is
+// total and pages never overlap.
+const (
+	UserSortID        = "id"
+	UserSortName      = "name"
+	UserSortEmail     = "email"
+	UserSortCreatedAt = "created_at"
+)
+
+var UserSortFields = []string{UserSortID, UserSortName, UserSortEmail, UserSortCreatedAt}
+
+const (
+	UserListDefaultLimit = 20
+	UserListMaxLimit     = 100
+)
+
+var (
+	ErrUserListSortInvalid   = NewError(ErrorKindValidation, "user_list_sort_invalid", "invalid sort field")
+	ErrUserListLimitInvalid  = NewError(ErrorKindValidation, "user_list_limit_invalid", "limit must be between 1 and 100")
+	ErrUserListCursorInvalid = NewError(ErrorKindValidation, "user_list_cursor_invalid", "invalid cursor")
+)
+
+// UserListQuery selects a page of users. Zero valued filters are ignored, and
+// After resumes the listing right past the user a cursor was taken from.
+type UserListQuery struct {
+	NameContains string
+	Email        string
+	Status       string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Sort         string
+	Desc         bool
+	Limit        int
+	After        *UserCursor
+}
+
+func (q *UserListQuery) Validate() error {
+	if !slices.Contains(UserSortFields, q.Sort) {
+		return ErrUserListSortInvalid
+	}
+	if q.Limit < 1 || q.Limit > UserListMaxLimit {
+		return ErrUserListLimitInvalid
+	}
+	if q.After != nil && (q.After.Sort != q.Sort || q.After.Desc != q.Desc) {
+		return ErrUserListCursorInvalid
+	}
+
+	return nil
+}
+
+// UserCursor is the position of a user within a listing: the value of the
+// sort field along with the id breaking ties.
+type UserCursor struct {
+	Sort  string
+	Desc  bool
+	ID    uint
+	Value interface{}
+}
+
+type encodedUserCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	ID    uint   `json:"i"`
+	Value string `json:"v,omitempty"`
+}
+
+// NewUserCursor returns the position of u in a listing sorted by sort.
+func NewUserCursor(u *User, sort string, desc bool) *UserCursor {
+	c := &UserCursor{Sort: sort, Desc: desc, ID: u.ID}
+
+	switch sort {
+	case UserSortName:
+		c.Value = u.Name
+	case UserSortEmail:
+		c.Value = u.Email
+	case UserSortCreatedAt:
+		c.Value = u.CreatedAt
+	}
+
+	return c
+}
+
+// Encode returns the cursor as an opaque URL safe string.
+func (c *UserCursor) Encode() string {
+	e := encodedUserCursor{Sort: c.Sort, Desc: c.Desc, ID: c.ID}
+
+	switch v := c.Value.(type) {
+	case string:
+		e.Value = v
+	case time.Time:
+		e.Value = v.UTC().Format(time.RFC3339Nano)
+	}
+
+	b, _ := json.Marshal(e)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeUserCursor parses a cursor returned by Encode.
+func DecodeUserCursor(s string) (*UserCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrUserListCursorInvalid
+	}
+
+	e := encodedUserCursor{}
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, ErrUserListCursorInvalid
+	}
+
+	c := &UserCursor{Sort: e.Sort, Desc: e.Desc, ID: e.ID}
+
+	switch e.Sort {
+	case UserSortID:
+	case UserSortName, UserSortEmail:
+		c.Value = e.Value
+	case UserSortCreatedAt:
+		t, err := time.Parse(time.RFC3339Nano, e.Value)
+		if err != nil {
+			return nil, ErrUserListCursorInvalid
+		}
+		c.Value = t
+	default:
+		return nil, ErrUserListCursorInvalid
+	}
+
+	return c, nil
+}
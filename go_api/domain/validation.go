@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// FieldViolation is a rule broken by a field of a request. Field is the name
+// the client sent it under.
+type FieldViolation struct {
+	Field string
+	Err   error
+}
+
+// ValidationError lists every field of a request that broke a rule, so they
+// can all be fixed at once.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Field + ": " + v.Err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap lets errors.Is match the error of any of the violations.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Violations))
+	for i, v := range e.Violations {
+		errs[i] = v.Err
+	}
+	return errs
+}
+
+// NewFieldError reports err as a *ValidationError on field, or returns nil
+// when err is. Each rule of a *PasswordPolicyError is a violation of its own.
+func NewFieldError(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &ValidationError{Violations: fieldViolations(field, err)}
+}
+
+func fieldViolations(field string, err error) []FieldViolation {
+	var policyErr *PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return []FieldViolation{{Field: field, Err: err}}
+	}
+
+	violations := make([]FieldViolation, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		violations[i] = FieldViolation{Field: field, Err: v}
+	}
+	return violations
+}
+
+// Rules any field can break, as reported by the validation of requests.
+var (
+	ErrFieldRequired = NewError(ErrorKindValidation, "required", "the field is required")
+	ErrFieldTooLong  = NewError(ErrorKindValidation, "too_long", "the field is too long")
+	ErrFieldChoice   = NewError(ErrorKindValidation, "choice_invalid", "the field is not one of the accepted values")
+)
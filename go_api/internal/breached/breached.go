@@ -0,0 +1,141 @@
+// Package breached looks passwords up in an offline breached-password file.
+//
+// The file uses the "ordered by hash" layout of the Have I Been Pwned
+// downloads: one upper or lower case SHA-1 hex digest per line, optionally
+// followed by ":count", sorted by digest. Opening the file builds an index of
+// where each 5 character hash prefix starts, so a lookup only reads the few
+// lines sharing the prefix of the password being checked.
+package breached
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	hashLength   = sha1.Size * 2
+	prefixLength = 5
+)
+
+var ErrFileNotSorted = errors.New("breached password file is not sorted by hash")
+
+// span is the byte range holding every line of one hash prefix.
+type span struct {
+	start int64
+	end   int64
+}
+
+type File struct {
+	file  *os.File
+	index map[uint32]span
+}
+
+// Open indexes the breached password file at path.
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := buildIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &File{file: f, index: index}, nil
+}
+
+func buildIndex(r io.Reader) (map[uint32]span, error) {
+	index := map[uint32]span{}
+	reader := bufio.NewReader(r)
+
+	var offset int64
+	var current uint32
+	var started bool
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			hash := bytes.TrimSpace(line)
+			if len(hash) > 0 {
+				if len(hash) < hashLength {
+					return nil, fmt.Errorf("line %d: invalid hash", lineNumber)
+				}
+				prefix, perr := parsePrefix(string(hash[:prefixLength]))
+				if perr != nil {
+					return nil, fmt.Errorf("line %d: invalid hash", lineNumber)
+				}
+
+				if !started || prefix != current {
+					if started && prefix < current {
+						return nil, ErrFileNotSorted
+					}
+					if _, ok := index[prefix]; ok {
+						return nil, ErrFileNotSorted
+					}
+					index[prefix] = span{start: offset, end: offset}
+					current, started = prefix, true
+				}
+
+				s := index[current]
+				s.end = offset + int64(len(line))
+				index[current] = s
+			}
+			offset += int64(len(line))
+		}
+		if err == io.EOF {
+			return index, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func parsePrefix(prefix string) (uint32, error) {
+	v, err := strconv.ParseUint(prefix, 16, 32)
+	return uint32(v), err
+}
+
+// IsBreached implements domain.BreachedPasswordChecker.
+func (bf *File) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	prefix, err := parsePrefix(hash[:prefixLength])
+	if err != nil {
+		return false, err
+	}
+
+	s, ok := bf.index[prefix]
+	if !ok {
+		return false, nil
+	}
+
+	buf := make([]byte, s.end-s.start)
+	_, err = bf.file.ReadAt(buf, s.start)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) >= hashLength && strings.EqualFold(string(line[:hashLength]), hash) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (bf *File) Close() error {
+	return bf.file.Close()
+}
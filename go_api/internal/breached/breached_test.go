@@ -0,0 +1,90 @@
+package breached
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func writeTestFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsBreached(t *testing.T) {
+
+	// Sorted by hash: "password" is 5BAA61..., "123456" is 7C4A8D...
+	content := strings.Join([]string{
+		"0000000000000000000000000000000000000000:1",
+		strings.ToLower(sha1Hex("password")) + ":9545824",
+		sha1Hex("123456") + ":37359195",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+	}, "\r\n") + "\r\n"
+
+	bf, err := Open(writeTestFile(t, content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bf.Close()
+
+	testCases := []struct {
+		name     string
+		password string
+		expected bool
+	}{
+		{name: "Breached", password: "123456", expected: true},
+		{name: "Breached with lower case hash", password: "password", expected: true},
+		{name: "Not breached", password: "Correct-Horse-Battery-Staple-42", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			breached, err := bf.IsBreached(tc.password)
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.Equal(t, tc.expected, breached, "Expected IsBreached result to match.")
+		})
+	}
+}
+
+func TestOpenInvalidFile(t *testing.T) {
+
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "Not sorted",
+			content: "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:1\n0000000000000000000000000000000000000000:1\n",
+		},
+		{
+			name:    "Invalid hash",
+			content: "not-a-hash\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bf, err := Open(writeTestFile(t, tc.content))
+			assert.Error(t, err, "Expect Error")
+			assert.Nil(t, bf, "Expect file to be nil")
+		})
+	}
+
+	bf, err := Open(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err, "Expect Error")
+	assert.Nil(t, bf, "Expect file to be nil")
+}
@@ -1,8 +1,15 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Daffc/GO-Sales/domain"
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 )
@@ -17,16 +24,163 @@ type Database struct {
 }
 
 type Server struct {
-	Port               string `envconfig:"SERVER_PORT" default:"8080"`
-	JwtSigningKey      []byte `envconfig:"JWT_SIGNING_KEY" required:"true"`
-	JwtSessionDuration uint   `envconfig:"JWT_SESSION_DURATION" default:"24"`
-	WriteTimeout       uint16 `envconfig:"SERVER_WRITE_TIMEOUT" default:"15"`
-	ReadTimeout        uint16 `envconfig:"SERVER_READ_TIMEOUT" default:"15"`
-	IdleTimeout        uint16 `envconfig:"SERVER_IDLE_TIMEOUT" default:"60"`
+	Port                 string            `envconfig:"SERVER_PORT" default:"8080"`
+	JwtSigningKey        []byte            `envconfig:"JWT_SIGNING_KEY"`
+	JwtPrivateKeyFile    string            `envconfig:"JWT_PRIVATE_KEY_FILE"`
+	JwtPublicKeyFiles    []string          `envconfig:"JWT_PUBLIC_KEY_FILES"`
+	JwtSessionDuration   uint              `envconfig:"JWT_SESSION_DURATION" default:"24"`
+	WriteTimeout         uint16            `envconfig:"SERVER_WRITE_TIMEOUT" default:"15"`
+	ReadTimeout          uint16            `envconfig:"SERVER_READ_TIMEOUT" default:"15"`
+	IdleTimeout          uint16            `envconfig:"SERVER_IDLE_TIMEOUT" default:"60"`
+	RequestTimeout       uint16            `envconfig:"SERVER_REQUEST_TIMEOUT" default:"10"`
+	PublicURL            string            `envconfig:"SERVER_PUBLIC_URL" default:"http://localhost:8080"`
+	FrontendURL          string            `envconfig:"SERVER_FRONTEND_URL" default:"http://localhost:3000"`
+	TrustedProxies       TrustedProxies    `envconfig:"SERVER_TRUSTED_PROXIES"`
+	RateLimitStore       string            `envconfig:"SERVER_RATE_LIMIT_STORE" default:"memory"`
+	RateLimits           RateLimitPolicies `envconfig:"SERVER_RATE_LIMITS" default:"POST /login=10/1m:ip,POST /login/mfa=10/1m:ip,POST /users=10/1m:ip,/users=120/1m:user"`
+	CorsAllowedOrigins   []string          `envconfig:"SERVER_CORS_ALLOWED_ORIGINS"`
+	CorsAllowedMethods   []string          `envconfig:"SERVER_CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE"`
+	CorsAllowedHeaders   []string          `envconfig:"SERVER_CORS_ALLOWED_HEADERS" default:"Authorization,Content-Type,X-API-Key,X-Request-ID"`
+	CorsExposedHeaders   []string          `envconfig:"SERVER_CORS_EXPOSED_HEADERS" default:"X-Request-ID,Retry-After,RateLimit-Policy,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset"`
+	CorsAllowCredentials bool              `envconfig:"SERVER_CORS_ALLOW_CREDENTIALS" default:"false"`
+	CorsMaxAge           uint              `envconfig:"SERVER_CORS_MAX_AGE" default:"600"`
 }
+
+// RateLimitPolicies maps route patterns, as registered on the mux, to the
+// policy limiting them. It is set as a comma separated list of
+// "<pattern>=<limit>/<period>:<key>" entries, such as "POST /login=10/1m:ip",
+// the key being one of domain.RateLimitKeys.
+type RateLimitPolicies map[string]domain.RateLimitPolicy
+
+func (p *RateLimitPolicies) Decode(value string) error {
+	policies := RateLimitPolicies{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		pattern, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("rate limit %q: missing policy", entry)
+		}
+		rate, key, ok := strings.Cut(spec, ":")
+		if !ok || !slices.Contains(domain.RateLimitKeys, key) {
+			return fmt.Errorf("rate limit %q: key must be one of %s", entry, strings.Join(domain.RateLimitKeys, ", "))
+		}
+		limit, period, ok := strings.Cut(rate, "/")
+		if !ok {
+			return fmt.Errorf("rate limit %q: rate must be <limit>/<period>", entry)
+		}
+
+		l, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil || l == 0 {
+			return fmt.Errorf("rate limit %q: invalid limit", entry)
+		}
+		d, err := time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("rate limit %q: invalid period", entry)
+		}
+
+		policies[strings.TrimSpace(pattern)] = domain.RateLimitPolicy{Limit: uint(l), Period: d, Key: key}
+	}
+
+	*p = policies
+
+	return nil
+}
+
+// TrustedProxies are the networks of the reverse proxies in front of the
+// server, the only peers whose X-Forwarded-For header is believed. It is set as
+// a comma separated list of networks or addresses, such as
+// "10.0.0.0/8,192.0.2.1".
+type TrustedProxies []netip.Prefix
+
+func (p *TrustedProxies) Decode(value string) error {
+	proxies := TrustedProxies{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return fmt.Errorf("trusted proxy %q: invalid address", entry)
+			}
+			addr = addr.Unmap()
+			proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return fmt.Errorf("trusted proxy %q: invalid network", entry)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+
+	*p = proxies
+
+	return nil
+}
+
+type Auth struct {
+	RequireVerifiedEmail       bool   `envconfig:"AUTH_REQUIRE_VERIFIED_EMAIL" default:"false"`
+	OpenSignup                 bool   `envconfig:"AUTH_OPEN_SIGNUP" default:"true"`
+	InvitationTTL              uint   `envconfig:"AUTH_INVITATION_TTL" default:"72"`
+	EmailVerificationTTL       uint   `envconfig:"AUTH_EMAIL_VERIFICATION_TTL" default:"24"`
+	EmailChangeTTL             uint   `envconfig:"AUTH_EMAIL_CHANGE_TTL" default:"24"`
+	LoginMaxAttempts           uint   `envconfig:"AUTH_LOGIN_MAX_ATTEMPTS" default:"5"`
+	LoginMaxAttemptsPerIP      uint   `envconfig:"AUTH_LOGIN_MAX_ATTEMPTS_PER_IP" default:"20"`
+	LoginLockoutDuration       uint   `envconfig:"AUTH_LOGIN_LOCKOUT_DURATION" default:"15"`
+	LoginBackoffBase           uint   `envconfig:"AUTH_LOGIN_BACKOFF_BASE" default:"1"`
+	LoginBackoffMax            uint   `envconfig:"AUTH_LOGIN_BACKOFF_MAX" default:"60"`
+	MfaIssuer                  string `envconfig:"AUTH_MFA_ISSUER" default:"GO-Sales"`
+	MfaChallengeTTL            uint   `envconfig:"AUTH_MFA_CHALLENGE_TTL" default:"5"`
+	ImpersonationTTL           uint   `envconfig:"AUTH_IMPERSONATION_TTL" default:"15"`
+	PasswordHistorySize        uint   `envconfig:"AUTH_PASSWORD_HISTORY_SIZE" default:"5"`
+	PasswordMinLength          int    `envconfig:"AUTH_PASSWORD_MIN_LENGTH" default:"6"`
+	PasswordMaxLength          int    `envconfig:"AUTH_PASSWORD_MAX_LENGTH" default:"64"`
+	PasswordRequireLowercase   bool   `envconfig:"AUTH_PASSWORD_REQUIRE_LOWERCASE" default:"true"`
+	PasswordRequireUppercase   bool   `envconfig:"AUTH_PASSWORD_REQUIRE_UPPERCASE" default:"true"`
+	PasswordRequireDigit       bool   `envconfig:"AUTH_PASSWORD_REQUIRE_DIGIT" default:"true"`
+	PasswordRequireSpecial     bool   `envconfig:"AUTH_PASSWORD_REQUIRE_SPECIAL" default:"true"`
+	PasswordForbidPersonalInfo bool   `envconfig:"AUTH_PASSWORD_FORBID_PERSONAL_INFO" default:"true"`
+	PasswordBreachedListFile   string `envconfig:"AUTH_PASSWORD_BREACHED_LIST_FILE"`
+	PasswordHashAlgorithm      string `envconfig:"AUTH_PASSWORD_HASH_ALGORITHM" default:"argon2id"`
+	PasswordBcryptCost         int    `envconfig:"AUTH_PASSWORD_BCRYPT_COST" default:"10"`
+	PasswordArgon2Memory       uint32 `envconfig:"AUTH_PASSWORD_ARGON2_MEMORY" default:"65536"`
+	PasswordArgon2Iterations   uint32 `envconfig:"AUTH_PASSWORD_ARGON2_ITERATIONS" default:"3"`
+	PasswordArgon2Parallelism  uint8  `envconfig:"AUTH_PASSWORD_ARGON2_PARALLELISM" default:"2"`
+}
+
+type Mail struct {
+	Host     string `envconfig:"SMTP_HOST"`
+	Port     string `envconfig:"SMTP_PORT" default:"587"`
+	User     string `envconfig:"SMTP_USERNAME"`
+	Password string `envconfig:"SMTP_PASSWORD"`
+	From     string `envconfig:"MAIL_FROM" default:"no-reply@go-sales.local"`
+}
+
+type Oidc struct {
+	IssuerURL    string   `envconfig:"OIDC_ISSUER_URL"`
+	ClientID     string   `envconfig:"OIDC_CLIENT_ID"`
+	ClientSecret string   `envconfig:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string   `envconfig:"OIDC_REDIRECT_URL"`
+	Scopes       []string `envconfig:"OIDC_SCOPES" default:"openid,email,profile"`
+	StateTTL     uint     `envconfig:"OIDC_STATE_TTL" default:"10"`
+}
+
 type Config struct {
 	Database Database
 	Server   Server
+	Auth     Auth
+	Mail     Mail
+	Oidc     Oidc
 }
 
 func NewConfigParser(envFilePath string) (*Config, error) {
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"net/netip"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/Daffc/GO-Sales/domain"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,10 +21,62 @@ func TestNewConfigParser(t *testing.T) {
 	DB_MIRGATION_FOLDER=./Migration
 	SERVER_PORT=3000
 	JWT_SIGNING_KEY=SigningKey
+	JWT_PRIVATE_KEY_FILE=./keys/current.pem
+	JWT_PUBLIC_KEY_FILES=./keys/previous.pub.pem,./keys/older.pub.pem
 	JWT_SESSION_DURATION=1000
 	SERVER_WRITE_TIMEOUT=15
 	SERVER_READ_TIMEOUT=15
-	SERVER_IDLE_TIMEOUT=60`
+	SERVER_IDLE_TIMEOUT=60
+	SERVER_REQUEST_TIMEOUT=5
+	SERVER_PUBLIC_URL=http://localhost:3000
+	SERVER_FRONTEND_URL=https://app.example.com
+	SERVER_TRUSTED_PROXIES=10.0.0.0/8,192.0.2.1
+	SERVER_RATE_LIMIT_STORE=mariadb
+	SERVER_RATE_LIMITS="POST /login=5/30s:ip, GET /me/api-keys=100/1h:api_key"
+	SERVER_CORS_ALLOWED_ORIGINS=https://admin.example.com,https://*.example.com
+	SERVER_CORS_ALLOWED_METHODS=GET,POST
+	SERVER_CORS_ALLOWED_HEADERS=Authorization,Content-Type
+	SERVER_CORS_EXPOSED_HEADERS=X-Request-ID
+	SERVER_CORS_ALLOW_CREDENTIALS=true
+	SERVER_CORS_MAX_AGE=3600
+	AUTH_REQUIRE_VERIFIED_EMAIL=true
+	AUTH_OPEN_SIGNUP=false
+	AUTH_INVITATION_TTL=24
+	AUTH_EMAIL_VERIFICATION_TTL=48
+	AUTH_EMAIL_CHANGE_TTL=12
+	AUTH_LOGIN_MAX_ATTEMPTS=3
+	AUTH_LOGIN_MAX_ATTEMPTS_PER_IP=10
+	AUTH_LOGIN_LOCKOUT_DURATION=30
+	AUTH_LOGIN_BACKOFF_BASE=2
+	AUTH_LOGIN_BACKOFF_MAX=120
+	AUTH_MFA_ISSUER=GO-Sales-Test
+	AUTH_MFA_CHALLENGE_TTL=10
+	AUTH_IMPERSONATION_TTL=30
+	AUTH_PASSWORD_HISTORY_SIZE=3
+	AUTH_PASSWORD_MIN_LENGTH=10
+	AUTH_PASSWORD_MAX_LENGTH=128
+	AUTH_PASSWORD_REQUIRE_LOWERCASE=true
+	AUTH_PASSWORD_REQUIRE_UPPERCASE=false
+	AUTH_PASSWORD_REQUIRE_DIGIT=true
+	AUTH_PASSWORD_REQUIRE_SPECIAL=false
+	AUTH_PASSWORD_FORBID_PERSONAL_INFO=true
+	AUTH_PASSWORD_BREACHED_LIST_FILE=./breached.txt
+	AUTH_PASSWORD_HASH_ALGORITHM=bcrypt
+	AUTH_PASSWORD_BCRYPT_COST=12
+	AUTH_PASSWORD_ARGON2_MEMORY=19456
+	AUTH_PASSWORD_ARGON2_ITERATIONS=2
+	AUTH_PASSWORD_ARGON2_PARALLELISM=1
+	SMTP_HOST=smtp.example.com
+	SMTP_PORT=25
+	SMTP_USERNAME=smtpuser
+	SMTP_PASSWORD=smtppassword
+	MAIL_FROM=no-reply@example.com
+	OIDC_ISSUER_URL=https://sso.example.com
+	OIDC_CLIENT_ID=go-sales
+	OIDC_CLIENT_SECRET=oidcsecret
+	OIDC_REDIRECT_URL=http://localhost:3000/login/oidc/callback
+	OIDC_SCOPES=openid,email
+	OIDC_STATE_TTL=5`
 	validEnvContentFilePath := "./.test.env"
 	err := os.WriteFile(validEnvContentFilePath, []byte(validEnvContent), 0644)
 	if err != nil {
@@ -58,10 +113,71 @@ func TestNewConfigParser(t *testing.T) {
 				Server: Server{
 					Port:               "3000",
 					JwtSigningKey:      []byte("SigningKey"),
+					JwtPrivateKeyFile:  "./keys/current.pem",
+					JwtPublicKeyFiles:  []string{"./keys/previous.pub.pem", "./keys/older.pub.pem"},
 					JwtSessionDuration: 1000,
 					WriteTimeout:       15,
 					ReadTimeout:        15,
 					IdleTimeout:        60,
+					RequestTimeout:     5,
+					PublicURL:          "http://localhost:3000",
+					FrontendURL:        "https://app.example.com",
+					TrustedProxies:     TrustedProxies{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")},
+					RateLimitStore:     "mariadb",
+					RateLimits: RateLimitPolicies{
+						"POST /login":      {Limit: 5, Period: 30 * time.Second, Key: domain.RateLimitKeyIP},
+						"GET /me/api-keys": {Limit: 100, Period: time.Hour, Key: domain.RateLimitKeyApiKey},
+					},
+					CorsAllowedOrigins:   []string{"https://admin.example.com", "https://*.example.com"},
+					CorsAllowedMethods:   []string{"GET", "POST"},
+					CorsAllowedHeaders:   []string{"Authorization", "Content-Type"},
+					CorsExposedHeaders:   []string{"X-Request-ID"},
+					CorsAllowCredentials: true,
+					CorsMaxAge:           3600,
+				},
+				Auth: Auth{
+					RequireVerifiedEmail:       true,
+					OpenSignup:                 false,
+					InvitationTTL:              24,
+					EmailVerificationTTL:       48,
+					EmailChangeTTL:             12,
+					LoginMaxAttempts:           3,
+					LoginMaxAttemptsPerIP:      10,
+					LoginLockoutDuration:       30,
+					LoginBackoffBase:           2,
+					LoginBackoffMax:            120,
+					MfaIssuer:                  "GO-Sales-Test",
+					MfaChallengeTTL:            10,
+					ImpersonationTTL:           30,
+					PasswordHistorySize:        3,
+					PasswordMinLength:          10,
+					PasswordMaxLength:          128,
+					PasswordRequireLowercase:   true,
+					PasswordRequireUppercase:   false,
+					PasswordRequireDigit:       true,
+					PasswordRequireSpecial:     false,
+					PasswordForbidPersonalInfo: true,
+					PasswordBreachedListFile:   "./breached.txt",
+					PasswordHashAlgorithm:      "bcrypt",
+					PasswordBcryptCost:         12,
+					PasswordArgon2Memory:       19456,
+					PasswordArgon2Iterations:   2,
+					PasswordArgon2Parallelism:  1,
+				},
+				Mail: Mail{
+					Host:     "smtp.example.com",
+					Port:     "25",
+					User:     "smtpuser",
+					Password: "smtppassword",
+					From:     "no-reply@example.com",
+				},
+				Oidc: Oidc{
+					IssuerURL:    "https://sso.example.com",
+					ClientID:     "go-sales",
+					ClientSecret: "oidcsecret",
+					RedirectURL:  "http://localhost:3000/login/oidc/callback",
+					Scopes:       []string{"openid", "email"},
+					StateTTL:     5,
 				},
 			},
 			mockEnvFilePath: validEnvContentFilePath,
@@ -99,3 +215,108 @@ func TestNewConfigParser(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimitPoliciesDecode(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		value          string
+		expectedReturn RateLimitPolicies
+		expectError    bool
+	}{
+		{
+			name:  "Valid",
+			value: "POST /login=10/1m:ip,/users=120/1m:user",
+			expectedReturn: RateLimitPolicies{
+				"POST /login": {Limit: 10, Period: time.Minute, Key: domain.RateLimitKeyIP},
+				"/users":      {Limit: 120, Period: time.Minute, Key: domain.RateLimitKeyUser},
+			},
+		},
+		{
+			name:           "Empty",
+			value:          "",
+			expectedReturn: RateLimitPolicies{},
+		},
+		{
+			name:        "Missing policy",
+			value:       "POST /login",
+			expectError: true,
+		},
+		{
+			name:        "Unknown key",
+			value:       "POST /login=10/1m:email",
+			expectError: true,
+		},
+		{
+			name:        "Zero limit",
+			value:       "POST /login=0/1m:ip",
+			expectError: true,
+		},
+		{
+			name:        "Invalid period",
+			value:       "POST /login=10/minute:ip",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			var policies RateLimitPolicies
+			err := policies.Decode(tc.value)
+
+			if tc.expectError {
+				assert.Error(t, err, "Expected error")
+			} else {
+				assert.NoError(t, err, "Expected error to be nil")
+				assert.Equal(t, tc.expectedReturn, policies, "Expected policies to match")
+			}
+		})
+	}
+}
+
+func TestTrustedProxiesDecode(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		value          string
+		expectedReturn TrustedProxies
+		expectError    bool
+	}{
+		{
+			name:           "Valid",
+			value:          "10.1.2.3/8, 192.0.2.1,2001:db8::/32,::ffff:198.51.100.7",
+			expectedReturn: TrustedProxies{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32"), netip.MustParsePrefix("2001:db8::/32"), netip.MustParsePrefix("198.51.100.7/32")},
+		},
+		{
+			name:           "Empty",
+			value:          "",
+			expectedReturn: TrustedProxies{},
+		},
+		{
+			name:        "Invalid address",
+			value:       "proxy.example.com",
+			expectError: true,
+		},
+		{
+			name:        "Invalid network",
+			value:       "10.0.0.0/33",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			var proxies TrustedProxies
+			err := proxies.Decode(tc.value)
+
+			if tc.expectError {
+				assert.Error(t, err, "Expected error")
+			} else {
+				assert.NoError(t, err, "Expected error to be nil")
+				assert.Equal(t, tc.expectedReturn, proxies, "Expected proxies to match")
+			}
+		})
+	}
+}
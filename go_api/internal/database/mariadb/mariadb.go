@@ -2,6 +2,8 @@ package mariadb
 
 import (
 	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/Daffc/GO-Sales/internal/config"
 
@@ -12,9 +14,19 @@ import (
 
 func NewDatabaseConnection(dbc *config.Database) (*gorm.DB, error) {
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", dbc.User, dbc.Password, dbc.Host, dbc.Port, dbc.Name)
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// Timestamps are stored and read in UTC whatever the timezone of the
+	// server, they are only rendered in the timezone of the user. The session
+	// time_zone is UTC as well, so NOW() and CURRENT_TIMESTAMP agree with the
+	// timestamps written by the API. Timestamps written before are converted
+	// by a migration, see DB_LEGACY_TIME_ZONE.
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%s", dbc.User, dbc.Password, dbc.Host, dbc.Port, dbc.Name, url.QueryEscape("'+00:00'"))
+
+	// TranslateError turns constraint violations into gorm.ErrDuplicatedKey
+	// and gorm.ErrForeignKeyViolated, so callers need no driver error codes.
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		NowFunc:        func() time.Time { return time.Now().UTC() },
+	})
 
 	return db, err
 }
@@ -0,0 +1,83 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/Daffc/GO-Sales/internal/config"
+)
+
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+type logMailer struct {
+	from string
+}
+
+// NewMailer returns a SMTP backed Mailer, or a Mailer that only logs messages
+// when no SMTP host is configured (development environments).
+func NewMailer(mc *config.Mail) Mailer {
+	if len(mc.Host) == 0 {
+		return &logMailer{from: mc.From}
+	}
+
+	var auth smtp.Auth
+	if len(mc.User) > 0 {
+		auth = smtp.PlainAuth("", mc.User, mc.Password, mc.Host)
+	}
+
+	return &smtpMailer{
+		addr: net.JoinHostPort(mc.Host, mc.Port),
+		auth: auth,
+		from: mc.From,
+	}
+}
+
+func (m *smtpMailer) Send(to string, subject string, body string) error {
+	msg, err := buildMessage(m.from, to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, msg)
+}
+
+func (m *logMailer) Send(to string, subject string, body string) error {
+	msg, err := buildMessage(m.from, to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("mail not sent (no SMTP host configured):\n%s", msg)
+	return nil
+}
+
+func buildMessage(from string, to string, subject string, body string) ([]byte, error) {
+	// Rejecting line breaks on headers avoids header injection.
+	for _, h := range []string{from, to, subject} {
+		if strings.ContainsAny(h, "\r\n") {
+			return nil, fmt.Errorf("invalid mail header value %q", h)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: " + to + "\r\n")
+	sb.WriteString("Subject: " + subject + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(body)
+
+	return []byte(sb.String()), nil
+}
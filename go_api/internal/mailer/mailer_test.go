@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"testing"
+
+	"github.com/Daffc/GO-Sales/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMailer(t *testing.T) {
+
+	testCases := []struct {
+		name         string
+		config       *config.Mail
+		expectedType interface{}
+	}{
+		{
+			name:         "Log mailer without host",
+			config:       &config.Mail{From: "no-reply@example.com"},
+			expectedType: &logMailer{},
+		},
+		{
+			name:         "SMTP mailer with host",
+			config:       &config.Mail{Host: "localhost", Port: "25", From: "no-reply@example.com"},
+			expectedType: &smtpMailer{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMailer(tc.config)
+			assert.IsType(t, tc.expectedType, m, "Expected mailer type to match")
+		})
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		from           string
+		to             string
+		subject        string
+		body           string
+		expectedOutput string
+		expectError    bool
+	}{
+		{
+			name:           "Success",
+			from:           "no-reply@example.com",
+			to:             "user1@example.com",
+			subject:        "Subject",
+			body:           "Body",
+			expectedOutput: "From: no-reply@example.com\r\nTo: user1@example.com\r\nSubject: Subject\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\nBody",
+			expectError:    false,
+		},
+		{
+			name:        "Header injection",
+			from:        "no-reply@example.com",
+			to:          "user1@example.com\r\nBcc: user2@example.com",
+			subject:     "Subject",
+			body:        "Body",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := buildMessage(tc.from, tc.to, tc.subject, tc.body)
+			if tc.expectError {
+				assert.Error(t, err, "Expected error")
+				assert.Nil(t, msg, "Expected message to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, tc.expectedOutput, string(msg), "Expected message to match")
+			}
+		})
+	}
+}
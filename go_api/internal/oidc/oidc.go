@@ -0,0 +1,118 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Daffc/GO-Sales/internal/config"
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// exchangeTimeout bounds the calls made to the provider while finishing a
+// login.
+const exchangeTimeout = 10 * time.Second
+
+var (
+	ErrMissingIDToken = errors.New("token response has no id_token")
+	ErrNonceMismatch  = errors.New("id_token nonce does not match")
+)
+
+// Identity is the account at the provider that completed a login.
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Provider interface {
+	Issuer() string
+	// AuthCodeURL returns the provider URL the user is sent to, carrying the
+	// state, the nonce and the S256 challenge of codeVerifier.
+	AuthCodeURL(state string, nonce string, codeVerifier string) string
+	// Exchange redeems an authorization code and returns the identity of its
+	// verified ID token.
+	Exchange(code string, codeVerifier string, nonce string) (*Identity, error)
+}
+
+type provider struct {
+	issuer       string
+	oauth2Config oauth2.Config
+	verifier     *gooidc.IDTokenVerifier
+}
+
+// NewProvider discovers the provider configuration from its issuer URL.
+func NewProvider(ctx context.Context, oc *config.Oidc) (Provider, error) {
+	if len(oc.IssuerURL) == 0 || len(oc.ClientID) == 0 || len(oc.RedirectURL) == 0 {
+		return nil, errors.New("oidc issuer url, client id and redirect url are required")
+	}
+
+	p, err := gooidc.NewProvider(ctx, oc.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider{
+		issuer: oc.IssuerURL,
+		oauth2Config: oauth2.Config{
+			ClientID:     oc.ClientID,
+			ClientSecret: oc.ClientSecret,
+			RedirectURL:  oc.RedirectURL,
+			Endpoint:     p.Endpoint(),
+			Scopes:       oc.Scopes,
+		},
+		verifier: p.Verifier(&gooidc.Config{ClientID: oc.ClientID}),
+	}, nil
+}
+
+func (p *provider) Issuer() string {
+	return p.issuer
+}
+
+func (p *provider) AuthCodeURL(state string, nonce string, codeVerifier string) string {
+	return p.oauth2Config.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier))
+}
+
+func (p *provider) Exchange(code string, codeVerifier string, nonce string) (*Identity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), exchangeTimeout)
+	defer cancel()
+
+	token, err := p.oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrMissingIDToken
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	err = idToken.Claims(&claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
@@ -0,0 +1,244 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/internal/config"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testClientID     = "go-sales"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "http://localhost:8080/login/oidc/callback"
+)
+
+type fakeAuthorization struct {
+	challenge string
+	nonce     string
+	subject   string
+	email     string
+}
+
+// fakeIssuer is an in-process OpenID Connect provider issuing RS256 ID tokens.
+type fakeIssuer struct {
+	server         *httptest.Server
+	keySet         *util.JwtKeySet
+	authorizations map[string]*fakeAuthorization
+	// overrideNonce replaces the nonce of the next ID token when set.
+	overrideNonce string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySet, err := util.NewJwtKeySet(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fi := &fakeIssuer{keySet: keySet, authorizations: map[string]*fakeAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		util.JSONResponse(w, map[string]interface{}{
+			"issuer":                                fi.server.URL,
+			"authorization_endpoint":                fi.server.URL + "/authorize",
+			"token_endpoint":                        fi.server.URL + "/token",
+			"jwks_uri":                              fi.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		}, http.StatusOK)
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		util.JSONResponse(w, fi.keySet.JWKS(), http.StatusOK)
+	})
+	mux.HandleFunc("POST /token", fi.token)
+
+	fi.server = httptest.NewServer(mux)
+	t.Cleanup(fi.server.Close)
+
+	return fi
+}
+
+// authorize plays the user signing in at the provider and returns the code
+// sent back to the redirect URL.
+func (fi *fakeIssuer) authorize(t *testing.T, authCodeURL string, subject string, email string) (string, string) {
+	u, err := url.Parse(authCodeURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+
+	assert.Equal(t, fi.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path, "Expected authorization endpoint to match.")
+	assert.Equal(t, "S256", q.Get("code_challenge_method"), "Expected PKCE challenge method to match.")
+	assert.Equal(t, testRedirectURL, q.Get("redirect_uri"), "Expected redirect URI to match.")
+
+	code := "code-" + q.Get("state")
+	fi.authorizations[code] = &fakeAuthorization{
+		challenge: q.Get("code_challenge"),
+		nonce:     q.Get("nonce"),
+		subject:   subject,
+		email:     email,
+	}
+
+	return code, q.Get("state")
+}
+
+func (fi *fakeIssuer) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || clientSecret != testClientSecret {
+		util.JSONResponse(w, map[string]string{"error": "invalid_client"}, http.StatusUnauthorized)
+		return
+	}
+
+	a, ok := fi.authorizations[r.PostFormValue("code")]
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" {
+		util.JSONResponse(w, map[string]string{"error": "invalid_grant"}, http.StatusBadRequest)
+		return
+	}
+	delete(fi.authorizations, r.PostFormValue("code"))
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != a.challenge {
+		util.JSONResponse(w, map[string]string{"error": "invalid_grant"}, http.StatusBadRequest)
+		return
+	}
+
+	nonce := a.nonce
+	if len(fi.overrideNonce) > 0 {
+		nonce = fi.overrideNonce
+	}
+
+	idToken, err := fi.keySet.Sign(jwt.MapClaims{
+		"iss":            fi.server.URL,
+		"sub":            a.subject,
+		"aud":            testClientID,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          a.email,
+		"email_verified": true,
+		"name":           "User1",
+	})
+	if err != nil {
+		util.JSONResponse(w, map[string]string{"error": "server_error"}, http.StatusInternalServerError)
+		return
+	}
+
+	util.JSONResponse(w, map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	}, http.StatusOK)
+}
+
+func newTestProvider(t *testing.T, fi *fakeIssuer) Provider {
+	p, err := NewProvider(context.Background(), &config.Oidc{
+		IssuerURL:    fi.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProviderExchange(t *testing.T) {
+
+	fi := newFakeIssuer(t)
+	p := newTestProvider(t, fi)
+
+	testCases := []struct {
+		name             string
+		codeVerifier     string
+		exchangeVerifier string
+		nonce            string
+		overrideNonce    string
+		useUnknownCode   bool
+		expectError      bool
+	}{
+		{
+			name:             "Success",
+			codeVerifier:     "verifier-0123456789-0123456789-0123456789",
+			exchangeVerifier: "verifier-0123456789-0123456789-0123456789",
+			nonce:            "nonce-1",
+			expectError:      false,
+		},
+		{
+			name:             "Wrong code verifier",
+			codeVerifier:     "verifier-0123456789-0123456789-0123456789",
+			exchangeVerifier: "verifier-9876543210-9876543210-9876543210",
+			nonce:            "nonce-2",
+			expectError:      true,
+		},
+		{
+			name:             "Nonce mismatch",
+			codeVerifier:     "verifier-0123456789-0123456789-0123456789",
+			exchangeVerifier: "verifier-0123456789-0123456789-0123456789",
+			nonce:            "nonce-3",
+			overrideNonce:    "replayed-nonce",
+			expectError:      true,
+		},
+		{
+			name:             "Unknown code",
+			codeVerifier:     "verifier-0123456789-0123456789-0123456789",
+			exchangeVerifier: "verifier-0123456789-0123456789-0123456789",
+			nonce:            "nonce-4",
+			useUnknownCode:   true,
+			expectError:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fi.overrideNonce = tc.overrideNonce
+
+			authCodeURL := p.AuthCodeURL("state-"+tc.nonce, tc.nonce, tc.codeVerifier)
+			code, state := fi.authorize(t, authCodeURL, "subject-1", "user1@example.com")
+			assert.Equal(t, "state-"+tc.nonce, state, "Expected state to match.")
+			if tc.useUnknownCode {
+				code = "unknown"
+			}
+
+			identity, err := p.Exchange(code, tc.exchangeVerifier, tc.nonce)
+
+			if tc.expectError {
+				assert.Error(t, err, "Expect Error")
+				assert.Nil(t, identity, "Expect identity to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, &Identity{
+					Issuer:        fi.server.URL,
+					Subject:       "subject-1",
+					Email:         "user1@example.com",
+					EmailVerified: true,
+					Name:          "User1",
+				}, identity, "Expected identity to match.")
+				assert.Equal(t, fi.server.URL, p.Issuer(), "Expected issuer to match.")
+			}
+		})
+	}
+}
+
+func TestNewProviderMissingConfiguration(t *testing.T) {
+	p, err := NewProvider(context.Background(), &config.Oidc{ClientID: testClientID})
+	assert.Error(t, err, "Expect Error")
+	assert.Nil(t, p, "Expect provider to be nil")
+}
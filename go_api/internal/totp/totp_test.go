@@ -0,0 +1,113 @@
+package totp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"image/png"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 appendix B secret for HMAC-SHA1: ASCII "12345678901234567890".
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateCodeRFCVectors(t *testing.T) {
+
+	testCases := []struct {
+		name         string
+		time         int64
+		expectedCode string
+	}{
+		{name: "T=59", time: 59, expectedCode: "94287082"},
+		{name: "T=1111111109", time: 1111111109, expectedCode: "07081804"},
+		{name: "T=1111111111", time: 1111111111, expectedCode: "14050471"},
+		{name: "T=1234567890", time: 1234567890, expectedCode: "89005924"},
+		{name: "T=2000000000", time: 2000000000, expectedCode: "69279037"},
+		{name: "T=20000000000", time: 20000000000, expectedCode: "65353130"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := generateCode(rfcSecret, Step(time.Unix(tc.time, 0)), 8)
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.Equal(t, tc.expectedCode, code, "Expected code to match RFC 6238 vector")
+		})
+	}
+}
+
+func TestGenerateCode(t *testing.T) {
+	code, err := GenerateCode(rfcSecret, time.Unix(59, 0))
+	assert.NoError(t, err, "Did not expect an error but got one")
+	assert.Equal(t, "287082", code, "Expected six digits code")
+
+	code, err = GenerateCode("not base32!", time.Unix(59, 0))
+	assert.ErrorIs(t, err, ErrInvalidSecret, "Expected invalid secret error")
+	assert.Empty(t, code, "Expected code to be empty")
+}
+
+func TestValidate(t *testing.T) {
+
+	now := time.Unix(1111111111, 0)
+	current, _ := GenerateCode(rfcSecret, now)
+	previous, _ := GenerateCode(rfcSecret, now.Add(-Period*time.Second))
+	next, _ := GenerateCode(rfcSecret, now.Add(Period*time.Second))
+	tooOld, _ := GenerateCode(rfcSecret, now.Add(-2*Period*time.Second))
+
+	testCases := []struct {
+		name         string
+		secret       string
+		code         string
+		expectedStep int64
+		expectedOk   bool
+	}{
+		{name: "Current step", secret: rfcSecret, code: current, expectedStep: Step(now), expectedOk: true},
+		{name: "Previous step", secret: rfcSecret, code: previous, expectedStep: Step(now) - 1, expectedOk: true},
+		{name: "Next step", secret: rfcSecret, code: next, expectedStep: Step(now) + 1, expectedOk: true},
+		{name: "Outside skew", secret: rfcSecret, code: tooOld, expectedStep: 0, expectedOk: false},
+		{name: "Wrong length", secret: rfcSecret, code: "12345", expectedStep: 0, expectedOk: false},
+		{name: "Invalid secret", secret: "not base32!", code: current, expectedStep: 0, expectedOk: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			step, ok := Validate(tc.secret, tc.code, now)
+			assert.Equal(t, tc.expectedOk, ok, "Expected validation result to match")
+			assert.Equal(t, tc.expectedStep, step, "Expected matched step to match")
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	key, err := encoding.DecodeString(secret)
+	assert.NoError(t, err, "Expected secret to be base32 encoded")
+	assert.Len(t, key, secretSize, "Expected secret size to match")
+}
+
+func TestKeyURI(t *testing.T) {
+	uri := KeyURI("GO Sales", "user1@example.com", rfcSecret)
+
+	u, err := url.Parse(uri)
+	assert.NoError(t, err, "Expected URI to be parseable")
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/GO Sales:user1@example.com", u.Path)
+	assert.Equal(t, rfcSecret, u.Query().Get("secret"))
+	assert.Equal(t, "GO Sales", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+	assert.Equal(t, "30", u.Query().Get("period"))
+}
+
+func TestQRCodePNG(t *testing.T) {
+	b, err := QRCodePNG(KeyURI("GO Sales", "user1@example.com", rfcSecret), 256)
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	img, err := png.Decode(bytes.NewReader(b))
+	assert.NoError(t, err, "Expected a valid PNG")
+	assert.Equal(t, 256, img.Bounds().Dx(), "Expected image size to match")
+}
@@ -0,0 +1,288 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrJwtKeySetEmpty   = errors.New("no jwt signing key configured")
+	ErrJwtKeyUnknown    = errors.New("unknown jwt key")
+	ErrJwtKeyUnexpected = errors.New("unexpected jwt signing method")
+)
+
+// JwtKey is a single key of a JwtKeySet. Signing keys hold the private half,
+// verification-only keys just the public one. HMAC keys hold the shared secret
+// in both and are never published.
+type JwtKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+	PublicKey  interface{}
+}
+
+// JwtKeySet signs tokens with a single active key and verifies them against
+// every key it holds, so keys can be rotated without invalidating sessions.
+type JwtKeySet struct {
+	signingKey *JwtKey
+	keys       map[string]*JwtKey
+}
+
+// NewHmacJwtKeySet returns a key set that signs and verifies with a shared
+// HS256 secret.
+func NewHmacJwtKeySet(secret []byte) (*JwtKeySet, error) {
+	if len(secret) == 0 {
+		return nil, ErrJwtKeySetEmpty
+	}
+
+	ks := &JwtKeySet{keys: map[string]*JwtKey{}}
+	ks.add(&JwtKey{Method: jwt.SigningMethodHS256, PrivateKey: secret, PublicKey: secret})
+	ks.signingKey = ks.keys[""]
+
+	return ks, nil
+}
+
+// LoadJwtKeySet builds a key set from PEM files. Tokens are signed with the
+// RSA or Ed25519 private key in privateKeyPath, while the public keys in
+// publicKeyPaths keep tokens issued by previous keys valid. When hmacSecret is
+// given, tokens signed before moving to asymmetric keys are accepted too.
+func LoadJwtKeySet(privateKeyPath string, publicKeyPaths []string, hmacSecret []byte) (*JwtKeySet, error) {
+	if len(privateKeyPath) == 0 {
+		return NewHmacJwtKeySet(hmacSecret)
+	}
+
+	ks := &JwtKeySet{keys: map[string]*JwtKey{}}
+
+	pemBytes, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, err := parsePrivateJwtKey(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", privateKeyPath, err)
+	}
+	ks.add(signingKey)
+	ks.signingKey = signingKey
+
+	for _, path := range publicKeyPaths {
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		k, err := parsePublicJwtKey(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		ks.add(k)
+	}
+
+	if len(hmacSecret) > 0 {
+		ks.add(&JwtKey{Method: jwt.SigningMethodHS256, PublicKey: hmacSecret})
+	}
+
+	return ks, nil
+}
+
+// NewJwtKeySet builds a key set from RSA or Ed25519 keys already in memory.
+func NewJwtKeySet(signingKey crypto.Signer, verificationKeys ...crypto.PublicKey) (*JwtKeySet, error) {
+	k, err := newJwtKey(signingKey, signingKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &JwtKeySet{keys: map[string]*JwtKey{}}
+	ks.add(k)
+	ks.signingKey = k
+
+	for _, publicKey := range verificationKeys {
+		k, err := newJwtKey(nil, publicKey)
+		if err != nil {
+			return nil, err
+		}
+		ks.add(k)
+	}
+
+	return ks, nil
+}
+
+// Sign issues a token for claims with the active signing key, identifying the
+// key through the "kid" header.
+func (ks *JwtKeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks == nil || ks.signingKey == nil {
+		return "", ErrJwtKeySetEmpty
+	}
+
+	token := jwt.NewWithClaims(ks.signingKey.Method, claims)
+	if len(ks.signingKey.ID) > 0 {
+		token.Header["kid"] = ks.signingKey.ID
+	}
+
+	return token.SignedString(ks.signingKey.PrivateKey)
+}
+
+// Keyfunc picks the verification key named by the token "kid" header. The
+// signing method must match the key, so a public key can never be used as an
+// HMAC secret.
+func (ks *JwtKeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if ks == nil {
+		return nil, ErrJwtKeySetEmpty
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrJwtKeyUnknown
+	}
+	if token.Method.Alg() != k.Method.Alg() {
+		return nil, ErrJwtKeyUnexpected
+	}
+
+	return k.PublicKey, nil
+}
+
+// JWKS lists the public keys of the set as a JSON Web Key Set. Shared secrets
+// are left out.
+func (ks *JwtKeySet) JWKS() *dto.JwksOutputDTO {
+	output := &dto.JwksOutputDTO{Keys: []dto.JwkDTO{}}
+	if ks == nil {
+		return output
+	}
+
+	// The signing key goes first, followed by the keys kept for rotation.
+	rotated := []*JwtKey{}
+	for _, k := range ks.keys {
+		if k.ID != ks.signingKey.ID {
+			rotated = append(rotated, k)
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool { return rotated[i].ID < rotated[j].ID })
+	keys := append([]*JwtKey{ks.signingKey}, rotated...)
+
+	for _, k := range keys {
+		jwk, ok := publicJwk(k)
+		if ok {
+			output.Keys = append(output.Keys, *jwk)
+		}
+	}
+
+	return output
+}
+
+// add keeps the first key added under a kid, so listing the public half of
+// the signing key for verification too neither replaces it nor publishes it
+// twice.
+func (ks *JwtKeySet) add(k *JwtKey) {
+	if _, ok := ks.keys[k.ID]; ok {
+		return
+	}
+	ks.keys[k.ID] = k
+}
+
+func parsePrivateJwtKey(pemBytes []byte) (*JwtKey, error) {
+	if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+		return newJwtKey(rsaKey, &rsaKey.PublicKey)
+	}
+
+	edKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, errors.New("expected an RSA or Ed25519 private key")
+	}
+
+	signer, ok := edKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("expected an RSA or Ed25519 private key")
+	}
+
+	return newJwtKey(signer, signer.Public())
+}
+
+func parsePublicJwtKey(pemBytes []byte) (*JwtKey, error) {
+	if rsaKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes); err == nil {
+		return newJwtKey(nil, rsaKey)
+	}
+
+	edKey, err := jwt.ParseEdPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, errors.New("expected an RSA or Ed25519 public key")
+	}
+
+	return newJwtKey(nil, edKey)
+}
+
+// newJwtKey identifies a key by its RFC 7638 thumbprint, so the same key
+// always gets the same "kid" without any extra configuration.
+func newJwtKey(privateKey interface{}, publicKey crypto.PublicKey) (*JwtKey, error) {
+	k := &JwtKey{PrivateKey: privateKey, PublicKey: publicKey}
+
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		k.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		k.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("unsupported jwt key type")
+	}
+
+	jwk, _ := publicJwk(k)
+
+	// Only the required members take part, encoding/json sorts them as the
+	// RFC expects.
+	members := map[string]string{"kty": jwk.Kty}
+	switch jwk.Kty {
+	case "RSA":
+		members["n"] = jwk.N
+		members["e"] = jwk.E
+	case "OKP":
+		members["crv"] = jwk.Crv
+		members["x"] = jwk.X
+	}
+
+	thumbprintInput, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(thumbprintInput)
+	k.ID = base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return k, nil
+}
+
+func publicJwk(k *JwtKey) (*dto.JwkDTO, bool) {
+	switch publicKey := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return &dto.JwkDTO{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			Kid: k.ID,
+			N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return &dto.JwkDTO{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: k.Method.Alg(),
+			Kid: k.ID,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(publicKey),
+		}, true
+	default:
+		return nil, false
+	}
+}
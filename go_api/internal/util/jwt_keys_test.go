@@ -0,0 +1,271 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePemFile(t *testing.T, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestJwtKeySet(t *testing.T) {
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, oldEdKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &domain.User{ID: 1, Name: "User1", Email: "user1@example.com", Role: domain.RoleUser}
+
+	rsaKeySet, err := NewJwtKeySet(rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKeySet, err := NewJwtKeySet(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKeySet, err := NewJwtKeySet(oldEdKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotatedKeySet, err := NewJwtKeySet(edKey, oldEdKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken, err := NewAccessToken(user, oldKeySet, 1, "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A token signed with HS256 using the RSA public key as the shared secret.
+	rsaKid := rsaKeySet.JWKS().Keys[0].Kid
+	confusedToken := jwt.NewWithClaims(jwt.SigningMethodHS256, domain.UserClaims{
+		ID: user.ID,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+	})
+	confusedToken.Header["kid"] = rsaKid
+	confusedTokenString, err := confusedToken.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("RS256 round trip", func(t *testing.T) {
+		token, err := NewAccessToken(user, rsaKeySet, 1, "token-id")
+		assert.NoError(t, err, "Did not expect an error but got one")
+
+		parsed, _ := jwt.Parse(token, rsaKeySet.Keyfunc)
+		assert.Equal(t, "RS256", parsed.Header["alg"], "Expected alg to match.")
+		assert.Equal(t, rsaKid, parsed.Header["kid"], "Expected kid to match.")
+
+		u, err := RecoverUserFromToken(token, rsaKeySet)
+		assert.NoError(t, err, "Did not expect an error but got one")
+		assert.Equal(t, user, u, "Expected user to match.")
+	})
+
+	t.Run("EdDSA round trip", func(t *testing.T) {
+		token, err := NewAccessToken(user, edKeySet, 1, "token-id")
+		assert.NoError(t, err, "Did not expect an error but got one")
+
+		u, err := RecoverUserFromToken(token, edKeySet)
+		assert.NoError(t, err, "Did not expect an error but got one")
+		assert.Equal(t, user, u, "Expected user to match.")
+	})
+
+	t.Run("Rotated key still verifies", func(t *testing.T) {
+		u, err := RecoverUserFromToken(oldToken, rotatedKeySet)
+		assert.NoError(t, err, "Did not expect an error but got one")
+		assert.Equal(t, user, u, "Expected user to match.")
+	})
+
+	t.Run("Retired key rejected", func(t *testing.T) {
+		u, err := RecoverUserFromToken(oldToken, edKeySet)
+		assert.Error(t, err, "Expect Error")
+		assert.Nil(t, u, "Expect user to be nil")
+	})
+
+	t.Run("Algorithm confusion rejected", func(t *testing.T) {
+		u, err := RecoverUserFromToken(confusedTokenString, rsaKeySet)
+		assert.Error(t, err, "Expect Error")
+		assert.Nil(t, u, "Expect user to be nil")
+	})
+
+	t.Run("JWKS", func(t *testing.T) {
+		jwks := rotatedKeySet.JWKS()
+		assert.Len(t, jwks.Keys, 2, "Expected both keys to be published.")
+		assert.Equal(t, edKeySet.JWKS().Keys[0], jwks.Keys[0], "Expected signing key to come first.")
+		assert.Equal(t, oldKeySet.JWKS().Keys[0], jwks.Keys[1], "Expected rotated key to match.")
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty, "Expected key type to match.")
+		assert.Equal(t, "Ed25519", jwks.Keys[0].Crv, "Expected curve to match.")
+
+		rsaJwk := rsaKeySet.JWKS().Keys[0]
+		assert.Equal(t, "RSA", rsaJwk.Kty, "Expected key type to match.")
+		assert.Equal(t, "AQAB", rsaJwk.E, "Expected exponent to match.")
+
+		duplicateKeySet, err := NewJwtKeySet(edKey, edKey.Public(), oldEdKey.Public())
+		assert.NoError(t, err, "Did not expect an error but got one")
+		assert.Equal(t, jwks, duplicateKeySet.JWKS(), "Expected the signing key to be published once.")
+
+		hmacKeySet, _ := NewHmacJwtKeySet([]byte("TestSigningKey"))
+		assert.Empty(t, hmacKeySet.JWKS().Keys, "Expected shared secrets not to be published.")
+	})
+}
+
+func TestJwkThumbprint(t *testing.T) {
+
+	// RFC 8037, appendix A.3.
+	publicKey := ed25519.PublicKey{
+		0xd7, 0x5a, 0x98, 0x01, 0x82, 0xb1, 0x0a, 0xb7, 0xd5, 0x4b, 0xfe, 0xd3, 0xc9, 0x64, 0x07, 0x3a,
+		0x0e, 0xe1, 0x72, 0xf3, 0xda, 0xa6, 0x23, 0x25, 0xaf, 0x02, 0x1a, 0x68, 0xf7, 0x07, 0x51, 0x1a,
+	}
+
+	k, err := newJwtKey(nil, publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", k.ID, "Expected kid to match.")
+}
+
+func TestLoadJwtKeySet(t *testing.T) {
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublicKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaPrivateKeyPath := writePemFile(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	edPrivateKeyDer, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPrivateKeyPath := writePemFile(t, "PRIVATE KEY", edPrivateKeyDer)
+
+	edPublicKeyDer, err := x509.MarshalPKIXPublicKey(edPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublicKeyPath := writePemFile(t, "PUBLIC KEY", edPublicKeyDer)
+
+	testCases := []struct {
+		name           string
+		privateKeyPath string
+		publicKeyPaths []string
+		hmacSecret     []byte
+		expectedJwks   int
+		expectedAlg    string
+		acceptsLegacy  bool
+		expectError    bool
+	}{
+		{
+			name:           "RSA private key",
+			privateKeyPath: rsaPrivateKeyPath,
+			expectedJwks:   1,
+			expectedAlg:    "RS256",
+		},
+		{
+			name:           "Ed25519 private key",
+			privateKeyPath: edPrivateKeyPath,
+			expectedJwks:   1,
+			expectedAlg:    "EdDSA",
+		},
+		{
+			name:           "Rotation with legacy secret",
+			privateKeyPath: rsaPrivateKeyPath,
+			publicKeyPaths: []string{edPublicKeyPath},
+			hmacSecret:     []byte("TestSigningKey"),
+			expectedJwks:   2,
+			expectedAlg:    "RS256",
+			acceptsLegacy:  true,
+		},
+		{
+			name:           "Signing key also listed as public key",
+			privateKeyPath: edPrivateKeyPath,
+			publicKeyPaths: []string{edPublicKeyPath},
+			expectedJwks:   1,
+			expectedAlg:    "EdDSA",
+		},
+		{
+			name:         "Shared secret only",
+			hmacSecret:   []byte("TestSigningKey"),
+			expectedJwks: 0,
+			expectedAlg:  "HS256",
+		},
+		{
+			name:        "Nothing configured",
+			expectError: true,
+		},
+		{
+			name:           "Missing file",
+			privateKeyPath: filepath.Join(t.TempDir(), "missing.pem"),
+			expectError:    true,
+		},
+		{
+			name:           "Public key as private key",
+			privateKeyPath: edPublicKeyPath,
+			expectError:    true,
+		},
+	}
+
+	legacyKeySet, _ := NewHmacJwtKeySet([]byte("TestSigningKey"))
+	legacyToken, err := NewAccessToken(&domain.User{ID: 1}, legacyKeySet, 1, "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, err := LoadJwtKeySet(tc.privateKeyPath, tc.publicKeyPaths, tc.hmacSecret)
+			if tc.expectError {
+				assert.Error(t, err, "Expect Error")
+				assert.Nil(t, ks, "Expect key set to be nil")
+				return
+			}
+
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.Len(t, ks.JWKS().Keys, tc.expectedJwks, "Expected JWKS size to match.")
+
+			token, err := NewAccessToken(&domain.User{ID: 1}, ks, 1, "token-id")
+			assert.NoError(t, err, "Did not expect an error but got one")
+			parsed, err := jwt.Parse(token, ks.Keyfunc)
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.Equal(t, tc.expectedAlg, parsed.Header["alg"], "Expected alg to match.")
+
+			_, err = RecoverUserFromToken(legacyToken, ks)
+			assert.Equal(t, tc.acceptsLegacy || tc.privateKeyPath == "", err == nil, "Expected legacy token acceptance to match.")
+		})
+	}
+}
@@ -2,33 +2,38 @@ package util
 
 import (
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/Daffc/GO-Sales/domain"
 	"github.com/golang-jwt/jwt"
 )
 
-func NewAccessToken(user *domain.User, jwtSigningKey []byte, JwtSessionDuration uint) (string, error) {
+// NewAccessToken issues the access token of a session, identified by tokenId
+// through the "jti" claim.
+func NewAccessToken(user *domain.User, jwtKeySet *JwtKeySet, JwtSessionDuration uint, tokenId string) (string, error) {
 
 	if user == nil {
 		return "", errors.New("user cannot be nil")
 	}
-	if len(jwtSigningKey) == 0 {
-		return "", errors.New("jwtSigningKey cannot be empty")
+	if jwtKeySet == nil {
+		return "", errors.New("jwtKeySet cannot be nil")
 	}
 
 	claims := domain.UserClaims{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:             user.ID,
+		OrganizationID: user.OrganizationID,
+		Name:           user.Name,
+		Email:          user.Email,
+		Role:           user.Role,
 		StandardClaims: jwt.StandardClaims{
+			Id:        tokenId,
 			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: time.Now().Add(time.Hour * time.Duration(JwtSessionDuration)).Unix(),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token, err := accessToken.SignedString(jwtSigningKey)
+	token, err := jwtKeySet.Sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -36,13 +41,50 @@ func NewAccessToken(user *domain.User, jwtSigningKey []byte, JwtSessionDuration
 	return token, nil
 }
 
-func RecoverUserFromToken(t string, jwtSigningKey []byte) (*domain.User, error) {
-	token, err := jwt.ParseWithClaims(
-		t,
-		&domain.UserClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			return jwtSigningKey, nil
-		})
+// NewImpersonationToken issues a short-lived access token that lets actor act
+// as user. Both identities are carried by the token.
+func NewImpersonationToken(user *domain.User, actor *domain.User, jwtKeySet *JwtKeySet, duration time.Duration, tokenId string) (string, error) {
+
+	if user == nil || actor == nil {
+		return "", errors.New("user and actor cannot be nil")
+	}
+	if jwtKeySet == nil {
+		return "", errors.New("jwtKeySet cannot be nil")
+	}
+
+	claims := domain.UserClaims{
+		ID:             user.ID,
+		OrganizationID: user.OrganizationID,
+		Name:           user.Name,
+		Email:          user.Email,
+		Role:           user.Role,
+		Actor: &domain.ActorClaims{
+			ID:    actor.ID,
+			Email: actor.Email,
+		},
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenId,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(duration).Unix(),
+		},
+	}
+
+	return jwtKeySet.Sign(claims)
+}
+
+func RecoverUserFromToken(t string, jwtKeySet *JwtKeySet) (*domain.User, error) {
+	userClaims, err := RecoverClaimsFromToken(t, jwtKeySet)
+	if err != nil {
+		return nil, err
+	}
+
+	return userClaims.User(), nil
+}
+
+// RecoverClaimsFromToken verifies an access token and returns its claims,
+// including the "jti" linking it to a session.
+func RecoverClaimsFromToken(t string, jwtKeySet *JwtKeySet) (*domain.UserClaims, error) {
+	token, err := jwt.ParseWithClaims(t, &domain.UserClaims{}, jwtKeySet.Keyfunc)
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +98,89 @@ func RecoverUserFromToken(t string, jwtSigningKey []byte) (*domain.User, error)
 		return nil, errors.New("invalid token claims: missing required fields")
 	}
 
-	user := &domain.User{
-		ID:    userClaims.ID,
-		Name:  userClaims.Name,
-		Email: userClaims.Email,
+	return userClaims, nil
+}
+
+const mfaChallengeAudience = "mfa-challenge"
+
+// NewMfaChallengeToken issues the short-lived token exchanged for an access
+// token once the second factor is verified. It carries no UserClaims so it is
+// refused wherever an access token is expected.
+func NewMfaChallengeToken(userId uint, jwtKeySet *JwtKeySet, duration time.Duration) (string, error) {
+	if userId == 0 {
+		return "", errors.New("userId cannot be zero")
+	}
+	if jwtKeySet == nil {
+		return "", errors.New("jwtKeySet cannot be nil")
+	}
+
+	claims := jwt.StandardClaims{
+		Subject:   strconv.FormatUint(uint64(userId), 10),
+		Audience:  mfaChallengeAudience,
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(duration).Unix(),
+	}
+
+	return jwtKeySet.Sign(claims)
+}
+
+func RecoverUserIdFromMfaChallengeToken(t string, jwtKeySet *JwtKeySet) (uint, error) {
+	token, err := jwt.ParseWithClaims(t, &jwt.StandardClaims{}, jwtKeySet.Keyfunc)
+	if err != nil {
+		return 0, err
+	}
+
+	claims, ok := token.Claims.(*jwt.StandardClaims)
+	if !ok || !token.Valid || !claims.VerifyAudience(mfaChallengeAudience, true) {
+		return 0, errors.New("invalid token claims")
+	}
+
+	userId, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil || userId == 0 {
+		return 0, errors.New("invalid token claims: missing required fields")
+	}
+
+	return uint(userId), nil
+}
+
+const invitationAudience = "invitation"
+
+// NewInvitationToken issues the signed token of an invitation link. Like the
+// MFA challenge token it carries no UserClaims, so it is refused wherever an
+// access token is expected.
+func NewInvitationToken(invitationId uint, jwtKeySet *JwtKeySet, duration time.Duration) (string, error) {
+	if invitationId == 0 {
+		return "", errors.New("invitationId cannot be zero")
+	}
+	if jwtKeySet == nil {
+		return "", errors.New("jwtKeySet cannot be nil")
+	}
+
+	claims := jwt.StandardClaims{
+		Subject:   strconv.FormatUint(uint64(invitationId), 10),
+		Audience:  invitationAudience,
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(duration).Unix(),
+	}
+
+	return jwtKeySet.Sign(claims)
+}
+
+func RecoverInvitationIdFromToken(t string, jwtKeySet *JwtKeySet) (uint, error) {
+	token, err := jwt.ParseWithClaims(t, &jwt.StandardClaims{}, jwtKeySet.Keyfunc)
+	if err != nil {
+		return 0, err
+	}
+
+	claims, ok := token.Claims.(*jwt.StandardClaims)
+	if !ok || !token.Valid || !claims.VerifyAudience(invitationAudience, true) {
+		return 0, errors.New("invalid token claims")
+	}
+
+	invitationId, err := strconv.ParseUint(claims.Subject, 10, 32)
+	if err != nil || invitationId == 0 {
+		return 0, errors.New("invalid token claims: missing required fields")
 	}
 
-	return user, nil
+	return uint(invitationId), nil
 }
@@ -24,6 +24,7 @@ func TestNewAccessToken(t *testing.T) {
 				ID:    1,
 				Name:  "User1",
 				Email: "user1@example.com",
+				Role:  domain.RoleAdmin,
 			},
 			jwtSigningKey:      []byte("TestSigningKey"),
 			jwtSessionDuration: uint(1),
@@ -63,7 +64,9 @@ func TestNewAccessToken(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 
-			token, err := NewAccessToken(tc.user, tc.jwtSigningKey, tc.jwtSessionDuration)
+			jwtKeySet, _ := NewHmacJwtKeySet(tc.jwtSigningKey)
+
+			token, err := NewAccessToken(tc.user, jwtKeySet, tc.jwtSessionDuration, "token-id")
 			if tc.expectError {
 				assert.Error(t, err, "Expected error")
 				assert.Empty(t, token, "Expected token to be empty")
@@ -88,6 +91,8 @@ func TestNewAccessToken(t *testing.T) {
 				assert.Equal(t, tc.user.ID, claims.ID, "Expected user ID to match.")
 				assert.Equal(t, tc.user.Name, claims.Name, "Expected user Name to match.")
 				assert.Equal(t, tc.user.Email, claims.Email, "Expected user Email to match.")
+				assert.Equal(t, tc.user.Role, claims.Role, "Expected user Role to match.")
+				assert.Equal(t, "token-id", claims.Id, "Expected token ID to match.")
 				assert.Equal(t, uint(time.Duration(int64(time.Second)*(claims.ExpiresAt-claims.IssuedAt)).Hours()), tc.jwtSessionDuration, "Expected token duration to match")
 			}
 		})
@@ -101,12 +106,14 @@ func TestRecoverUserFromToken(t *testing.T) {
 		ID:    1,
 		Name:  "User1",
 		Email: "user1@example.com",
+		Role:  domain.RoleUser,
 	}
 
 	claims := domain.UserClaims{
 		ID:    validUser.ID,
 		Name:  validUser.Name,
 		Email: validUser.Email,
+		Role:  validUser.Role,
 		StandardClaims: jwt.StandardClaims{
 			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: time.Now().Add(time.Hour * time.Duration(1)).Unix(),
@@ -189,7 +196,9 @@ func TestRecoverUserFromToken(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			u, err := RecoverUserFromToken(tc.token, tc.jwtSigningKey)
+			jwtKeySet, _ := NewHmacJwtKeySet(tc.jwtSigningKey)
+
+			u, err := RecoverUserFromToken(tc.token, jwtKeySet)
 			if tc.expectError {
 				assert.Error(t, err, "Expect Error")
 				assert.Nil(t, u, "Expect user to be nil")
@@ -199,3 +208,168 @@ func TestRecoverUserFromToken(t *testing.T) {
 		})
 	}
 }
+
+func TestMfaChallengeToken(t *testing.T) {
+
+	jwtSigningKey := []byte("TestSigningKey")
+	jwtKeySet, err := NewHmacJwtKeySet(jwtSigningKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validChallenge, err := NewMfaChallengeToken(1, jwtKeySet, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiredChallenge, err := NewMfaChallengeToken(1, jwtKeySet, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accessToken, err := NewAccessToken(&domain.User{ID: 1, Name: "User1", Email: "user1@example.com"}, jwtKeySet, 1, "token-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		token          string
+		jwtSigningKey  []byte
+		expectedUserId uint
+		expectError    bool
+	}{
+		{
+			name:           "Success",
+			token:          validChallenge,
+			jwtSigningKey:  jwtSigningKey,
+			expectedUserId: 1,
+			expectError:    false,
+		},
+		{
+			name:          "Expired challenge",
+			token:         expiredChallenge,
+			jwtSigningKey: jwtSigningKey,
+			expectError:   true,
+		},
+		{
+			name:          "Wrong signing key",
+			token:         validChallenge,
+			jwtSigningKey: []byte("OtherSigningKey"),
+			expectError:   true,
+		},
+		{
+			name:          "Access token used as challenge",
+			token:         accessToken,
+			jwtSigningKey: jwtSigningKey,
+			expectError:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenKeySet, _ := NewHmacJwtKeySet(tc.jwtSigningKey)
+
+			userId, err := RecoverUserIdFromMfaChallengeToken(tc.token, tokenKeySet)
+			if tc.expectError {
+				assert.Error(t, err, "Expect Error")
+				assert.Zero(t, userId, "Expect user id to be zero")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, tc.expectedUserId, userId, "Expected user id to match")
+			}
+		})
+	}
+
+	t.Run("Challenge used as access token", func(t *testing.T) {
+		u, err := RecoverUserFromToken(validChallenge, jwtKeySet)
+		assert.Error(t, err, "Expect Error")
+		assert.Nil(t, u, "Expect user to be nil")
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		_, err := NewMfaChallengeToken(0, jwtKeySet, time.Minute)
+		assert.Error(t, err, "Expect Error")
+		_, err = NewMfaChallengeToken(1, nil, time.Minute)
+		assert.Error(t, err, "Expect Error")
+	})
+}
+
+func TestInvitationToken(t *testing.T) {
+
+	jwtSigningKey := []byte("TestSigningKey")
+	jwtKeySet, err := NewHmacJwtKeySet(jwtSigningKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validInvitation, err := NewInvitationToken(7, jwtKeySet, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiredInvitation, err := NewInvitationToken(7, jwtKeySet, -time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mfaChallenge, err := NewMfaChallengeToken(7, jwtKeySet, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name                 string
+		token                string
+		jwtSigningKey        []byte
+		expectedInvitationId uint
+		expectError          bool
+	}{
+		{
+			name:                 "Success",
+			token:                validInvitation,
+			jwtSigningKey:        jwtSigningKey,
+			expectedInvitationId: 7,
+			expectError:          false,
+		},
+		{
+			name:          "Expired invitation",
+			token:         expiredInvitation,
+			jwtSigningKey: jwtSigningKey,
+			expectError:   true,
+		},
+		{
+			name:          "Wrong signing key",
+			token:         validInvitation,
+			jwtSigningKey: []byte("OtherSigningKey"),
+			expectError:   true,
+		},
+		{
+			name:          "MFA challenge used as invitation",
+			token:         mfaChallenge,
+			jwtSigningKey: jwtSigningKey,
+			expectError:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenKeySet, _ := NewHmacJwtKeySet(tc.jwtSigningKey)
+
+			invitationId, err := RecoverInvitationIdFromToken(tc.token, tokenKeySet)
+			if tc.expectError {
+				assert.Error(t, err, "Expect Error")
+				assert.Zero(t, invitationId, "Expect invitation id to be zero")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, tc.expectedInvitationId, invitationId, "Expected invitation id to match")
+			}
+		})
+	}
+
+	t.Run("Invitation used as access token", func(t *testing.T) {
+		u, err := RecoverUserFromToken(validInvitation, jwtKeySet)
+		assert.Error(t, err, "Expect Error")
+		assert.Nil(t, u, "Expect user to be nil")
+	})
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// LocalizedResponseWriter is a response writer whose JSON responses have their
+// timestamps rendered in Location.
+type LocalizedResponseWriter struct {
+	http.ResponseWriter
+	Location *time.Location
+}
+
+func NewLocalizedResponseWriter(w http.ResponseWriter, loc *time.Location) *LocalizedResponseWriter {
+	return &LocalizedResponseWriter{ResponseWriter: w, Location: loc}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// LocalizeTimes moves every non zero time.Time reachable from v through
+// pointers, slices and exported struct fields to loc. The instants are left
+// untouched, only the offset they are rendered with changes. Values that are
+// not addressable, such as v itself when passed by value, are skipped.
+func LocalizeTimes(v interface{}, loc *time.Location) {
+	localizeTimes(reflect.ValueOf(v), loc)
+}
+
+func localizeTimes(v reflect.Value, loc *time.Location) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		// The pointer may be shared with the value it was copied from, so it
+		// is replaced rather than written through.
+		if v.Type().Elem() == timeType && v.CanSet() {
+			t := v.Elem().Interface().(time.Time)
+			if !t.IsZero() {
+				t = t.In(loc)
+				v.Set(reflect.ValueOf(&t))
+			}
+			return
+		}
+		localizeTimes(v.Elem(), loc)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			t := v.Interface().(time.Time)
+			if v.CanSet() && !t.IsZero() {
+				v.Set(reflect.ValueOf(t.In(loc)))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				localizeTimes(v.Field(i), loc)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			localizeTimes(v.Index(i), loc)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalizeTimes(t *testing.T) {
+
+	type Inner struct {
+		At time.Time
+	}
+	type Outer struct {
+		Inner
+		CreatedAt time.Time
+		ExpiresAt *time.Time
+		Missing   *time.Time
+		Zero      time.Time
+		Items     []*Inner
+		hidden    time.Time
+	}
+
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := at
+	v := &Outer{
+		Inner:     Inner{At: at},
+		CreatedAt: at,
+		ExpiresAt: &expiresAt,
+		Items:     []*Inner{{At: at}},
+		hidden:    at,
+	}
+
+	LocalizeTimes(v, loc)
+
+	for _, got := range []time.Time{v.At, v.CreatedAt, *v.ExpiresAt, v.Items[0].At} {
+		assert.Equal(t, loc, got.Location(), "Expected the time to be moved to the location")
+		assert.True(t, got.Equal(at), "Expected the instant to be kept")
+	}
+	assert.Equal(t, time.UTC, expiresAt.Location(), "Expected pointed times to be replaced rather than written through")
+	assert.Nil(t, v.Missing, "Expected nil times to be left alone")
+	assert.True(t, v.Zero.IsZero(), "Expected zero times to be left alone")
+	assert.Equal(t, time.UTC, v.hidden.Location(), "Expected unexported fields to be left alone")
+}
+
+func TestJSONResponseLocalized(t *testing.T) {
+
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	rr := httptest.NewRecorder()
+
+	JSONResponse(NewLocalizedResponseWriter(rr, loc), []*struct {
+		At time.Time `json:"at"`
+	}{{At: at}}, 200)
+
+	assert.Equal(t, "[{\"at\":\"2024-01-01T09:00:00-03:00\"}]\n", rr.Body.String(), "Expected the time rendered in the location")
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"), "Expected the header to reach the wrapped writer")
+}
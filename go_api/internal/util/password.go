@@ -0,0 +1,197 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+var (
+	ErrPasswordHashAlgorithm = errors.New("unknown password hash algorithm")
+	ErrPasswordHashFormat    = errors.New("invalid password hash format")
+	ErrPasswordHashParams    = errors.New("invalid password hash parameters")
+)
+
+// PasswordHasher hashes passwords with its configured algorithm and
+// parameters, while still verifying hashes produced by the other supported
+// ones so stored passwords can be upgraded when their owner logs in.
+//
+// Hashes are stored in PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>. bcrypt hashes keep their
+// modular crypt format ($2a$10$...), which follows the same layout.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches encoded, whichever supported
+	// algorithm produced it.
+	Verify(password string, encoded string) (bool, error)
+	// NeedsRehash reports whether encoded was produced by another algorithm
+	// or with other parameters than the current ones.
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idParams are the argon2id cost parameters, Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewPasswordHasher returns the hasher for algorithm, either bcrypt or
+// argon2id.
+func NewPasswordHasher(algorithm string, bcryptCost int, argon2idParams Argon2idParams) (PasswordHasher, error) {
+	switch algorithm {
+	case PasswordHashBcrypt:
+		return NewBcryptHasher(bcryptCost)
+	case PasswordHashArgon2id:
+		return NewArgon2idHasher(argon2idParams)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrPasswordHashAlgorithm, algorithm)
+	}
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) (PasswordHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, ErrPasswordHashParams
+	}
+	return &bcryptHasher{cost: cost}, nil
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Verify(password string, encoded string) (bool, error) {
+	return verifyPassword(password, encoded)
+}
+
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	if !isBcryptHash(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cost
+}
+
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) (PasswordHasher, error) {
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 || params.SaltLength < 8 || params.KeyLength < 16 {
+		return nil, ErrPasswordHashParams
+	}
+	return &argon2idHasher{params: params}, nil
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(password string, encoded string) (bool, error) {
+	return verifyPassword(password, encoded)
+}
+
+func (h *argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2idHash(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.params.Memory ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		uint32(len(salt)) != h.params.SaltLength ||
+		uint32(len(key)) != h.params.KeyLength
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// verifyPassword checks password against a hash of any supported algorithm.
+func verifyPassword(password string, encoded string) (bool, error) {
+	switch {
+	case isBcryptHash(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, salt, key, err := decodeArgon2idHash(encoded)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	default:
+		return false, ErrPasswordHashAlgorithm
+	}
+}
+
+func decodeArgon2idHash(encoded string) (*Argon2idParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	params := &Argon2idParams{}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,146 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasher(t *testing.T) {
+
+	bcryptHasher, err := NewBcryptHasher(bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2idHasher, err := NewArgon2idHasher(testArgon2idParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		hasher         PasswordHasher
+		expectedPrefix string
+	}{
+		{
+			name:           "bcrypt",
+			hasher:         bcryptHasher,
+			expectedPrefix: "$2a$04$",
+		},
+		{
+			name:           "argon2id",
+			hasher:         argon2idHasher,
+			expectedPrefix: "$argon2id$v=19$m=1024,t=1,p=1$",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := tc.hasher.Hash("Password@1")
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.True(t, strings.HasPrefix(hash, tc.expectedPrefix), "Expected hash to be PHC encoded, got %q", hash)
+
+			other, err := tc.hasher.Hash("Password@1")
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.NotEqual(t, hash, other, "Expected hashes to be salted")
+
+			ok, err := tc.hasher.Verify("Password@1", hash)
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.True(t, ok, "Expected password to match")
+
+			ok, err = tc.hasher.Verify("Password@2", hash)
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.False(t, ok, "Expected password not to match")
+
+			assert.False(t, tc.hasher.NeedsRehash(hash), "Expected a current hash not to need a rehash")
+		})
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("Password@1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakerArgon2idHasher, err := NewArgon2idHasher(Argon2idParams{Memory: 512, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakerArgon2idHash, err := weakerArgon2idHasher.Hash("Password@1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	argon2idHasher, err := NewArgon2idHasher(testArgon2idParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strongerBcryptHasher, err := NewBcryptHasher(bcrypt.MinCost + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		hasher   PasswordHasher
+		encoded  string
+		expected bool
+	}{
+		{name: "bcrypt to argon2id", hasher: argon2idHasher, encoded: string(bcryptHash), expected: true},
+		{name: "argon2id parameters changed", hasher: argon2idHasher, encoded: weakerArgon2idHash, expected: true},
+		{name: "argon2id to bcrypt", hasher: strongerBcryptHasher, encoded: weakerArgon2idHash, expected: true},
+		{name: "bcrypt cost changed", hasher: strongerBcryptHasher, encoded: string(bcryptHash), expected: true},
+		{name: "Unknown format", hasher: argon2idHasher, encoded: "plaintext", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.hasher.NeedsRehash(tc.encoded), "Expected NeedsRehash to match")
+
+			// Hashes from any supported algorithm keep verifying.
+			ok, err := tc.hasher.Verify("Password@1", tc.encoded)
+			if tc.encoded == "plaintext" {
+				assert.ErrorIs(t, err, ErrPasswordHashAlgorithm, "Expected unknown algorithm error")
+				assert.False(t, ok, "Expected password not to match")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.True(t, ok, "Expected password to match")
+			}
+		})
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+
+	testCases := []struct {
+		name        string
+		algorithm   string
+		bcryptCost  int
+		params      Argon2idParams
+		expectError bool
+	}{
+		{name: "bcrypt", algorithm: PasswordHashBcrypt, bcryptCost: bcrypt.DefaultCost},
+		{name: "argon2id", algorithm: PasswordHashArgon2id, params: testArgon2idParams},
+		{name: "Unknown algorithm", algorithm: "md5", expectError: true},
+		{name: "bcrypt cost too low", algorithm: PasswordHashBcrypt, bcryptCost: 1, expectError: true},
+		{name: "argon2id without memory", algorithm: PasswordHashArgon2id, params: Argon2idParams{Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := NewPasswordHasher(tc.algorithm, tc.bcryptCost, tc.params)
+			if tc.expectError {
+				assert.Error(t, err, "Expected error")
+				assert.Nil(t, h, "Expected hasher to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.NotNil(t, h, "Expected hasher not to be nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+)
+
+// ProblemContentType is the media type of problem details, as of RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+var problemStatuses = map[domain.ErrorKind]int{
+	domain.ErrorKindValidation:      http.StatusBadRequest,
+	domain.ErrorKindUnauthorized:    http.StatusUnauthorized,
+	domain.ErrorKindForbidden:       http.StatusForbidden,
+	domain.ErrorKindNotFound:        http.StatusNotFound,
+	domain.ErrorKindConflict:        http.StatusConflict,
+	domain.ErrorKindTooLarge:        http.StatusRequestEntityTooLarge,
+	domain.ErrorKindLocked:          http.StatusLocked,
+	domain.ErrorKindTooManyRequests: http.StatusTooManyRequests,
+	domain.ErrorKindUnavailable:     http.StatusServiceUnavailable,
+	domain.ErrorKindInternal:        http.StatusInternalServerError,
+}
+
+// NewProblem describes err as a failure of r. The status follows the kind of
+// the domain error err is or wraps, any other error is internal and its
+// message is left out, as it could expose internals.
+func NewProblem(r *http.Request, err error) *dto.ProblemOutputDTO {
+	p := &dto.ProblemOutputDTO{Type: "about:blank", Instance: r.URL.Path}
+	p.RequestId, _ = RequestIdFromContext(r.Context())
+
+	// Checked first, as they also wrap every domain error they list.
+	var validationErr *domain.ValidationError
+	var policyErr *domain.PasswordPolicyError
+	switch {
+	case errors.As(err, &validationErr):
+		p.Status = http.StatusUnprocessableEntity
+		p.Code = "validation_failed"
+		p.Detail = "the request has invalid fields"
+		for _, v := range validationErr.Violations {
+			p.Violations = append(p.Violations, dto.ProblemViolationOutputDTO{Field: v.Field, Code: domain.ErrorOf(v.Err).Code, Detail: v.Err.Error()})
+		}
+	case errors.As(err, &policyErr):
+		p.Status = http.StatusBadRequest
+		p.Code = "password_policy"
+		p.Detail = "password does not meet the policy"
+		for _, v := range policyErr.Violations {
+			p.Violations = append(p.Violations, dto.ProblemViolationOutputDTO{Code: domain.ErrorOf(v).Code, Detail: v.Error()})
+		}
+	default:
+		// Statements aborted by the deadline of the request.
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = domain.ErrRequestTimeout
+		}
+
+		domainErr := domain.ErrorOf(err)
+		p.Status = problemStatuses[domainErr.Kind]
+		p.Code = domainErr.Code
+		p.Detail = domainErr.Message
+		// Wrapping errors add details, such as the field at fault.
+		if domainErr.Kind != domain.ErrorKindInternal {
+			p.Detail = err.Error()
+		}
+	}
+
+	p.Title = http.StatusText(p.Status)
+
+	return p
+}
+
+// ProblemResponse answers r with err described as problem details. Refused
+// logins also tell when to try again through the Retry-After header.
+func ProblemResponse(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(r, err)
+
+	var blocked *domain.LoginBlockedError
+	if errors.As(err, &blocked) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProblem(t *testing.T) {
+
+	testCases := []struct {
+		name            string
+		err             error
+		expectedProblem *dto.ProblemOutputDTO
+	}{
+		{
+			name: "Not found",
+			err:  domain.ErrUserNotFound,
+			expectedProblem: &dto.ProblemOutputDTO{
+				Type:     "about:blank",
+				Title:    "Not Found",
+				Status:   http.StatusNotFound,
+				Detail:   "user not found",
+				Instance: "/users/1",
+				Code:     "user_not_found",
+			},
+		},
+		{
+			name: "Wrapped",
+			err:  fmt.Errorf("%w: unexpected EOF", domain.ErrRequestBodyInvalid),
+			expectedProblem: &dto.ProblemOutputDTO{
+				Type:     "about:blank",
+				Title:    "Bad Request",
+				Status:   http.StatusBadRequest,
+				Detail:   "invalid request body: unexpected EOF",
+				Instance: "/users/1",
+				Code:     "request_body_invalid",
+			},
+		},
+		{
+			name: "Deadline exceeded",
+			err:  fmt.Errorf("select users: %w", context.DeadlineExceeded),
+			expectedProblem: &dto.ProblemOutputDTO{
+				Type:     "about:blank",
+				Title:    "Service Unavailable",
+				Status:   http.StatusServiceUnavailable,
+				Detail:   "the request took too long, try again later",
+				Instance: "/users/1",
+				Code:     "request_timeout",
+			},
+		},
+		{
+			name: "Unknown error",
+			err:  errors.New("dial tcp: connection refused"),
+			expectedProblem: &dto.ProblemOutputDTO{
+				Type:     "about:blank",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "internal server error",
+				Instance: "/users/1",
+				Code:     "internal",
+			},
+		},
+		{
+			name: "Invalid fields",
+			err: &domain.ValidationError{Violations: []domain.FieldViolation{
+				{Field: "name", Err: domain.ErrFieldRequired},
+				{Field: "email", Err: domain.ErrUserEmailRequired},
+			}},
+			expectedProblem: &dto.ProblemOutputDTO{
+				Type:     "about:blank",
+				Title:    "Unprocessable Entity",
+				Status:   http.StatusUnprocessableEntity,
+				Detail:   "the request has invalid fields",
+				Instance: "/users/1",
+				Code:     "validation_failed",
+				Violations: []dto.ProblemViolationOutputDTO{
+					{Field: "name", Code: "required", Detail: "the field is required"},
+					{Field: "email", Code: "user_email_invalid", Detail: "invalid user email"},
+				},
+			},
+		},
+		{
+			name: "Password policy",
+			err: &domain.PasswordPolicyError{Violations: []error{
+				domain.ErrUserPasswordFormatNumber,
+			}},
+			expectedProblem: &dto.ProblemOutputDTO{
+				Type:     "about:blank",
+				Title:    "Bad Request",
+				Status:   http.StatusBadRequest,
+				Detail:   "password does not meet the policy",
+				Instance: "/users/1",
+				Code:     "password_policy",
+				Violations: []dto.ProblemViolationOutputDTO{
+					{Code: "password_digit_missing", Detail: domain.ErrUserPasswordFormatNumber.Error()},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+			assert.Equal(t, tc.expectedProblem, NewProblem(req, tc.err))
+		})
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req = req.WithContext(ContextWithRequestId(req.Context(), "req-1"))
+	rr := httptest.NewRecorder()
+
+	ProblemResponse(rr, req, &domain.LoginBlockedError{Reason: domain.ErrAccountLocked, RetryAfter: 90*time.Second + time.Millisecond})
+
+	assert.Equal(t, http.StatusLocked, rr.Code, "Expected status code to match")
+	assert.Equal(t, ProblemContentType, rr.Header().Get("Content-Type"), "Expected content type to match")
+	assert.Equal(t, "91", rr.Header().Get("Retry-After"), "Expected Retry-After to be rounded up")
+
+	var p dto.ProblemOutputDTO
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "account_locked", p.Code)
+	assert.Equal(t, "req-1", p.RequestId)
+}
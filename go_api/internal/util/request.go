@@ -0,0 +1,102 @@
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ForwardedForHeader lists the addresses a request went through, each proxy
+// appending the peer it got the request from.
+const ForwardedForHeader = "X-Forwarded-For"
+
+type clientIpContextKey struct{}
+
+// ContextWithClientIP attaches the IP address of the client to ctx.
+func ContextWithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIpContextKey{}, ip)
+}
+
+// ClientIP returns the IP address of the client that sent the request, as
+// attached to its context, or else the one of the peer.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIpContextKey{}).(string); ok {
+		return ip
+	}
+
+	return peerIP(r)
+}
+
+// ForwardedClientIP returns the IP address of the client behind the proxies
+// in trusted. The X-Forwarded-For hops are read from the right and the first
+// one outside of trusted is the client, the hops left of it were written by
+// the client itself. The header is ignored unless the peer is trusted.
+func ForwardedClientIP(r *http.Request, trusted []netip.Prefix) string {
+	ip := peerIP(r)
+	if !isTrustedProxy(ip, trusted) {
+		return ip
+	}
+
+	hops := []string{}
+	for _, header := range r.Header.Values(ForwardedForHeader) {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// The trusted proxy that appended the hop before is the last
+			// address that can be vouched for.
+			break
+		}
+
+		ip = addr.Unmap().String()
+		if !isTrustedProxy(ip, trusted) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// peerIP returns the IP address of the peer that sent the request.
+func peerIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type requestIdContextKey struct{}
+
+// ContextWithRequestId attaches the ID of the request to ctx.
+func ContextWithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdContextKey{}, requestId)
+}
+
+// RequestIdFromContext returns the ID of the request. The second value is false
+// when the request was given none.
+func RequestIdFromContext(ctx context.Context) (string, bool) {
+	requestId, ok := ctx.Value(requestIdContextKey{}).(string)
+	return requestId, ok
+}
@@ -0,0 +1,117 @@
+package util
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		expectedIP string
+	}{
+		{
+			name:       "IPv4 with port",
+			remoteAddr: "192.0.2.1:1234",
+			expectedIP: "192.0.2.1",
+		},
+		{
+			name:       "IPv6 with port",
+			remoteAddr: "[2001:db8::1]:1234",
+			expectedIP: "2001:db8::1",
+		},
+		{
+			name:       "Without port",
+			remoteAddr: "192.0.2.1",
+			expectedIP: "192.0.2.1",
+		},
+		{
+			name:       "Empty",
+			remoteAddr: "",
+			expectedIP: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tc.remoteAddr}
+			assert.Equal(t, tc.expectedIP, ClientIP(r), "Expected client IP to match.")
+		})
+	}
+}
+
+func TestForwardedClientIP(t *testing.T) {
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expectedIP   string
+	}{
+		{
+			name:         "Untrusted peer",
+			remoteAddr:   "192.0.2.1:1234",
+			forwardedFor: []string{"198.51.100.7"},
+			expectedIP:   "192.0.2.1",
+		},
+		{
+			name:       "Trusted peer without header",
+			remoteAddr: "10.0.0.1:1234",
+			expectedIP: "10.0.0.1",
+		},
+		{
+			name:         "Trusted peer",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.7"},
+			expectedIP:   "198.51.100.7",
+		},
+		{
+			name:         "Chain of trusted proxies",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.7, 10.0.0.2", "10.0.0.3"},
+			expectedIP:   "198.51.100.7",
+		},
+		{
+			name:         "Hop forged by the client",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"203.0.113.9, 198.51.100.7"},
+			expectedIP:   "198.51.100.7",
+		},
+		{
+			name:         "Malformed hop",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.7, unknown, 10.0.0.2"},
+			expectedIP:   "10.0.0.2",
+		},
+		{
+			name:         "Only trusted hops",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"10.0.0.2"},
+			expectedIP:   "10.0.0.2",
+		},
+		{
+			name:         "IPv6 trusted peer",
+			remoteAddr:   "[2001:db8::1]:1234",
+			forwardedFor: []string{"::ffff:198.51.100.7"},
+			expectedIP:   "198.51.100.7",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tc.remoteAddr, Header: http.Header{}}
+			for _, value := range tc.forwardedFor {
+				r.Header.Add(ForwardedForHeader, value)
+			}
+
+			assert.Equal(t, tc.expectedIP, ForwardedClientIP(r, trusted), "Expected client IP to match.")
+		})
+	}
+}
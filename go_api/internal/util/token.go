@@ -0,0 +1,30 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// NewOpaqueToken generates a URL safe random token with `size` bytes of entropy.
+func NewOpaqueToken(size int) (string, error) {
+	if size <= 0 {
+		return "", errors.New("token size must be positive")
+	}
+
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashOpaqueToken returns the hex encoded SHA-256 digest of the token, which is
+// what gets persisted so a database leak does not expose usable tokens.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOpaqueToken(t *testing.T) {
+
+	testCases := []struct {
+		name        string
+		size        int
+		expectError bool
+	}{
+		{
+			name:        "Success",
+			size:        32,
+			expectError: false,
+		},
+		{
+			name:        "Zero size",
+			size:        0,
+			expectError: true,
+		},
+		{
+			name:        "Negative size",
+			size:        -1,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := NewOpaqueToken(tc.size)
+			if tc.expectError {
+				assert.Error(t, err, "Expected error")
+				assert.Empty(t, token, "Expected token to be empty")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				decoded, err := base64.RawURLEncoding.DecodeString(token)
+				assert.NoError(t, err, "Expected token to be base64url encoded")
+				assert.Len(t, decoded, tc.size, "Expected token entropy to match")
+			}
+		})
+	}
+
+	t.Run("Unique tokens", func(t *testing.T) {
+		t1, _ := NewOpaqueToken(32)
+		t2, _ := NewOpaqueToken(32)
+		assert.NotEqual(t, t1, t2, "Expected tokens to differ")
+	})
+}
+
+func TestHashOpaqueToken(t *testing.T) {
+	assert.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", HashOpaqueToken("test"), "Expected SHA-256 hex digest")
+	assert.Equal(t, HashOpaqueToken("token"), HashOpaqueToken("token"), "Expected hash to be deterministic")
+	assert.NotEqual(t, HashOpaqueToken("token1"), HashOpaqueToken("token2"), "Expected different tokens to differ")
+}
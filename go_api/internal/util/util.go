@@ -5,7 +5,13 @@ import (
 	"net/http"
 )
 
+// JSONResponse writes response as JSON. Through a LocalizedResponseWriter,
+// its timestamps are rendered in the location of the writer.
 func JSONResponse(w http.ResponseWriter, response interface{}, statusCode int) {
+	if lw, ok := w.(*LocalizedResponseWriter); ok {
+		LocalizeTimes(response, lw.Location)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(response)
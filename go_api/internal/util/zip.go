@@ -0,0 +1,34 @@
+package util
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+)
+
+// JSONFile is an entry of a JSON bundle, Value being encoded as its content.
+type JSONFile struct {
+	Name  string
+	Value interface{}
+}
+
+// WriteJSONZip writes files to w as a ZIP archive of indented JSON documents,
+// in the given order.
+func WriteJSONZip(w io.Writer, files []JSONFile) error {
+	zw := zip.NewWriter(w)
+
+	for _, f := range files {
+		fw, err := zw.Create(f.Name)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.Value); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
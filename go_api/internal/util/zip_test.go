@@ -0,0 +1,49 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSONZip(t *testing.T) {
+
+	buf := &bytes.Buffer{}
+	err := WriteJSONZip(buf, []JSONFile{
+		{Name: "user.json", Value: map[string]int{"id": 1}},
+		{Name: "sessions.json", Value: []string{}},
+	})
+	if !assert.NoError(t, err, "Expected no error writing the archive") {
+		return
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !assert.NoError(t, err, "Expected a readable archive") {
+		return
+	}
+
+	expected := []struct {
+		name    string
+		content string
+	}{
+		{name: "user.json", content: "{\n  \"id\": 1\n}\n"},
+		{name: "sessions.json", content: "[]\n"},
+	}
+
+	if assert.Len(t, zr.File, len(expected), "Expected one entry per file") {
+		for i, e := range expected {
+			assert.Equal(t, e.name, zr.File[i].Name, "Expected entries in order")
+
+			rc, err := zr.File[i].Open()
+			if !assert.NoError(t, err, "Expected entry to open") {
+				continue
+			}
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			assert.Equal(t, e.content, string(content), "Expected indented JSON content")
+		}
+	}
+}
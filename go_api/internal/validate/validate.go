@@ -0,0 +1,100 @@
+// Package validate checks the fields of requests against rules, collecting
+// every broken rule rather than stopping at the first one.
+package validate
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Daffc/GO-Sales/domain"
+)
+
+// StringRule checks a string, returning the error of the broken rule.
+type StringRule func(value string) error
+
+// Validator collects the rules broken by the fields of a request. Rules are
+// declared field by field:
+//
+//	return validate.New().
+//		String("name", input.Name, validate.Required, validate.MaxLength(255)).
+//		String("email", input.Email, validate.Required, validate.Email).
+//		Err()
+type Validator struct {
+	violations []domain.FieldViolation
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// String checks value against rules in order. Only the first broken rule of a
+// field is reported, as the next ones often follow from it.
+func (v *Validator) String(field string, value string, rules ...StringRule) *Validator {
+	for _, rule := range rules {
+		if err := rule(value); err != nil {
+			v.violations = append(v.violations, domain.FieldViolation{Field: field, Err: err})
+			break
+		}
+	}
+
+	return v
+}
+
+// OptionalString checks value as String does, unless it is absent.
+func (v *Validator) OptionalString(field string, value *string, rules ...StringRule) *Validator {
+	if value == nil {
+		return v
+	}
+
+	return v.String(field, *value, rules...)
+}
+
+// Err returns every broken rule as a *domain.ValidationError, or nil.
+func (v *Validator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	return &domain.ValidationError{Violations: v.violations}
+}
+
+// Required refuses empty and blank values.
+func Required(value string) error {
+	if len(strings.TrimSpace(value)) == 0 {
+		return domain.ErrFieldRequired
+	}
+
+	return nil
+}
+
+// MaxLength refuses values longer than max characters.
+func MaxLength(max int) StringRule {
+	return func(value string) error {
+		if utf8.RuneCountInString(value) > max {
+			return fmt.Errorf("%w, it must be at most %d characters long", domain.ErrFieldTooLong, max)
+		}
+
+		return nil
+	}
+}
+
+// Email refuses values that are not email addresses.
+func Email(value string) error {
+	u := domain.User{Email: value}
+
+	return u.ValidateEmail()
+}
+
+// OneOf refuses values other than choices.
+func OneOf(choices ...string) StringRule {
+	return func(value string) error {
+		for _, c := range choices {
+			if value == c {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%w, it must be one of %s", domain.ErrFieldChoice, strings.Join(choices, ", "))
+	}
+}
@@ -0,0 +1,73 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidator(t *testing.T) {
+
+	name := ""
+	role := "owner"
+
+	testCases := []struct {
+		name          string
+		validator     *Validator
+		expectedError error
+	}{
+		{
+			name: "Valid",
+			validator: New().
+				String("name", "User1", Required, MaxLength(5)).
+				String("email", "user1@example.com", Required, Email).
+				OptionalString("role", nil, OneOf(domain.RoleUser, domain.RoleAdmin)),
+			expectedError: nil,
+		},
+		{
+			name: "Every field invalid",
+			validator: New().
+				String("name", "User10", Required, MaxLength(5)).
+				String("email", "  ", Required, Email).
+				String("email_confirmation", "user1", Required, Email).
+				OptionalString("nickname", &name, Required).
+				OptionalString("role", &role, OneOf(domain.RoleUser, domain.RoleAdmin)),
+			expectedError: &domain.ValidationError{Violations: []domain.FieldViolation{
+				{Field: "name", Err: domain.ErrFieldTooLong},
+				{Field: "email", Err: domain.ErrFieldRequired},
+				{Field: "email_confirmation", Err: domain.ErrUserEmailRequired},
+				{Field: "nickname", Err: domain.ErrFieldRequired},
+				{Field: "role", Err: domain.ErrFieldChoice},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.validator.Err()
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationErr *domain.ValidationError
+			if !assert.ErrorAs(t, err, &validationErr) {
+				return
+			}
+			expected := tc.expectedError.(*domain.ValidationError)
+			assert.Len(t, validationErr.Violations, len(expected.Violations), "Expected every invalid field to be reported")
+			for i, v := range expected.Violations {
+				assert.Equal(t, v.Field, validationErr.Violations[i].Field, "Expected field to match")
+				assert.ErrorIs(t, validationErr.Violations[i].Err, v.Err, "Expected rule to match")
+			}
+		})
+	}
+}
+
+func TestRuleMessages(t *testing.T) {
+	assert.EqualError(t, MaxLength(3)("abcd"), "the field is too long, it must be at most 3 characters long")
+	assert.NoError(t, MaxLength(3)("ção"), "Expected characters to be counted rather than bytes")
+	assert.EqualError(t, OneOf("user", "admin")("owner"), "the field is not one of the accepted values, it must be one of user, admin")
+}
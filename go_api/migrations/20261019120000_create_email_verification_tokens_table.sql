@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN email_verified_at datetime NULL AFTER password;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Accounts created before verification existed are considered verified.
+UPDATE users SET email_verified_at = created_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE email_verification_tokens (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    expires_at datetime NOT NULL,
+    created_at datetime,
+    CONSTRAINT UC_EmailVerificationTokenHash UNIQUE (token_hash),
+    CONSTRAINT FK_EmailVerificationTokenUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE email_verification_tokens;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN email_verified_at;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN role varchar(32) NOT NULL DEFAULT 'user' AFTER password;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE login_throttles (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    scope varchar(16) NOT NULL,
+    subject varchar(255) NOT NULL,
+    failures INTEGER UNSIGNED NOT NULL DEFAULT 0,
+    last_failed_at datetime NOT NULL,
+    locked_until datetime NULL,
+    CONSTRAINT UC_LoginThrottleScopeSubject UNIQUE (scope, subject)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE login_throttles;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN role;
+-- +goose StatementEnd
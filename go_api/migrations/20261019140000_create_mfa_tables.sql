@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE totp_credentials (
+    user_id INTEGER PRIMARY KEY,
+    secret varchar(64) NOT NULL,
+    confirmed_at datetime NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at datetime,
+    updated_at datetime,
+    CONSTRAINT FK_TotpCredentialUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE mfa_recovery_codes (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash varchar(64) NOT NULL,
+    used_at datetime NULL,
+    created_at datetime,
+    CONSTRAINT UC_MfaRecoveryCodeHash UNIQUE (user_id, code_hash),
+    CONSTRAINT FK_MfaRecoveryCodeUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE mfa_recovery_codes;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE totp_credentials;
+-- +goose StatementEnd
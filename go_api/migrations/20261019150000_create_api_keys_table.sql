@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    name varchar(255) NOT NULL,
+    prefix varchar(16) NOT NULL,
+    key_hash varchar(64) NOT NULL,
+    scopes varchar(255) NOT NULL,
+    expires_at datetime NULL,
+    last_used_at datetime NULL,
+    created_at datetime,
+    CONSTRAINT UC_ApiKeyHash UNIQUE (key_hash),
+    CONSTRAINT FK_ApiKeyUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE api_keys;
+-- +goose StatementEnd
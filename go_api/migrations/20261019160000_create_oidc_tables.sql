@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_identities (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    issuer varchar(255) NOT NULL,
+    subject varchar(255) NOT NULL,
+    email varchar(255) NOT NULL,
+    created_at datetime,
+    CONSTRAINT UC_UserIdentityIssuerSubject UNIQUE (issuer, subject),
+    CONSTRAINT FK_UserIdentityUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE oidc_login_states (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    state_hash varchar(64) NOT NULL,
+    nonce varchar(64) NOT NULL,
+    code_verifier varchar(128) NOT NULL,
+    user_id INTEGER NULL,
+    expires_at datetime NOT NULL,
+    created_at datetime,
+    CONSTRAINT UC_OidcLoginStateHash UNIQUE (state_hash),
+    CONSTRAINT FK_OidcLoginStateUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE oidc_login_states;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE user_identities;
+-- +goose StatementEnd
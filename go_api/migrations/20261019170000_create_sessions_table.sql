@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE sessions (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    token_id varchar(64) NOT NULL,
+    ip varchar(45) NOT NULL,
+    user_agent varchar(255) NOT NULL,
+    created_at datetime,
+    last_seen_at datetime NOT NULL,
+    expires_at datetime NOT NULL,
+    revoked_at datetime NULL,
+    CONSTRAINT UC_SessionTokenId UNIQUE (token_id),
+    CONSTRAINT FK_SessionUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE sessions;
+-- +goose StatementEnd
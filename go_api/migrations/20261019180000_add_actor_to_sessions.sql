@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- No foreign key on purpose, the actor stays recorded after their account is
+-- removed.
+ALTER TABLE sessions ADD COLUMN actor_id INTEGER NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN actor_id;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE organizations (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    name text NOT NULL,
+    created_at datetime,
+    updated_at datetime
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO organizations (id, name, created_at, updated_at) VALUES (1, 'Default', NOW(), NOW());
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN organization_id INTEGER NOT NULL DEFAULT 1 AFTER id,
+    ADD CONSTRAINT FK_UserOrganization FOREIGN KEY (organization_id) REFERENCES organizations(id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users ALTER COLUMN organization_id DROP DEFAULT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP FOREIGN KEY FK_UserOrganization;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN organization_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE organizations;
+-- +goose StatementEnd
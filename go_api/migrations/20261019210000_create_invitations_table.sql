@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE invitations (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    organization_id INTEGER NOT NULL,
+    email varchar(255) NOT NULL,
+    role varchar(32) NOT NULL,
+    invited_by_id INTEGER NOT NULL,
+    expires_at datetime NOT NULL,
+    accepted_at datetime NULL,
+    created_at datetime,
+    CONSTRAINT FK_InvitationOrganization FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    CONSTRAINT FK_InvitationInvitedBy FOREIGN KEY (invited_by_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE invitations;
+-- +goose StatementEnd
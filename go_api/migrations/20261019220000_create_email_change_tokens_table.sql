@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE email_change_tokens (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    new_email varchar(255) NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    expires_at datetime NOT NULL,
+    created_at datetime,
+    CONSTRAINT UC_EmailChangeTokenHash UNIQUE (token_hash),
+    CONSTRAINT FK_EmailChangeTokenUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE email_change_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN status varchar(16) NOT NULL DEFAULT 'active' AFTER role;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE users SET status = 'deleted' WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- Soft deleted users keep their row, so the email is only unique among live
+-- users. NULLs never collide in a unique index.
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN live_email varchar(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    DROP INDEX UC_Email,
+    ADD CONSTRAINT UC_Email UNIQUE (live_email);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP INDEX UC_Email,
+    DROP COLUMN live_email,
+    ADD CONSTRAINT UC_Email UNIQUE (email);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN status;
+-- +goose StatementEnd
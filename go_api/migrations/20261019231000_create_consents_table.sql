@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE consents (
+    id INTEGER PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    purpose varchar(32) NOT NULL,
+    granted boolean NOT NULL,
+    source varchar(16) NOT NULL,
+    created_at datetime,
+    CONSTRAINT FK_ConsentUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE consents;
+-- +goose StatementEnd
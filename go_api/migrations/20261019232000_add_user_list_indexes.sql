@@ -0,0 +1,57 @@
+-- +goose Up
+-- TEXT columns can only be indexed by a prefix, which cannot give the order of
+-- a page. Names and emails are limited to 255 characters by validation. The
+-- live email is computed from the email, so it is dropped while the latter
+-- changes type.
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP INDEX UC_Email,
+    DROP COLUMN live_email;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+    MODIFY COLUMN name varchar(255) NOT NULL,
+    MODIFY COLUMN email varchar(255) NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN live_email varchar(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    ADD CONSTRAINT UC_Email UNIQUE (live_email);
+-- +goose StatementEnd
+
+-- Users are listed a page at a time within an organization, by one of the
+-- sort fields and then by id.
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD INDEX IDX_UserOrganizationName (organization_id, name, id),
+    ADD INDEX IDX_UserOrganizationEmail (organization_id, email, id),
+    ADD INDEX IDX_UserOrganizationCreatedAt (organization_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP INDEX IDX_UserOrganizationName,
+    DROP INDEX IDX_UserOrganizationEmail,
+    DROP INDEX IDX_UserOrganizationCreatedAt;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP INDEX UC_Email,
+    DROP COLUMN live_email;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+    MODIFY COLUMN name text NOT NULL,
+    MODIFY COLUMN email text NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN live_email varchar(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    ADD CONSTRAINT UC_Email UNIQUE (live_email);
+-- +goose StatementEnd
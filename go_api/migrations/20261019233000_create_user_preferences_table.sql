@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_preferences (
+    user_id INTEGER PRIMARY KEY,
+    locale varchar(16) NOT NULL,
+    timezone varchar(64) NOT NULL,
+    currency char(3) NOT NULL,
+    page_size INTEGER NOT NULL,
+    updated_at datetime,
+    CONSTRAINT FK_UserPreferencesUser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_preferences;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE rate_limit_buckets (
+    bucket_key varchar(255) PRIMARY KEY,
+    tokens DOUBLE NOT NULL,
+    refilled_at datetime(6) NOT NULL,
+    expires_at datetime(6) NOT NULL,
+    INDEX IDX_RateLimitBucketsExpiresAt (expires_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE rate_limit_buckets;
+-- +goose StatementEnd
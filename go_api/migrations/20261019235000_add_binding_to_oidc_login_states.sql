@@ -0,0 +1,11 @@
+-- +goose Up
+-- States pending at the upgrade have no binding and are refused, they expire
+-- within minutes anyway.
+-- +goose StatementBegin
+ALTER TABLE oidc_login_states ADD COLUMN binding_hash varchar(64) NOT NULL DEFAULT '' AFTER state_hash;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE oidc_login_states DROP COLUMN binding_hash;
+-- +goose StatementEnd
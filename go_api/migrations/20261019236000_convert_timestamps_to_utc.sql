@@ -0,0 +1,201 @@
+-- +goose ENVSUB ON
+-- +goose Up
+-- Timestamps used to be written in the local time zone of the API server and
+-- are now written in UTC. Before upgrading, set DB_LEGACY_TIME_ZONE to the
+-- time zone the API server ran in, either as an offset such as '-03:00' or,
+-- when the time zone tables of the database are loaded, as a name such as
+-- 'America/Sao_Paulo' so daylight saving time is accounted for. Left unset,
+-- the server is taken to have run in UTC and nothing changes. Tables created
+-- after the switch to UTC are left out.
+-- +goose StatementBegin
+BEGIN NOT ATOMIC
+    IF CONVERT_TZ('2000-01-01 00:00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00') IS NULL THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'DB_LEGACY_TIME_ZONE is not a time zone known to the database';
+    END IF;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE users SET
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    updated_at = CONVERT_TZ(updated_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    deleted_at = CONVERT_TZ(deleted_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    email_verified_at = CONVERT_TZ(email_verified_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE email_verification_tokens SET
+    expires_at = CONVERT_TZ(expires_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE login_throttles SET
+    last_failed_at = CONVERT_TZ(last_failed_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    locked_until = CONVERT_TZ(locked_until, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE totp_credentials SET
+    confirmed_at = CONVERT_TZ(confirmed_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    updated_at = CONVERT_TZ(updated_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE mfa_recovery_codes SET
+    used_at = CONVERT_TZ(used_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE api_keys SET
+    expires_at = CONVERT_TZ(expires_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    last_used_at = CONVERT_TZ(last_used_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE user_identities SET
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE oidc_login_states SET
+    expires_at = CONVERT_TZ(expires_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE sessions SET
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    last_seen_at = CONVERT_TZ(last_seen_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    expires_at = CONVERT_TZ(expires_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    revoked_at = CONVERT_TZ(revoked_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE password_histories SET
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE organizations SET
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    updated_at = CONVERT_TZ(updated_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE invitations SET
+    expires_at = CONVERT_TZ(expires_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    accepted_at = CONVERT_TZ(accepted_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE email_change_tokens SET
+    expires_at = CONVERT_TZ(expires_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00'),
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE consents SET
+    created_at = CONVERT_TZ(created_at, '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+BEGIN NOT ATOMIC
+    IF CONVERT_TZ('2000-01-01 00:00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}', '+00:00') IS NULL THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'DB_LEGACY_TIME_ZONE is not a time zone known to the database';
+    END IF;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE users SET
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    updated_at = CONVERT_TZ(updated_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    deleted_at = CONVERT_TZ(deleted_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    email_verified_at = CONVERT_TZ(email_verified_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE email_verification_tokens SET
+    expires_at = CONVERT_TZ(expires_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE login_throttles SET
+    last_failed_at = CONVERT_TZ(last_failed_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    locked_until = CONVERT_TZ(locked_until, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE totp_credentials SET
+    confirmed_at = CONVERT_TZ(confirmed_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    updated_at = CONVERT_TZ(updated_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE mfa_recovery_codes SET
+    used_at = CONVERT_TZ(used_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE api_keys SET
+    expires_at = CONVERT_TZ(expires_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    last_used_at = CONVERT_TZ(last_used_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE user_identities SET
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE oidc_login_states SET
+    expires_at = CONVERT_TZ(expires_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE sessions SET
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    last_seen_at = CONVERT_TZ(last_seen_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    expires_at = CONVERT_TZ(expires_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    revoked_at = CONVERT_TZ(revoked_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE password_histories SET
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE organizations SET
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    updated_at = CONVERT_TZ(updated_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE invitations SET
+    expires_at = CONVERT_TZ(expires_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    accepted_at = CONVERT_TZ(accepted_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE email_change_tokens SET
+    expires_at = CONVERT_TZ(expires_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}'),
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE consents SET
+    created_at = CONVERT_TZ(created_at, '+00:00', '${DB_LEGACY_TIME_ZONE:-+00:00}');
+-- +goose StatementEnd
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository interface {
+	CreateApiKey(ctx context.Context, k *domain.ApiKey) (*domain.ApiKey, error)
+	ListApiKeysByUserId(ctx context.Context, userId uint) ([]*domain.ApiKey, error)
+	FindApiKeyByHash(ctx context.Context, keyHash string) (*domain.ApiKey, error)
+	DeleteApiKey(ctx context.Context, userId uint, id uint) error
+	UpdateApiKeyLastUsedAt(ctx context.Context, k *domain.ApiKey) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlApiKeyRepository(db *gorm.DB) (ApiKeyRepository, error) {
+	return &apiKeyRepository{db: db}, nil
+}
+
+func (r *apiKeyRepository) CreateApiKey(ctx context.Context, k *domain.ApiKey) (*domain.ApiKey, error) {
+
+	k.CreatedAt = time.Now()
+
+	result := r.db.WithContext(ctx).Create(k)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return k, nil
+}
+
+func (r *apiKeyRepository) ListApiKeysByUserId(ctx context.Context, userId uint) ([]*domain.ApiKey, error) {
+	ks := []*domain.ApiKey{}
+
+	result := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("id").Find(&ks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return ks, nil
+}
+
+func (r *apiKeyRepository) FindApiKeyByHash(ctx context.Context, keyHash string) (*domain.ApiKey, error) {
+	k := &domain.ApiKey{}
+
+	result := r.db.WithContext(ctx).First(&k, "key_hash = ?", keyHash)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return k, nil
+}
+
+func (r *apiKeyRepository) DeleteApiKey(ctx context.Context, userId uint, id uint) error {
+
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userId).Delete(&domain.ApiKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) UpdateApiKeyLastUsedAt(ctx context.Context, k *domain.ApiKey) error {
+
+	result := r.db.WithContext(ctx).Model(&k).Where("id = ?", k.ID).Update("last_used_at", k.LastUsedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+type ConsentRepository interface {
+	CreateConsent(ctx context.Context, c *domain.Consent) (*domain.Consent, error)
+	ListConsentsByUserId(ctx context.Context, userId uint) ([]*domain.Consent, error)
+}
+
+type consentRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlConsentRepository(db *gorm.DB) (ConsentRepository, error) {
+	return &consentRepository{db: db}, nil
+}
+
+func (r *consentRepository) CreateConsent(ctx context.Context, c *domain.Consent) (*domain.Consent, error) {
+
+	c.CreatedAt = time.Now()
+
+	result := r.db.WithContext(ctx).Create(c)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return c, nil
+}
+
+// ListConsentsByUserId returns the consent history of a user, oldest first.
+func (r *consentRepository) ListConsentsByUserId(ctx context.Context, userId uint) ([]*domain.Consent, error) {
+	cs := []*domain.Consent{}
+
+	result := r.db.WithContext(ctx).Where("user_id = ?", userId).Order("id").Find(&cs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return cs, nil
+}
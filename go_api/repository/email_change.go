@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+type EmailChangeRepository interface {
+	CreateToken(ctx context.Context, t *domain.EmailChangeToken) (*domain.EmailChangeToken, error)
+	FindTokenByHash(ctx context.Context, tokenHash string) (*domain.EmailChangeToken, error)
+	DeleteTokensByUserId(ctx context.Context, userId uint) error
+}
+
+type emailChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlEmailChangeRepository(db *gorm.DB) (EmailChangeRepository, error) {
+	return &emailChangeRepository{db: db}, nil
+}
+
+func (r *emailChangeRepository) CreateToken(ctx context.Context, t *domain.EmailChangeToken) (*domain.EmailChangeToken, error) {
+
+	t.CreatedAt = time.Now()
+
+	result := r.db.WithContext(ctx).Create(t)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return t, nil
+}
+
+func (r *emailChangeRepository) FindTokenByHash(ctx context.Context, tokenHash string) (*domain.EmailChangeToken, error) {
+	t := &domain.EmailChangeToken{}
+
+	result := r.db.WithContext(ctx).First(&t, "token_hash = ?", tokenHash)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return t, nil
+}
+
+func (r *emailChangeRepository) DeleteTokensByUserId(ctx context.Context, userId uint) error {
+
+	result := r.db.WithContext(ctx).Where("user_id = ?", userId).Delete(&domain.EmailChangeToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
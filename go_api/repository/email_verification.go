@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+type EmailVerificationRepository interface {
+	CreateToken(ctx context.Context, t *domain.EmailVerificationToken) (*domain.EmailVerificationToken, error)
+	FindTokenByHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error)
+	DeleteTokensByUserId(ctx context.Context, userId uint) error
+}
+
+type emailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlEmailVerificationRepository(db *gorm.DB) (EmailVerificationRepository, error) {
+	return &emailVerificationRepository{db: db}, nil
+}
+
+func (r *emailVerificationRepository) CreateToken(ctx context.Context, t *domain.EmailVerificationToken) (*domain.EmailVerificationToken, error) {
+
+	t.CreatedAt = time.Now()
+
+	result := r.db.WithContext(ctx).Create(t)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return t, nil
+}
+
+func (r *emailVerificationRepository) FindTokenByHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	t := &domain.EmailVerificationToken{}
+
+	result := r.db.WithContext(ctx).First(&t, "token_hash = ?", tokenHash)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return t, nil
+}
+
+func (r *emailVerificationRepository) DeleteTokensByUserId(ctx context.Context, userId uint) error {
+
+	result := r.db.WithContext(ctx).Where("user_id = ?", userId).Delete(&domain.EmailVerificationToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
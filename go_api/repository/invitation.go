@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+// InvitationRepository reads and writes invitations. Like users, invitations
+// are owned by an organization, see UserRepository for the scoping rules.
+type InvitationRepository interface {
+	ForTenant(organizationId uint) InvitationRepository
+	AllTenants() InvitationRepository
+	CreateInvitation(ctx context.Context, i *domain.Invitation) (*domain.Invitation, error)
+	ListPendingInvitations(ctx context.Context, now time.Time) ([]*domain.Invitation, error)
+	FindInvitationById(ctx context.Context, id uint) (*domain.Invitation, error)
+	DeleteInvitation(ctx context.Context, id uint) error
+	AcceptInvitation(ctx context.Context, i *domain.Invitation, u *domain.User) (*domain.User, error)
+}
+
+type invitationRepository struct {
+	db *gorm.DB
+	// tenant scopes the context of every statement, unset until ForTenant or
+	// AllTenants is called.
+	tenant func(ctx context.Context) context.Context
+}
+
+func NewMysqlInvitationRepository(db *gorm.DB) (InvitationRepository, error) {
+	return &invitationRepository{db: db}, nil
+}
+
+// ForTenant returns a repository only seeing the invitations of an
+// organization.
+func (r *invitationRepository) ForTenant(organizationId uint) InvitationRepository {
+	return &invitationRepository{db: r.db, tenant: func(ctx context.Context) context.Context {
+		return ContextWithTenant(ctx, organizationId)
+	}}
+}
+
+// AllTenants returns a repository seeing the invitations of every
+// organization.
+func (r *invitationRepository) AllTenants() InvitationRepository {
+	return &invitationRepository{db: r.db, tenant: ContextWithAllTenants}
+}
+
+// session runs statements with ctx, so they are cancelled along with it, within
+// the tenant scope of the repository.
+func (r *invitationRepository) session(ctx context.Context) *gorm.DB {
+	if r.tenant != nil {
+		ctx = r.tenant(ctx)
+	}
+
+	return r.db.WithContext(ctx)
+}
+
+func (r *invitationRepository) CreateInvitation(ctx context.Context, i *domain.Invitation) (*domain.Invitation, error) {
+
+	i.CreatedAt = time.Now()
+
+	result := r.session(ctx).Create(i)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return i, nil
+}
+
+// ListPendingInvitations returns the invitations neither accepted nor expired
+// at now, oldest first.
+func (r *invitationRepository) ListPendingInvitations(ctx context.Context, now time.Time) ([]*domain.Invitation, error) {
+	is := []*domain.Invitation{}
+
+	result := r.session(ctx).Where("accepted_at IS NULL AND expires_at > ?", now).Order("id").Find(&is)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return is, nil
+}
+
+func (r *invitationRepository) FindInvitationById(ctx context.Context, id uint) (*domain.Invitation, error) {
+	i := &domain.Invitation{}
+
+	result := r.session(ctx).First(&i, "id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return i, nil
+}
+
+// DeleteInvitation revokes a pending invitation. Accepted ones are kept as a
+// record of how their user joined.
+func (r *invitationRepository) DeleteInvitation(ctx context.Context, id uint) error {
+
+	result := r.session(ctx).Where("id = ? AND accepted_at IS NULL", id).Delete(&domain.Invitation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvitation marks i as accepted and creates u in the same transaction.
+// It fails with gorm.ErrRecordNotFound if i was accepted or revoked since it
+// was read, so an invitation never creates two users.
+func (r *invitationRepository) AcceptInvitation(ctx context.Context, i *domain.Invitation, u *domain.User) (*domain.User, error) {
+
+	now := time.Now()
+
+	err := r.session(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Invitation{}).Where("id = ? AND accepted_at IS NULL", i.ID).Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		u.CreatedAt = now
+		u.UpdatedAt = now
+
+		return tx.Create(u).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	i.AcceptedAt = &now
+
+	return u, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository interface {
+	FindThrottle(ctx context.Context, scope string, subject string) (*domain.LoginThrottle, error)
+	AddFailure(ctx context.Context, f *domain.LoginFailure) (*domain.LoginThrottle, error)
+	DeleteThrottle(ctx context.Context, scope string, subject string) error
+	DeleteExpiredThrottles(ctx context.Context, lastFailedBefore time.Time, now time.Time) error
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlLoginThrottleRepository(db *gorm.DB) (LoginThrottleRepository, error) {
+	return &loginThrottleRepository{db: db}, nil
+}
+
+func (r *loginThrottleRepository) FindThrottle(ctx context.Context, scope string, subject string) (*domain.LoginThrottle, error) {
+	t := &domain.LoginThrottle{}
+
+	result := r.db.WithContext(ctx).First(&t, "scope = ? AND subject = ?", scope, subject)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return t, nil
+}
+
+// AddFailure counts f in a single statement, creating the throttle of its
+// subject on the first failure, so that concurrent failures all count. The
+// assignments run in order, so the lock sees the updated count while both
+// still see the previous last failure. The throttle is returned as stored.
+func (r *loginThrottleRepository) AddFailure(ctx context.Context, f *domain.LoginFailure) (*domain.LoginThrottle, error) {
+	t := &domain.LoginThrottle{
+		Scope:        f.Scope,
+		Subject:      f.Subject,
+		Failures:     1,
+		LastFailedAt: f.FailedAt,
+	}
+	if f.MaxFailures == 1 {
+		t.LockedUntil = &f.LockUntil
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("IF(last_failed_at < ?, 1, failures + 1)", f.ForgetBefore)},
+			{Column: clause.Column{Name: "locked_until"}, Value: gorm.Expr("IF(? > 0 AND failures >= ?, ?, IF(last_failed_at < ?, NULL, locked_until))", f.MaxFailures, f.MaxFailures, f.LockUntil, f.ForgetBefore)},
+			{Column: clause.Column{Name: "last_failed_at"}, Value: f.FailedAt},
+		}}).Create(t)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		t = &domain.LoginThrottle{}
+		result = tx.First(&t, "scope = ? AND subject = ?", f.Scope, f.Subject)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (r *loginThrottleRepository) DeleteThrottle(ctx context.Context, scope string, subject string) error {
+
+	result := r.db.WithContext(ctx).Where("scope = ? AND subject = ?", scope, subject).Delete(&domain.LoginThrottle{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// DeleteExpiredThrottles drops the throttles whose last failure is older than
+// lastFailedBefore and that are no longer locked at now.
+func (r *loginThrottleRepository) DeleteExpiredThrottles(ctx context.Context, lastFailedBefore time.Time, now time.Time) error {
+
+	result := r.db.WithContext(ctx).Where("last_failed_at < ? AND (locked_until IS NULL OR locked_until <= ?)", lastFailedBefore, now).Delete(&domain.LoginThrottle{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
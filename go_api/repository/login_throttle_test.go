@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottleRepositoryAddFailureStatement(t *testing.T) {
+
+	db, statements := newDryRunDB(t)
+	r, err := NewMysqlLoginThrottleRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	_, err = r.AddFailure(context.Background(), &domain.LoginFailure{
+		Scope:        domain.LoginThrottleScopeAccount,
+		Subject:      "user1@example.com",
+		FailedAt:     now,
+		ForgetBefore: now.Add(-15 * time.Minute),
+		MaxFailures:  3,
+		LockUntil:    now.Add(15 * time.Minute),
+	})
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	// The count is raised by the database rather than written back, and the
+	// lock is decided on the raised count.
+	if assert.Len(t, *statements, 2, "Expected an upsert and a read") {
+		assert.Contains(t, (*statements)[0].sql, "ON DUPLICATE KEY UPDATE `failures`=IF(last_failed_at < ?, 1, failures + 1),`locked_until`=IF(? > 0 AND failures >= ?, ?, IF(last_failed_at < ?, NULL, locked_until)),`last_failed_at`=?", "Expected an atomic increment")
+		assert.Contains(t, (*statements)[1].sql, "SELECT", "Expected the throttle to be read back")
+	}
+}
+
+func TestLoginThrottleRepositoryDeleteExpiredThrottlesStatement(t *testing.T) {
+
+	db, statements := newDryRunDB(t)
+	r, err := NewMysqlLoginThrottleRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	err = r.DeleteExpiredThrottles(context.Background(), now.Add(-15*time.Minute), now)
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	// A throttle still locked is kept, whenever its last failure was.
+	if assert.Len(t, *statements, 1, "Expected a single delete") {
+		assert.Contains(t, (*statements)[0].sql, "DELETE FROM `login_throttles` WHERE last_failed_at < ? AND (locked_until IS NULL OR locked_until <= ?)", "Expected expired throttles to be deleted")
+		assert.Equal(t, []interface{}{now.Add(-15 * time.Minute), now}, (*statements)[0].vars, "Expected the cutoffs")
+	}
+}
+
+func TestLoginThrottleRepositoryConcurrentFailures(t *testing.T) {
+
+	db := newMariaDBTestDB(t)
+	r, err := NewMysqlLoginThrottleRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject := fmt.Sprintf("concurrent-%d@example.com", time.Now().UnixNano())
+	t.Cleanup(func() { r.DeleteThrottle(context.Background(), domain.LoginThrottleScopeAccount, subject) })
+
+	now := time.Now().UTC().Truncate(time.Second)
+	failure := &domain.LoginFailure{
+		Scope:        domain.LoginThrottleScopeAccount,
+		Subject:      subject,
+		FailedAt:     now,
+		ForgetBefore: now.Add(-15 * time.Minute),
+		MaxFailures:  5,
+		LockUntil:    now.Add(15 * time.Minute),
+	}
+
+	// Every failure counts, the first ones included, although they all
+	// race to create the throttle.
+	const attempts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.AddFailure(context.Background(), failure)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err, "Did not expect an error but got one")
+	}
+
+	throttle, err := r.FindThrottle(context.Background(), domain.LoginThrottleScopeAccount, subject)
+	if assert.NoError(t, err, "Did not expect an error but got one") {
+		assert.Equal(t, uint(attempts), throttle.Failures, "Expected every failure to be counted")
+		if assert.NotNil(t, throttle.LockedUntil, "Expected the throttle to be locked") {
+			assert.True(t, failure.LockUntil.Equal(*throttle.LockedUntil), "Expected the lock to match")
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/internal/database/mariadb"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// testDatabaseDSNEnv names the variable holding the DSN of a scratch MariaDB
+// database, such as "user:password@tcp(localhost:3306)/go_sales_test?parseTime=True&loc=UTC".
+// The tests that need a real server, to check how concurrent statements
+// interleave, are skipped without it.
+const testDatabaseDSNEnv = "TEST_DATABASE_DSN"
+
+// newMariaDBTestDB connects to the database of testDatabaseDSNEnv, configured
+// like the one of the API, and brings its schema up to date.
+func newMariaDBTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv(testDatabaseDSNEnv)
+	if len(dsn) == 0 {
+		t.Skipf("%s is not set", testDatabaseDSNEnv)
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		NowFunc:        func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = mariadb.RunMigrations(db, "../migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = RegisterTenantScope(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
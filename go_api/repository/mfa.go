@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+type MfaRepository interface {
+	FindTotpCredential(ctx context.Context, userId uint) (*domain.TotpCredential, error)
+	SaveTotpCredential(ctx context.Context, c *domain.TotpCredential) error
+	MarkTotpStepUsed(ctx context.Context, userId uint, step int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userId uint, codes []*domain.MfaRecoveryCode) error
+	FindUnusedRecoveryCode(ctx context.Context, userId uint, codeHash string) (*domain.MfaRecoveryCode, error)
+	MarkRecoveryCodeUsed(ctx context.Context, c *domain.MfaRecoveryCode) error
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlMfaRepository(db *gorm.DB) (MfaRepository, error) {
+	return &mfaRepository{db: db}, nil
+}
+
+func (r *mfaRepository) FindTotpCredential(ctx context.Context, userId uint) (*domain.TotpCredential, error) {
+	c := &domain.TotpCredential{}
+
+	result := r.db.WithContext(ctx).First(&c, "user_id = ?", userId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return c, nil
+}
+
+func (r *mfaRepository) SaveTotpCredential(ctx context.Context, c *domain.TotpCredential) error {
+
+	c.UpdatedAt = time.Now()
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = c.UpdatedAt
+	}
+
+	result := r.db.WithContext(ctx).Save(c)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) MarkTotpStepUsed(ctx context.Context, userId uint, step int64) error {
+
+	// Conditioning on the last used step prevents two concurrent logins using the same code.
+	result := r.db.WithContext(ctx).Model(&domain.TotpCredential{}).Where("user_id = ? AND last_used_step < ?", userId, step).Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userId uint, codes []*domain.MfaRecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ?", userId).Delete(&domain.MfaRecoveryCode{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if len(codes) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		for _, c := range codes {
+			c.UserID = userId
+			c.CreatedAt = now
+		}
+
+		return tx.Create(codes).Error
+	})
+}
+
+func (r *mfaRepository) FindUnusedRecoveryCode(ctx context.Context, userId uint, codeHash string) (*domain.MfaRecoveryCode, error) {
+	c := &domain.MfaRecoveryCode{}
+
+	result := r.db.WithContext(ctx).First(&c, "user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return c, nil
+}
+
+func (r *mfaRepository) MarkRecoveryCodeUsed(ctx context.Context, c *domain.MfaRecoveryCode) error {
+
+	// Conditioning on used_at prevents two concurrent logins consuming the same code.
+	result := r.db.WithContext(ctx).Model(&c).Where("id = ? AND used_at IS NULL", c.ID).Update("used_at", c.UsedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestMfaRepositoryMarkTotpStepUsedStatement(t *testing.T) {
+
+	db, statements := newDryRunDB(t)
+	r, err := NewMysqlMfaRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run affects no rows, as when another login stored the step first.
+	err = r.MarkTotpStepUsed(context.Background(), 1, 42)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Expected an already used step to be reported")
+
+	if assert.Len(t, *statements, 1, "Expected a single statement") {
+		s := (*statements)[0]
+		assert.Contains(t, s.sql, "WHERE user_id = ? AND last_used_step < ?", "Expected the update to be conditioned on the last used step")
+		assert.Contains(t, s.vars, int64(42), "Expected the step to be stored")
+	}
+}
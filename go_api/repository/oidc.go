@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+type OidcRepository interface {
+	CreateLoginState(ctx context.Context, s *domain.OidcLoginState) (*domain.OidcLoginState, error)
+	ConsumeLoginState(ctx context.Context, stateHash string) (*domain.OidcLoginState, error)
+	FindIdentity(ctx context.Context, issuer string, subject string) (*domain.UserIdentity, error)
+	CreateIdentity(ctx context.Context, i *domain.UserIdentity) (*domain.UserIdentity, error)
+}
+
+type oidcRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlOidcRepository(db *gorm.DB) (OidcRepository, error) {
+	return &oidcRepository{db: db}, nil
+}
+
+func (r *oidcRepository) CreateLoginState(ctx context.Context, s *domain.OidcLoginState) (*domain.OidcLoginState, error) {
+
+	s.CreatedAt = time.Now()
+
+	result := r.db.WithContext(ctx).Create(s)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return s, nil
+}
+
+// ConsumeLoginState returns the state and deletes it, so a callback can only
+// be completed once.
+func (r *oidcRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*domain.OidcLoginState, error) {
+	s := &domain.OidcLoginState{}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.First(&s, "state_hash = ?", stateHash)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Delete(&domain.OidcLoginState{}, s.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *oidcRepository) FindIdentity(ctx context.Context, issuer string, subject string) (*domain.UserIdentity, error) {
+	i := &domain.UserIdentity{}
+
+	result := r.db.WithContext(ctx).First(&i, "issuer = ? AND subject = ?", issuer, subject)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return i, nil
+}
+
+func (r *oidcRepository) CreateIdentity(ctx context.Context, i *domain.UserIdentity) (*domain.UserIdentity, error) {
+
+	i.CreatedAt = time.Now()
+
+	result := r.db.WithContext(ctx).Create(i)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return i, nil
+}
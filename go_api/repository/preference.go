@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+type PreferenceRepository interface {
+	FindPreferencesByUserId(ctx context.Context, userId uint) (*domain.UserPreferences, error)
+	SavePreferences(ctx context.Context, p *domain.UserPreferences) error
+}
+
+type preferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlPreferenceRepository(db *gorm.DB) (PreferenceRepository, error) {
+	return &preferenceRepository{db: db}, nil
+}
+
+func (r *preferenceRepository) FindPreferencesByUserId(ctx context.Context, userId uint) (*domain.UserPreferences, error) {
+	p := &domain.UserPreferences{}
+
+	result := r.db.WithContext(ctx).First(&p, "user_id = ?", userId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return p, nil
+}
+
+func (r *preferenceRepository) SavePreferences(ctx context.Context, p *domain.UserPreferences) error {
+
+	p.UpdatedAt = time.Now()
+
+	result := r.db.WithContext(ctx).Save(p)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitRepository stores token buckets. UpdateBucket hands update the
+// bucket stored under key, or an empty one when there is none, and stores it
+// back once update returns. No other update of the same bucket runs meanwhile.
+type RateLimitRepository interface {
+	UpdateBucket(ctx context.Context, key string, update func(b *domain.RateLimitBucket)) error
+	DeleteExpiredBuckets(ctx context.Context, now time.Time) error
+}
+
+type memoryRateLimitRepository struct {
+	mu      sync.Mutex
+	buckets map[string]*domain.RateLimitBucket
+}
+
+// NewMemoryRateLimitRepository keeps buckets in the memory of the process,
+// each instance of the API then limits the requests it serves on its own.
+func NewMemoryRateLimitRepository() RateLimitRepository {
+	return &memoryRateLimitRepository{buckets: map[string]*domain.RateLimitBucket{}}
+}
+
+func (r *memoryRateLimitRepository) UpdateBucket(ctx context.Context, key string, update func(b *domain.RateLimitBucket)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &domain.RateLimitBucket{BucketKey: key}
+		r.buckets[key] = b
+	}
+
+	update(b)
+
+	return nil
+}
+
+func (r *memoryRateLimitRepository) DeleteExpiredBuckets(ctx context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, b := range r.buckets {
+		if !now.Before(b.ExpiresAt) {
+			delete(r.buckets, key)
+		}
+	}
+
+	return nil
+}
+
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewMysqlRateLimitRepository shares buckets between every instance of the
+// API using the database.
+func NewMysqlRateLimitRepository(db *gorm.DB) (RateLimitRepository, error) {
+	return &rateLimitRepository{db: db}, nil
+}
+
+// emptyBucketRefilledAt dates the bucket created for a key seen for the first
+// time. It was refilled so long ago that it comes out full, as a missing
+// bucket would.
+var emptyBucketRefilledAt = time.Unix(0, 0).UTC()
+
+func (r *rateLimitRepository) UpdateBucket(ctx context.Context, key string, update func(b *domain.RateLimitBucket)) error {
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking a missing row only locks the gap it would go in, which
+		// concurrent first requests all get and then deadlock inserting
+		// into. The row is made to exist first instead. On a duplicate key,
+		// the no-op update takes the row lock exclusively, where INSERT
+		// IGNORE would share it and deadlock the upgrade below.
+		empty := &domain.RateLimitBucket{BucketKey: key, RefilledAt: emptyBucketRefilledAt, ExpiresAt: emptyBucketRefilledAt}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(empty)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		b := &domain.RateLimitBucket{}
+		result = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&b, "bucket_key = ?", key)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		update(b)
+
+		result = tx.Model(&domain.RateLimitBucket{}).Where("bucket_key = ?", key).Updates(map[string]interface{}{
+			"tokens":      b.Tokens,
+			"refilled_at": b.RefilledAt,
+			"expires_at":  b.ExpiresAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return nil
+	})
+}
+
+func (r *rateLimitRepository) DeleteExpiredBuckets(ctx context.Context, now time.Time) error {
+
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&domain.RateLimitBucket{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
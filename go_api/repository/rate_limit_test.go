@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitRepositoryUpdateBucketStatements(t *testing.T) {
+
+	db, statements := newDryRunDB(t)
+	r, err := NewMysqlRateLimitRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.UpdateBucket(context.Background(), "POST /login|ip:192.0.2.1", func(b *domain.RateLimitBucket) {
+		b.Tokens = 4
+	})
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	// The bucket is created before it is locked, so there always is a row to
+	// lock rather than a gap.
+	if assert.Len(t, *statements, 3, "Expected an insert, a locking read and an update") {
+		assert.Contains(t, (*statements)[0].sql, "INSERT INTO `rate_limit_buckets`", "Expected the bucket to be created first")
+		assert.Contains(t, (*statements)[0].sql, "ON DUPLICATE KEY UPDATE `bucket_key`=`bucket_key`", "Expected an existing bucket to be left as is")
+		assert.Contains(t, (*statements)[1].sql, "FOR UPDATE", "Expected the bucket to be locked")
+		assert.Contains(t, (*statements)[2].sql, "UPDATE `rate_limit_buckets` SET", "Expected the bucket to be stored back")
+		assert.Contains(t, (*statements)[2].vars, float64(4), "Expected the updated bucket")
+	}
+}
+
+func TestRateLimitRepositoryConcurrentFirstRequests(t *testing.T) {
+
+	db := newMariaDBTestDB(t)
+	r, err := NewMysqlRateLimitRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := fmt.Sprintf("GET /concurrent|ip:%d", time.Now().UnixNano())
+	t.Cleanup(func() { db.Where("bucket_key = ?", key).Delete(&domain.RateLimitBucket{}) })
+
+	policy := domain.RateLimitPolicy{Limit: 5, Period: time.Hour, Key: domain.RateLimitKeyIP}
+
+	// Every request races to create the bucket, none of them may fail and
+	// only the limit may be let through.
+	const requests = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- r.UpdateBucket(context.Background(), key, func(b *domain.RateLimitBucket) {
+				if b.Take(policy, time.Now()).Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err, "Did not expect an error but got one")
+	}
+	assert.Equal(t, int(policy.Limit), allowed, "Expected the limit to be let through")
+}
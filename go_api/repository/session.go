@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, s *domain.Session) (*domain.Session, error)
+	FindSessionByTokenId(ctx context.Context, tokenId string) (*domain.Session, error)
+	ListActiveSessionsByUserId(ctx context.Context, userId uint, now time.Time) ([]*domain.Session, error)
+	RevokeSession(ctx context.Context, userId uint, id uint, revokedAt time.Time) error
+	RevokeOtherSessions(ctx context.Context, userId uint, exceptId uint, revokedAt time.Time) error
+	RevokeImpersonationSessions(ctx context.Context, actorId uint, revokedAt time.Time) error
+	UpdateSessionLastSeenAt(ctx context.Context, s *domain.Session) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewMysqlSessionRepository(db *gorm.DB) (SessionRepository, error) {
+	return &sessionRepository{db: db}, nil
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, s *domain.Session) (*domain.Session, error) {
+
+	result := r.db.WithContext(ctx).Create(s)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return s, nil
+}
+
+func (r *sessionRepository) FindSessionByTokenId(ctx context.Context, tokenId string) (*domain.Session, error) {
+	s := &domain.Session{}
+
+	result := r.db.WithContext(ctx).First(&s, "token_id = ?", tokenId)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return s, nil
+}
+
+func (r *sessionRepository) ListActiveSessionsByUserId(ctx context.Context, userId uint, now time.Time) ([]*domain.Session, error) {
+	ss := []*domain.Session{}
+
+	result := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, now).Order("last_seen_at DESC").Find(&ss)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return ss, nil
+}
+
+func (r *sessionRepository) RevokeSession(ctx context.Context, userId uint, id uint, revokedAt time.Time) error {
+
+	result := r.db.WithContext(ctx).Model(&domain.Session{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) RevokeOtherSessions(ctx context.Context, userId uint, exceptId uint, revokedAt time.Time) error {
+
+	result := r.db.WithContext(ctx).Model(&domain.Session{}).Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userId, exceptId).Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) RevokeImpersonationSessions(ctx context.Context, actorId uint, revokedAt time.Time) error {
+
+	result := r.db.WithContext(ctx).Model(&domain.Session{}).Where("actor_id = ? AND revoked_at IS NULL", actorId).Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+func (r *sessionRepository) UpdateSessionLastSeenAt(ctx context.Context, s *domain.Session) error {
+
+	result := r.db.WithContext(ctx).Model(&s).Where("id = ?", s.ID).Update("last_seen_at", s.LastSeenAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepositoryContext(t *testing.T) {
+
+	type callerKey struct{}
+	ctx := context.WithValue(context.Background(), callerKey{}, "request")
+
+	db, statements := newDryRunDB(t)
+	r, err := NewMysqlSessionRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both run on every authenticated request.
+	_, err = r.FindSessionByTokenId(ctx, "token-id")
+	assert.NoError(t, err, "Did not expect an error but got one")
+	err = r.UpdateSessionLastSeenAt(ctx, &domain.Session{ID: 3, LastSeenAt: time.Now()})
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	if assert.Len(t, *statements, 2, "Expected a read and an update") {
+		for _, s := range *statements {
+			assert.Equal(t, "request", s.ctx.Value(callerKey{}), "Expected statement to run with the context of the caller")
+		}
+	}
+}
+
+func TestSessionRepositoryRevokeImpersonationSessionsStatement(t *testing.T) {
+
+	db, statements := newDryRunDB(t)
+	r, err := NewMysqlSessionRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.RevokeImpersonationSessions(context.Background(), 2, time.Now())
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	if assert.Len(t, *statements, 1, "Expected a single update") {
+		assert.Contains(t, (*statements)[0].sql, "actor_id = ?", "Expected the sessions to be matched on their actor")
+		assert.Contains(t, (*statements)[0].sql, "revoked_at IS NULL", "Expected revoked sessions to be left as is")
+		assert.Contains(t, (*statements)[0].vars, uint(2), "Expected the actor")
+	}
+}
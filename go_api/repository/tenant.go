@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// tenantField is the field marking a model as owned by an organization.
+const tenantField = "OrganizationID"
+
+var (
+	ErrTenantRequired = errors.New("query on a tenant owned table without a tenant")
+	ErrTenantMismatch = errors.New("row belongs to another tenant")
+)
+
+type tenantContextKey struct{}
+
+// tenantScope is what a statement is allowed to see: the rows of a single
+// organization, or every row when allTenants is set.
+type tenantScope struct {
+	organizationId uint
+	allTenants     bool
+}
+
+// ContextWithTenant scopes the statements run with ctx to an organization.
+func ContextWithTenant(ctx context.Context, organizationId uint) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantScope{organizationId: organizationId})
+}
+
+// ContextWithAllTenants lets the statements run with ctx reach every
+// organization. It is meant for lookups made before the tenant is known, such
+// as finding a user by email on login.
+func ContextWithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantScope{allTenants: true})
+}
+
+func tenantFromContext(ctx context.Context) (tenantScope, bool) {
+	if ctx == nil {
+		return tenantScope{}, false
+	}
+	scope, ok := ctx.Value(tenantContextKey{}).(tenantScope)
+	return scope, ok
+}
+
+// RegisterTenantScope installs the callbacks isolating organizations. Every
+// query, update and delete on a model with an OrganizationID field is limited
+// to the tenant of the statement context, and creates are stamped with it.
+// Statements without a tenant fail with ErrTenantRequired, so a forgotten
+// scope is an error rather than a leak. Raw SQL is not inspected.
+func RegisterTenantScope(db *gorm.DB) error {
+	cb := db.Callback()
+
+	if err := cb.Create().Before("gorm:create").Register("tenant:create", assignTenant); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:query", scopeToTenant); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:update", scopeToTenant); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant:delete", scopeToTenant); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant:row", scopeToTenant); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func tenantOwned(db *gorm.DB) *schema.Field {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(tenantField)
+}
+
+func scopeToTenant(db *gorm.DB) {
+	field := tenantOwned(db)
+	if field == nil {
+		return
+	}
+
+	scope, ok := tenantFromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(ErrTenantRequired)
+		return
+	}
+	if scope.allTenants {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: db.Statement.Table, Name: field.DBName}, Value: scope.organizationId},
+	}})
+}
+
+func assignTenant(db *gorm.DB) {
+	field := tenantOwned(db)
+	if field == nil {
+		return
+	}
+
+	scope, ok := tenantFromContext(db.Statement.Context)
+	if !ok {
+		db.AddError(ErrTenantRequired)
+		return
+	}
+
+	assign := func(rv reflect.Value) {
+		value, zero := field.ValueOf(db.Statement.Context, rv)
+		switch {
+		case scope.allTenants:
+			// The caller picked the organization, it just has to pick one.
+			if zero {
+				db.AddError(ErrTenantRequired)
+			}
+		case zero:
+			if err := field.Set(db.Statement.Context, rv, scope.organizationId); err != nil {
+				db.AddError(err)
+			}
+		case value != scope.organizationId:
+			db.AddError(ErrTenantMismatch)
+		}
+	}
+
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			assign(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		assign(rv)
+	}
+}
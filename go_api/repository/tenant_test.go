@@ -0,0 +1,585 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// dryRunConnPool lets statements and transactions be built without a server,
+// nothing is ever sent to it.
+type dryRunConnPool struct{}
+
+var errDryRun = errors.New("dry run connection")
+
+func (p *dryRunConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (p *dryRunConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (p *dryRunConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (p *dryRunConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p *dryRunConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+
+func (p *dryRunConnPool) Commit() error {
+	return nil
+}
+
+func (p *dryRunConnPool) Rollback() error {
+	return nil
+}
+
+type recordedStatement struct {
+	sql  string
+	vars []interface{}
+	ctx  context.Context
+}
+
+// newDryRunDB returns a database that only builds statements, along with the
+// statements it produced.
+func newDryRunDB(t *testing.T) (*gorm.DB, *[]recordedStatement) {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      &dryRunConnPool{},
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = RegisterTenantScope(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements := &[]recordedStatement{}
+	record := func(db *gorm.DB) {
+		if db.Error != nil {
+			return
+		}
+		*statements = append(*statements, recordedStatement{sql: db.Statement.SQL.String(), vars: db.Statement.Vars, ctx: db.Statement.Context})
+	}
+	db.Callback().Create().After("gorm:create").Register("test:record", record)
+	db.Callback().Query().After("gorm:query").Register("test:record", record)
+	db.Callback().Update().After("gorm:update").Register("test:record", record)
+	db.Callback().Delete().After("gorm:delete").Register("test:record", record)
+
+	return db, statements
+}
+
+func newDryRunUserRepository(t *testing.T) (UserRepository, *[]recordedStatement) {
+	db, statements := newDryRunDB(t)
+
+	r, err := NewMysqlUserRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r, statements
+}
+
+func TestUserRepositoryTenantScope(t *testing.T) {
+
+	testCases := []struct {
+		name string
+		run  func(r UserRepository) error
+	}{
+		{
+			name: "ListUsers",
+			run: func(r UserRepository) error {
+				_, err := r.ListUsers(context.Background(), &domain.UserListQuery{Sort: domain.UserSortID, Limit: 20})
+				return err
+			},
+		},
+		{
+			name: "FindUserById",
+			run: func(r UserRepository) error {
+				_, err := r.FindUserById(context.Background(), 7)
+				return err
+			},
+		},
+		{
+			name: "FindUserByEmail",
+			run: func(r UserRepository) error {
+				_, err := r.FindUserByEmail(context.Background(), "user1@example.com")
+				return err
+			},
+		},
+		{
+			name: "UpdateUserPasswordHash",
+			run: func(r UserRepository) error {
+				return r.UpdateUserPasswordHash(context.Background(), 7, "old", "new")
+			},
+		},
+		{
+			name: "UpdateUserEmailVerifiedAt",
+			run: func(r UserRepository) error {
+				return r.UpdateUserEmailVerifiedAt(context.Background(), &domain.User{ID: 7})
+			},
+		},
+		{
+			name: "UpdateUserProfile",
+			run: func(r UserRepository) error {
+				return r.UpdateUserProfile(context.Background(), &domain.User{ID: 7, Name: "User1"})
+			},
+		},
+		{
+			name: "UpdateUserEmail",
+			run: func(r UserRepository) error {
+				return r.UpdateUserEmail(context.Background(), &domain.User{ID: 7, Email: "user1@example.com"})
+			},
+		},
+		{
+			name: "UpdateUserStatus",
+			run: func(r UserRepository) error {
+				return r.UpdateUserStatus(context.Background(), &domain.User{ID: 7, Status: domain.UserStatusSuspended})
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, statements := newDryRunUserRepository(t)
+
+			err := tc.run(r.ForTenant(2))
+			assert.NoError(t, err, "Did not expect an error but got one")
+			if assert.Len(t, *statements, 1, "Expected a single statement") {
+				s := (*statements)[0]
+				assert.Contains(t, s.sql, "`users`.`organization_id` = ?", "Expected statement to be scoped to the tenant")
+				assert.Contains(t, s.vars, uint(2), "Expected statement to be bound to the tenant")
+				assert.NotContains(t, s.vars, uint(3), "Expected statement not to reach another tenant")
+			}
+
+			*statements = nil
+			err = tc.run(r.AllTenants())
+			assert.NoError(t, err, "Did not expect an error but got one")
+			if assert.Len(t, *statements, 1, "Expected a single statement") {
+				assert.NotContains(t, (*statements)[0].sql, "organization_id", "Expected statement to reach every tenant")
+			}
+
+			*statements = nil
+			err = tc.run(r)
+			assert.ErrorIs(t, err, ErrTenantRequired, "Expected unscoped statement to be refused")
+			assert.Empty(t, *statements, "Expected unscoped statement not to be sent")
+		})
+	}
+}
+
+func TestUserRepositoryTenantScopeOnUpdatePassword(t *testing.T) {
+
+	r, statements := newDryRunUserRepository(t)
+
+	err := r.ForTenant(2).UpdateUserPassword(context.Background(), &domain.User{ID: 7, Password: "hash"})
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	// The current hash is read, then replaced, both within the tenant. The
+	// history insert in between is not tenant owned.
+	if assert.Len(t, *statements, 3, "Expected a read, an insert and an update") {
+		for _, i := range []int{0, 2} {
+			assert.Contains(t, (*statements)[i].sql, "`users`.`organization_id` = ?", "Expected statement to be scoped to the tenant")
+			assert.Contains(t, (*statements)[i].vars, uint(2), "Expected statement to be bound to the tenant")
+		}
+	}
+}
+
+func TestUserRepositoryContext(t *testing.T) {
+
+	type callerKey struct{}
+	ctx := context.WithValue(context.Background(), callerKey{}, "request")
+
+	r, statements := newDryRunUserRepository(t)
+
+	_, err := r.ForTenant(2).FindUserById(ctx, 7)
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	if assert.Len(t, *statements, 1, "Expected a single statement") {
+		s := (*statements)[0]
+		assert.Equal(t, "request", s.ctx.Value(callerKey{}), "Expected statement to run with the context of the caller")
+		assert.Contains(t, s.vars, uint(2), "Expected the tenant scope to be kept")
+	}
+
+	_, err = r.ForTenant(2).FindUserById(context.Background(), 7)
+	assert.NoError(t, err, "Did not expect an error but got one")
+	if assert.Len(t, *statements, 2, "Expected a second statement") {
+		assert.Nil(t, (*statements)[1].ctx.Value(callerKey{}), "Expected the context not to leak between calls")
+	}
+}
+
+func TestInvitationRepositoryContext(t *testing.T) {
+
+	type callerKey struct{}
+	ctx := context.WithValue(context.Background(), callerKey{}, "request")
+
+	db, statements := newDryRunDB(t)
+	r, err := NewMysqlInvitationRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.ForTenant(2).FindInvitationById(ctx, 7)
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	if assert.Len(t, *statements, 1, "Expected a single statement") {
+		s := (*statements)[0]
+		assert.Equal(t, "request", s.ctx.Value(callerKey{}), "Expected statement to run with the context of the caller")
+		assert.Contains(t, s.vars, uint(2), "Expected the tenant scope to be kept")
+	}
+}
+
+func TestUserRepositoryTenantScopeOnCreate(t *testing.T) {
+
+	testCases := []struct {
+		name                   string
+		allTenants             bool
+		organizationId         uint
+		expectedOrganizationId uint
+		expectedError          error
+	}{
+		{
+			name:                   "Assigned to the tenant",
+			organizationId:         0,
+			expectedOrganizationId: 2,
+		},
+		{
+			name:                   "Same tenant",
+			organizationId:         2,
+			expectedOrganizationId: 2,
+		},
+		{
+			name:           "Other tenant",
+			organizationId: 3,
+			expectedError:  ErrTenantMismatch,
+		},
+		{
+			name:                   "All tenants with an explicit organization",
+			allTenants:             true,
+			organizationId:         3,
+			expectedOrganizationId: 3,
+		},
+		{
+			name:           "All tenants without an organization",
+			allTenants:     true,
+			organizationId: 0,
+			expectedError:  ErrTenantRequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, statements := newDryRunUserRepository(t)
+
+			scoped := r.ForTenant(2)
+			if tc.allTenants {
+				scoped = r.AllTenants()
+			}
+
+			u, err := scoped.CreateUser(context.Background(), &domain.User{OrganizationID: tc.organizationId, Name: "User1", Email: "user1@example.com"})
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError, "Expected CreateUser error to match.")
+				assert.Nil(t, u, "Expected user to be nil")
+				assert.Empty(t, *statements, "Expected refused insert not to be sent")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, tc.expectedOrganizationId, u.OrganizationID, "Expected organization to match.")
+				if assert.Len(t, *statements, 1, "Expected a single statement") {
+					assert.Contains(t, (*statements)[0].vars, tc.expectedOrganizationId, "Expected insert to carry the organization")
+				}
+			}
+		})
+	}
+
+	r, _ := newDryRunUserRepository(t)
+	_, err := r.CreateUser(context.Background(), &domain.User{OrganizationID: 2, Name: "User1", Email: "user1@example.com"})
+	assert.ErrorIs(t, err, ErrTenantRequired, "Expected unscoped insert to be refused")
+}
+
+func TestUserRepositoryCreateUsers(t *testing.T) {
+	r, statements := newDryRunUserRepository(t)
+
+	us := []*domain.User{
+		{Name: "User1", Email: "user1@example.com"},
+		{Name: "User2", Email: "user2@example.com"},
+	}
+
+	err := r.ForTenant(2).CreateUsers(context.Background(), us, 100)
+	assert.NoError(t, err, "Did not expect an error but got one")
+	for _, u := range us {
+		assert.Equal(t, uint(2), u.OrganizationID, "Expected users to be assigned to the tenant")
+	}
+	if assert.Len(t, *statements, 1, "Expected a single batch") {
+		s := (*statements)[0]
+		assert.Contains(t, s.sql, "INSERT INTO `users`", "Expected statement to match")
+		assert.Contains(t, s.vars, "user1@example.com", "Expected insert to carry every user")
+		assert.Contains(t, s.vars, "user2@example.com", "Expected insert to carry every user")
+		assert.Contains(t, s.vars, uint(2), "Expected insert to carry the organization")
+	}
+
+	r, statements = newDryRunUserRepository(t)
+	err = r.ForTenant(2).CreateUsers(context.Background(), []*domain.User{{OrganizationID: 3, Name: "User1", Email: "user1@example.com"}}, 2)
+	assert.ErrorIs(t, err, ErrTenantMismatch, "Expected insert into another tenant to be refused")
+	assert.Empty(t, *statements, "Expected refused insert not to be sent")
+}
+
+func TestUserRepositoryFindTakenEmails(t *testing.T) {
+	r, statements := newDryRunUserRepository(t)
+
+	_, err := r.AllTenants().FindTakenEmails(context.Background(), []string{"user1@example.com", "user2@example.com"})
+	assert.NoError(t, err, "Did not expect an error but got one")
+	if assert.Len(t, *statements, 1, "Expected a single statement") {
+		s := (*statements)[0]
+		assert.Contains(t, s.sql, "SELECT `email` FROM `users` WHERE email IN (?,?)", "Expected statement to match")
+		assert.Contains(t, s.sql, "`users`.`deleted_at` IS NULL", "Expected deleted users to be left out")
+		assert.NotContains(t, s.sql, "organization_id", "Expected every organization to be searched")
+	}
+}
+
+func TestUserRepositoryLifecycle(t *testing.T) {
+
+	testCases := []struct {
+		name               string
+		run                func(r UserRepository) error
+		expectedSQL        []string
+		expectedStatements int
+	}{
+		{
+			name:               "DeleteUser",
+			run:                func(r UserRepository) error { return r.DeleteUser(context.Background(), 7) },
+			expectedSQL:        []string{"UPDATE `users` SET", "`deleted_at`=?", "`status`=?", "`users`.`deleted_at` IS NULL"},
+			expectedStatements: 1,
+		},
+		{
+			name:               "RestoreUser",
+			run:                func(r UserRepository) error { return r.RestoreUser(context.Background(), 7) },
+			expectedSQL:        []string{"UPDATE `users` SET", "`deleted_at`=?", "`status`=?", "deleted_at IS NOT NULL AND status <> ?"},
+			expectedStatements: 2,
+		},
+		{
+			name:               "PurgeUser",
+			run:                func(r UserRepository) error { return r.PurgeUser(context.Background(), 7) },
+			expectedSQL:        []string{"DELETE FROM `users`", "deleted_at IS NOT NULL AND status <> ?"},
+			expectedStatements: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, statements := newDryRunUserRepository(t)
+
+			// Nothing is ever affected by a dry run, so the user is reported
+			// as missing. Restoring and purging then look for an erased user
+			// to tell why.
+			err := tc.run(r.ForTenant(2))
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Expected dry run to affect no row")
+			if assert.Len(t, *statements, tc.expectedStatements, "Expected statements count to match") {
+				for _, sql := range tc.expectedSQL {
+					assert.Contains(t, (*statements)[0].sql, sql, "Expected statement to match")
+				}
+				if tc.expectedStatements > 1 {
+					assert.Contains(t, (*statements)[0].vars, domain.UserStatusErased, "Expected erased users to be left alone")
+					assert.Contains(t, (*statements)[1].sql, "SELECT count(*) FROM `users` WHERE (id = ? AND status = ?)", "Expected lookup of an erased user")
+				}
+				for _, s := range *statements {
+					assert.Contains(t, s.sql, "`users`.`organization_id` = ?", "Expected statement to be scoped to the tenant")
+					assert.Contains(t, s.vars, uint(2), "Expected statement to be bound to the tenant")
+				}
+			}
+		})
+	}
+}
+
+func TestUserRepositoryListUsers(t *testing.T) {
+
+	createdFrom := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		query        *domain.UserListQuery
+		expectedSQL  []string
+		expectedVars []interface{}
+	}{
+		{
+			name:        "First page",
+			query:       &domain.UserListQuery{Sort: domain.UserSortID, Limit: 21},
+			expectedSQL: []string{"ORDER BY id ASC LIMIT ?"},
+		},
+		{
+			name: "Filters",
+			query: &domain.UserListQuery{
+				NameContains: "50%_off",
+				Email:        "user@example.com",
+				Status:       domain.UserStatusActive,
+				CreatedFrom:  &createdFrom,
+				Sort:         domain.UserSortID,
+				Limit:        21,
+			},
+			expectedSQL:  []string{"name LIKE ?", "email = ?", "status = ?", "created_at >= ?"},
+			expectedVars: []interface{}{`%50\%\_off%`, "user@example.com", domain.UserStatusActive},
+		},
+		{
+			name:         "After id",
+			query:        &domain.UserListQuery{Sort: domain.UserSortID, Desc: true, Limit: 21, After: &domain.UserCursor{Sort: domain.UserSortID, Desc: true, ID: 9}},
+			expectedSQL:  []string{"id < ?", "ORDER BY id DESC"},
+			expectedVars: []interface{}{uint(9)},
+		},
+		{
+			name:         "After name",
+			query:        &domain.UserListQuery{Sort: domain.UserSortName, Limit: 21, After: &domain.UserCursor{Sort: domain.UserSortName, ID: 9, Value: "User9"}},
+			expectedSQL:  []string{"name >= ? AND (name > ? OR id > ?)", "ORDER BY name ASC, id ASC"},
+			expectedVars: []interface{}{"User9", uint(9)},
+		},
+		{
+			name:         "Before created at",
+			query:        &domain.UserListQuery{Sort: domain.UserSortCreatedAt, Desc: true, Limit: 21, After: &domain.UserCursor{Sort: domain.UserSortCreatedAt, Desc: true, ID: 9, Value: createdFrom}},
+			expectedSQL:  []string{"created_at <= ? AND (created_at < ? OR id < ?)", "ORDER BY created_at DESC, id DESC"},
+			expectedVars: []interface{}{createdFrom, uint(9)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, statements := newDryRunUserRepository(t)
+
+			_, err := r.ForTenant(2).ListUsers(context.Background(), tc.query)
+			assert.NoError(t, err, "Expected no error")
+			if assert.Len(t, *statements, 1, "Expected a single statement") {
+				s := (*statements)[0]
+				for _, sql := range tc.expectedSQL {
+					assert.Contains(t, s.sql, sql, "Expected statement to match")
+				}
+				for _, v := range tc.expectedVars {
+					assert.Contains(t, s.vars, v, "Expected statement to be bound to the query")
+				}
+				assert.Contains(t, s.sql, "`users`.`deleted_at` IS NULL", "Expected deleted users to be left out")
+				assert.Contains(t, s.sql, "`users`.`organization_id` = ?", "Expected statement to be scoped to the tenant")
+			}
+		})
+	}
+}
+
+func TestUserRepositoryFindUserData(t *testing.T) {
+	r, statements := newDryRunUserRepository(t)
+
+	_, err := r.ForTenant(2).FindUserData(context.Background(), 7)
+	assert.NoError(t, err, "Expected no error")
+	if assert.NotEmpty(t, *statements, "Expected statements") {
+		s := (*statements)[0]
+		assert.Contains(t, s.sql, "`users`.`organization_id` = ?", "Expected the user lookup to be scoped to the tenant")
+		assert.NotContains(t, s.sql, "deleted_at", "Expected deleted users to be exported too")
+	}
+
+	tables := []string{"`sessions`", "`api_keys`", "`user_identities`", "`password_histories`", "`email_change_tokens`", "`consents`", "`invitations`", "`totp_credentials`", "`user_preferences`"}
+	for _, table := range tables {
+		found := false
+		for _, s := range *statements {
+			if strings.Contains(s.sql, "FROM "+table) {
+				found = true
+			}
+		}
+		assert.True(t, found, "Expected %s to be exported", table)
+	}
+}
+
+func TestUserRepositoryAnonymizeUser(t *testing.T) {
+	r, statements := newDryRunUserRepository(t)
+
+	// Nothing is ever affected by a dry run, so the user is reported as
+	// missing once their current email was read, and nothing else is run.
+	err := r.ForTenant(2).AnonymizeUser(context.Background(), &domain.User{ID: 7, Name: "Erased user", Email: "erased-7@erased.invalid"}, nil)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Expected dry run to affect no row")
+	if assert.Len(t, *statements, 2, "Expected the user to be read then updated") {
+		s := (*statements)[0]
+		for _, sql := range []string{"SELECT `email` FROM `users`", "`users`.`organization_id` = ?", "FOR UPDATE"} {
+			assert.Contains(t, s.sql, sql, "Expected statement to match")
+		}
+
+		s = (*statements)[1]
+		for _, sql := range []string{"UPDATE `users` SET", "`email`=?", "`name`=?", "`password`=?", "`status`=?", "`deleted_at`=?", "`users`.`organization_id` = ?"} {
+			assert.Contains(t, s.sql, sql, "Expected statement to match")
+		}
+		assert.NotContains(t, s.sql, "`users`.`deleted_at` IS NULL", "Expected deleted users to be erasable")
+		assert.Contains(t, s.vars, "erased-7@erased.invalid", "Expected the anonymized email")
+		assert.Contains(t, s.vars, domain.UserStatusErased, "Expected the user to be marked as erased")
+	}
+}
+
+func TestInvitationRepositoryTenantScope(t *testing.T) {
+
+	testCases := []struct {
+		name string
+		run  func(r InvitationRepository) error
+	}{
+		{
+			name: "ListPendingInvitations",
+			run: func(r InvitationRepository) error {
+				_, err := r.ListPendingInvitations(context.Background(), time.Now())
+				return err
+			},
+		},
+		{
+			name: "FindInvitationById",
+			run: func(r InvitationRepository) error {
+				_, err := r.FindInvitationById(context.Background(), 7)
+				return err
+			},
+		},
+		{
+			name: "DeleteInvitation",
+			run: func(r InvitationRepository) error {
+				return r.DeleteInvitation(context.Background(), 7)
+			},
+		},
+		{
+			name: "AcceptInvitation",
+			run: func(r InvitationRepository) error {
+				_, err := r.AcceptInvitation(context.Background(), &domain.Invitation{ID: 7}, &domain.User{Name: "User1", Email: "user1@example.com"})
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, statements := newDryRunDB(t)
+			r, err := NewMysqlInvitationRepository(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Nothing is ever affected by a dry run, so deletes and updates
+			// report the row as missing.
+			err = tc.run(r.ForTenant(2))
+			if err != nil {
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Did not expect an error but got one")
+			}
+			if assert.Len(t, *statements, 1, "Expected a single statement") {
+				s := (*statements)[0]
+				assert.Contains(t, s.sql, "`invitations`.`organization_id` = ?", "Expected statement to be scoped to the tenant")
+				assert.Contains(t, s.vars, uint(2), "Expected statement to be bound to the tenant")
+			}
+
+			*statements = nil
+			err = tc.run(r)
+			assert.ErrorIs(t, err, ErrTenantRequired, "Expected unscoped statement to be refused")
+			assert.Empty(t, *statements, "Expected unscoped statement not to be sent")
+		})
+	}
+}
@@ -1,35 +1,86 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Daffc/GO-Sales/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrUserErased is returned when restoring or purging a user that was erased
+// rather than deleted.
+var ErrUserErased = errors.New("user was erased")
+
+// UserRepository reads and writes users. Users are owned by an organization,
+// so the repository returned by the constructor refuses every statement until
+// it is scoped with ForTenant, or explicitly opened with AllTenants.
 type UserRepository interface {
-	CreateUser(u *domain.User) (*domain.User, error)
-	ListUsers() ([]*domain.User, error)
-	FindUserById(id uint) (*domain.User, error)
-	FindUserByEmail(email string) (*domain.User, error)
-	UpdateUserPassword(u *domain.User) error
+	ForTenant(organizationId uint) UserRepository
+	AllTenants() UserRepository
+	CreateUser(ctx context.Context, u *domain.User) (*domain.User, error)
+	CreateUsers(ctx context.Context, us []*domain.User, batchSize int) error
+	ListUsers(ctx context.Context, q *domain.UserListQuery) ([]*domain.User, error)
+	FindUserById(ctx context.Context, id uint) (*domain.User, error)
+	FindUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	FindTakenEmails(ctx context.Context, emails []string) ([]string, error)
+	UpdateUserPassword(ctx context.Context, u *domain.User) error
+	UpdateUserPasswordHash(ctx context.Context, userId uint, oldHash string, newHash string) error
+	ListPasswordHistory(ctx context.Context, userId uint, limit int) ([]*domain.PasswordHistory, error)
+	UpdateUserEmailVerifiedAt(ctx context.Context, u *domain.User) error
+	UpdateUserProfile(ctx context.Context, u *domain.User) error
+	UpdateUserEmail(ctx context.Context, u *domain.User) error
+	UpdateUserStatus(ctx context.Context, u *domain.User) error
+	DeleteUser(ctx context.Context, id uint) error
+	RestoreUser(ctx context.Context, id uint) error
+	PurgeUser(ctx context.Context, id uint) error
+	FindUserData(ctx context.Context, id uint) (*domain.UserData, error)
+	AnonymizeUser(ctx context.Context, u *domain.User, withdrawals []*domain.Consent) error
 }
 
 type userRepository struct {
 	db *gorm.DB
+	// tenant scopes the context of every statement, unset until ForTenant or
+	// AllTenants is called.
+	tenant func(ctx context.Context) context.Context
 }
 
 func NewMysqlUserRepository(db *gorm.DB) (UserRepository, error) {
 	return &userRepository{db: db}, nil
 }
 
-func (r *userRepository) CreateUser(u *domain.User) (*domain.User, error) {
+// ForTenant returns a repository only seeing the users of an organization.
+func (r *userRepository) ForTenant(organizationId uint) UserRepository {
+	return &userRepository{db: r.db, tenant: func(ctx context.Context) context.Context {
+		return ContextWithTenant(ctx, organizationId)
+	}}
+}
+
+// AllTenants returns a repository seeing the users of every organization.
+func (r *userRepository) AllTenants() UserRepository {
+	return &userRepository{db: r.db, tenant: ContextWithAllTenants}
+}
+
+// session runs statements with ctx, so they are cancelled along with it, within
+// the tenant scope of the repository.
+func (r *userRepository) session(ctx context.Context) *gorm.DB {
+	if r.tenant != nil {
+		ctx = r.tenant(ctx)
+	}
+
+	return r.db.WithContext(ctx)
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, u *domain.User) (*domain.User, error) {
 
 	u.CreatedAt = time.Now()
 	u.UpdatedAt = time.Now()
 
-	result := r.db.Create(u)
+	result := r.session(ctx).Create(u)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -37,14 +88,82 @@ func (r *userRepository) CreateUser(u *domain.User) (*domain.User, error) {
 	return u, nil
 }
 
-func (r *userRepository) ListUsers() ([]*domain.User, error) {
-	us := []*domain.User{}
+// CreateUsers inserts us batchSize rows at a time. GORM runs the batches within
+// a single transaction, so either all of them are created or none is. A taken
+// email fails with gorm.ErrDuplicatedKey.
+func (r *userRepository) CreateUsers(ctx context.Context, us []*domain.User, batchSize int) error {
+
+	now := time.Now()
+	for _, u := range us {
+		u.CreatedAt = now
+		u.UpdatedAt = now
+	}
+
+	result := r.session(ctx).CreateInBatches(us, batchSize)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// FindTakenEmails returns which of emails belong to a live user.
+func (r *userRepository) FindTakenEmails(ctx context.Context, emails []string) ([]string, error) {
+	taken := []string{}
 
-	result := r.db.Find(&us)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	result := r.session(ctx).Model(&domain.User{}).Where("email IN ?", emails).Pluck("email", &taken)
+	if result.Error != nil {
 		return nil, result.Error
 	}
 
+	return taken, nil
+}
+
+// ListUsers returns a page of users matching q, in the order it asks for. The
+// sort field is used as a column name and must be one of
+// domain.UserSortFields, as checked by UserListQuery.Validate.
+func (r *userRepository) ListUsers(ctx context.Context, q *domain.UserListQuery) ([]*domain.User, error) {
+	us := []*domain.User{}
+
+	tx := r.session(ctx)
+	if len(q.NameContains) > 0 {
+		tx = tx.Where("name LIKE ?", "%"+likeEscaper.Replace(q.NameContains)+"%")
+	}
+	if len(q.Email) > 0 {
+		tx = tx.Where("email = ?", q.Email)
+	}
+	if len(q.Status) > 0 {
+		tx = tx.Where("status = ?", q.Status)
+	}
+	if q.CreatedFrom != nil {
+		tx = tx.Where("created_at >= ?", q.CreatedFrom)
+	}
+	if q.CreatedTo != nil {
+		tx = tx.Where("created_at <= ?", q.CreatedTo)
+	}
+
+	direction, comparison := "ASC", ">"
+	if q.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if q.After != nil {
+		if q.Sort == domain.UserSortID {
+			tx = tx.Where(fmt.Sprintf("id %s ?", comparison), q.After.ID)
+		} else {
+			// Same as (sort, id) > (value, id), spelled with a bound on the
+			// sort field alone so the page is read as a range of the index
+			// on (organization_id, sort, id).
+			tx = tx.Where(fmt.Sprintf("%[1]s %[2]s= ? AND (%[1]s %[2]s ? OR id %[2]s ?)", q.Sort, comparison), q.After.Value, q.After.Value, q.After.ID)
+		}
+	}
+
+	order := fmt.Sprintf("id %s", direction)
+	if q.Sort != domain.UserSortID {
+		order = fmt.Sprintf("%s %s, %s", q.Sort, direction, order)
+	}
+
+	result := tx.Order(order).Limit(q.Limit).Find(&us)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -52,10 +171,13 @@ func (r *userRepository) ListUsers() ([]*domain.User, error) {
 	return us, nil
 }
 
-func (r *userRepository) FindUserById(id uint) (*domain.User, error) {
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *userRepository) FindUserById(ctx context.Context, id uint) (*domain.User, error) {
 	u := &domain.User{}
 
-	result := r.db.First(&u, "id = ?", id)
+	result := r.session(ctx).First(&u, "id = ?", id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -63,10 +185,10 @@ func (r *userRepository) FindUserById(id uint) (*domain.User, error) {
 	return u, nil
 }
 
-func (r *userRepository) FindUserByEmail(email string) (*domain.User, error) {
+func (r *userRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	u := &domain.User{}
 
-	result := r.db.First(&u, "email = ?", email)
+	result := r.session(ctx).First(&u, "email = ?", email)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -74,12 +196,292 @@ func (r *userRepository) FindUserByEmail(email string) (*domain.User, error) {
 	return u, nil
 }
 
-func (r *userRepository) UpdateUserPassword(u *domain.User) error {
+// UpdateUserPassword replaces the password hash of u, keeping the replaced one
+// in the password history.
+func (r *userRepository) UpdateUserPassword(ctx context.Context, u *domain.User) error {
+
+	return r.session(ctx).Transaction(func(tx *gorm.DB) error {
+		current := &domain.User{}
+		result := tx.Select("id", "password").First(&current, "id = ?", u.ID)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	result := r.db.Model(&u).Where("id = ?", u.ID).Update("password", u.Password)
+		result = tx.Create(&domain.PasswordHistory{
+			UserID:    u.ID,
+			Password:  current.Password,
+			CreatedAt: time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Model(&u).Where("id = ?", u.ID).Update("password", u.Password)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return nil
+	})
+}
+
+// UpdateUserPasswordHash replaces a hash of the same password, as done when
+// upgrading the hashing algorithm, so the password history is left untouched.
+// Nothing is updated if the password was changed since oldHash was read.
+func (r *userRepository) UpdateUserPasswordHash(ctx context.Context, userId uint, oldHash string, newHash string) error {
+
+	result := r.session(ctx).Model(&domain.User{}).Where("id = ? AND password = ?", userId, oldHash).Update("password", newHash)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// ListPasswordHistory returns the limit most recently replaced password hashes
+// of a user, newest first.
+func (r *userRepository) ListPasswordHistory(ctx context.Context, userId uint, limit int) ([]*domain.PasswordHistory, error) {
+	hs := []*domain.PasswordHistory{}
+
+	result := r.session(ctx).Where("user_id = ?", userId).Order("id DESC").Limit(limit).Find(&hs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return hs, nil
+}
+
+func (r *userRepository) UpdateUserEmailVerifiedAt(ctx context.Context, u *domain.User) error {
+
+	result := r.session(ctx).Model(&u).Where("id = ?", u.ID).Update("email_verified_at", u.EmailVerifiedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// UpdateUserProfile saves the fields a user can edit on their own.
+func (r *userRepository) UpdateUserProfile(ctx context.Context, u *domain.User) error {
+
+	result := r.session(ctx).Model(&u).Where("id = ?", u.ID).Select("name").Updates(u)
 	if result.Error != nil {
 		return result.Error
 	}
 
 	return nil
 }
+
+// UpdateUserEmail replaces the email of u along with when it was verified. A
+// taken address fails with gorm.ErrDuplicatedKey.
+func (r *userRepository) UpdateUserEmail(ctx context.Context, u *domain.User) error {
+
+	result := r.session(ctx).Model(&u).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"email":             u.Email,
+		"email_verified_at": u.EmailVerifiedAt,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// UpdateUserStatus saves the status of a live user.
+func (r *userRepository) UpdateUserStatus(ctx context.Context, u *domain.User) error {
+
+	result := r.session(ctx).Model(&u).Where("id = ?", u.ID).Update("status", u.Status)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
+
+// DeleteUser soft deletes a live user, freeing their email for new accounts.
+// It fails with gorm.ErrRecordNotFound when there is no such live user.
+func (r *userRepository) DeleteUser(ctx context.Context, id uint) error {
+
+	result := r.session(ctx).Model(&domain.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     domain.UserStatusDeleted,
+		"deleted_at": time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RestoreUser brings back a soft deleted user as active. It fails with
+// gorm.ErrRecordNotFound when there is no such deleted user, with
+// ErrUserErased when they were erased, and with gorm.ErrDuplicatedKey when a
+// live user took the email meanwhile.
+func (r *userRepository) RestoreUser(ctx context.Context, id uint) error {
+
+	result := r.session(ctx).Unscoped().Model(&domain.User{}).Where("id = ? AND deleted_at IS NOT NULL AND status <> ?", id, domain.UserStatusErased).Updates(map[string]interface{}{
+		"status":     domain.UserStatusActive,
+		"deleted_at": nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.notDeleted(ctx, id)
+	}
+
+	return nil
+}
+
+// PurgeUser permanently removes a soft deleted user along with everything
+// referencing them. It fails with gorm.ErrRecordNotFound when there is no such
+// deleted user, so live accounts have to be deleted first, and with
+// ErrUserErased when they were erased, as their consent history must stay.
+func (r *userRepository) PurgeUser(ctx context.Context, id uint) error {
+
+	result := r.session(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL AND status <> ?", id, domain.UserStatusErased).Delete(&domain.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.notDeleted(ctx, id)
+	}
+
+	return nil
+}
+
+// notDeleted tells why no soft deleted user id was found: either they were
+// erased, or there is no such deleted user at all.
+func (r *userRepository) notDeleted(ctx context.Context, id uint) error {
+
+	var count int64
+	result := r.session(ctx).Unscoped().Model(&domain.User{}).Where("id = ? AND status = ?", id, domain.UserStatusErased).Count(&count)
+	if result.Error != nil {
+		return result.Error
+	}
+	if count > 0 {
+		return ErrUserErased
+	}
+
+	return gorm.ErrRecordNotFound
+}
+
+// FindUserData gathers everything held about a user, deleted or not.
+func (r *userRepository) FindUserData(ctx context.Context, id uint) (*domain.UserData, error) {
+	db := r.session(ctx)
+	d := &domain.UserData{User: &domain.User{}, Organization: &domain.Organization{}}
+
+	result := db.Unscoped().First(&d.User, "id = ?", id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = db.First(&d.Organization, "id = ?", d.User.OrganizationID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, rows := range []interface{}{&d.Sessions, &d.ApiKeys, &d.Identities, &d.PasswordHistory, &d.EmailChanges, &d.Consents} {
+		result = db.Where("user_id = ?", id).Order("id").Find(rows)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+
+	result = db.Where("invited_by_id = ?", id).Order("id").Find(&d.InvitationsSent)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	preferences := []*domain.UserPreferences{}
+	result = db.Where("user_id = ?", id).Find(&preferences)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(preferences) > 0 {
+		d.Preferences = preferences[0]
+	}
+
+	credentials := []*domain.TotpCredential{}
+	result = db.Where("user_id = ?", id).Find(&credentials)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(credentials) > 0 {
+		d.TotpCredential = credentials[0]
+	}
+
+	return d, nil
+}
+
+// AnonymizeUser overwrites the name, email and password of u in place, marks
+// them as erased and drops the data only meaningful to them, such as
+// sessions, keys and linked identities, as well as the invitations sent to
+// their former email. The row itself is kept so whatever references it stays
+// valid. withdrawals are recorded in the consent history along with it. It
+// fails with gorm.ErrRecordNotFound when there is no such user.
+func (r *userRepository) AnonymizeUser(ctx context.Context, u *domain.User, withdrawals []*domain.Consent) error {
+
+	return r.session(ctx).Transaction(func(tx *gorm.DB) error {
+		current := &domain.User{}
+		result := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("email").First(current, u.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Unscoped().Model(&domain.User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+			"name":              u.Name,
+			"email":             u.Email,
+			"password":          u.Password,
+			"email_verified_at": nil,
+			"status":            domain.UserStatusErased,
+			"deleted_at":        time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		result = tx.Where("email = ?", current.Email).Delete(&domain.Invitation{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		owned := []interface{}{
+			&domain.Session{},
+			&domain.ApiKey{},
+			&domain.UserIdentity{},
+			&domain.OidcLoginState{},
+			&domain.TotpCredential{},
+			&domain.MfaRecoveryCode{},
+			&domain.PasswordHistory{},
+			&domain.EmailVerificationToken{},
+			&domain.EmailChangeToken{},
+			&domain.UserPreferences{},
+		}
+		for _, model := range owned {
+			result = tx.Where("user_id = ?", u.ID).Delete(model)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		for _, c := range withdrawals {
+			c.UserID = u.ID
+			c.CreatedAt = time.Now()
+		}
+		if len(withdrawals) > 0 {
+			result = tx.Create(&withdrawals)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		return nil
+	})
+}
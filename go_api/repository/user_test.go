@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestUserRepositoryEraseIsFinal(t *testing.T) {
+
+	db := newMariaDBTestDB(t)
+	org := &domain.Organization{Name: fmt.Sprintf("Erasure %d", time.Now().UnixNano())}
+	if err := db.Create(org).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.WithContext(ContextWithAllTenants(context.Background())).Unscoped().Where("organization_id = ?", org.ID).Delete(&domain.User{})
+		db.Delete(org)
+	})
+
+	ctx := context.Background()
+	users, err := NewMysqlUserRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users = users.ForTenant(org.ID)
+	invitations, err := NewMysqlInvitationRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitations = invitations.ForTenant(org.ID)
+
+	admin, err := users.CreateUser(ctx, &domain.User{Name: "Admin", Email: fmt.Sprintf("admin-%d@example.com", org.ID), Role: domain.RoleAdmin, Status: domain.UserStatusActive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	email := fmt.Sprintf("user-%d@example.com", org.ID)
+	user, err := users.CreateUser(ctx, &domain.User{Name: "User1", Email: email, Role: domain.RoleUser, Status: domain.UserStatusActive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitation, err := invitations.CreateInvitation(ctx, &domain.Invitation{Email: email, Role: domain.RoleUser, InvitedByID: admin.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = users.AnonymizeUser(ctx, &domain.User{ID: user.ID, Name: "Erased user", Email: fmt.Sprintf("erased-%d@erased.invalid", user.ID)}, nil)
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	d, err := users.FindUserData(ctx, user.ID)
+	if assert.NoError(t, err, "Did not expect an error but got one") {
+		assert.Equal(t, domain.UserStatusErased, d.User.Status, "Expected the user to be marked as erased")
+	}
+	_, err = invitations.FindInvitationById(ctx, invitation.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Expected the invitation to the former email to be deleted")
+
+	err = users.RestoreUser(ctx, user.ID)
+	assert.ErrorIs(t, err, ErrUserErased, "Expected the erased user not to be restored")
+	err = users.PurgeUser(ctx, user.ID)
+	assert.ErrorIs(t, err, ErrUserErased, "Expected the erased user not to be purged")
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeySize       = 32
+	apiKeyPrefixSize = 12
+	// apiKeyLastUsedResolution limits how often a busy key writes its
+	// last-used timestamp.
+	apiKeyLastUsedResolution = time.Minute
+)
+
+type ApiKeyUseCase interface {
+	// CreateApiKey, ListApiKeys and RevokeApiKey only reach users of the
+	// organization tenantId.
+	CreateApiKey(ctx context.Context, tenantId uint, userId uint, input *dto.ApiKeyInputDTO) (*dto.ApiKeyCreatedOutputDTO, error)
+	ListApiKeys(ctx context.Context, tenantId uint, userId uint) ([]*dto.ApiKeyOutputDTO, error)
+	RevokeApiKey(ctx context.Context, tenantId uint, userId uint, keyId uint) error
+	AuthenticateApiKey(ctx context.Context, key string) (*domain.User, []string, error)
+}
+
+type apiKeyUseCase struct {
+	apiKeyRepository repository.ApiKeyRepository
+	userRepository   repository.UserRepository
+	now              func() time.Time
+}
+
+func NewApiKeyUseCase(apiKeyRepository repository.ApiKeyRepository, userRepository repository.UserRepository) ApiKeyUseCase {
+	return &apiKeyUseCase{
+		apiKeyRepository: apiKeyRepository,
+		userRepository:   userRepository,
+		now:              time.Now,
+	}
+}
+
+func (uc *apiKeyUseCase) CreateApiKey(ctx context.Context, tenantId uint, userId uint, input *dto.ApiKeyInputDTO) (*dto.ApiKeyCreatedOutputDTO, error) {
+	k := &domain.ApiKey{
+		UserID:    userId,
+		Name:      strings.TrimSpace(input.Name),
+		Scopes:    strings.Join(input.Scopes, " "),
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	if err := k.ValidateName(); err != nil {
+		return nil, err
+	}
+	if err := k.ValidateScopes(); err != nil {
+		return nil, err
+	}
+	if err := k.ValidateExpiresAt(uc.now()); err != nil {
+		return nil, err
+	}
+
+	_, err := uc.userRepository.ForTenant(tenantId).FindUserById(ctx, userId)
+	if err != nil {
+		return nil, userNotFound(err)
+	}
+
+	secret, err := util.NewOpaqueToken(apiKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	key := domain.ApiKeyPrefix + secret
+	k.Prefix = key[:apiKeyPrefixSize]
+	k.KeyHash = util.HashOpaqueToken(key)
+
+	k, err = uc.apiKeyRepository.CreateApiKey(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ApiKeyCreatedOutputDTO{
+		ApiKeyOutputDTO: *newApiKeyOutputDTO(k),
+		Key:             key,
+	}, nil
+}
+
+func (uc *apiKeyUseCase) ListApiKeys(ctx context.Context, tenantId uint, userId uint) ([]*dto.ApiKeyOutputDTO, error) {
+	_, err := uc.userRepository.ForTenant(tenantId).FindUserById(ctx, userId)
+	if err != nil {
+		return nil, userNotFound(err)
+	}
+
+	ks, err := uc.apiKeyRepository.ListApiKeysByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*dto.ApiKeyOutputDTO, len(ks))
+	for i, k := range ks {
+		output[i] = newApiKeyOutputDTO(k)
+	}
+
+	return output, nil
+}
+
+func (uc *apiKeyUseCase) RevokeApiKey(ctx context.Context, tenantId uint, userId uint, keyId uint) error {
+	_, err := uc.userRepository.ForTenant(tenantId).FindUserById(ctx, userId)
+	if err != nil {
+		return userNotFound(err)
+	}
+
+	err = uc.apiKeyRepository.DeleteApiKey(ctx, userId, keyId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.ErrApiKeyNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AuthenticateApiKey resolves a raw API key to its owner and granted scopes.
+func (uc *apiKeyUseCase) AuthenticateApiKey(ctx context.Context, key string) (*domain.User, []string, error) {
+	if !strings.HasPrefix(key, domain.ApiKeyPrefix) {
+		return nil, nil, domain.ErrApiKeyInvalid
+	}
+
+	k, err := uc.apiKeyRepository.FindApiKeyByHash(ctx, util.HashOpaqueToken(key))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, nil, domain.ErrApiKeyInvalid
+		default:
+			return nil, nil, err
+		}
+	}
+
+	now := uc.now()
+	if k.IsExpired(now) {
+		return nil, nil, domain.ErrApiKeyInvalid
+	}
+
+	u, err := uc.userRepository.AllTenants().FindUserById(ctx, k.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, nil, domain.ErrApiKeyInvalid
+		default:
+			return nil, nil, err
+		}
+	}
+
+	if u.IsSuspended() {
+		return nil, nil, domain.ErrUserSuspended
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyLastUsedResolution {
+		k.LastUsedAt = &now
+		err = uc.apiKeyRepository.UpdateApiKeyLastUsedAt(ctx, k)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return u, k.ScopeList(), nil
+}
+
+func newApiKeyOutputDTO(k *domain.ApiKey) *dto.ApiKeyOutputDTO {
+	return &dto.ApiKeyOutputDTO{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.ScopeList(),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newTestApiKeyUseCase(apiKeyRepository *mockApiKeyRepository, userRepository *mockUserRepository, now time.Time) *apiKeyUseCase {
+	uc := NewApiKeyUseCase(apiKeyRepository, userRepository).(*apiKeyUseCase)
+	uc.now = func() time.Time { return now }
+	return uc
+}
+
+func TestCreateApiKey(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+	user := &domain.User{ID: 1, OrganizationID: 2, Email: "user1@example.com"}
+
+	mockApiKeyRepository := new(mockApiKeyRepository)
+	mockUserRepository := new(mockUserRepository)
+
+	testCases := []struct {
+		name          string
+		input         *dto.ApiKeyInputDTO
+		expectCreate  bool
+		expectedError error
+	}{
+		{
+			name:          "Success",
+			input:         &dto.ApiKeyInputDTO{Name: " CI ", Scopes: []string{domain.ScopeUsersRead}, ExpiresAt: &future},
+			expectCreate:  true,
+			expectedError: nil,
+		},
+		{
+			name:          "Missing name",
+			input:         &dto.ApiKeyInputDTO{Name: " ", Scopes: []string{domain.ScopeUsersRead}},
+			expectCreate:  false,
+			expectedError: domain.ErrApiKeyNameRequired,
+		},
+		{
+			name:          "Missing scopes",
+			input:         &dto.ApiKeyInputDTO{Name: "CI"},
+			expectCreate:  false,
+			expectedError: domain.ErrApiKeyScopeInvalid,
+		},
+		{
+			name:          "Unknown scope",
+			input:         &dto.ApiKeyInputDTO{Name: "CI", Scopes: []string{"orders:delete"}},
+			expectCreate:  false,
+			expectedError: domain.ErrApiKeyScopeInvalid,
+		},
+		{
+			name:          "Expiration in the past",
+			input:         &dto.ApiKeyInputDTO{Name: "CI", Scopes: []string{domain.ScopeUsersRead}, ExpiresAt: &past},
+			expectCreate:  false,
+			expectedError: domain.ErrApiKeyExpiresAtPast,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiKeyRepository.ExpectedCalls = nil
+			mockUserRepository.ExpectedCalls = nil
+
+			created := &domain.ApiKey{}
+			if tc.expectCreate {
+				mockUserRepository.On("ForTenant", user.OrganizationID).Once()
+				mockUserRepository.On("FindUserById", mock.Anything, user.ID).Return(user, nil)
+				mockApiKeyRepository.On("CreateApiKey", mock.Anything, mock.AnythingOfType("*domain.ApiKey")).Run(func(args mock.Arguments) {
+					*created = *args.Get(1).(*domain.ApiKey)
+					created.ID = 7
+				}).Return(created, nil)
+			}
+
+			uc := newTestApiKeyUseCase(mockApiKeyRepository, mockUserRepository, now)
+
+			output, err := uc.CreateApiKey(context.Background(), user.OrganizationID, user.ID, tc.input)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err, "Expected CreateApiKey error to match.")
+				assert.Nil(t, output, "Expected output to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.True(t, strings.HasPrefix(output.Key, domain.ApiKeyPrefix), "Expected key to carry the api key prefix")
+				assert.Equal(t, output.Key[:len(output.Prefix)], output.Prefix, "Expected prefix to match the key.")
+				assert.Equal(t, util.HashOpaqueToken(output.Key), created.KeyHash, "Expected only the key hash to be stored.")
+				assert.Equal(t, "CI", output.Name, "Expected name to match.")
+				assert.Equal(t, tc.input.Scopes, output.Scopes, "Expected scopes to match.")
+				assert.Equal(t, uint(7), output.ID, "Expected ID to match.")
+			}
+
+			mockApiKeyRepository.AssertExpectations(t)
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+
+	mockApiKeyRepository := new(mockApiKeyRepository)
+	mockUserRepository := new(mockUserRepository)
+
+	testCases := []struct {
+		name          string
+		mockUserError error
+		mockError     error
+		expectDelete  bool
+		expectedError error
+	}{
+		{
+			name:          "Success",
+			mockError:     nil,
+			expectDelete:  true,
+			expectedError: nil,
+		},
+		{
+			name:          "Not found",
+			mockError:     gorm.ErrRecordNotFound,
+			expectDelete:  true,
+			expectedError: domain.ErrApiKeyNotFound,
+		},
+		{
+			name:          "Repository error",
+			mockError:     gorm.ErrInvalidDB,
+			expectDelete:  true,
+			expectedError: gorm.ErrInvalidDB,
+		},
+		{
+			name:          "User of another organization",
+			mockUserError: gorm.ErrRecordNotFound,
+			expectDelete:  false,
+			expectedError: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiKeyRepository.ExpectedCalls = nil
+			mockUserRepository.ExpectedCalls = nil
+			mockUserRepository.On("ForTenant", uint(2)).Once()
+			mockUserRepository.On("FindUserById", mock.Anything, uint(1)).Return(&domain.User{ID: 1, OrganizationID: 2}, tc.mockUserError)
+			if tc.expectDelete {
+				mockApiKeyRepository.On("DeleteApiKey", mock.Anything, uint(1), uint(7)).Return(tc.mockError)
+			}
+
+			uc := NewApiKeyUseCase(mockApiKeyRepository, mockUserRepository)
+
+			err := uc.RevokeApiKey(context.Background(), 2, 1, 7)
+
+			assert.Equal(t, tc.expectedError, err, "Expected RevokeApiKey error to match.")
+			mockApiKeyRepository.AssertExpectations(t)
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthenticateApiKey(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	recent := now.Add(-time.Second)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+	suspendedUser := &domain.User{ID: 1, Email: "user1@example.com", Status: domain.UserStatusSuspended}
+	key := domain.ApiKeyPrefix + "secret"
+
+	mockApiKeyRepository := new(mockApiKeyRepository)
+	mockUserRepository := new(mockUserRepository)
+
+	testCases := []struct {
+		name           string
+		key            string
+		mockKeyReturn  *domain.ApiKey
+		mockKeyError   error
+		expectUser     bool
+		mockUserReturn *domain.User
+		expectLastUsed bool
+		expectedScopes []string
+		expectedError  error
+	}{
+		{
+			name:           "Valid key",
+			key:            key,
+			mockKeyReturn:  &domain.ApiKey{ID: 7, UserID: 1, Scopes: "users:read users:write"},
+			expectUser:     true,
+			expectLastUsed: true,
+			expectedScopes: []string{domain.ScopeUsersRead, domain.ScopeUsersWrite},
+		},
+		{
+			name:           "Recently used key",
+			key:            key,
+			mockKeyReturn:  &domain.ApiKey{ID: 7, UserID: 1, Scopes: "users:read", LastUsedAt: &recent},
+			expectUser:     true,
+			expectLastUsed: false,
+			expectedScopes: []string{domain.ScopeUsersRead},
+		},
+		{
+			name:          "Expired key",
+			key:           key,
+			mockKeyReturn: &domain.ApiKey{ID: 7, UserID: 1, Scopes: "users:read", ExpiresAt: &past},
+			expectedError: domain.ErrApiKeyInvalid,
+		},
+		{
+			name:          "Unknown key",
+			key:           key,
+			mockKeyReturn: nil,
+			mockKeyError:  gorm.ErrRecordNotFound,
+			expectedError: domain.ErrApiKeyInvalid,
+		},
+		{
+			name:           "Suspended owner",
+			key:            key,
+			mockKeyReturn:  &domain.ApiKey{ID: 7, UserID: 1, Scopes: "users:read"},
+			expectUser:     true,
+			mockUserReturn: suspendedUser,
+			expectedError:  domain.ErrUserSuspended,
+		},
+		{
+			name:          "Missing prefix",
+			key:           "secret",
+			expectedError: domain.ErrApiKeyInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockApiKeyRepository.ExpectedCalls = nil
+			mockUserRepository.ExpectedCalls = nil
+
+			if strings.HasPrefix(tc.key, domain.ApiKeyPrefix) {
+				mockApiKeyRepository.On("FindApiKeyByHash", mock.Anything, util.HashOpaqueToken(tc.key)).Return(tc.mockKeyReturn, tc.mockKeyError)
+			}
+			if tc.expectUser {
+				owner := user
+				if tc.mockUserReturn != nil {
+					owner = tc.mockUserReturn
+				}
+				mockUserRepository.On("FindUserById", mock.Anything, user.ID).Return(owner, nil)
+			}
+			if tc.expectLastUsed {
+				mockApiKeyRepository.On("UpdateApiKeyLastUsedAt", mock.Anything, mock.MatchedBy(func(k *domain.ApiKey) bool {
+					return k.LastUsedAt != nil && k.LastUsedAt.Equal(now)
+				})).Return(nil)
+			}
+
+			uc := newTestApiKeyUseCase(mockApiKeyRepository, mockUserRepository, now)
+
+			u, scopes, err := uc.AuthenticateApiKey(context.Background(), tc.key)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err, "Expected AuthenticateApiKey error to match.")
+				assert.Nil(t, u, "Expected user to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, user, u, "Expected user to match.")
+				assert.Equal(t, tc.expectedScopes, scopes, "Expected scopes to match.")
+			}
+
+			mockApiKeyRepository.AssertExpectations(t)
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
@@ -1,53 +1,233 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"log"
+	"time"
 
+	"github.com/Daffc/GO-Sales/domain"
 	"github.com/Daffc/GO-Sales/domain/dto"
 	"github.com/Daffc/GO-Sales/internal/util"
 	"github.com/Daffc/GO-Sales/repository"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type AuthUseCase interface {
-	Login(input *dto.LoginInputDTO) (*dto.LoginOutputDTO, error)
+	Login(ctx context.Context, input *dto.LoginInputDTO) (*dto.LoginOutputDTO, error)
+	LoginMfa(ctx context.Context, input *dto.MfaLoginInputDTO) (*dto.LoginOutputDTO, error)
+	LoginExternalUser(ctx context.Context, user *domain.User, ip string, userAgent string) (*dto.LoginOutputDTO, error)
+	UnlockUser(ctx context.Context, tenantId uint, userId uint) error
+	CheckUserStatus(ctx context.Context, userId uint) error
+	CheckImpersonatorStatus(ctx context.Context, actorId uint) error
 }
 
 type authUseCase struct {
-	userRepository     repository.UserRepository
-	JwtSigningKey      []byte
-	JwtSessionDuration uint
+	userRepository       repository.UserRepository
+	loginThrottleUseCase LoginThrottleUseCase
+	mfaUseCase           MfaUseCase
+	sessionUseCase       SessionUseCase
+	passwordHasher       util.PasswordHasher
+	JwtKeySet            *util.JwtKeySet
+	JwtSessionDuration   uint
+	MfaChallengeDuration uint
+	RequireVerifiedEmail bool
 }
 
-func NewAuthUseCase(userRepository repository.UserRepository, jwtSigningKey []byte, jwtSessionDuration uint) AuthUseCase {
+func NewAuthUseCase(userRepository repository.UserRepository, loginThrottleUseCase LoginThrottleUseCase, mfaUseCase MfaUseCase, sessionUseCase SessionUseCase, passwordHasher util.PasswordHasher, jwtKeySet *util.JwtKeySet, jwtSessionDuration uint, mfaChallengeDuration uint, requireVerifiedEmail bool) AuthUseCase {
 	auc := &authUseCase{
-		userRepository:     userRepository,
-		JwtSigningKey:      jwtSigningKey,
-		JwtSessionDuration: jwtSessionDuration,
+		userRepository:       userRepository,
+		loginThrottleUseCase: loginThrottleUseCase,
+		mfaUseCase:           mfaUseCase,
+		sessionUseCase:       sessionUseCase,
+		passwordHasher:       passwordHasher,
+		JwtKeySet:            jwtKeySet,
+		JwtSessionDuration:   jwtSessionDuration,
+		MfaChallengeDuration: mfaChallengeDuration,
+		RequireVerifiedEmail: requireVerifiedEmail,
 	}
 	return auc
 }
 
-func (ac *authUseCase) Login(input *dto.LoginInputDTO) (*dto.LoginOutputDTO, error) {
-	user, err := ac.userRepository.FindUserByEmail(input.Email)
+func (ac *authUseCase) Login(ctx context.Context, input *dto.LoginInputDTO) (*dto.LoginOutputDTO, error) {
+	err := ac.loginThrottleUseCase.Check(ctx, input.Email, input.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := ac.userRepository.AllTenants().FindUserByEmail(ctx, input.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// Only the IP is throttled, no account has this email.
+			return nil, ac.wrongCredentials(ctx, "", input.IP)
+		default:
+			return nil, err
+		}
+	}
+
+	ok, err := ac.passwordHasher.Verify(input.Password, user.Password)
+	if err != nil || !ok {
+		return nil, ac.wrongCredentials(ctx, input.Email, input.IP)
+	}
+
+	ac.rehashPassword(ctx, user, input.Password)
+
+	return ac.LoginExternalUser(ctx, user, input.IP, input.UserAgent)
+}
+
+// rehashPassword upgrades the stored hash when it was produced by an older
+// algorithm or parameters. The password was just verified, so a failure only
+// delays the upgrade to a later login.
+func (ac *authUseCase) rehashPassword(ctx context.Context, user *domain.User, password string) {
+	if !ac.passwordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := ac.passwordHasher.Hash(password)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	err = ac.userRepository.AllTenants().UpdateUserPasswordHash(ctx, user.ID, user.Password, hashedPassword)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	user.Password = hashedPassword
+}
+
+// LoginExternalUser finishes the login of a user whose credentials were
+// already checked, either by Login or by an external identity provider.
+func (ac *authUseCase) LoginExternalUser(ctx context.Context, user *domain.User, ip string, userAgent string) (*dto.LoginOutputDTO, error) {
+	if user.IsSuspended() {
+		return nil, domain.ErrUserSuspended
+	}
+
+	if ac.RequireVerifiedEmail && !user.IsEmailVerified() {
+		return nil, domain.ErrUserEmailNotVerified
+	}
+
+	mfaEnabled, err := ac.mfaUseCase.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The failure counter is kept until the second factor is verified, so the
+	// password step cannot be used to reset it between code guesses.
+	if mfaEnabled {
+		mfaToken, err := util.NewMfaChallengeToken(user.ID, ac.JwtKeySet, time.Minute*time.Duration(ac.MfaChallengeDuration))
+		if err != nil {
+			return nil, domain.ErrInternal
+		}
+
+		loginOutputDTO := dto.LoginOutputDTO{
+			ID:          user.ID,
+			Name:        user.Name,
+			Email:       user.Email,
+			MfaRequired: true,
+			MfaToken:    mfaToken,
+		}
+
+		return &loginOutputDTO, nil
+	}
+
+	return ac.completeLogin(ctx, user, ip, userAgent)
+}
+
+func (ac *authUseCase) LoginMfa(ctx context.Context, input *dto.MfaLoginInputDTO) (*dto.LoginOutputDTO, error) {
+	userId, err := util.RecoverUserIdFromMfaChallengeToken(input.MfaToken, ac.JwtKeySet)
+	if err != nil {
+		return nil, domain.ErrMfaChallengeInvalid
+	}
+
+	user, err := ac.userRepository.AllTenants().FindUserById(ctx, userId)
 	if err != nil {
 		switch {
 		case errors.Is(err, gorm.ErrRecordNotFound):
-			return nil, errors.New("wrong credentials")
+			return nil, domain.ErrMfaChallengeInvalid
 		default:
 			return nil, err
 		}
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
+	// The account may have been suspended since the password step.
+	if user.IsSuspended() {
+		return nil, domain.ErrUserSuspended
+	}
+
+	err = ac.loginThrottleUseCase.Check(ctx, user.Email, input.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ac.mfaUseCase.Verify(ctx, user.ID, input.Code, input.RecoveryCode)
+	if err != nil {
+		if errors.Is(err, domain.ErrMfaCodeInvalid) {
+			if err := ac.loginThrottleUseCase.RegisterFailure(ctx, user.Email, input.IP); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	return ac.completeLogin(ctx, user, input.IP, input.UserAgent)
+}
+
+func (ac *authUseCase) UnlockUser(ctx context.Context, tenantId uint, userId uint) error {
+	return ac.loginThrottleUseCase.UnlockUser(ctx, tenantId, userId)
+}
+
+// CheckUserStatus refuses users that were deleted or suspended after their
+// token was issued.
+func (ac *authUseCase) CheckUserStatus(ctx context.Context, userId uint) error {
+	user, err := ac.userRepository.AllTenants().FindUserById(ctx, userId)
+	if err != nil {
+		return userNotFound(err)
+	}
+
+	if user.IsSuspended() {
+		return domain.ErrUserSuspended
+	}
+
+	return nil
+}
+
+// CheckImpersonatorStatus refuses impersonations whose actor was deleted,
+// suspended or is no longer an admin since the impersonation started.
+func (ac *authUseCase) CheckImpersonatorStatus(ctx context.Context, actorId uint) error {
+	actor, err := ac.userRepository.AllTenants().FindUserById(ctx, actorId)
+	if err != nil {
+		return userNotFound(err)
+	}
+
+	if actor.IsSuspended() {
+		return domain.ErrUserSuspended
+	}
+	if !actor.IsAdmin() {
+		return domain.ErrRoleForbidden
+	}
+
+	return nil
+}
+
+// completeLogin records the session of the login and issues its access token.
+func (ac *authUseCase) completeLogin(ctx context.Context, user *domain.User, ip string, userAgent string) (*dto.LoginOutputDTO, error) {
+	err := ac.loginThrottleUseCase.RegisterSuccess(ctx, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := ac.sessionUseCase.CreateSession(ctx, user.ID, ip, userAgent, time.Hour*time.Duration(ac.JwtSessionDuration))
 	if err != nil {
-		return nil, errors.New("wrong credentials")
+		return nil, err
 	}
 
-	ss, err := util.NewAccessToken(user, ac.JwtSigningKey, ac.JwtSessionDuration)
+	ss, err := util.NewAccessToken(user, ac.JwtKeySet, ac.JwtSessionDuration, session.TokenID)
 	if err != nil {
-		return nil, errors.New("internal server error")
+		return nil, domain.ErrInternal
 	}
 
 	loginOutputDTO := dto.LoginOutputDTO{
@@ -59,3 +239,12 @@ func (ac *authUseCase) Login(input *dto.LoginInputDTO) (*dto.LoginOutputDTO, err
 
 	return &loginOutputDTO, nil
 }
+
+func (ac *authUseCase) wrongCredentials(ctx context.Context, email string, ip string) error {
+	err := ac.loginThrottleUseCase.RegisterFailure(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+
+	return domain.ErrUserCredentialsInvalid
+}
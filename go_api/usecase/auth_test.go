@@ -1,7 +1,7 @@
 package usecase
 
 import (
-	"errors"
+	"context"
 	"testing"
 	"time"
 
@@ -9,20 +9,28 @@ import (
 	"github.com/Daffc/GO-Sales/domain/dto"
 	"github.com/Daffc/GO-Sales/internal/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 func TestLogin(t *testing.T) {
 
-	validJwtSigningKey := []byte("testJwtSigningKey")
+	validJwtKeySet, err := util.NewHmacJwtKeySet([]byte("testJwtSigningKey"))
+	if err != nil {
+		t.Fatal(err)
+	}
 	validJwtSessionDuration := uint(2)
 
 	validLoginCredentials := &dto.LoginInputDTO{
-		Email:    "user1@example.com",
-		Password: "Password@1",
+		Email:     "user1@example.com",
+		Password:  "Password@1",
+		IP:        "192.0.2.1",
+		UserAgent: "Mozilla/5.0",
 	}
 
+	validSession := &domain.Session{ID: 1, UserID: 1, TokenID: "token-id"}
+
 	validHashedPassword, err := bcrypt.GenerateFromPassword([]byte(validLoginCredentials.Password), 0)
 	if err != nil {
 		t.Fatal(err)
@@ -37,28 +45,50 @@ func TestLogin(t *testing.T) {
 		UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
 	}
 
-	validAccessToken, err := util.NewAccessToken(validUser, validJwtSigningKey, validJwtSessionDuration)
+	verifiedAt := time.Date(1, time.January, 2, 0, 0, 0, 0, time.UTC)
+	verifiedUser := *validUser
+	verifiedUser.EmailVerifiedAt = &verifiedAt
+
+	suspendedUser := *validUser
+	suspendedUser.Status = domain.UserStatusSuspended
+
+	validAccessToken, err := util.NewAccessToken(validUser, validJwtKeySet, validJwtSessionDuration, validSession.TokenID)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	mockUserRepository := new(mockUserRepository)
+	mockLoginThrottleUseCase := new(mockLoginThrottleUseCase)
+	mockMfaUseCase := new(mockMfaUseCase)
+	mockSessionUseCase := new(mockSessionUseCase)
+
+	validMfaToken, err := util.NewMfaChallengeToken(validUser.ID, validJwtKeySet, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockedError := &domain.LoginBlockedError{Reason: domain.ErrAccountLocked, RetryAfter: time.Minute}
 
 	testCases := []struct {
 		name                     string
 		hashedPassword           []byte
-		JwtSigningKey            []byte
+		JwtKeySet                *util.JwtKeySet
 		JwtSessionDuration       uint
+		requireVerifiedEmail     bool
 		loginInput               *dto.LoginInputDTO
+		mockThrottleCheckError   error
+		expectedThrottleCall     string
 		mockUserRepositoryReturn *domain.User
 		mockUserRepositoryError  error
+		mockMfaEnabled           bool
 		expectedOutput           *dto.LoginOutputDTO
 		expectedError            error
 	}{
 		{
 			name:                     "Success",
+			expectedThrottleCall:     "RegisterSuccess",
 			hashedPassword:           validHashedPassword,
-			JwtSigningKey:            validJwtSigningKey,
+			JwtKeySet:                validJwtKeySet,
 			JwtSessionDuration:       validJwtSessionDuration,
 			loginInput:               validLoginCredentials,
 			mockUserRepositoryReturn: validUser,
@@ -75,19 +105,20 @@ func TestLogin(t *testing.T) {
 		},
 		{
 			name:                     "Credentials Not Found",
+			expectedThrottleCall:     "RegisterFailure",
 			hashedPassword:           validHashedPassword,
-			JwtSigningKey:            validJwtSigningKey,
+			JwtKeySet:                validJwtKeySet,
 			JwtSessionDuration:       validJwtSessionDuration,
 			loginInput:               validLoginCredentials,
 			mockUserRepositoryReturn: nil,
 			mockUserRepositoryError:  gorm.ErrRecordNotFound,
 			expectedOutput:           nil,
-			expectedError:            errors.New("wrong credentials"),
+			expectedError:            domain.ErrUserCredentialsInvalid,
 		},
 		{
 			name:                     "Others Recover User Errors",
 			hashedPassword:           validHashedPassword,
-			JwtSigningKey:            validJwtSigningKey,
+			JwtKeySet:                validJwtKeySet,
 			JwtSessionDuration:       validJwtSessionDuration,
 			loginInput:               validLoginCredentials,
 			mockUserRepositoryReturn: nil,
@@ -96,10 +127,11 @@ func TestLogin(t *testing.T) {
 			expectedError:            gorm.ErrInvalidData,
 		},
 		{
-			name:               "Wrong Password",
-			hashedPassword:     validHashedPassword,
-			JwtSigningKey:      validJwtSigningKey,
-			JwtSessionDuration: validJwtSessionDuration,
+			name:                 "Wrong Password",
+			expectedThrottleCall: "RegisterFailure",
+			hashedPassword:       validHashedPassword,
+			JwtKeySet:            validJwtKeySet,
+			JwtSessionDuration:   validJwtSessionDuration,
 			loginInput: &dto.LoginInputDTO{
 				Email:    "user1@example.com",
 				Password: "WrondPassword",
@@ -107,13 +139,14 @@ func TestLogin(t *testing.T) {
 			mockUserRepositoryReturn: validUser,
 			mockUserRepositoryError:  nil,
 			expectedOutput:           nil,
-			expectedError:            errors.New("wrong credentials"),
+			expectedError:            domain.ErrUserCredentialsInvalid,
 		},
 		{
-			name:               "Wrong Password",
-			hashedPassword:     validHashedPassword,
-			JwtSigningKey:      validJwtSigningKey,
-			JwtSessionDuration: validJwtSessionDuration,
+			name:                 "Wrong Password",
+			expectedThrottleCall: "RegisterFailure",
+			hashedPassword:       validHashedPassword,
+			JwtKeySet:            validJwtKeySet,
+			JwtSessionDuration:   validJwtSessionDuration,
 			loginInput: &dto.LoginInputDTO{
 				Email:    "user1@example.com",
 				Password: "WrondPassword",
@@ -121,34 +154,493 @@ func TestLogin(t *testing.T) {
 			mockUserRepositoryReturn: validUser,
 			mockUserRepositoryError:  nil,
 			expectedOutput:           nil,
-			expectedError:            errors.New("wrong credentials"),
+			expectedError:            domain.ErrUserCredentialsInvalid,
 		},
 		{
 			name:                     "Erro Token Generation",
+			expectedThrottleCall:     "RegisterSuccess",
 			hashedPassword:           validHashedPassword,
-			JwtSigningKey:            []byte(""),
+			JwtKeySet:                nil,
 			JwtSessionDuration:       validJwtSessionDuration,
 			loginInput:               validLoginCredentials,
 			mockUserRepositoryReturn: validUser,
 			mockUserRepositoryError:  nil,
 			expectedOutput:           nil,
-			expectedError:            errors.New("internal server error"),
+			expectedError:            domain.ErrInternal,
+		},
+		{
+			name:                     "Unverified Email Required",
+			hashedPassword:           validHashedPassword,
+			JwtKeySet:                validJwtKeySet,
+			JwtSessionDuration:       validJwtSessionDuration,
+			requireVerifiedEmail:     true,
+			loginInput:               validLoginCredentials,
+			mockUserRepositoryReturn: validUser,
+			mockUserRepositoryError:  nil,
+			expectedOutput:           nil,
+			expectedError:            domain.ErrUserEmailNotVerified,
+		},
+		{
+			name:                     "Verified Email Required",
+			expectedThrottleCall:     "RegisterSuccess",
+			hashedPassword:           validHashedPassword,
+			JwtKeySet:                validJwtKeySet,
+			JwtSessionDuration:       validJwtSessionDuration,
+			requireVerifiedEmail:     true,
+			loginInput:               validLoginCredentials,
+			mockUserRepositoryReturn: &verifiedUser,
+			mockUserRepositoryError:  nil,
+			expectedOutput: &dto.LoginOutputDTO{
+				ID:        validUser.ID,
+				Name:      validUser.Name,
+				Email:     validUser.Email,
+				CreatedAt: validUser.CreatedAt,
+				UpdatedAt: validUser.UpdatedAt,
+				Token:     validAccessToken,
+			},
+			expectedError: nil,
+		},
+		{
+			name:                     "Login Blocked",
+			JwtKeySet:                validJwtKeySet,
+			JwtSessionDuration:       validJwtSessionDuration,
+			loginInput:               validLoginCredentials,
+			mockThrottleCheckError:   blockedError,
+			mockUserRepositoryReturn: nil,
+			mockUserRepositoryError:  nil,
+			expectedOutput:           nil,
+			expectedError:            blockedError,
+		},
+		{
+			name:                     "MFA Required",
+			JwtKeySet:                validJwtKeySet,
+			JwtSessionDuration:       validJwtSessionDuration,
+			loginInput:               validLoginCredentials,
+			mockUserRepositoryReturn: validUser,
+			mockUserRepositoryError:  nil,
+			mockMfaEnabled:           true,
+			expectedOutput: &dto.LoginOutputDTO{
+				ID:          validUser.ID,
+				Name:        validUser.Name,
+				Email:       validUser.Email,
+				MfaRequired: true,
+				MfaToken:    validMfaToken,
+			},
+			expectedError: nil,
+		},
+		{
+			name:                     "Suspended",
+			hashedPassword:           validHashedPassword,
+			JwtKeySet:                validJwtKeySet,
+			JwtSessionDuration:       validJwtSessionDuration,
+			loginInput:               validLoginCredentials,
+			mockUserRepositoryReturn: &suspendedUser,
+			expectedOutput:           nil,
+			expectedError:            domain.ErrUserSuspended,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserRepository.ExpectedCalls = nil
-			mockUserRepository.On("FindUserByEmail", tc.loginInput.Email).Return(tc.mockUserRepositoryReturn, tc.mockUserRepositoryError)
+			mockLoginThrottleUseCase.ExpectedCalls = nil
+			mockMfaUseCase.ExpectedCalls = nil
+			mockSessionUseCase.ExpectedCalls = nil
 
-			authUseCase := NewAuthUseCase(mockUserRepository, tc.JwtSigningKey, tc.JwtSessionDuration)
+			mockLoginThrottleUseCase.On("Check", mock.Anything, tc.loginInput.Email, tc.loginInput.IP).Return(tc.mockThrottleCheckError)
+			if tc.mockUserRepositoryReturn != nil {
+				mockMfaUseCase.On("IsEnabled", mock.Anything, tc.mockUserRepositoryReturn.ID).Return(tc.mockMfaEnabled, nil).Maybe()
+			}
+			if tc.mockThrottleCheckError == nil {
+				mockUserRepository.On("FindUserByEmail", mock.Anything, tc.loginInput.Email).Return(tc.mockUserRepositoryReturn, tc.mockUserRepositoryError)
+			}
+			switch tc.expectedThrottleCall {
+			case "RegisterSuccess":
+				mockLoginThrottleUseCase.On("RegisterSuccess", mock.Anything, tc.loginInput.Email).Return(nil)
+				mockSessionUseCase.On("CreateSession", mock.Anything, tc.mockUserRepositoryReturn.ID, tc.loginInput.IP, tc.loginInput.UserAgent, time.Hour*time.Duration(tc.JwtSessionDuration)).Return(validSession, nil)
+			case "RegisterFailure":
+				// Unknown emails only count against the IP.
+				email := tc.loginInput.Email
+				if tc.mockUserRepositoryReturn == nil {
+					email = ""
+				}
+				mockLoginThrottleUseCase.On("RegisterFailure", mock.Anything, email, tc.loginInput.IP).Return(nil)
+			}
 
-			lod, err := authUseCase.Login(tc.loginInput)
+			authUseCase := NewAuthUseCase(mockUserRepository, mockLoginThrottleUseCase, mockMfaUseCase, mockSessionUseCase, testPasswordHasher, tc.JwtKeySet, tc.JwtSessionDuration, 5, tc.requireVerifiedEmail)
+
+			lod, err := authUseCase.Login(context.Background(), tc.loginInput)
+
+			// Tokens embed their issue time, compare their subject instead of
+			// the raw string so the test does not depend on the clock.
+			if lod != nil && tc.expectedOutput != nil {
+				lodCopy := *lod
+				if lod.MfaRequired {
+					userId, tokenErr := util.RecoverUserIdFromMfaChallengeToken(lod.MfaToken, tc.JwtKeySet)
+					assert.NoError(t, tokenErr, "Expected a valid MFA challenge token.")
+					assert.Equal(t, tc.mockUserRepositoryReturn.ID, userId, "Expected MFA challenge subject to match.")
+					lodCopy.MfaToken = tc.expectedOutput.MfaToken
+				} else {
+					claims, tokenErr := util.RecoverClaimsFromToken(lod.Token, tc.JwtKeySet)
+					assert.NoError(t, tokenErr, "Expected a valid access token.")
+					assert.Equal(t, tc.mockUserRepositoryReturn.ID, claims.ID, "Expected access token subject to match.")
+					assert.Equal(t, validSession.TokenID, claims.Id, "Expected access token to be linked to the session.")
+					lodCopy.Token = tc.expectedOutput.Token
+				}
+				lod = &lodCopy
+			}
 
 			assert.Equal(t, lod, tc.expectedOutput, "Expected Logind output to match.")
 			assert.Equal(t, err, tc.expectedError, "Expected Logind error to match.")
 
 			mockUserRepository.AssertExpectations(t)
+			mockLoginThrottleUseCase.AssertExpectations(t)
+			mockSessionUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoginRehashPassword(t *testing.T) {
+
+	jwtKeySet, err := util.NewHmacJwtKeySet([]byte("testJwtSigningKey"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	argon2idHasher, err := util.NewArgon2idHasher(util.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := &dto.LoginInputDTO{Email: "user1@example.com", Password: "Password@1", IP: "192.0.2.1", UserAgent: "Mozilla/5.0"}
+
+	bcryptHash, err := testPasswordHasher.Hash(input.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2idHash, err := argon2idHasher.Hash(input.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockUserRepository := new(mockUserRepository)
+	mockLoginThrottleUseCase := new(mockLoginThrottleUseCase)
+	mockMfaUseCase := new(mockMfaUseCase)
+	mockSessionUseCase := new(mockSessionUseCase)
+
+	testCases := []struct {
+		name         string
+		storedHash   string
+		mockError    error
+		expectRehash bool
+	}{
+		{
+			name:         "Outdated algorithm",
+			storedHash:   bcryptHash,
+			expectRehash: true,
+		},
+		{
+			name:         "Rehash failure does not block the login",
+			storedHash:   bcryptHash,
+			mockError:    gorm.ErrInvalidDB,
+			expectRehash: true,
+		},
+		{
+			name:         "Current algorithm",
+			storedHash:   argon2idHash,
+			expectRehash: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockLoginThrottleUseCase.ExpectedCalls = nil
+			mockMfaUseCase.ExpectedCalls = nil
+			mockSessionUseCase.ExpectedCalls = nil
+
+			user := &domain.User{ID: 1, Name: "User1", Email: input.Email, Password: tc.storedHash}
+
+			mockLoginThrottleUseCase.On("Check", mock.Anything, input.Email, input.IP).Return(nil)
+			mockLoginThrottleUseCase.On("RegisterSuccess", mock.Anything, input.Email).Return(nil)
+			mockUserRepository.On("FindUserByEmail", mock.Anything, input.Email).Return(user, nil)
+			mockMfaUseCase.On("IsEnabled", mock.Anything, user.ID).Return(false, nil)
+			mockSessionUseCase.On("CreateSession", mock.Anything, user.ID, input.IP, input.UserAgent, 2*time.Hour).Return(&domain.Session{ID: 1, UserID: 1, TokenID: "token-id"}, nil)
+			if tc.expectRehash {
+				mockUserRepository.On("UpdateUserPasswordHash", mock.Anything, user.ID, tc.storedHash, mock.MatchedBy(func(newHash string) bool {
+					ok, err := argon2idHasher.Verify(input.Password, newHash)
+					return err == nil && ok && !argon2idHasher.NeedsRehash(newHash)
+				})).Return(tc.mockError)
+			}
+
+			authUseCase := NewAuthUseCase(mockUserRepository, mockLoginThrottleUseCase, mockMfaUseCase, mockSessionUseCase, argon2idHasher, jwtKeySet, 2, 5, false)
+
+			lod, err := authUseCase.Login(context.Background(), input)
+
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.NotEmpty(t, lod.Token, "Expected an access token")
+
+			mockUserRepository.AssertExpectations(t)
+			mockSessionUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+
+	mockLoginThrottleUseCase := new(mockLoginThrottleUseCase)
+
+	testCases := []struct {
+		name          string
+		input         uint
+		mockError     error
+		expectedError error
+	}{
+		{
+			name:          "Success",
+			input:         1,
+			mockError:     nil,
+			expectedError: nil,
+		},
+		{
+			name:          "User not found",
+			input:         100,
+			mockError:     gorm.ErrRecordNotFound,
+			expectedError: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockLoginThrottleUseCase.ExpectedCalls = nil
+			mockLoginThrottleUseCase.On("UnlockUser", mock.Anything, uint(2), tc.input).Return(tc.mockError)
+
+			authUseCase := NewAuthUseCase(new(mockUserRepository), mockLoginThrottleUseCase, new(mockMfaUseCase), new(mockSessionUseCase), testPasswordHasher, nil, 1, 5, false)
+
+			err := authUseCase.UnlockUser(context.Background(), 2, tc.input)
+
+			assert.Equal(t, tc.expectedError, err, "Expected UnlockUser error to match.")
+			mockLoginThrottleUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckUserStatus(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+
+	testCases := []struct {
+		name           string
+		mockUserReturn *domain.User
+		mockUserError  error
+		expectedError  error
+	}{
+		{
+			name:           "Active",
+			mockUserReturn: &domain.User{ID: 1, Status: domain.UserStatusActive},
+		},
+		{
+			name:           "Suspended",
+			mockUserReturn: &domain.User{ID: 1, Status: domain.UserStatusSuspended},
+			expectedError:  domain.ErrUserSuspended,
+		},
+		{
+			name:          "Deleted",
+			mockUserError: gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockUserRepository.On("FindUserById", mock.Anything, uint(1)).Return(tc.mockUserReturn, tc.mockUserError)
+
+			authUseCase := NewAuthUseCase(mockUserRepository, new(mockLoginThrottleUseCase), new(mockMfaUseCase), new(mockSessionUseCase), testPasswordHasher, nil, 1, 5, false)
+
+			err := authUseCase.CheckUserStatus(context.Background(), 1)
+
+			assert.Equal(t, tc.expectedError, err, "Expected CheckUserStatus error to match.")
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCheckImpersonatorStatus(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+
+	testCases := []struct {
+		name           string
+		mockUserReturn *domain.User
+		mockUserError  error
+		expectedError  error
+	}{
+		{
+			name:           "Active admin",
+			mockUserReturn: &domain.User{ID: 2, Role: domain.RoleAdmin, Status: domain.UserStatusActive},
+		},
+		{
+			name:           "Suspended admin",
+			mockUserReturn: &domain.User{ID: 2, Role: domain.RoleAdmin, Status: domain.UserStatusSuspended},
+			expectedError:  domain.ErrUserSuspended,
+		},
+		{
+			name:           "No longer admin",
+			mockUserReturn: &domain.User{ID: 2, Role: domain.RoleUser, Status: domain.UserStatusActive},
+			expectedError:  domain.ErrRoleForbidden,
+		},
+		{
+			name:          "Deleted",
+			mockUserError: gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockUserRepository.On("FindUserById", mock.Anything, uint(2)).Return(tc.mockUserReturn, tc.mockUserError)
+
+			authUseCase := NewAuthUseCase(mockUserRepository, new(mockLoginThrottleUseCase), new(mockMfaUseCase), new(mockSessionUseCase), testPasswordHasher, nil, 1, 5, false)
+
+			err := authUseCase.CheckImpersonatorStatus(context.Background(), 2)
+
+			assert.Equal(t, tc.expectedError, err, "Expected CheckImpersonatorStatus error to match.")
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoginMfa(t *testing.T) {
+
+	validJwtKeySet, err := util.NewHmacJwtKeySet([]byte("testJwtSigningKey"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	validJwtSessionDuration := uint(2)
+
+	validSession := &domain.Session{ID: 1, UserID: 1, TokenID: "token-id"}
+
+	validUser := &domain.User{
+		ID:    1,
+		Name:  "User1",
+		Email: "user1@example.com",
+	}
+
+	validMfaToken, err := util.NewMfaChallengeToken(validUser.ID, validJwtKeySet, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validAccessToken, err := util.NewAccessToken(validUser, validJwtKeySet, validJwtSessionDuration, validSession.TokenID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockedError := &domain.LoginBlockedError{Reason: domain.ErrAccountLocked, RetryAfter: time.Minute}
+
+	mockUserRepository := new(mockUserRepository)
+	mockLoginThrottleUseCase := new(mockLoginThrottleUseCase)
+	mockMfaUseCase := new(mockMfaUseCase)
+	mockSessionUseCase := new(mockSessionUseCase)
+
+	testCases := []struct {
+		name                   string
+		input                  *dto.MfaLoginInputDTO
+		mockUserReturn         *domain.User
+		mockUserError          error
+		mockThrottleCheckError error
+		expectVerify           bool
+		mockVerifyError        error
+		expectedThrottleCall   string
+		expectedOutput         *dto.LoginOutputDTO
+		expectedError          error
+	}{
+		{
+			name:                 "Success",
+			input:                &dto.MfaLoginInputDTO{MfaToken: validMfaToken, Code: "123456", IP: "192.0.2.1", UserAgent: "Mozilla/5.0"},
+			mockUserReturn:       validUser,
+			expectVerify:         true,
+			expectedThrottleCall: "RegisterSuccess",
+			expectedOutput: &dto.LoginOutputDTO{
+				ID:    validUser.ID,
+				Name:  validUser.Name,
+				Email: validUser.Email,
+				Token: validAccessToken,
+			},
+			expectedError: nil,
+		},
+		{
+			name:          "Invalid challenge",
+			input:         &dto.MfaLoginInputDTO{MfaToken: "invalid", Code: "123456"},
+			expectedError: domain.ErrMfaChallengeInvalid,
+		},
+		{
+			name:          "Access token as challenge",
+			input:         &dto.MfaLoginInputDTO{MfaToken: validAccessToken, Code: "123456"},
+			expectedError: domain.ErrMfaChallengeInvalid,
+		},
+		{
+			name:           "User removed",
+			input:          &dto.MfaLoginInputDTO{MfaToken: validMfaToken, Code: "123456"},
+			mockUserReturn: nil,
+			mockUserError:  gorm.ErrRecordNotFound,
+			expectedError:  domain.ErrMfaChallengeInvalid,
+		},
+		{
+			name:                   "Blocked",
+			input:                  &dto.MfaLoginInputDTO{MfaToken: validMfaToken, Code: "123456", IP: "192.0.2.1"},
+			mockUserReturn:         validUser,
+			mockThrottleCheckError: blockedError,
+			expectedError:          blockedError,
+		},
+		{
+			name:                 "Wrong code",
+			input:                &dto.MfaLoginInputDTO{MfaToken: validMfaToken, Code: "000000", IP: "192.0.2.1"},
+			mockUserReturn:       validUser,
+			expectVerify:         true,
+			mockVerifyError:      domain.ErrMfaCodeInvalid,
+			expectedThrottleCall: "RegisterFailure",
+			expectedError:        domain.ErrMfaCodeInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockLoginThrottleUseCase.ExpectedCalls = nil
+			mockMfaUseCase.ExpectedCalls = nil
+			mockSessionUseCase.ExpectedCalls = nil
+
+			if tc.mockUserReturn != nil || tc.mockUserError != nil {
+				mockUserRepository.On("FindUserById", mock.Anything, validUser.ID).Return(tc.mockUserReturn, tc.mockUserError)
+			}
+			if tc.mockUserReturn != nil {
+				mockLoginThrottleUseCase.On("Check", mock.Anything, validUser.Email, tc.input.IP).Return(tc.mockThrottleCheckError)
+			}
+			if tc.expectVerify {
+				mockMfaUseCase.On("Verify", mock.Anything, validUser.ID, tc.input.Code, tc.input.RecoveryCode).Return(tc.mockVerifyError)
+			}
+			switch tc.expectedThrottleCall {
+			case "RegisterSuccess":
+				mockLoginThrottleUseCase.On("RegisterSuccess", mock.Anything, validUser.Email).Return(nil)
+				mockSessionUseCase.On("CreateSession", mock.Anything, validUser.ID, tc.input.IP, tc.input.UserAgent, time.Hour*time.Duration(validJwtSessionDuration)).Return(validSession, nil)
+			case "RegisterFailure":
+				mockLoginThrottleUseCase.On("RegisterFailure", mock.Anything, validUser.Email, tc.input.IP).Return(nil)
+			}
+
+			authUseCase := NewAuthUseCase(mockUserRepository, mockLoginThrottleUseCase, mockMfaUseCase, mockSessionUseCase, testPasswordHasher, validJwtKeySet, validJwtSessionDuration, 5, false)
+
+			lod, err := authUseCase.LoginMfa(context.Background(), tc.input)
+
+			assert.Equal(t, tc.expectedOutput, lod, "Expected LoginMfa output to match.")
+			assert.Equal(t, tc.expectedError, err, "Expected LoginMfa error to match.")
+
+			mockUserRepository.AssertExpectations(t)
+			mockLoginThrottleUseCase.AssertExpectations(t)
+			mockMfaUseCase.AssertExpectations(t)
+			mockSessionUseCase.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/mailer"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+const emailChangeTokenSize = 32
+
+type EmailChangeUseCase interface {
+	CheckNewEmail(ctx context.Context, newEmail string) (string, error)
+	RequestEmailChange(ctx context.Context, user *domain.User, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
+}
+
+type emailChangeUseCase struct {
+	userRepository        repository.UserRepository
+	emailChangeRepository repository.EmailChangeRepository
+	mailer                mailer.Mailer
+	publicURL             string
+	tokenDuration         uint
+	now                   func() time.Time
+}
+
+func NewEmailChangeUseCase(userRepository repository.UserRepository, emailChangeRepository repository.EmailChangeRepository, mailer mailer.Mailer, publicURL string, tokenDuration uint) EmailChangeUseCase {
+	return &emailChangeUseCase{
+		userRepository:        userRepository,
+		emailChangeRepository: emailChangeRepository,
+		mailer:                mailer,
+		publicURL:             publicURL,
+		tokenDuration:         tokenDuration,
+		now:                   time.Now,
+	}
+}
+
+// CheckNewEmail tells whether an account could be moved to newEmail, which is
+// returned trimmed. The address must be valid and not used by any account.
+func (uc *emailChangeUseCase) CheckNewEmail(ctx context.Context, newEmail string) (string, error) {
+	u := domain.User{Email: strings.TrimSpace(newEmail)}
+	if err := u.ValidateEmail(); err != nil {
+		return "", err
+	}
+
+	_, err := uc.userRepository.AllTenants().FindUserByEmail(ctx, u.Email)
+	switch {
+	case err == nil:
+		return "", domain.ErrUserEmailTaken
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return "", err
+	}
+
+	return u.Email, nil
+}
+
+// RequestEmailChange mails a confirmation link to newEmail. The address of
+// user is left untouched until the link is opened, and the current address is
+// told about the request. A new request replaces the pending one.
+func (uc *emailChangeUseCase) RequestEmailChange(ctx context.Context, user *domain.User, newEmail string) error {
+	email, err := uc.CheckNewEmail(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	u := domain.User{Email: email}
+
+	err = uc.emailChangeRepository.DeleteTokensByUserId(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	token, err := util.NewOpaqueToken(emailChangeTokenSize)
+	if err != nil {
+		return err
+	}
+
+	t := domain.EmailChangeToken{
+		UserID:    user.ID,
+		NewEmail:  u.Email,
+		TokenHash: util.HashOpaqueToken(token),
+		ExpiresAt: uc.now().Add(time.Hour * time.Duration(uc.tokenDuration)),
+	}
+
+	_, err = uc.emailChangeRepository.CreateToken(ctx, &t)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/email-change/confirm?token=%s", uc.publicURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hello %s,\n\nPlease confirm your new email address by opening the link below:\n\n%s\n\nThe link expires in %d hours.\n", user.Name, link, uc.tokenDuration)
+
+	err = uc.mailer.Send(u.Email, "Confirm your new email address", body)
+	if err != nil {
+		return err
+	}
+
+	notice := fmt.Sprintf("Hello %s,\n\nA change of the email address of your account to %s was requested. If you did not ask for it, change your password.\n", user.Name, u.Email)
+
+	return uc.mailer.Send(user.Email, "Your email address is being changed", notice)
+}
+
+// ConfirmEmailChange swaps the email of the user for the one the token was
+// sent to.
+func (uc *emailChangeUseCase) ConfirmEmailChange(ctx context.Context, token string) error {
+	if len(token) == 0 {
+		return domain.ErrEmailChangeTokenInvalid
+	}
+
+	t, err := uc.emailChangeRepository.FindTokenByHash(ctx, util.HashOpaqueToken(token))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.ErrEmailChangeTokenInvalid
+		default:
+			return err
+		}
+	}
+
+	now := uc.now()
+	if t.IsExpired(now) {
+		return domain.ErrEmailChangeTokenInvalid
+	}
+
+	user, err := uc.userRepository.AllTenants().FindUserById(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+
+	user.Email = t.NewEmail
+	user.EmailVerifiedAt = &now
+
+	// The address may have been taken since the request, the unique
+	// constraint is what settles it.
+	err = uc.userRepository.AllTenants().UpdateUserEmail(ctx, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return domain.ErrUserEmailTaken
+		default:
+			return err
+		}
+	}
+
+	return uc.emailChangeRepository.DeleteTokensByUserId(ctx, user.ID)
+}
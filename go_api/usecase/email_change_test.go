@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newTestEmailChangeUseCase(userRepository *mockUserRepository, emailChangeRepository *mockEmailChangeRepository, mailer *mockMailer, now time.Time) *emailChangeUseCase {
+	uc := NewEmailChangeUseCase(userRepository, emailChangeRepository, mailer, "http://localhost:8080", 24).(*emailChangeUseCase)
+	uc.now = func() time.Time { return now }
+	return uc
+}
+
+func TestRequestEmailChange(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockUserRepository := new(mockUserRepository)
+	mockEmailChangeRepository := new(mockEmailChangeRepository)
+	mockMailer := new(mockMailer)
+
+	user := &domain.User{ID: 1, Name: "User1", Email: "user1@example.com"}
+
+	testCases := []struct {
+		name            string
+		newEmail        string
+		mockFindError   error
+		mockCreateError error
+		expectCreate    bool
+		expectSend      bool
+		expectedError   error
+	}{
+		{
+			name:          "Success",
+			newEmail:      " new@example.com ",
+			mockFindError: gorm.ErrRecordNotFound,
+			expectCreate:  true,
+			expectSend:    true,
+		},
+		{
+			name:          "Invalid email",
+			newEmail:      "new@",
+			expectedError: domain.ErrUserEmailRequired,
+		},
+		{
+			name:          "Email taken",
+			newEmail:      "new@example.com",
+			expectedError: domain.ErrUserEmailTaken,
+		},
+		{
+			name:            "Create token error",
+			newEmail:        "new@example.com",
+			mockFindError:   gorm.ErrRecordNotFound,
+			mockCreateError: gorm.ErrInvalidDB,
+			expectCreate:    true,
+			expectedError:   gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockEmailChangeRepository.ExpectedCalls = nil
+			mockMailer.ExpectedCalls = nil
+
+			if tc.expectedError != domain.ErrUserEmailRequired {
+				mockUserRepository.On("FindUserByEmail", mock.Anything, "new@example.com").Return(&domain.User{ID: 2}, tc.mockFindError)
+			}
+
+			var storedHash string
+			if tc.expectCreate {
+				mockEmailChangeRepository.On("DeleteTokensByUserId", mock.Anything, user.ID).Return(nil).Once()
+				mockEmailChangeRepository.On("CreateToken", mock.Anything, mock.MatchedBy(func(t *domain.EmailChangeToken) bool {
+					storedHash = t.TokenHash
+					return t.UserID == user.ID && t.NewEmail == "new@example.com" && t.ExpiresAt.Equal(now.Add(24*time.Hour))
+				})).Return(&domain.EmailChangeToken{}, tc.mockCreateError)
+			}
+
+			if tc.expectSend {
+				mockMailer.On("Send", "new@example.com", mock.Anything, mock.MatchedBy(func(body string) bool {
+					// The link must carry the raw token whose hash was persisted.
+					i := strings.Index(body, "/email-change/confirm?token=")
+					if i < 0 {
+						return false
+					}
+					token := strings.Fields(body[i+len("/email-change/confirm?token="):])[0]
+					return util.HashOpaqueToken(token) == storedHash
+				})).Return(nil)
+				mockMailer.On("Send", user.Email, mock.Anything, mock.MatchedBy(func(body string) bool {
+					return strings.Contains(body, "new@example.com")
+				})).Return(nil)
+			}
+
+			uc := newTestEmailChangeUseCase(mockUserRepository, mockEmailChangeRepository, mockMailer, now)
+
+			err := uc.RequestEmailChange(context.Background(), user, tc.newEmail)
+
+			assert.Equal(t, tc.expectedError, err, "Expected RequestEmailChange error to match.")
+			assert.Equal(t, "user1@example.com", user.Email, "Expected email not to change before confirmation.")
+
+			mockUserRepository.AssertExpectations(t)
+			mockEmailChangeRepository.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockUserRepository := new(mockUserRepository)
+	mockEmailChangeRepository := new(mockEmailChangeRepository)
+
+	testCases := []struct {
+		name            string
+		token           string
+		mockTokenReturn *domain.EmailChangeToken
+		mockTokenError  error
+		mockUpdateError error
+		expectUpdate    bool
+		expectDelete    bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			token:           "valid-token",
+			mockTokenReturn: &domain.EmailChangeToken{UserID: 1, NewEmail: "new@example.com", ExpiresAt: now.Add(time.Hour)},
+			expectUpdate:    true,
+			expectDelete:    true,
+		},
+		{
+			name:          "Missing token",
+			token:         "",
+			expectedError: domain.ErrEmailChangeTokenInvalid,
+		},
+		{
+			name:            "Unknown token",
+			token:           "unknown-token",
+			mockTokenReturn: (*domain.EmailChangeToken)(nil),
+			mockTokenError:  gorm.ErrRecordNotFound,
+			expectedError:   domain.ErrEmailChangeTokenInvalid,
+		},
+		{
+			name:            "Expired token",
+			token:           "expired-token",
+			mockTokenReturn: &domain.EmailChangeToken{UserID: 1, NewEmail: "new@example.com", ExpiresAt: now},
+			expectedError:   domain.ErrEmailChangeTokenInvalid,
+		},
+		{
+			name:            "Email taken since the request",
+			token:           "valid-token",
+			mockTokenReturn: &domain.EmailChangeToken{UserID: 1, NewEmail: "new@example.com", ExpiresAt: now.Add(time.Hour)},
+			mockUpdateError: gorm.ErrDuplicatedKey,
+			expectUpdate:    true,
+			expectedError:   domain.ErrUserEmailTaken,
+		},
+		{
+			name:            "Update error",
+			token:           "valid-token",
+			mockTokenReturn: &domain.EmailChangeToken{UserID: 1, NewEmail: "new@example.com", ExpiresAt: now.Add(time.Hour)},
+			mockUpdateError: errors.New("connection lost"),
+			expectUpdate:    true,
+			expectedError:   errors.New("connection lost"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockEmailChangeRepository.ExpectedCalls = nil
+
+			if tc.mockTokenReturn != nil || tc.mockTokenError != nil {
+				mockEmailChangeRepository.On("FindTokenByHash", mock.Anything, util.HashOpaqueToken(tc.token)).Return(tc.mockTokenReturn, tc.mockTokenError)
+			}
+			if tc.expectUpdate {
+				mockUserRepository.On("FindUserById", mock.Anything, uint(1)).Return(&domain.User{ID: 1, Email: "user1@example.com"}, nil)
+				mockUserRepository.On("UpdateUserEmail", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+					return u.ID == 1 && u.Email == "new@example.com" && u.EmailVerifiedAt != nil && u.EmailVerifiedAt.Equal(now)
+				})).Return(tc.mockUpdateError)
+			}
+			if tc.expectDelete {
+				mockEmailChangeRepository.On("DeleteTokensByUserId", mock.Anything, uint(1)).Return(nil)
+			}
+
+			uc := newTestEmailChangeUseCase(mockUserRepository, mockEmailChangeRepository, new(mockMailer), now)
+
+			err := uc.ConfirmEmailChange(context.Background(), tc.token)
+
+			assert.Equal(t, tc.expectedError, err, "Expected ConfirmEmailChange error to match.")
+
+			mockUserRepository.AssertExpectations(t)
+			mockEmailChangeRepository.AssertExpectations(t)
+		})
+	}
+}
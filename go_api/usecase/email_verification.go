@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/mailer"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+const emailVerificationTokenSize = 32
+
+type EmailVerificationUseCase interface {
+	SendVerificationEmail(ctx context.Context, user *domain.User) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, input *dto.ResendVerificationEmailInputDTO) error
+}
+
+type emailVerificationUseCase struct {
+	userRepository              repository.UserRepository
+	emailVerificationRepository repository.EmailVerificationRepository
+	mailer                      mailer.Mailer
+	publicURL                   string
+	tokenDuration               uint
+}
+
+func NewEmailVerificationUseCase(userRepository repository.UserRepository, emailVerificationRepository repository.EmailVerificationRepository, mailer mailer.Mailer, publicURL string, tokenDuration uint) EmailVerificationUseCase {
+	return &emailVerificationUseCase{
+		userRepository:              userRepository,
+		emailVerificationRepository: emailVerificationRepository,
+		mailer:                      mailer,
+		publicURL:                   publicURL,
+		tokenDuration:               tokenDuration,
+	}
+}
+
+func (uc *emailVerificationUseCase) SendVerificationEmail(ctx context.Context, user *domain.User) error {
+	token, err := util.NewOpaqueToken(emailVerificationTokenSize)
+	if err != nil {
+		return err
+	}
+
+	t := domain.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: util.HashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(time.Hour * time.Duration(uc.tokenDuration)),
+	}
+
+	_, err = uc.emailVerificationRepository.CreateToken(ctx, &t)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", uc.publicURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hello %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours.\n", user.Name, link, uc.tokenDuration)
+
+	return uc.mailer.Send(user.Email, "Confirm your email address", body)
+}
+
+func (uc *emailVerificationUseCase) VerifyEmail(ctx context.Context, token string) error {
+	if len(token) == 0 {
+		return domain.ErrEmailVerificationTokenInvalid
+	}
+
+	t, err := uc.emailVerificationRepository.FindTokenByHash(ctx, util.HashOpaqueToken(token))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.ErrEmailVerificationTokenInvalid
+		default:
+			return err
+		}
+	}
+
+	if t.IsExpired(time.Now()) {
+		return domain.ErrEmailVerificationTokenInvalid
+	}
+
+	user, err := uc.userRepository.AllTenants().FindUserById(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsEmailVerified() {
+		verifiedAt := time.Now()
+		user.EmailVerifiedAt = &verifiedAt
+
+		err = uc.userRepository.AllTenants().UpdateUserEmailVerifiedAt(ctx, user)
+		if err != nil {
+			return err
+		}
+	}
+
+	return uc.emailVerificationRepository.DeleteTokensByUserId(ctx, user.ID)
+}
+
+func (uc *emailVerificationUseCase) ResendVerificationEmail(ctx context.Context, input *dto.ResendVerificationEmailInputDTO) error {
+	user, err := uc.userRepository.AllTenants().FindUserByEmail(ctx, input.Email)
+	if err != nil {
+		switch {
+		// Unknown addresses are silently ignored to avoid account enumeration.
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	err = uc.emailVerificationRepository.DeleteTokensByUserId(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return uc.SendVerificationEmail(ctx, user)
+}
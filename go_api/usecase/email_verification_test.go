@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestSendVerificationEmail(t *testing.T) {
+
+	mockEmailVerificationRepository := new(mockEmailVerificationRepository)
+	mockMailer := new(mockMailer)
+
+	user := &domain.User{
+		ID:    1,
+		Name:  "User1",
+		Email: "user1@example.com",
+	}
+
+	testCases := []struct {
+		name            string
+		mockCreateError error
+		mockSendError   error
+		expectSend      bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			mockCreateError: nil,
+			mockSendError:   nil,
+			expectSend:      true,
+			expectedError:   nil,
+		},
+		{
+			name:            "Create token error",
+			mockCreateError: gorm.ErrInvalidDB,
+			expectSend:      false,
+			expectedError:   gorm.ErrInvalidDB,
+		},
+		{
+			name:            "Send error",
+			mockCreateError: nil,
+			mockSendError:   errors.New("smtp unavailable"),
+			expectSend:      true,
+			expectedError:   errors.New("smtp unavailable"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockEmailVerificationRepository.ExpectedCalls = nil
+			mockMailer.ExpectedCalls = nil
+
+			var storedHash string
+			mockEmailVerificationRepository.On("CreateToken", mock.Anything, mock.MatchedBy(func(evt *domain.EmailVerificationToken) bool {
+				storedHash = evt.TokenHash
+				return evt.UserID == user.ID && evt.ExpiresAt.After(time.Now().Add(23*time.Hour))
+			})).Return(&domain.EmailVerificationToken{}, tc.mockCreateError)
+
+			if tc.expectSend {
+				mockMailer.On("Send", user.Email, mock.Anything, mock.MatchedBy(func(body string) bool {
+					// The link must carry the raw token whose hash was persisted.
+					i := strings.Index(body, "/verify-email?token=")
+					if i < 0 {
+						return false
+					}
+					token := strings.Fields(body[i+len("/verify-email?token="):])[0]
+					return strings.HasPrefix(body[:i], "Hello User1") && util.HashOpaqueToken(token) == storedHash
+				})).Return(tc.mockSendError)
+			}
+
+			emailVerificationUseCase := NewEmailVerificationUseCase(new(mockUserRepository), mockEmailVerificationRepository, mockMailer, "http://localhost:8080", 24)
+
+			err := emailVerificationUseCase.SendVerificationEmail(context.Background(), user)
+
+			assert.Equal(t, tc.expectedError, err, "Expected SendVerificationEmail error to match.")
+
+			mockEmailVerificationRepository.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	mockEmailVerificationRepository := new(mockEmailVerificationRepository)
+
+	validToken := "valid-token"
+	verifiedAt := time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name            string
+		token           string
+		mockTokenReturn *domain.EmailVerificationToken
+		mockTokenError  error
+		mockUserReturn  *domain.User
+		mockUpdateError error
+		expectUpdate    bool
+		expectDelete    bool
+		expectedError   error
+	}{
+		{
+			name:  "Success",
+			token: validToken,
+			mockTokenReturn: &domain.EmailVerificationToken{
+				UserID:    1,
+				ExpiresAt: time.Now().Add(time.Hour),
+			},
+			mockUserReturn: &domain.User{ID: 1, Email: "user1@example.com"},
+			expectUpdate:   true,
+			expectDelete:   true,
+			expectedError:  nil,
+		},
+		{
+			name:  "Already verified",
+			token: validToken,
+			mockTokenReturn: &domain.EmailVerificationToken{
+				UserID:    1,
+				ExpiresAt: time.Now().Add(time.Hour),
+			},
+			mockUserReturn: &domain.User{ID: 1, Email: "user1@example.com", EmailVerifiedAt: &verifiedAt},
+			expectUpdate:   false,
+			expectDelete:   true,
+			expectedError:  nil,
+		},
+		{
+			name:          "Empty token",
+			token:         "",
+			expectedError: domain.ErrEmailVerificationTokenInvalid,
+		},
+		{
+			name:            "Unknown token",
+			token:           "unknown-token",
+			mockTokenReturn: nil,
+			mockTokenError:  gorm.ErrRecordNotFound,
+			expectedError:   domain.ErrEmailVerificationTokenInvalid,
+		},
+		{
+			name:            "Token lookup error",
+			token:           validToken,
+			mockTokenReturn: nil,
+			mockTokenError:  gorm.ErrInvalidDB,
+			expectedError:   gorm.ErrInvalidDB,
+		},
+		{
+			name:  "Expired token",
+			token: validToken,
+			mockTokenReturn: &domain.EmailVerificationToken{
+				UserID:    1,
+				ExpiresAt: time.Now().Add(-time.Hour),
+			},
+			expectedError: domain.ErrEmailVerificationTokenInvalid,
+		},
+		{
+			name:  "Update error",
+			token: validToken,
+			mockTokenReturn: &domain.EmailVerificationToken{
+				UserID:    1,
+				ExpiresAt: time.Now().Add(time.Hour),
+			},
+			mockUserReturn:  &domain.User{ID: 1, Email: "user1@example.com"},
+			mockUpdateError: gorm.ErrInvalidDB,
+			expectUpdate:    true,
+			expectDelete:    false,
+			expectedError:   gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockEmailVerificationRepository.ExpectedCalls = nil
+
+			if tc.mockTokenReturn != nil || tc.mockTokenError != nil {
+				mockEmailVerificationRepository.On("FindTokenByHash", mock.Anything, util.HashOpaqueToken(tc.token)).Return(tc.mockTokenReturn, tc.mockTokenError)
+			}
+			if tc.mockUserReturn != nil {
+				mockUserRepository.On("FindUserById", mock.Anything, tc.mockTokenReturn.UserID).Return(tc.mockUserReturn, nil)
+			}
+			if tc.expectUpdate {
+				mockUserRepository.On("UpdateUserEmailVerifiedAt", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+					return u.ID == tc.mockUserReturn.ID && u.EmailVerifiedAt != nil
+				})).Return(tc.mockUpdateError)
+			}
+			if tc.expectDelete {
+				mockEmailVerificationRepository.On("DeleteTokensByUserId", mock.Anything, tc.mockUserReturn.ID).Return(nil)
+			}
+
+			emailVerificationUseCase := NewEmailVerificationUseCase(mockUserRepository, mockEmailVerificationRepository, new(mockMailer), "http://localhost:8080", 24)
+
+			err := emailVerificationUseCase.VerifyEmail(context.Background(), tc.token)
+
+			assert.Equal(t, tc.expectedError, err, "Expected VerifyEmail error to match.")
+
+			mockUserRepository.AssertExpectations(t)
+			mockEmailVerificationRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	mockEmailVerificationRepository := new(mockEmailVerificationRepository)
+	mockMailer := new(mockMailer)
+
+	verifiedAt := time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		input          *dto.ResendVerificationEmailInputDTO
+		mockUserReturn *domain.User
+		mockUserError  error
+		expectSend     bool
+		expectedError  error
+	}{
+		{
+			name:           "Success",
+			input:          &dto.ResendVerificationEmailInputDTO{Email: "user1@example.com"},
+			mockUserReturn: &domain.User{ID: 1, Name: "User1", Email: "user1@example.com"},
+			expectSend:     true,
+			expectedError:  nil,
+		},
+		{
+			name:           "Unknown email",
+			input:          &dto.ResendVerificationEmailInputDTO{Email: "unknown@example.com"},
+			mockUserReturn: nil,
+			mockUserError:  gorm.ErrRecordNotFound,
+			expectSend:     false,
+			expectedError:  nil,
+		},
+		{
+			name:           "Already verified",
+			input:          &dto.ResendVerificationEmailInputDTO{Email: "user1@example.com"},
+			mockUserReturn: &domain.User{ID: 1, Name: "User1", Email: "user1@example.com", EmailVerifiedAt: &verifiedAt},
+			expectSend:     false,
+			expectedError:  nil,
+		},
+		{
+			name:           "Recover user error",
+			input:          &dto.ResendVerificationEmailInputDTO{Email: "user1@example.com"},
+			mockUserReturn: nil,
+			mockUserError:  gorm.ErrInvalidDB,
+			expectSend:     false,
+			expectedError:  gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockEmailVerificationRepository.ExpectedCalls = nil
+			mockMailer.ExpectedCalls = nil
+
+			mockUserRepository.On("FindUserByEmail", mock.Anything, tc.input.Email).Return(tc.mockUserReturn, tc.mockUserError)
+			if tc.expectSend {
+				mockEmailVerificationRepository.On("DeleteTokensByUserId", mock.Anything, tc.mockUserReturn.ID).Return(nil)
+				mockEmailVerificationRepository.On("CreateToken", mock.Anything, mock.Anything).Return(&domain.EmailVerificationToken{}, nil)
+				mockMailer.On("Send", tc.mockUserReturn.Email, mock.Anything, mock.Anything).Return(nil)
+			}
+
+			emailVerificationUseCase := NewEmailVerificationUseCase(mockUserRepository, mockEmailVerificationRepository, mockMailer, "http://localhost:8080", 24)
+
+			err := emailVerificationUseCase.ResendVerificationEmail(context.Background(), tc.input)
+
+			assert.Equal(t, tc.expectedError, err, "Expected ResendVerificationEmail error to match.")
+
+			mockUserRepository.AssertExpectations(t)
+			mockEmailVerificationRepository.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+)
+
+type ImpersonationUseCase interface {
+	Impersonate(ctx context.Context, actor *domain.User, userId uint, ip string, userAgent string) (*dto.ImpersonationOutputDTO, error)
+}
+
+type impersonationUseCase struct {
+	userRepository        repository.UserRepository
+	sessionUseCase        SessionUseCase
+	JwtKeySet             *util.JwtKeySet
+	ImpersonationDuration uint
+}
+
+func NewImpersonationUseCase(userRepository repository.UserRepository, sessionUseCase SessionUseCase, jwtKeySet *util.JwtKeySet, impersonationDuration uint) ImpersonationUseCase {
+	return &impersonationUseCase{
+		userRepository:        userRepository,
+		sessionUseCase:        sessionUseCase,
+		JwtKeySet:             jwtKeySet,
+		ImpersonationDuration: impersonationDuration,
+	}
+}
+
+// Impersonate issues a token letting actor act as the user with id userId.
+// The session it opens records the actor, so it shows up in the user session
+// list and can be revoked like any other.
+func (uc *impersonationUseCase) Impersonate(ctx context.Context, actor *domain.User, userId uint, ip string, userAgent string) (*dto.ImpersonationOutputDTO, error) {
+	if actor.ID == userId {
+		return nil, domain.ErrImpersonationSelf
+	}
+
+	user, err := uc.userRepository.ForTenant(actor.OrganizationID).FindUserById(ctx, userId)
+	if err != nil {
+		return nil, userNotFound(err)
+	}
+
+	if user.IsAdmin() {
+		return nil, domain.ErrImpersonationAdmin
+	}
+
+	duration := time.Minute * time.Duration(uc.ImpersonationDuration)
+
+	session, err := uc.sessionUseCase.CreateImpersonationSession(ctx, user.ID, actor.ID, ip, userAgent, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := util.NewImpersonationToken(user, actor, uc.JwtKeySet, duration, session.TokenID)
+	if err != nil {
+		return nil, domain.ErrInternal
+	}
+
+	log.Printf("impersonation started: actor %d <%s> as user %d <%s>, session %d from %s", actor.ID, actor.Email, user.ID, user.Email, session.ID, ip)
+
+	return &dto.ImpersonationOutputDTO{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		ActorID:   actor.ID,
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
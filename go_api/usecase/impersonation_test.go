@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestImpersonate(t *testing.T) {
+
+	jwtKeySet, err := util.NewHmacJwtKeySet([]byte("testJwtSigningKey"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiresAt := time.Date(2024, time.January, 1, 12, 15, 0, 0, time.UTC)
+
+	admin := &domain.User{ID: 2, OrganizationID: 4, Name: "Admin", Email: "admin@example.com", Role: domain.RoleAdmin}
+	user := &domain.User{ID: 1, OrganizationID: 4, Name: "User1", Email: "user1@example.com", Role: domain.RoleUser}
+	otherAdmin := &domain.User{ID: 3, OrganizationID: 4, Name: "Admin2", Email: "admin2@example.com", Role: domain.RoleAdmin}
+	session := &domain.Session{ID: 9, UserID: user.ID, TokenID: "token-id", ExpiresAt: expiresAt}
+
+	mockUserRepository := new(mockUserRepository)
+	mockSessionUseCase := new(mockSessionUseCase)
+
+	testCases := []struct {
+		name           string
+		userId         uint
+		mockUserReturn *domain.User
+		mockUserError  error
+		expectSession  bool
+		expectedError  error
+	}{
+		{
+			name:           "Success",
+			userId:         user.ID,
+			mockUserReturn: user,
+			expectSession:  true,
+		},
+		{
+			name:          "Self",
+			userId:        admin.ID,
+			expectedError: domain.ErrImpersonationSelf,
+		},
+		{
+			name:           "Admin target",
+			userId:         otherAdmin.ID,
+			mockUserReturn: otherAdmin,
+			expectedError:  domain.ErrImpersonationAdmin,
+		},
+		{
+			name:           "Unknown user",
+			userId:         100,
+			mockUserReturn: nil,
+			mockUserError:  gorm.ErrRecordNotFound,
+			expectedError:  domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockSessionUseCase.ExpectedCalls = nil
+
+			if tc.mockUserReturn != nil || tc.mockUserError != nil {
+				mockUserRepository.On("ForTenant", admin.OrganizationID)
+				mockUserRepository.On("FindUserById", mock.Anything, tc.userId).Return(tc.mockUserReturn, tc.mockUserError)
+			}
+			if tc.expectSession {
+				mockSessionUseCase.On("CreateImpersonationSession", mock.Anything, user.ID, admin.ID, "192.0.2.1", "Mozilla/5.0", 15*time.Minute).Return(session, nil)
+			}
+
+			uc := NewImpersonationUseCase(mockUserRepository, mockSessionUseCase, jwtKeySet, 15)
+
+			output, err := uc.Impersonate(context.Background(), admin, tc.userId, "192.0.2.1", "Mozilla/5.0")
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err, "Expected Impersonate error to match.")
+				assert.Nil(t, output, "Expected output to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, user.ID, output.ID, "Expected impersonated user to match.")
+				assert.Equal(t, admin.ID, output.ActorID, "Expected actor to match.")
+				assert.Equal(t, expiresAt, output.ExpiresAt, "Expected expiration to match.")
+
+				claims, err := util.RecoverClaimsFromToken(output.Token, jwtKeySet)
+				assert.NoError(t, err, "Expected a valid impersonation token.")
+				assert.Equal(t, user.ID, claims.ID, "Expected token subject to be the impersonated user.")
+				assert.Equal(t, &domain.ActorClaims{ID: admin.ID, Email: admin.Email}, claims.Actor, "Expected token actor to match.")
+				assert.Equal(t, session.TokenID, claims.Id, "Expected token to be linked to the session.")
+				assert.Equal(t, int64(15*time.Minute/time.Second), claims.ExpiresAt-claims.IssuedAt, "Expected a short-lived token.")
+			}
+
+			mockUserRepository.AssertExpectations(t)
+			mockSessionUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/mailer"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+type InvitationUseCase interface {
+	CreateInvitation(ctx context.Context, inviter *domain.User, input *dto.InvitationInputDTO) (*dto.InvitationOutputDTO, error)
+	ListInvitations(ctx context.Context, tenantId uint) ([]*dto.InvitationOutputDTO, error)
+	RevokeInvitation(ctx context.Context, tenantId uint, invitationId uint) error
+	AcceptInvitation(ctx context.Context, input *dto.AcceptInvitationInputDTO) (*dto.UserOutputDTO, error)
+}
+
+type invitationUseCase struct {
+	invitationRepository repository.InvitationRepository
+	userRepository       repository.UserRepository
+	mailer               mailer.Mailer
+	passwordHasher       util.PasswordHasher
+	passwordPolicy       *domain.PasswordPolicy
+	JwtKeySet            *util.JwtKeySet
+	// frontendURL is where the page accepting invitations is served, the
+	// API itself only takes the token along with the name and password.
+	frontendURL string
+	// InvitationDuration is how long, in hours, an invitation can be accepted.
+	InvitationDuration uint
+	now                func() time.Time
+}
+
+func NewInvitationUseCase(invitationRepository repository.InvitationRepository, userRepository repository.UserRepository, mailer mailer.Mailer, passwordHasher util.PasswordHasher, passwordPolicy *domain.PasswordPolicy, jwtKeySet *util.JwtKeySet, frontendURL string, invitationDuration uint) InvitationUseCase {
+	return &invitationUseCase{
+		invitationRepository: invitationRepository,
+		userRepository:       userRepository,
+		mailer:               mailer,
+		passwordHasher:       passwordHasher,
+		passwordPolicy:       passwordPolicy,
+		JwtKeySet:            jwtKeySet,
+		frontendURL:          frontendURL,
+		InvitationDuration:   invitationDuration,
+		now:                  time.Now,
+	}
+}
+
+// CreateInvitation invites a person into the organization of inviter and
+// mails them the signed invitation link.
+func (uc *invitationUseCase) CreateInvitation(ctx context.Context, inviter *domain.User, input *dto.InvitationInputDTO) (*dto.InvitationOutputDTO, error) {
+	duration := time.Hour * time.Duration(uc.InvitationDuration)
+
+	i := &domain.Invitation{
+		OrganizationID: inviter.OrganizationID,
+		Email:          strings.TrimSpace(input.Email),
+		Role:           input.Role,
+		InvitedByID:    inviter.ID,
+		ExpiresAt:      uc.now().Add(duration),
+	}
+	if len(i.Role) == 0 {
+		i.Role = domain.RoleUser
+	}
+
+	if err := i.ValidateEmail(); err != nil {
+		return nil, err
+	}
+	if err := i.ValidateRole(); err != nil {
+		return nil, err
+	}
+
+	err := uc.checkEmailAvailable(ctx, i.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err = uc.invitationRepository.ForTenant(inviter.OrganizationID).CreateInvitation(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := util.NewInvitationToken(i.ID, uc.JwtKeySet, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	link := fmt.Sprintf("%s/invitations/accept?token=%s", uc.frontendURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hello,\n\n%s invited you to join GO-Sales. Open the link below to choose your name and password:\n\n%s\n\nThe link expires in %d hours.\n", inviter.Name, link, uc.InvitationDuration)
+
+	err = uc.mailer.Send(i.Email, "You have been invited to GO-Sales", body)
+	if err != nil {
+		return nil, err
+	}
+
+	return newInvitationOutputDTO(i), nil
+}
+
+// ListInvitations returns the pending invitations of an organization.
+func (uc *invitationUseCase) ListInvitations(ctx context.Context, tenantId uint) ([]*dto.InvitationOutputDTO, error) {
+	is, err := uc.invitationRepository.ForTenant(tenantId).ListPendingInvitations(ctx, uc.now())
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*dto.InvitationOutputDTO, len(is))
+	for i, inv := range is {
+		output[i] = newInvitationOutputDTO(inv)
+	}
+
+	return output, nil
+}
+
+func (uc *invitationUseCase) RevokeInvitation(ctx context.Context, tenantId uint, invitationId uint) error {
+	err := uc.invitationRepository.ForTenant(tenantId).DeleteInvitation(ctx, invitationId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.ErrInvitationNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AcceptInvitation creates the invited user with the name and password they
+// picked. Their email is verified, since the link reached them through it.
+func (uc *invitationUseCase) AcceptInvitation(ctx context.Context, input *dto.AcceptInvitationInputDTO) (*dto.UserOutputDTO, error) {
+	invitationId, err := util.RecoverInvitationIdFromToken(input.Token, uc.JwtKeySet)
+	if err != nil {
+		return nil, domain.ErrInvitationInvalid
+	}
+
+	// The token is all there is to tell the organization, so the lookup spans
+	// every tenant.
+	i, err := uc.invitationRepository.AllTenants().FindInvitationById(ctx, invitationId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, domain.ErrInvitationInvalid
+		default:
+			return nil, err
+		}
+	}
+
+	now := uc.now()
+	if i.IsAccepted() || i.IsExpired(now) {
+		return nil, domain.ErrInvitationInvalid
+	}
+
+	u := &domain.User{
+		OrganizationID:  i.OrganizationID,
+		Name:            strings.TrimSpace(input.Name),
+		Email:           i.Email,
+		Password:        input.Password,
+		Role:            i.Role,
+		Status:          domain.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+
+	err = u.ValidateAll(uc.passwordPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.checkEmailAvailable(ctx, u.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := uc.passwordHasher.Hash(u.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Password = hashedPassword
+
+	user, err := uc.invitationRepository.ForTenant(i.OrganizationID).AcceptInvitation(ctx, i, u)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, domain.ErrInvitationInvalid
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return nil, domain.ErrUserEmailTaken
+		default:
+			return nil, err
+		}
+	}
+
+	return newUserOutputDTO(user), nil
+}
+
+// checkEmailAvailable refuses addresses already used by an account, in any
+// organization since emails are unique across the deployment.
+func (uc *invitationUseCase) checkEmailAvailable(ctx context.Context, email string) error {
+	_, err := uc.userRepository.AllTenants().FindUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		return domain.ErrUserEmailTaken
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+func newInvitationOutputDTO(i *domain.Invitation) *dto.InvitationOutputDTO {
+	return &dto.InvitationOutputDTO{
+		ID:          i.ID,
+		Email:       i.Email,
+		Role:        i.Role,
+		InvitedByID: i.InvitedByID,
+		ExpiresAt:   i.ExpiresAt,
+		CreatedAt:   i.CreatedAt,
+	}
+}
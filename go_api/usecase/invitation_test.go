@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newTestInvitationUseCase(invitationRepository *mockInvitationRepository, userRepository *mockUserRepository, mailer *mockMailer, jwtKeySet *util.JwtKeySet, now time.Time) *invitationUseCase {
+	uc := NewInvitationUseCase(invitationRepository, userRepository, mailer, testPasswordHasher, testPasswordPolicy, jwtKeySet, "https://app.example.com", 72).(*invitationUseCase)
+	uc.now = func() time.Time { return now }
+	return uc
+}
+
+func TestCreateInvitation(t *testing.T) {
+
+	jwtKeySet, err := util.NewHmacJwtKeySet([]byte("testJwtSigningKey"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	admin := &domain.User{ID: 1, OrganizationID: 2, Name: "Admin", Email: "admin@example.com", Role: domain.RoleAdmin}
+
+	mockInvitationRepository := new(mockInvitationRepository)
+	mockUserRepository := new(mockUserRepository)
+	mockMailer := new(mockMailer)
+
+	testCases := []struct {
+		name          string
+		input         *dto.InvitationInputDTO
+		mockUserError error
+		expectCreate  bool
+		mockSendError error
+		expectedRole  string
+		expectedError error
+	}{
+		{
+			name:          "Success",
+			input:         &dto.InvitationInputDTO{Email: " user1@example.com ", Role: domain.RoleAdmin},
+			mockUserError: gorm.ErrRecordNotFound,
+			expectCreate:  true,
+			expectedRole:  domain.RoleAdmin,
+		},
+		{
+			name:          "Default role",
+			input:         &dto.InvitationInputDTO{Email: "user1@example.com"},
+			mockUserError: gorm.ErrRecordNotFound,
+			expectCreate:  true,
+			expectedRole:  domain.RoleUser,
+		},
+		{
+			name:          "Invalid email",
+			input:         &dto.InvitationInputDTO{Email: "user1", Role: domain.RoleUser},
+			expectedError: domain.ErrUserEmailRequired,
+		},
+		{
+			name:          "Invalid role",
+			input:         &dto.InvitationInputDTO{Email: "user1@example.com", Role: "owner"},
+			expectedError: domain.ErrInvitationRoleInvalid,
+		},
+		{
+			name:          "Email taken",
+			input:         &dto.InvitationInputDTO{Email: "user1@example.com", Role: domain.RoleUser},
+			mockUserError: nil,
+			expectedError: domain.ErrUserEmailTaken,
+		},
+		{
+			name:          "Send error",
+			input:         &dto.InvitationInputDTO{Email: "user1@example.com", Role: domain.RoleUser},
+			mockUserError: gorm.ErrRecordNotFound,
+			expectCreate:  true,
+			mockSendError: errors.New("smtp unavailable"),
+			expectedRole:  domain.RoleUser,
+			expectedError: errors.New("smtp unavailable"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockInvitationRepository.ExpectedCalls = nil
+			mockUserRepository.ExpectedCalls = nil
+			mockMailer.ExpectedCalls = nil
+
+			if tc.mockUserError != nil || tc.expectedError == domain.ErrUserEmailTaken {
+				mockUserRepository.On("FindUserByEmail", mock.Anything, "user1@example.com").Return(&domain.User{ID: 5}, tc.mockUserError)
+			}
+
+			var mailBody string
+			if tc.expectCreate {
+				mockInvitationRepository.On("ForTenant", admin.OrganizationID)
+				mockInvitationRepository.On("CreateInvitation", mock.Anything, mock.MatchedBy(func(i *domain.Invitation) bool {
+					return i.OrganizationID == admin.OrganizationID &&
+						i.InvitedByID == admin.ID &&
+						i.Email == "user1@example.com" &&
+						i.Role == tc.expectedRole &&
+						i.ExpiresAt.Equal(now.Add(72*time.Hour))
+				})).Run(func(args mock.Arguments) {
+					args.Get(1).(*domain.Invitation).ID = 7
+				}).Return(&domain.Invitation{ID: 7, Email: "user1@example.com", Role: tc.expectedRole}, nil)
+				mockMailer.On("Send", "user1@example.com", "You have been invited to GO-Sales", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+					mailBody = args.String(2)
+				}).Return(tc.mockSendError)
+			}
+
+			uc := newTestInvitationUseCase(mockInvitationRepository, mockUserRepository, mockMailer, jwtKeySet, now)
+
+			output, err := uc.CreateInvitation(context.Background(), admin, tc.input)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err, "Expected CreateInvitation error to match.")
+				assert.Nil(t, output, "Expected output to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, uint(7), output.ID, "Expected ID to match.")
+				assert.Equal(t, tc.expectedRole, output.Role, "Expected role to match.")
+
+				// The mailed link opens the page of the front end that
+				// accepts the invitation, not the API route only taking a
+				// POST, and carries a token naming the invitation.
+				_, query, found := strings.Cut(mailBody, "https://app.example.com/invitations/accept?token=")
+				assert.True(t, found, "Expected mail to carry the invitation link")
+				token, _ := url.QueryUnescape(strings.Fields(query)[0])
+				invitationId, err := util.RecoverInvitationIdFromToken(token, jwtKeySet)
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, uint(7), invitationId, "Expected token to name the invitation.")
+			}
+
+			mockInvitationRepository.AssertExpectations(t)
+			mockUserRepository.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeInvitation(t *testing.T) {
+
+	mockInvitationRepository := new(mockInvitationRepository)
+
+	testCases := []struct {
+		name          string
+		mockError     error
+		expectedError error
+	}{
+		{
+			name:          "Success",
+			mockError:     nil,
+			expectedError: nil,
+		},
+		{
+			name:          "Not found",
+			mockError:     gorm.ErrRecordNotFound,
+			expectedError: domain.ErrInvitationNotFound,
+		},
+		{
+			name:          "Repository error",
+			mockError:     gorm.ErrInvalidDB,
+			expectedError: gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockInvitationRepository.ExpectedCalls = nil
+			mockInvitationRepository.On("ForTenant", uint(2))
+			mockInvitationRepository.On("DeleteInvitation", mock.Anything, uint(7)).Return(tc.mockError)
+
+			uc := newTestInvitationUseCase(mockInvitationRepository, new(mockUserRepository), new(mockMailer), nil, time.Now())
+
+			err := uc.RevokeInvitation(context.Background(), 2, 7)
+
+			assert.Equal(t, tc.expectedError, err, "Expected RevokeInvitation error to match.")
+			mockInvitationRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+
+	jwtKeySet, err := util.NewHmacJwtKeySet([]byte("testJwtSigningKey"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := util.NewInvitationToken(7, jwtKeySet, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	acceptedAt := now.Add(-time.Minute)
+
+	pending := &domain.Invitation{ID: 7, OrganizationID: 2, Email: "user1@example.com", Role: domain.RoleAdmin, ExpiresAt: now.Add(time.Hour)}
+	expired := &domain.Invitation{ID: 7, OrganizationID: 2, Email: "user1@example.com", Role: domain.RoleAdmin, ExpiresAt: now}
+	accepted := &domain.Invitation{ID: 7, OrganizationID: 2, Email: "user1@example.com", Role: domain.RoleAdmin, ExpiresAt: now.Add(time.Hour), AcceptedAt: &acceptedAt}
+
+	mockInvitationRepository := new(mockInvitationRepository)
+	mockUserRepository := new(mockUserRepository)
+
+	testCases := []struct {
+		name                 string
+		input                *dto.AcceptInvitationInputDTO
+		mockInvitationReturn *domain.Invitation
+		mockInvitationError  error
+		mockUserError        error
+		expectAccept         bool
+		mockAcceptError      error
+		expectedError        error
+	}{
+		{
+			name:                 "Success",
+			input:                &dto.AcceptInvitationInputDTO{Token: token, Name: " User1 ", Password: "Password@1"},
+			mockInvitationReturn: pending,
+			mockUserError:        gorm.ErrRecordNotFound,
+			expectAccept:         true,
+		},
+		{
+			name:          "Invalid token",
+			input:         &dto.AcceptInvitationInputDTO{Token: "invalid", Name: "User1", Password: "Password@1"},
+			expectedError: domain.ErrInvitationInvalid,
+		},
+		{
+			name:                "Revoked invitation",
+			input:               &dto.AcceptInvitationInputDTO{Token: token, Name: "User1", Password: "Password@1"},
+			mockInvitationError: gorm.ErrRecordNotFound,
+			expectedError:       domain.ErrInvitationInvalid,
+		},
+		{
+			name:                 "Expired invitation",
+			input:                &dto.AcceptInvitationInputDTO{Token: token, Name: "User1", Password: "Password@1"},
+			mockInvitationReturn: expired,
+			expectedError:        domain.ErrInvitationInvalid,
+		},
+		{
+			name:                 "Accepted invitation",
+			input:                &dto.AcceptInvitationInputDTO{Token: token, Name: "User1", Password: "Password@1"},
+			mockInvitationReturn: accepted,
+			expectedError:        domain.ErrInvitationInvalid,
+		},
+		{
+			name:                 "Missing name",
+			input:                &dto.AcceptInvitationInputDTO{Token: token, Name: " ", Password: "Password@1"},
+			mockInvitationReturn: pending,
+			expectedError:        domain.ErrUserNameRequired,
+		},
+		{
+			name:                 "Email taken",
+			input:                &dto.AcceptInvitationInputDTO{Token: token, Name: "User1", Password: "Password@1"},
+			mockInvitationReturn: pending,
+			mockUserError:        nil,
+			expectedError:        domain.ErrUserEmailTaken,
+		},
+		{
+			name:                 "Accepted concurrently",
+			input:                &dto.AcceptInvitationInputDTO{Token: token, Name: "User1", Password: "Password@1"},
+			mockInvitationReturn: pending,
+			mockUserError:        gorm.ErrRecordNotFound,
+			expectAccept:         true,
+			mockAcceptError:      gorm.ErrRecordNotFound,
+			expectedError:        domain.ErrInvitationInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockInvitationRepository.ExpectedCalls = nil
+			mockUserRepository.ExpectedCalls = nil
+
+			if tc.mockInvitationReturn != nil || tc.mockInvitationError != nil {
+				mockInvitationRepository.On("FindInvitationById", mock.Anything, uint(7)).Return(tc.mockInvitationReturn, tc.mockInvitationError)
+			}
+			if tc.mockUserError != nil || tc.expectedError == domain.ErrUserEmailTaken {
+				mockUserRepository.On("FindUserByEmail", mock.Anything, "user1@example.com").Return(&domain.User{ID: 5}, tc.mockUserError)
+			}
+			created := &domain.User{}
+			if tc.expectAccept {
+				mockInvitationRepository.On("ForTenant", pending.OrganizationID)
+				mockInvitationRepository.On("AcceptInvitation", mock.Anything, pending, mock.MatchedBy(func(u *domain.User) bool {
+					return u.OrganizationID == pending.OrganizationID &&
+						u.Name == "User1" &&
+						u.Email == pending.Email &&
+						u.Role == pending.Role &&
+						u.IsEmailVerified() &&
+						verifyTestPassword(tc.input.Password, u.Password)
+				})).Run(func(args mock.Arguments) {
+					*created = *args.Get(2).(*domain.User)
+					created.ID = 9
+				}).Return(created, tc.mockAcceptError)
+			}
+
+			uc := newTestInvitationUseCase(mockInvitationRepository, mockUserRepository, new(mockMailer), jwtKeySet, now)
+
+			output, err := uc.AcceptInvitation(context.Background(), tc.input)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError, "Expected AcceptInvitation error to match.")
+				assert.Nil(t, output, "Expected output to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, uint(9), output.ID, "Expected ID to match.")
+				assert.Equal(t, pending.OrganizationID, output.OrganizationID, "Expected organization to match.")
+				assert.Equal(t, pending.Role, output.Role, "Expected role to match.")
+				assert.True(t, output.EmailVerified, "Expected email to be verified.")
+			}
+
+			mockInvitationRepository.AssertExpectations(t)
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
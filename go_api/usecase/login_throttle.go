@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+// loginThrottlePurgeInterval is how often expired throttles are dropped.
+const loginThrottlePurgeInterval = time.Minute
+
+// LoginThrottlePolicy holds the brute-force protection settings. A zero
+// MaxAccountFailures or MaxIPFailures disables lockout for that scope.
+type LoginThrottlePolicy struct {
+	MaxAccountFailures uint
+	MaxIPFailures      uint
+	LockoutDuration    time.Duration
+	BackoffBase        time.Duration
+	BackoffMax         time.Duration
+}
+
+type LoginThrottleUseCase interface {
+	Check(ctx context.Context, email string, ip string) error
+	RegisterFailure(ctx context.Context, email string, ip string) error
+	RegisterSuccess(ctx context.Context, email string) error
+	UnlockUser(ctx context.Context, tenantId uint, userId uint) error
+}
+
+type loginThrottleUseCase struct {
+	userRepository          repository.UserRepository
+	loginThrottleRepository repository.LoginThrottleRepository
+	policy                  LoginThrottlePolicy
+	now                     func() time.Time
+
+	mu       sync.Mutex
+	purgedAt time.Time
+}
+
+func NewLoginThrottleUseCase(userRepository repository.UserRepository, loginThrottleRepository repository.LoginThrottleRepository, policy LoginThrottlePolicy) LoginThrottleUseCase {
+	return &loginThrottleUseCase{
+		userRepository:          userRepository,
+		loginThrottleRepository: loginThrottleRepository,
+		policy:                  policy,
+		now:                     time.Now,
+	}
+}
+
+func (uc *loginThrottleUseCase) Check(ctx context.Context, email string, ip string) error {
+	now := uc.now()
+
+	t, err := uc.findThrottle(ctx, domain.LoginThrottleScopeAccount, normalizeEmail(email))
+	if err != nil {
+		return err
+	}
+	if t != nil {
+		if t.IsLocked(now) {
+			return &domain.LoginBlockedError{Reason: domain.ErrAccountLocked, RetryAfter: t.LockedUntil.Sub(now)}
+		}
+		if wait := uc.backoffRemaining(t, now); wait > 0 {
+			return &domain.LoginBlockedError{Reason: domain.ErrTooManyLoginAttempts, RetryAfter: wait}
+		}
+	}
+
+	if len(ip) == 0 {
+		return nil
+	}
+
+	t, err = uc.findThrottle(ctx, domain.LoginThrottleScopeIP, ip)
+	if err != nil {
+		return err
+	}
+	if t != nil && t.IsLocked(now) {
+		return &domain.LoginBlockedError{Reason: domain.ErrTooManyLoginAttempts, RetryAfter: t.LockedUntil.Sub(now)}
+	}
+
+	return nil
+}
+
+// RegisterFailure counts a failed login against the account of email and
+// against ip. An empty email, such as one no account uses, only counts against
+// ip, so unknown emails do not each get a throttle of their own.
+func (uc *loginThrottleUseCase) RegisterFailure(ctx context.Context, email string, ip string) error {
+	err := uc.purge(ctx, uc.now())
+	if err != nil {
+		return err
+	}
+
+	if email = normalizeEmail(email); len(email) > 0 {
+		err = uc.registerFailure(ctx, domain.LoginThrottleScopeAccount, email, uc.policy.MaxAccountFailures)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(ip) == 0 {
+		return nil
+	}
+
+	return uc.registerFailure(ctx, domain.LoginThrottleScopeIP, ip, uc.policy.MaxIPFailures)
+}
+
+// RegisterSuccess clears the account counter. The IP counter is left to expire
+// on its own, otherwise a valid account could be used to reset it.
+func (uc *loginThrottleUseCase) RegisterSuccess(ctx context.Context, email string) error {
+	return uc.loginThrottleRepository.DeleteThrottle(ctx, domain.LoginThrottleScopeAccount, normalizeEmail(email))
+}
+
+func (uc *loginThrottleUseCase) UnlockUser(ctx context.Context, tenantId uint, userId uint) error {
+	user, err := uc.userRepository.ForTenant(tenantId).FindUserById(ctx, userId)
+	if err != nil {
+		return userNotFound(err)
+	}
+
+	return uc.loginThrottleRepository.DeleteThrottle(ctx, domain.LoginThrottleScopeAccount, normalizeEmail(user.Email))
+}
+
+func (uc *loginThrottleUseCase) registerFailure(ctx context.Context, scope string, subject string, maxFailures uint) error {
+	now := uc.now()
+
+	// Failures older than the lockout window are forgotten.
+	_, err := uc.loginThrottleRepository.AddFailure(ctx, &domain.LoginFailure{
+		Scope:        scope,
+		Subject:      subject,
+		FailedAt:     now,
+		ForgetBefore: now.Add(-uc.policy.LockoutDuration),
+		MaxFailures:  maxFailures,
+		LockUntil:    now.Add(uc.policy.LockoutDuration),
+	})
+
+	return err
+}
+
+// purge drops the throttles that no longer lock nor slow down anyone, at most
+// once per loginThrottlePurgeInterval, so they do not pile up.
+func (uc *loginThrottleUseCase) purge(ctx context.Context, now time.Time) error {
+	uc.mu.Lock()
+	if now.Sub(uc.purgedAt) < loginThrottlePurgeInterval {
+		uc.mu.Unlock()
+		return nil
+	}
+	uc.purgedAt = now
+	uc.mu.Unlock()
+
+	keep := max(uc.policy.LockoutDuration, uc.policy.BackoffMax)
+
+	return uc.loginThrottleRepository.DeleteExpiredThrottles(ctx, now.Add(-keep), now)
+}
+
+func (uc *loginThrottleUseCase) findThrottle(ctx context.Context, scope string, subject string) (*domain.LoginThrottle, error) {
+	t, err := uc.loginThrottleRepository.FindThrottle(ctx, scope, subject)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// backoffRemaining returns how long the account must wait before the next
+// attempt, doubling BackoffBase on every consecutive failure up to BackoffMax.
+func (uc *loginThrottleUseCase) backoffRemaining(t *domain.LoginThrottle, now time.Time) time.Duration {
+	if t.Failures == 0 || uc.policy.BackoffBase <= 0 {
+		return 0
+	}
+
+	delay := uc.policy.BackoffMax
+	if shift := t.Failures - 1; shift < 32 {
+		if d := uc.policy.BackoffBase << shift; d > 0 && d < uc.policy.BackoffMax {
+			delay = d
+		}
+	}
+
+	return t.LastFailedAt.Add(delay).Sub(now)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
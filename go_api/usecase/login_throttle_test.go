@@ -0,0 +1,326 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+var testLoginThrottlePolicy = LoginThrottlePolicy{
+	MaxAccountFailures: 3,
+	MaxIPFailures:      10,
+	LockoutDuration:    15 * time.Minute,
+	BackoffBase:        time.Second,
+	BackoffMax:         time.Minute,
+}
+
+func newTestLoginThrottleUseCase(userRepository *mockUserRepository, loginThrottleRepository *mockLoginThrottleRepository, now time.Time) *loginThrottleUseCase {
+	uc := NewLoginThrottleUseCase(userRepository, loginThrottleRepository, testLoginThrottlePolicy).(*loginThrottleUseCase)
+	uc.now = func() time.Time { return now }
+	uc.purgedAt = now
+	return uc
+}
+
+func TestLoginThrottleCheck(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	lockedUntil := now.Add(10 * time.Minute)
+	expiredLock := now.Add(-time.Minute)
+
+	mockLoginThrottleRepository := new(mockLoginThrottleRepository)
+
+	testCases := []struct {
+		name              string
+		email             string
+		ip                string
+		mockAccountReturn *domain.LoginThrottle
+		mockAccountError  error
+		mockIPReturn      *domain.LoginThrottle
+		mockIPError       error
+		expectIPLookup    bool
+		expectedError     error
+	}{
+		{
+			name:             "No failures",
+			email:            "User1@Example.com ",
+			ip:               "192.0.2.1",
+			mockAccountError: gorm.ErrRecordNotFound,
+			mockIPError:      gorm.ErrRecordNotFound,
+			expectIPLookup:   true,
+			expectedError:    nil,
+		},
+		{
+			name:  "Account locked",
+			email: "user1@example.com",
+			ip:    "192.0.2.1",
+			mockAccountReturn: &domain.LoginThrottle{
+				Failures:     3,
+				LastFailedAt: now.Add(-5 * time.Minute),
+				LockedUntil:  &lockedUntil,
+			},
+			expectedError: &domain.LoginBlockedError{Reason: domain.ErrAccountLocked, RetryAfter: 10 * time.Minute},
+		},
+		{
+			name:  "Account backoff",
+			email: "user1@example.com",
+			ip:    "192.0.2.1",
+			mockAccountReturn: &domain.LoginThrottle{
+				Failures:     2,
+				LastFailedAt: now.Add(-500 * time.Millisecond),
+			},
+			expectedError: &domain.LoginBlockedError{Reason: domain.ErrTooManyLoginAttempts, RetryAfter: 1500 * time.Millisecond},
+		},
+		{
+			name:  "Backoff capped",
+			email: "user1@example.com",
+			ip:    "192.0.2.1",
+			mockAccountReturn: &domain.LoginThrottle{
+				Failures:     40,
+				LastFailedAt: now.Add(-30 * time.Second),
+				LockedUntil:  &expiredLock,
+			},
+			expectedError: &domain.LoginBlockedError{Reason: domain.ErrTooManyLoginAttempts, RetryAfter: 30 * time.Second},
+		},
+		{
+			name:  "Backoff elapsed and lock expired",
+			email: "user1@example.com",
+			ip:    "192.0.2.1",
+			mockAccountReturn: &domain.LoginThrottle{
+				Failures:     3,
+				LastFailedAt: now.Add(-15 * time.Minute),
+				LockedUntil:  &expiredLock,
+			},
+			mockIPError:    gorm.ErrRecordNotFound,
+			expectIPLookup: true,
+			expectedError:  nil,
+		},
+		{
+			name:             "IP locked",
+			email:            "user1@example.com",
+			ip:               "192.0.2.1",
+			mockAccountError: gorm.ErrRecordNotFound,
+			mockIPReturn: &domain.LoginThrottle{
+				Failures:     10,
+				LastFailedAt: now,
+				LockedUntil:  &lockedUntil,
+			},
+			expectIPLookup: true,
+			expectedError:  &domain.LoginBlockedError{Reason: domain.ErrTooManyLoginAttempts, RetryAfter: 10 * time.Minute},
+		},
+		{
+			name:             "Without IP",
+			email:            "user1@example.com",
+			ip:               "",
+			mockAccountError: gorm.ErrRecordNotFound,
+			expectIPLookup:   false,
+			expectedError:    nil,
+		},
+		{
+			name:             "Repository error",
+			email:            "user1@example.com",
+			ip:               "192.0.2.1",
+			mockAccountError: gorm.ErrInvalidDB,
+			expectedError:    gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockLoginThrottleRepository.ExpectedCalls = nil
+
+			mockLoginThrottleRepository.On("FindThrottle", mock.Anything, domain.LoginThrottleScopeAccount, "user1@example.com").Return(tc.mockAccountReturn, tc.mockAccountError)
+			if tc.expectIPLookup {
+				mockLoginThrottleRepository.On("FindThrottle", mock.Anything, domain.LoginThrottleScopeIP, tc.ip).Return(tc.mockIPReturn, tc.mockIPError)
+			}
+
+			uc := newTestLoginThrottleUseCase(new(mockUserRepository), mockLoginThrottleRepository, now)
+
+			err := uc.Check(context.Background(), tc.email, tc.ip)
+
+			assert.Equal(t, tc.expectedError, err, "Expected Check error to match.")
+			mockLoginThrottleRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLoginThrottleRegisterFailure(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	mockLoginThrottleRepository := new(mockLoginThrottleRepository)
+
+	accountFailure := &domain.LoginFailure{
+		Scope:        domain.LoginThrottleScopeAccount,
+		Subject:      "user1@example.com",
+		FailedAt:     now,
+		ForgetBefore: now.Add(-testLoginThrottlePolicy.LockoutDuration),
+		MaxFailures:  testLoginThrottlePolicy.MaxAccountFailures,
+		LockUntil:    now.Add(testLoginThrottlePolicy.LockoutDuration),
+	}
+	ipFailure := &domain.LoginFailure{
+		Scope:        domain.LoginThrottleScopeIP,
+		Subject:      "192.0.2.1",
+		FailedAt:     now,
+		ForgetBefore: now.Add(-testLoginThrottlePolicy.LockoutDuration),
+		MaxFailures:  testLoginThrottlePolicy.MaxIPFailures,
+		LockUntil:    now.Add(testLoginThrottlePolicy.LockoutDuration),
+	}
+
+	testCases := []struct {
+		name                 string
+		email                string
+		ip                   string
+		mockAccountError     error
+		expectAccountFailure bool
+		expectIPFailure      bool
+		expectedError        error
+	}{
+		{
+			name:                 "Account and IP",
+			email:                " User1@example.com",
+			ip:                   "192.0.2.1",
+			expectAccountFailure: true,
+			expectIPFailure:      true,
+		},
+		{
+			name:                 "No IP",
+			email:                " User1@example.com",
+			expectAccountFailure: true,
+		},
+		{
+			name:            "Unknown email",
+			ip:              "192.0.2.1",
+			expectIPFailure: true,
+		},
+		{
+			name:                 "Account failure not counted",
+			email:                " User1@example.com",
+			ip:                   "192.0.2.1",
+			mockAccountError:     gorm.ErrInvalidDB,
+			expectAccountFailure: true,
+			expectedError:        gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockLoginThrottleRepository.ExpectedCalls = nil
+			mockLoginThrottleRepository.Calls = nil
+
+			if tc.expectAccountFailure {
+				mockLoginThrottleRepository.On("AddFailure", mock.Anything, accountFailure).Return(&domain.LoginThrottle{}, tc.mockAccountError)
+			}
+			if tc.expectIPFailure {
+				mockLoginThrottleRepository.On("AddFailure", mock.Anything, ipFailure).Return(&domain.LoginThrottle{}, nil)
+			}
+
+			uc := newTestLoginThrottleUseCase(new(mockUserRepository), mockLoginThrottleRepository, now)
+
+			err := uc.RegisterFailure(context.Background(), tc.email, tc.ip)
+
+			assert.Equal(t, tc.expectedError, err, "Expected error to match")
+			mockLoginThrottleRepository.AssertExpectations(t)
+			mockLoginThrottleRepository.AssertNumberOfCalls(t, "AddFailure", len(mockLoginThrottleRepository.ExpectedCalls))
+		})
+	}
+}
+
+func TestLoginThrottlePurge(t *testing.T) {
+
+	start := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	mockLoginThrottleRepository := new(mockLoginThrottleRepository)
+	mockLoginThrottleRepository.On("AddFailure", mock.Anything, mock.Anything).Return(&domain.LoginThrottle{}, nil)
+	mockLoginThrottleRepository.On("DeleteExpiredThrottles", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	uc := NewLoginThrottleUseCase(new(mockUserRepository), mockLoginThrottleRepository, testLoginThrottlePolicy).(*loginThrottleUseCase)
+	now := start
+	uc.now = func() time.Time { return now }
+
+	err := uc.RegisterFailure(context.Background(), "user1@example.com", "192.0.2.1")
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	// No purge is due before a minute.
+	now = start.Add(30 * time.Second)
+	err = uc.RegisterFailure(context.Background(), "user1@example.com", "192.0.2.1")
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	now = start.Add(time.Minute + 30*time.Second)
+	err = uc.RegisterFailure(context.Background(), "user1@example.com", "192.0.2.1")
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	// Throttles are kept as long as they may still lock the account.
+	mockLoginThrottleRepository.AssertNumberOfCalls(t, "DeleteExpiredThrottles", 2)
+	mockLoginThrottleRepository.AssertCalled(t, "DeleteExpiredThrottles", mock.Anything, start.Add(-testLoginThrottlePolicy.LockoutDuration), start)
+	mockLoginThrottleRepository.AssertCalled(t, "DeleteExpiredThrottles", mock.Anything, now.Add(-testLoginThrottlePolicy.LockoutDuration), now)
+}
+
+func TestLoginThrottleRegisterSuccess(t *testing.T) {
+
+	mockLoginThrottleRepository := new(mockLoginThrottleRepository)
+	mockLoginThrottleRepository.On("DeleteThrottle", mock.Anything, domain.LoginThrottleScopeAccount, "user1@example.com").Return(nil)
+
+	uc := newTestLoginThrottleUseCase(new(mockUserRepository), mockLoginThrottleRepository, time.Now())
+
+	err := uc.RegisterSuccess(context.Background(), "USER1@example.com")
+
+	assert.NoError(t, err, "Did not expect an error but got one")
+	mockLoginThrottleRepository.AssertExpectations(t)
+}
+
+func TestLoginThrottleUnlockUser(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	mockLoginThrottleRepository := new(mockLoginThrottleRepository)
+
+	testCases := []struct {
+		name           string
+		input          uint
+		mockUserReturn *domain.User
+		mockUserError  error
+		expectDelete   bool
+		expectedError  error
+	}{
+		{
+			name:           "Success",
+			input:          1,
+			mockUserReturn: &domain.User{ID: 1, Email: "user1@example.com"},
+			expectDelete:   true,
+			expectedError:  nil,
+		},
+		{
+			name:           "User not found",
+			input:          100,
+			mockUserReturn: nil,
+			mockUserError:  gorm.ErrRecordNotFound,
+			expectDelete:   false,
+			expectedError:  domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockLoginThrottleRepository.ExpectedCalls = nil
+
+			mockUserRepository.On("ForTenant", uint(2)).Once()
+			mockUserRepository.On("FindUserById", mock.Anything, tc.input).Return(tc.mockUserReturn, tc.mockUserError)
+			if tc.expectDelete {
+				mockLoginThrottleRepository.On("DeleteThrottle", mock.Anything, domain.LoginThrottleScopeAccount, tc.mockUserReturn.Email).Return(nil)
+			}
+
+			uc := newTestLoginThrottleUseCase(mockUserRepository, mockLoginThrottleRepository, time.Now())
+
+			err := uc.UnlockUser(context.Background(), 2, tc.input)
+
+			assert.Equal(t, tc.expectedError, err, "Expected UnlockUser error to match.")
+			mockUserRepository.AssertExpectations(t)
+			mockLoginThrottleRepository.AssertExpectations(t)
+		})
+	}
+}
@@ -1,34 +1,583 @@
 package usecase
 
 import (
+	"context"
+	"time"
+
 	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/oidc"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// testPasswordHasher uses the cost of the hashes built by the tests, so a
+// login does not trigger a rehash.
+var testPasswordHasher, _ = util.NewBcryptHasher(bcrypt.DefaultCost)
+
 type mockUserRepository struct {
 	mock.Mock
 }
 
-func (m *mockUserRepository) CreateUser(u *domain.User) (*domain.User, error) {
-	args := m.Called(u)
+func (m *mockUserRepository) CreateUser(ctx context.Context, u *domain.User) (*domain.User, error) {
+	args := m.Called(ctx, u)
 	return args.Get(0).(*domain.User), args.Error(1)
 }
-func (m *mockUserRepository) ListUsers() ([]*domain.User, error) {
-	args := m.Called()
+
+func (m *mockUserRepository) CreateUsers(ctx context.Context, us []*domain.User, batchSize int) error {
+	args := m.Called(ctx, us, batchSize)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) FindTakenEmails(ctx context.Context, emails []string) ([]string, error) {
+	args := m.Called(ctx, emails)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockUserRepository) ListUsers(ctx context.Context, q *domain.UserListQuery) ([]*domain.User, error) {
+	args := m.Called(ctx, q)
 	return args.Get(0).([]*domain.User), args.Error(1)
 }
 
-func (m *mockUserRepository) FindUserById(id uint) (*domain.User, error) {
-	args := m.Called(id)
+func (m *mockUserRepository) FindUserById(ctx context.Context, id uint) (*domain.User, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *mockUserRepository) FindUserByEmail(email string) (*domain.User, error) {
-	args := m.Called(email)
+func (m *mockUserRepository) FindUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *mockUserRepository) UpdateUserPassword(u *domain.User) error {
-	args := m.Called(u)
-	return args.Error(1)
+func (m *mockUserRepository) UpdateUserPassword(ctx context.Context, u *domain.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) UpdateUserPasswordHash(ctx context.Context, userId uint, oldHash string, newHash string) error {
+	args := m.Called(ctx, userId, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) ListPasswordHistory(ctx context.Context, userId uint, limit int) ([]*domain.PasswordHistory, error) {
+	args := m.Called(ctx, userId, limit)
+	return args.Get(0).([]*domain.PasswordHistory), args.Error(1)
+}
+
+func (m *mockUserRepository) UpdateUserEmailVerifiedAt(ctx context.Context, u *domain.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) UpdateUserProfile(ctx context.Context, u *domain.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) UpdateUserEmail(ctx context.Context, u *domain.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) UpdateUserStatus(ctx context.Context, u *domain.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) DeleteUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) RestoreUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) PurgeUser(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockUserRepository) FindUserData(ctx context.Context, id uint) (*domain.UserData, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.UserData), args.Error(1)
+}
+
+func (m *mockUserRepository) AnonymizeUser(ctx context.Context, u *domain.User, withdrawals []*domain.Consent) error {
+	args := m.Called(ctx, u, withdrawals)
+	return args.Error(0)
+}
+
+// ForTenant records the tenant and returns the same mock, so tests can assert
+// which organization a use case scoped its queries to.
+func (m *mockUserRepository) ForTenant(organizationId uint) repository.UserRepository {
+	m.Called(organizationId)
+	return m
+}
+
+// AllTenants returns the same mock without recording a call, lookups that
+// span every organization need no expectation.
+func (m *mockUserRepository) AllTenants() repository.UserRepository {
+	return m
+}
+
+type mockEmailVerificationRepository struct {
+	mock.Mock
+}
+
+func (m *mockEmailVerificationRepository) CreateToken(ctx context.Context, t *domain.EmailVerificationToken) (*domain.EmailVerificationToken, error) {
+	args := m.Called(ctx, t)
+	return args.Get(0).(*domain.EmailVerificationToken), args.Error(1)
+}
+
+func (m *mockEmailVerificationRepository) FindTokenByHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*domain.EmailVerificationToken), args.Error(1)
+}
+
+func (m *mockEmailVerificationRepository) DeleteTokensByUserId(ctx context.Context, userId uint) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+type mockEmailChangeRepository struct {
+	mock.Mock
+}
+
+func (m *mockEmailChangeRepository) CreateToken(ctx context.Context, t *domain.EmailChangeToken) (*domain.EmailChangeToken, error) {
+	args := m.Called(ctx, t)
+	return args.Get(0).(*domain.EmailChangeToken), args.Error(1)
+}
+
+func (m *mockEmailChangeRepository) FindTokenByHash(ctx context.Context, tokenHash string) (*domain.EmailChangeToken, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*domain.EmailChangeToken), args.Error(1)
+}
+
+func (m *mockEmailChangeRepository) DeleteTokensByUserId(ctx context.Context, userId uint) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+type mockEmailChangeUseCase struct {
+	mock.Mock
+}
+
+func (m *mockEmailChangeUseCase) CheckNewEmail(ctx context.Context, newEmail string) (string, error) {
+	args := m.Called(ctx, newEmail)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockEmailChangeUseCase) RequestEmailChange(ctx context.Context, user *domain.User, newEmail string) error {
+	args := m.Called(ctx, user, newEmail)
+	return args.Error(0)
+}
+
+func (m *mockEmailChangeUseCase) ConfirmEmailChange(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+type mockMailer struct {
+	mock.Mock
+}
+
+func (m *mockMailer) Send(to string, subject string, body string) error {
+	args := m.Called(to, subject, body)
+	return args.Error(0)
+}
+
+type mockEmailVerificationUseCase struct {
+	mock.Mock
+}
+
+func (m *mockEmailVerificationUseCase) SendVerificationEmail(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *mockEmailVerificationUseCase) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *mockEmailVerificationUseCase) ResendVerificationEmail(ctx context.Context, input *dto.ResendVerificationEmailInputDTO) error {
+	args := m.Called(ctx, input)
+	return args.Error(0)
+}
+
+type mockLoginThrottleRepository struct {
+	mock.Mock
+}
+
+func (m *mockLoginThrottleRepository) FindThrottle(ctx context.Context, scope string, subject string) (*domain.LoginThrottle, error) {
+	args := m.Called(ctx, scope, subject)
+	return args.Get(0).(*domain.LoginThrottle), args.Error(1)
+}
+
+func (m *mockLoginThrottleRepository) AddFailure(ctx context.Context, f *domain.LoginFailure) (*domain.LoginThrottle, error) {
+	args := m.Called(ctx, f)
+	return args.Get(0).(*domain.LoginThrottle), args.Error(1)
+}
+
+func (m *mockLoginThrottleRepository) DeleteThrottle(ctx context.Context, scope string, subject string) error {
+	args := m.Called(ctx, scope, subject)
+	return args.Error(0)
+}
+
+func (m *mockLoginThrottleRepository) DeleteExpiredThrottles(ctx context.Context, lastFailedBefore time.Time, now time.Time) error {
+	args := m.Called(ctx, lastFailedBefore, now)
+	return args.Error(0)
+}
+
+type mockLoginThrottleUseCase struct {
+	mock.Mock
+}
+
+func (m *mockLoginThrottleUseCase) Check(ctx context.Context, email string, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *mockLoginThrottleUseCase) RegisterFailure(ctx context.Context, email string, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *mockLoginThrottleUseCase) RegisterSuccess(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *mockLoginThrottleUseCase) UnlockUser(ctx context.Context, tenantId uint, userId uint) error {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Error(0)
+}
+
+type mockMfaRepository struct {
+	mock.Mock
+}
+
+func (m *mockMfaRepository) FindTotpCredential(ctx context.Context, userId uint) (*domain.TotpCredential, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).(*domain.TotpCredential), args.Error(1)
+}
+
+func (m *mockMfaRepository) SaveTotpCredential(ctx context.Context, c *domain.TotpCredential) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *mockMfaRepository) MarkTotpStepUsed(ctx context.Context, userId uint, step int64) error {
+	args := m.Called(ctx, userId, step)
+	return args.Error(0)
+}
+
+func (m *mockMfaRepository) ReplaceRecoveryCodes(ctx context.Context, userId uint, codes []*domain.MfaRecoveryCode) error {
+	args := m.Called(ctx, userId, codes)
+	return args.Error(0)
+}
+
+func (m *mockMfaRepository) FindUnusedRecoveryCode(ctx context.Context, userId uint, codeHash string) (*domain.MfaRecoveryCode, error) {
+	args := m.Called(ctx, userId, codeHash)
+	return args.Get(0).(*domain.MfaRecoveryCode), args.Error(1)
+}
+
+func (m *mockMfaRepository) MarkRecoveryCodeUsed(ctx context.Context, c *domain.MfaRecoveryCode) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+type mockMfaUseCase struct {
+	mock.Mock
+}
+
+func (m *mockMfaUseCase) EnrollTotp(ctx context.Context, user *domain.User) (*dto.TotpEnrollmentOutputDTO, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(*dto.TotpEnrollmentOutputDTO), args.Error(1)
+}
+
+func (m *mockMfaUseCase) ConfirmTotp(ctx context.Context, user *domain.User, input *dto.TotpConfirmInputDTO) (*dto.RecoveryCodesOutputDTO, error) {
+	args := m.Called(ctx, user, input)
+	return args.Get(0).(*dto.RecoveryCodesOutputDTO), args.Error(1)
+}
+
+func (m *mockMfaUseCase) IsEnabled(ctx context.Context, userId uint) (bool, error) {
+	args := m.Called(ctx, userId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockMfaUseCase) Verify(ctx context.Context, userId uint, code string, recoveryCode string) error {
+	args := m.Called(ctx, userId, code, recoveryCode)
+	return args.Error(0)
+}
+
+type mockApiKeyRepository struct {
+	mock.Mock
+}
+
+func (m *mockApiKeyRepository) CreateApiKey(ctx context.Context, k *domain.ApiKey) (*domain.ApiKey, error) {
+	args := m.Called(ctx, k)
+	return args.Get(0).(*domain.ApiKey), args.Error(1)
+}
+
+func (m *mockApiKeyRepository) ListApiKeysByUserId(ctx context.Context, userId uint) ([]*domain.ApiKey, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]*domain.ApiKey), args.Error(1)
+}
+
+func (m *mockApiKeyRepository) FindApiKeyByHash(ctx context.Context, keyHash string) (*domain.ApiKey, error) {
+	args := m.Called(ctx, keyHash)
+	return args.Get(0).(*domain.ApiKey), args.Error(1)
+}
+
+func (m *mockApiKeyRepository) DeleteApiKey(ctx context.Context, userId uint, id uint) error {
+	args := m.Called(ctx, userId, id)
+	return args.Error(0)
+}
+
+func (m *mockApiKeyRepository) UpdateApiKeyLastUsedAt(ctx context.Context, k *domain.ApiKey) error {
+	args := m.Called(ctx, k)
+	return args.Error(0)
+}
+
+type mockOidcRepository struct {
+	mock.Mock
+}
+
+func (m *mockOidcRepository) CreateLoginState(ctx context.Context, s *domain.OidcLoginState) (*domain.OidcLoginState, error) {
+	args := m.Called(ctx, s)
+	return args.Get(0).(*domain.OidcLoginState), args.Error(1)
+}
+
+func (m *mockOidcRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*domain.OidcLoginState, error) {
+	args := m.Called(ctx, stateHash)
+	return args.Get(0).(*domain.OidcLoginState), args.Error(1)
+}
+
+func (m *mockOidcRepository) FindIdentity(ctx context.Context, issuer string, subject string) (*domain.UserIdentity, error) {
+	args := m.Called(ctx, issuer, subject)
+	return args.Get(0).(*domain.UserIdentity), args.Error(1)
+}
+
+func (m *mockOidcRepository) CreateIdentity(ctx context.Context, i *domain.UserIdentity) (*domain.UserIdentity, error) {
+	args := m.Called(ctx, i)
+	return args.Get(0).(*domain.UserIdentity), args.Error(1)
+}
+
+type mockOidcProvider struct {
+	mock.Mock
+}
+
+func (m *mockOidcProvider) Issuer() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *mockOidcProvider) AuthCodeURL(state string, nonce string, codeVerifier string) string {
+	args := m.Called(state, nonce, codeVerifier)
+	return args.String(0)
+}
+
+func (m *mockOidcProvider) Exchange(code string, codeVerifier string, nonce string) (*oidc.Identity, error) {
+	args := m.Called(code, codeVerifier, nonce)
+	return args.Get(0).(*oidc.Identity), args.Error(1)
+}
+
+type mockAuthUseCase struct {
+	mock.Mock
+}
+
+func (m *mockAuthUseCase) Login(ctx context.Context, input *dto.LoginInputDTO) (*dto.LoginOutputDTO, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*dto.LoginOutputDTO), args.Error(1)
+}
+
+func (m *mockAuthUseCase) LoginMfa(ctx context.Context, input *dto.MfaLoginInputDTO) (*dto.LoginOutputDTO, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*dto.LoginOutputDTO), args.Error(1)
+}
+
+func (m *mockAuthUseCase) LoginExternalUser(ctx context.Context, user *domain.User, ip string, userAgent string) (*dto.LoginOutputDTO, error) {
+	args := m.Called(ctx, user, ip, userAgent)
+	return args.Get(0).(*dto.LoginOutputDTO), args.Error(1)
+}
+
+func (m *mockAuthUseCase) UnlockUser(ctx context.Context, tenantId uint, userId uint) error {
+	args := m.Called(ctx, tenantId, userId)
+	return args.Error(0)
+}
+
+func (m *mockAuthUseCase) CheckUserStatus(ctx context.Context, userId uint) error {
+	args := m.Called(ctx, userId)
+	return args.Error(0)
+}
+
+func (m *mockAuthUseCase) CheckImpersonatorStatus(ctx context.Context, actorId uint) error {
+	args := m.Called(ctx, actorId)
+	return args.Error(0)
+}
+
+type mockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *mockSessionRepository) CreateSession(ctx context.Context, s *domain.Session) (*domain.Session, error) {
+	args := m.Called(ctx, s)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *mockSessionRepository) FindSessionByTokenId(ctx context.Context, tokenId string) (*domain.Session, error) {
+	args := m.Called(ctx, tokenId)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *mockSessionRepository) ListActiveSessionsByUserId(ctx context.Context, userId uint, now time.Time) ([]*domain.Session, error) {
+	args := m.Called(ctx, userId, now)
+	return args.Get(0).([]*domain.Session), args.Error(1)
+}
+
+func (m *mockSessionRepository) RevokeSession(ctx context.Context, userId uint, id uint, revokedAt time.Time) error {
+	args := m.Called(ctx, userId, id, revokedAt)
+	return args.Error(0)
+}
+
+func (m *mockSessionRepository) RevokeOtherSessions(ctx context.Context, userId uint, exceptId uint, revokedAt time.Time) error {
+	args := m.Called(ctx, userId, exceptId, revokedAt)
+	return args.Error(0)
+}
+
+func (m *mockSessionRepository) RevokeImpersonationSessions(ctx context.Context, actorId uint, revokedAt time.Time) error {
+	args := m.Called(ctx, actorId, revokedAt)
+	return args.Error(0)
+}
+
+func (m *mockSessionRepository) UpdateSessionLastSeenAt(ctx context.Context, s *domain.Session) error {
+	args := m.Called(ctx, s)
+	return args.Error(0)
+}
+
+type mockSessionUseCase struct {
+	mock.Mock
+}
+
+func (m *mockSessionUseCase) CreateSession(ctx context.Context, userId uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error) {
+	args := m.Called(ctx, userId, ip, userAgent, duration)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *mockSessionUseCase) CreateImpersonationSession(ctx context.Context, userId uint, actorId uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error) {
+	args := m.Called(ctx, userId, actorId, ip, userAgent, duration)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *mockSessionUseCase) ListSessions(ctx context.Context, userId uint, currentSessionId uint) ([]*dto.SessionOutputDTO, error) {
+	args := m.Called(ctx, userId, currentSessionId)
+	return args.Get(0).([]*dto.SessionOutputDTO), args.Error(1)
+}
+
+func (m *mockSessionUseCase) RevokeSession(ctx context.Context, userId uint, sessionId uint) error {
+	args := m.Called(ctx, userId, sessionId)
+	return args.Error(0)
+}
+
+func (m *mockSessionUseCase) RevokeOtherSessions(ctx context.Context, userId uint, currentSessionId uint) error {
+	args := m.Called(ctx, userId, currentSessionId)
+	return args.Error(0)
+}
+
+func (m *mockSessionUseCase) RevokeImpersonationSessions(ctx context.Context, actorId uint) error {
+	args := m.Called(ctx, actorId)
+	return args.Error(0)
+}
+
+func (m *mockSessionUseCase) ValidateSession(ctx context.Context, userId uint, tokenId string) (*domain.Session, error) {
+	args := m.Called(ctx, userId, tokenId)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+type mockBreachedPasswordChecker struct {
+	mock.Mock
+}
+
+func (m *mockBreachedPasswordChecker) IsBreached(password string) (bool, error) {
+	args := m.Called(password)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockInvitationRepository struct {
+	mock.Mock
+}
+
+// ForTenant records the tenant and returns the same mock, see
+// mockUserRepository.ForTenant.
+func (m *mockInvitationRepository) ForTenant(organizationId uint) repository.InvitationRepository {
+	m.Called(organizationId)
+	return m
+}
+
+// AllTenants returns the same mock without recording a call.
+func (m *mockInvitationRepository) AllTenants() repository.InvitationRepository {
+	return m
+}
+
+func (m *mockInvitationRepository) CreateInvitation(ctx context.Context, i *domain.Invitation) (*domain.Invitation, error) {
+	args := m.Called(ctx, i)
+	return args.Get(0).(*domain.Invitation), args.Error(1)
+}
+
+func (m *mockInvitationRepository) ListPendingInvitations(ctx context.Context, now time.Time) ([]*domain.Invitation, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]*domain.Invitation), args.Error(1)
+}
+
+func (m *mockInvitationRepository) FindInvitationById(ctx context.Context, id uint) (*domain.Invitation, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.Invitation), args.Error(1)
+}
+
+func (m *mockInvitationRepository) DeleteInvitation(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockInvitationRepository) AcceptInvitation(ctx context.Context, i *domain.Invitation, u *domain.User) (*domain.User, error) {
+	args := m.Called(ctx, i, u)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+type mockConsentRepository struct {
+	mock.Mock
+}
+
+func (m *mockConsentRepository) CreateConsent(ctx context.Context, c *domain.Consent) (*domain.Consent, error) {
+	args := m.Called(ctx, c)
+	return args.Get(0).(*domain.Consent), args.Error(1)
+}
+
+func (m *mockConsentRepository) ListConsentsByUserId(ctx context.Context, userId uint) ([]*domain.Consent, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]*domain.Consent), args.Error(1)
+}
+
+type mockPreferenceRepository struct {
+	mock.Mock
+}
+
+func (m *mockPreferenceRepository) FindPreferencesByUserId(ctx context.Context, userId uint) (*domain.UserPreferences, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).(*domain.UserPreferences), args.Error(1)
+}
+
+func (m *mockPreferenceRepository) SavePreferences(ctx context.Context, p *domain.UserPreferences) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func verifyTestPassword(password string, encoded string) bool {
+	ok, err := testPasswordHasher.Verify(password, encoded)
+	return err == nil && ok
 }
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/totp"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaRecoveryCodeCount = 10
+	mfaRecoveryCodeSize  = 10
+	mfaQRCodeSize        = 256
+)
+
+type MfaUseCase interface {
+	EnrollTotp(ctx context.Context, user *domain.User) (*dto.TotpEnrollmentOutputDTO, error)
+	ConfirmTotp(ctx context.Context, user *domain.User, input *dto.TotpConfirmInputDTO) (*dto.RecoveryCodesOutputDTO, error)
+	IsEnabled(ctx context.Context, userId uint) (bool, error)
+	Verify(ctx context.Context, userId uint, code string, recoveryCode string) error
+}
+
+type mfaUseCase struct {
+	mfaRepository repository.MfaRepository
+	issuer        string
+	now           func() time.Time
+}
+
+func NewMfaUseCase(mfaRepository repository.MfaRepository, issuer string) MfaUseCase {
+	return &mfaUseCase{
+		mfaRepository: mfaRepository,
+		issuer:        issuer,
+		now:           time.Now,
+	}
+}
+
+func (uc *mfaUseCase) EnrollTotp(ctx context.Context, user *domain.User) (*dto.TotpEnrollmentOutputDTO, error) {
+	c, err := uc.findTotpCredential(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if c != nil && c.IsConfirmed() {
+		return nil, domain.ErrMfaAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// Starting over replaces any pending, unconfirmed secret.
+	err = uc.mfaRepository.SaveTotpCredential(ctx, &domain.TotpCredential{
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uri := totp.KeyURI(uc.issuer, user.Email, secret)
+
+	qrCode, err := totp.QRCodePNG(uri, mfaQRCodeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TotpEnrollmentOutputDTO{
+		Secret:     secret,
+		OtpauthURI: uri,
+		QRCodePNG:  qrCode,
+	}, nil
+}
+
+func (uc *mfaUseCase) ConfirmTotp(ctx context.Context, user *domain.User, input *dto.TotpConfirmInputDTO) (*dto.RecoveryCodesOutputDTO, error) {
+	c, err := uc.findTotpCredential(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, domain.ErrMfaNotEnrolled
+	}
+	if c.IsConfirmed() {
+		return nil, domain.ErrMfaAlreadyEnabled
+	}
+
+	now := uc.now()
+
+	step, ok := totp.Validate(c.Secret, input.Code, now)
+	if !ok {
+		return nil, domain.ErrMfaCodeInvalid
+	}
+
+	c.ConfirmedAt = &now
+	c.LastUsedStep = step
+
+	err = uc.mfaRepository.SaveTotpCredential(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, mfaRecoveryCodeCount)
+	recoveryCodes := make([]*domain.MfaRecoveryCode, mfaRecoveryCodeCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		recoveryCodes[i] = &domain.MfaRecoveryCode{CodeHash: util.HashOpaqueToken(normalizeRecoveryCode(codes[i]))}
+	}
+
+	err = uc.mfaRepository.ReplaceRecoveryCodes(ctx, user.ID, recoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.RecoveryCodesOutputDTO{RecoveryCodes: codes}, nil
+}
+
+func (uc *mfaUseCase) IsEnabled(ctx context.Context, userId uint) (bool, error) {
+	c, err := uc.findTotpCredential(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+
+	return c != nil && c.IsConfirmed(), nil
+}
+
+// Verify checks either a TOTP code or, when given, a one-time recovery code.
+func (uc *mfaUseCase) Verify(ctx context.Context, userId uint, code string, recoveryCode string) error {
+	if len(recoveryCode) > 0 {
+		return uc.useRecoveryCode(ctx, userId, recoveryCode)
+	}
+
+	c, err := uc.findTotpCredential(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if c == nil || !c.IsConfirmed() {
+		return domain.ErrMfaNotEnrolled
+	}
+
+	step, ok := totp.Validate(c.Secret, code, uc.now())
+	// A code is only accepted once, even inside its validity window.
+	if !ok || step <= c.LastUsedStep {
+		return domain.ErrMfaCodeInvalid
+	}
+
+	err = uc.mfaRepository.MarkTotpStepUsed(ctx, userId, step)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.ErrMfaCodeInvalid
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *mfaUseCase) useRecoveryCode(ctx context.Context, userId uint, recoveryCode string) error {
+	rc, err := uc.mfaRepository.FindUnusedRecoveryCode(ctx, userId, util.HashOpaqueToken(normalizeRecoveryCode(recoveryCode)))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.ErrMfaCodeInvalid
+		default:
+			return err
+		}
+	}
+
+	usedAt := uc.now()
+	rc.UsedAt = &usedAt
+
+	err = uc.mfaRepository.MarkRecoveryCodeUsed(ctx, rc)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.ErrMfaCodeInvalid
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (uc *mfaUseCase) findTotpCredential(ctx context.Context, userId uint) (*domain.TotpCredential, error) {
+	c, err := uc.mfaRepository.FindTotpCredential(ctx, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// newRecoveryCode returns a random code formatted as "xxxx-xxxx-xxxx-xxxx".
+func newRecoveryCode() (string, error) {
+	b := make([]byte, mfaRecoveryCodeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	s := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+
+	return s[0:4] + "-" + s[4:8] + "-" + s[8:12] + "-" + s[12:16], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
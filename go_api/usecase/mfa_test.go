@@ -0,0 +1,347 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/totp"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+const testTotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func newTestMfaUseCase(mfaRepository *mockMfaRepository, now time.Time) *mfaUseCase {
+	uc := NewMfaUseCase(mfaRepository, "GO-Sales").(*mfaUseCase)
+	uc.now = func() time.Time { return now }
+	return uc
+}
+
+func TestEnrollTotp(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+
+	mockMfaRepository := new(mockMfaRepository)
+
+	testCases := []struct {
+		name          string
+		mockReturn    *domain.TotpCredential
+		mockError     error
+		expectSave    bool
+		expectedError error
+	}{
+		{
+			name:          "New enrollment",
+			mockReturn:    nil,
+			mockError:     gorm.ErrRecordNotFound,
+			expectSave:    true,
+			expectedError: nil,
+		},
+		{
+			name:          "Pending enrollment replaced",
+			mockReturn:    &domain.TotpCredential{UserID: 1, Secret: testTotpSecret},
+			mockError:     nil,
+			expectSave:    true,
+			expectedError: nil,
+		},
+		{
+			name:          "Already enabled",
+			mockReturn:    &domain.TotpCredential{UserID: 1, Secret: testTotpSecret, ConfirmedAt: &now},
+			mockError:     nil,
+			expectSave:    false,
+			expectedError: domain.ErrMfaAlreadyEnabled,
+		},
+		{
+			name:          "Repository error",
+			mockReturn:    nil,
+			mockError:     gorm.ErrInvalidDB,
+			expectSave:    false,
+			expectedError: gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMfaRepository.ExpectedCalls = nil
+
+			mockMfaRepository.On("FindTotpCredential", mock.Anything, user.ID).Return(tc.mockReturn, tc.mockError)
+			if tc.expectSave {
+				mockMfaRepository.On("SaveTotpCredential", mock.Anything, mock.MatchedBy(func(c *domain.TotpCredential) bool {
+					return c.UserID == user.ID && c.ConfirmedAt == nil && c.Secret != testTotpSecret
+				})).Return(nil)
+			}
+
+			uc := newTestMfaUseCase(mockMfaRepository, now)
+
+			output, err := uc.EnrollTotp(context.Background(), user)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err, "Expected EnrollTotp error to match.")
+				assert.Nil(t, output, "Expected output to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.NotEmpty(t, output.Secret, "Expected secret not to be empty")
+				assert.Equal(t, totp.KeyURI("GO-Sales", user.Email, output.Secret), output.OtpauthURI, "Expected otpauth URI to match")
+				assert.Equal(t, []byte("\x89PNG"), output.QRCodePNG[:4], "Expected a PNG QR code")
+			}
+
+			mockMfaRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConfirmTotp(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+	validCode, _ := totp.GenerateCode(testTotpSecret, now)
+
+	mockMfaRepository := new(mockMfaRepository)
+
+	testCases := []struct {
+		name          string
+		input         *dto.TotpConfirmInputDTO
+		mockReturn    *domain.TotpCredential
+		mockError     error
+		expectSave    bool
+		expectedError error
+	}{
+		{
+			name:          "Success",
+			input:         &dto.TotpConfirmInputDTO{Code: validCode},
+			mockReturn:    &domain.TotpCredential{UserID: 1, Secret: testTotpSecret},
+			expectSave:    true,
+			expectedError: nil,
+		},
+		{
+			name:          "Wrong code",
+			input:         &dto.TotpConfirmInputDTO{Code: "000000"},
+			mockReturn:    &domain.TotpCredential{UserID: 1, Secret: testTotpSecret},
+			expectSave:    false,
+			expectedError: domain.ErrMfaCodeInvalid,
+		},
+		{
+			name:          "Not enrolled",
+			input:         &dto.TotpConfirmInputDTO{Code: validCode},
+			mockReturn:    nil,
+			mockError:     gorm.ErrRecordNotFound,
+			expectSave:    false,
+			expectedError: domain.ErrMfaNotEnrolled,
+		},
+		{
+			name:          "Already enabled",
+			input:         &dto.TotpConfirmInputDTO{Code: validCode},
+			mockReturn:    &domain.TotpCredential{UserID: 1, Secret: testTotpSecret, ConfirmedAt: &now},
+			expectSave:    false,
+			expectedError: domain.ErrMfaAlreadyEnabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMfaRepository.ExpectedCalls = nil
+
+			mockMfaRepository.On("FindTotpCredential", mock.Anything, user.ID).Return(tc.mockReturn, tc.mockError)
+
+			var storedHashes []string
+			if tc.expectSave {
+				mockMfaRepository.On("SaveTotpCredential", mock.Anything, mock.MatchedBy(func(c *domain.TotpCredential) bool {
+					return c.IsConfirmed() && c.ConfirmedAt.Equal(now) && c.LastUsedStep == totp.Step(now)
+				})).Return(nil)
+				mockMfaRepository.On("ReplaceRecoveryCodes", mock.Anything, user.ID, mock.MatchedBy(func(codes []*domain.MfaRecoveryCode) bool {
+					storedHashes = nil
+					for _, c := range codes {
+						storedHashes = append(storedHashes, c.CodeHash)
+					}
+					return len(codes) == mfaRecoveryCodeCount
+				})).Return(nil)
+			}
+
+			uc := newTestMfaUseCase(mockMfaRepository, now)
+
+			output, err := uc.ConfirmTotp(context.Background(), user, tc.input)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err, "Expected ConfirmTotp error to match.")
+				assert.Nil(t, output, "Expected output to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Len(t, output.RecoveryCodes, mfaRecoveryCodeCount, "Expected recovery codes count to match")
+				for i, code := range output.RecoveryCodes {
+					assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, code, "Expected recovery code format")
+					assert.Equal(t, storedHashes[i], util.HashOpaqueToken(normalizeRecoveryCode(code)), "Expected only the code hash to be stored")
+				}
+			}
+
+			mockMfaRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMfaIsEnabled(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	mockMfaRepository := new(mockMfaRepository)
+
+	testCases := []struct {
+		name           string
+		mockReturn     *domain.TotpCredential
+		mockError      error
+		expectedOutput bool
+		expectedError  error
+	}{
+		{name: "Confirmed", mockReturn: &domain.TotpCredential{ConfirmedAt: &now}, expectedOutput: true},
+		{name: "Pending", mockReturn: &domain.TotpCredential{}, expectedOutput: false},
+		{name: "Not enrolled", mockError: gorm.ErrRecordNotFound, expectedOutput: false},
+		{name: "Repository error", mockError: gorm.ErrInvalidDB, expectedOutput: false, expectedError: gorm.ErrInvalidDB},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMfaRepository.ExpectedCalls = nil
+			mockMfaRepository.On("FindTotpCredential", mock.Anything, uint(1)).Return(tc.mockReturn, tc.mockError)
+
+			uc := newTestMfaUseCase(mockMfaRepository, now)
+
+			enabled, err := uc.IsEnabled(context.Background(), 1)
+
+			assert.Equal(t, tc.expectedOutput, enabled, "Expected IsEnabled output to match.")
+			assert.Equal(t, tc.expectedError, err, "Expected IsEnabled error to match.")
+		})
+	}
+}
+
+func TestMfaVerify(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	confirmedAt := now.Add(-24 * time.Hour)
+	validCode, _ := totp.GenerateCode(testTotpSecret, now)
+	driftedCode, _ := totp.GenerateCode(testTotpSecret, now.Add(-totp.Period*time.Second))
+	recoveryCode := "abcd-efgh-ijkl-mnop"
+
+	mockMfaRepository := new(mockMfaRepository)
+
+	testCases := []struct {
+		name               string
+		code               string
+		recoveryCode       string
+		mockCredential     *domain.TotpCredential
+		mockRecoveryReturn *domain.MfaRecoveryCode
+		mockRecoveryError  error
+		mockStepError      error
+		expectedStep       int64
+		expectedError      error
+	}{
+		{
+			name:           "Valid code",
+			code:           validCode,
+			mockCredential: &domain.TotpCredential{UserID: 1, Secret: testTotpSecret, ConfirmedAt: &confirmedAt},
+			expectedStep:   totp.Step(now),
+			expectedError:  nil,
+		},
+		{
+			name:           "Code from previous step",
+			code:           driftedCode,
+			mockCredential: &domain.TotpCredential{UserID: 1, Secret: testTotpSecret, ConfirmedAt: &confirmedAt},
+			expectedStep:   totp.Step(now) - 1,
+			expectedError:  nil,
+		},
+		{
+			name:           "Replayed code",
+			code:           validCode,
+			mockCredential: &domain.TotpCredential{UserID: 1, Secret: testTotpSecret, ConfirmedAt: &confirmedAt, LastUsedStep: totp.Step(now)},
+			expectedError:  domain.ErrMfaCodeInvalid,
+		},
+		{
+			name:           "Code used concurrently",
+			code:           validCode,
+			mockCredential: &domain.TotpCredential{UserID: 1, Secret: testTotpSecret, ConfirmedAt: &confirmedAt},
+			mockStepError:  gorm.ErrRecordNotFound,
+			expectedStep:   totp.Step(now),
+			expectedError:  domain.ErrMfaCodeInvalid,
+		},
+		{
+			name:           "Wrong code",
+			code:           "000000",
+			mockCredential: &domain.TotpCredential{UserID: 1, Secret: testTotpSecret, ConfirmedAt: &confirmedAt},
+			expectedError:  domain.ErrMfaCodeInvalid,
+		},
+		{
+			name:           "Not confirmed",
+			code:           validCode,
+			mockCredential: &domain.TotpCredential{UserID: 1, Secret: testTotpSecret},
+			expectedError:  domain.ErrMfaNotEnrolled,
+		},
+		{
+			name:               "Valid recovery code",
+			recoveryCode:       "ABCD EFGH IJKL MNOP",
+			mockRecoveryReturn: &domain.MfaRecoveryCode{ID: 1, UserID: 1},
+			expectedError:      nil,
+		},
+		{
+			name:               "Unknown recovery code",
+			recoveryCode:       recoveryCode,
+			mockRecoveryReturn: nil,
+			mockRecoveryError:  gorm.ErrRecordNotFound,
+			expectedError:      domain.ErrMfaCodeInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockMfaRepository.ExpectedCalls = nil
+
+			if tc.mockCredential != nil {
+				mockMfaRepository.On("FindTotpCredential", mock.Anything, uint(1)).Return(tc.mockCredential, nil)
+			}
+			if tc.expectedStep != 0 {
+				mockMfaRepository.On("MarkTotpStepUsed", mock.Anything, uint(1), tc.expectedStep).Return(tc.mockStepError)
+			}
+			if len(tc.recoveryCode) > 0 {
+				mockMfaRepository.On("FindUnusedRecoveryCode", mock.Anything, uint(1), util.HashOpaqueToken("abcdefghijklmnop")).Return(tc.mockRecoveryReturn, tc.mockRecoveryError)
+			}
+			if tc.mockRecoveryReturn != nil {
+				mockMfaRepository.On("MarkRecoveryCodeUsed", mock.Anything, mock.MatchedBy(func(c *domain.MfaRecoveryCode) bool {
+					return c.ID == tc.mockRecoveryReturn.ID && c.UsedAt != nil && c.UsedAt.Equal(now)
+				})).Return(nil)
+			}
+
+			uc := newTestMfaUseCase(mockMfaRepository, now)
+
+			err := uc.Verify(context.Background(), 1, tc.code, tc.recoveryCode)
+
+			assert.Equal(t, tc.expectedError, err, "Expected Verify error to match.")
+			mockMfaRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMfaVerifySameStepTwice(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	confirmedAt := now.Add(-24 * time.Hour)
+	code, _ := totp.GenerateCode(testTotpSecret, now)
+
+	// Both logins read the credential before either stored the step, only the
+	// conditional update tells them apart.
+	mockMfaRepository := new(mockMfaRepository)
+	mockMfaRepository.On("FindTotpCredential", mock.Anything, uint(1)).Return(&domain.TotpCredential{UserID: 1, Secret: testTotpSecret, ConfirmedAt: &confirmedAt}, nil)
+	mockMfaRepository.On("MarkTotpStepUsed", mock.Anything, uint(1), totp.Step(now)).Return(nil).Once()
+	mockMfaRepository.On("MarkTotpStepUsed", mock.Anything, uint(1), totp.Step(now)).Return(gorm.ErrRecordNotFound).Once()
+
+	uc := newTestMfaUseCase(mockMfaRepository, now)
+
+	err := uc.Verify(context.Background(), 1, code, "")
+	assert.NoError(t, err, "Did not expect an error but got one")
+
+	err = uc.Verify(context.Background(), 1, code, "")
+	assert.Equal(t, domain.ErrMfaCodeInvalid, err, "Expected the second use of the code to be refused")
+	mockMfaRepository.AssertExpectations(t)
+}
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/oidc"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateSize        = 32
+	oidcBindingSize      = 32
+	oidcNonceSize        = 32
+	oidcCodeVerifierSize = 48
+)
+
+type OidcUseCase interface {
+	StartLogin(ctx context.Context) (*dto.OidcAuthorizationOutputDTO, error)
+	StartLink(ctx context.Context, user *domain.User) (*dto.OidcAuthorizationOutputDTO, error)
+	Callback(ctx context.Context, input *dto.OidcCallbackInputDTO) (*dto.LoginOutputDTO, error)
+}
+
+type oidcUseCase struct {
+	oidcRepository repository.OidcRepository
+	userRepository repository.UserRepository
+	authUseCase    AuthUseCase
+	provider       oidc.Provider
+	stateDuration  uint
+	now            func() time.Time
+}
+
+func NewOidcUseCase(oidcRepository repository.OidcRepository, userRepository repository.UserRepository, authUseCase AuthUseCase, provider oidc.Provider, stateDuration uint) OidcUseCase {
+	return &oidcUseCase{
+		oidcRepository: oidcRepository,
+		userRepository: userRepository,
+		authUseCase:    authUseCase,
+		provider:       provider,
+		stateDuration:  stateDuration,
+		now:            time.Now,
+	}
+}
+
+func (uc *oidcUseCase) StartLogin(ctx context.Context) (*dto.OidcAuthorizationOutputDTO, error) {
+	return uc.start(ctx, nil)
+}
+
+// StartLink starts a flow that links the identity used at the provider to an
+// already authenticated user.
+func (uc *oidcUseCase) StartLink(ctx context.Context, user *domain.User) (*dto.OidcAuthorizationOutputDTO, error) {
+	return uc.start(ctx, &user.ID)
+}
+
+func (uc *oidcUseCase) Callback(ctx context.Context, input *dto.OidcCallbackInputDTO) (*dto.LoginOutputDTO, error) {
+	s, err := uc.oidcRepository.ConsumeLoginState(ctx, util.HashOpaqueToken(input.State))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, domain.ErrOidcStateInvalid
+		default:
+			return nil, err
+		}
+	}
+	if s.IsExpired(uc.now()) {
+		return nil, domain.ErrOidcStateInvalid
+	}
+	// The callback must come from the browser that started the flow, otherwise
+	// a victim could be made to finish a flow started by someone else.
+	if len(input.Binding) == 0 || subtle.ConstantTimeCompare([]byte(util.HashOpaqueToken(input.Binding)), []byte(s.BindingHash)) != 1 {
+		return nil, domain.ErrOidcStateInvalid
+	}
+
+	identity, err := uc.provider.Exchange(input.Code, s.CodeVerifier, s.Nonce)
+	if err != nil {
+		log.Println(err)
+		return nil, domain.ErrOidcLoginFailed
+	}
+
+	var user *domain.User
+	if s.UserID != nil {
+		user, err = uc.linkIdentity(ctx, *s.UserID, identity)
+	} else {
+		user, err = uc.findLinkedUser(ctx, identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.authUseCase.LoginExternalUser(ctx, user, input.IP, input.UserAgent)
+}
+
+func (uc *oidcUseCase) start(ctx context.Context, userId *uint) (*dto.OidcAuthorizationOutputDTO, error) {
+	state, err := util.NewOpaqueToken(oidcStateSize)
+	if err != nil {
+		return nil, err
+	}
+	binding, err := util.NewOpaqueToken(oidcBindingSize)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := util.NewOpaqueToken(oidcNonceSize)
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier, err := util.NewOpaqueToken(oidcCodeVerifierSize)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := uc.now().Add(time.Minute * time.Duration(uc.stateDuration))
+	_, err = uc.oidcRepository.CreateLoginState(ctx, &domain.OidcLoginState{
+		StateHash:    util.HashOpaqueToken(state),
+		BindingHash:  util.HashOpaqueToken(binding),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		UserID:       userId,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.OidcAuthorizationOutputDTO{
+		AuthorizationURL: uc.provider.AuthCodeURL(state, nonce, codeVerifier),
+		Binding:          binding,
+		ExpiresAt:        expiresAt,
+	}, nil
+}
+
+// findLinkedUser resolves the user of an identity through its link, or links
+// it on first use when both sides have verified the same email address.
+func (uc *oidcUseCase) findLinkedUser(ctx context.Context, identity *oidc.Identity) (*domain.User, error) {
+	i, err := uc.findIdentity(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if i != nil {
+		return uc.userRepository.AllTenants().FindUserById(ctx, i.UserID)
+	}
+
+	if !identity.EmailVerified || len(identity.Email) == 0 {
+		return nil, domain.ErrOidcAccountNotLinked
+	}
+
+	user, err := uc.userRepository.AllTenants().FindUserByEmail(ctx, identity.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, domain.ErrOidcAccountNotLinked
+		default:
+			return nil, err
+		}
+	}
+
+	// An unverified local account may have been registered by someone else
+	// with this address, linking it would hand them the SSO login.
+	if !user.IsEmailVerified() {
+		return nil, domain.ErrOidcAccountNotLinked
+	}
+
+	err = uc.createIdentity(ctx, user.ID, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (uc *oidcUseCase) linkIdentity(ctx context.Context, userId uint, identity *oidc.Identity) (*domain.User, error) {
+	i, err := uc.findIdentity(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case i == nil:
+		err = uc.createIdentity(ctx, userId, identity)
+		if err != nil {
+			return nil, err
+		}
+	case i.UserID != userId:
+		return nil, domain.ErrOidcIdentityLinked
+	}
+
+	return uc.userRepository.AllTenants().FindUserById(ctx, userId)
+}
+
+func (uc *oidcUseCase) findIdentity(ctx context.Context, identity *oidc.Identity) (*domain.UserIdentity, error) {
+	i, err := uc.oidcRepository.FindIdentity(ctx, identity.Issuer, identity.Subject)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return i, nil
+}
+
+func (uc *oidcUseCase) createIdentity(ctx context.Context, userId uint, identity *oidc.Identity) error {
+	_, err := uc.oidcRepository.CreateIdentity(ctx, &domain.UserIdentity{
+		UserID:  userId,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	})
+
+	return err
+}
@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/oidc"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+const testOidcIssuer = "https://sso.example.com"
+
+func newTestOidcUseCase(oidcRepository *mockOidcRepository, userRepository *mockUserRepository, authUseCase *mockAuthUseCase, provider *mockOidcProvider, now time.Time) *oidcUseCase {
+	uc := NewOidcUseCase(oidcRepository, userRepository, authUseCase, provider, 10).(*oidcUseCase)
+	uc.now = func() time.Time { return now }
+	return uc
+}
+
+func TestStartOidcLogin(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	user := &domain.User{ID: 1, Email: "user1@example.com"}
+
+	mockOidcRepository := new(mockOidcRepository)
+
+	testCases := []struct {
+		name           string
+		user           *domain.User
+		expectedUserId *uint
+	}{
+		{
+			name:           "Login",
+			user:           nil,
+			expectedUserId: nil,
+		},
+		{
+			name:           "Link",
+			user:           user,
+			expectedUserId: &user.ID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOidcRepository.ExpectedCalls = nil
+			mockOidcProvider := new(mockOidcProvider)
+
+			var stored *domain.OidcLoginState
+			mockOidcRepository.On("CreateLoginState", mock.Anything, mock.AnythingOfType("*domain.OidcLoginState")).Run(func(args mock.Arguments) {
+				stored = args.Get(1).(*domain.OidcLoginState)
+			}).Return(&domain.OidcLoginState{}, nil)
+			mockOidcProvider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything).Return(testOidcIssuer + "/authorize")
+
+			uc := newTestOidcUseCase(mockOidcRepository, new(mockUserRepository), new(mockAuthUseCase), mockOidcProvider, now)
+
+			var output *dto.OidcAuthorizationOutputDTO
+			var err error
+			if tc.user != nil {
+				output, err = uc.StartLink(context.Background(), tc.user)
+			} else {
+				output, err = uc.StartLogin(context.Background())
+			}
+
+			assert.NoError(t, err, "Did not expect an error but got one")
+			assert.Equal(t, testOidcIssuer+"/authorize", output.AuthorizationURL, "Expected authorization URL to match.")
+
+			call := mockOidcProvider.Calls[0]
+			state, nonce, codeVerifier := call.Arguments.String(0), call.Arguments.String(1), call.Arguments.String(2)
+			assert.Equal(t, util.HashOpaqueToken(state), stored.StateHash, "Expected only the state hash to be stored.")
+			assert.NotEmpty(t, output.Binding, "Expected a binding for the browser.")
+			assert.Equal(t, util.HashOpaqueToken(output.Binding), stored.BindingHash, "Expected only the binding hash to be stored.")
+			assert.Equal(t, stored.ExpiresAt, output.ExpiresAt, "Expected the binding to expire with the state.")
+			assert.Equal(t, nonce, stored.Nonce, "Expected nonce to match.")
+			assert.Equal(t, codeVerifier, stored.CodeVerifier, "Expected code verifier to match.")
+			assert.GreaterOrEqual(t, len(codeVerifier), 43, "Expected a PKCE compliant code verifier.")
+			assert.Equal(t, tc.expectedUserId, stored.UserID, "Expected user id to match.")
+			assert.Equal(t, now.Add(10*time.Minute), stored.ExpiresAt, "Expected expiration to match.")
+
+			mockOidcRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOidcCallback(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	verifiedAt := now.Add(-time.Hour)
+	linkUserId := uint(2)
+
+	verifiedUser := &domain.User{ID: 1, Email: "user1@example.com", EmailVerifiedAt: &verifiedAt}
+	unverifiedUser := &domain.User{ID: 1, Email: "user1@example.com"}
+	linkUser := &domain.User{ID: linkUserId, Email: "user2@example.com", EmailVerifiedAt: &verifiedAt}
+
+	identity := &oidc.Identity{Issuer: testOidcIssuer, Subject: "subject-1", Email: "user1@example.com", EmailVerified: true}
+	unverifiedIdentity := &oidc.Identity{Issuer: testOidcIssuer, Subject: "subject-1", Email: "user1@example.com", EmailVerified: false}
+
+	loginState := &domain.OidcLoginState{ID: 1, BindingHash: util.HashOpaqueToken("binding"), Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(time.Minute)}
+	expiredState := &domain.OidcLoginState{ID: 1, BindingHash: util.HashOpaqueToken("binding"), Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(-time.Minute)}
+	linkState := &domain.OidcLoginState{ID: 1, BindingHash: util.HashOpaqueToken("binding"), Nonce: "nonce", CodeVerifier: "verifier", UserID: &linkUserId, ExpiresAt: now.Add(time.Minute)}
+
+	loginOutput := &dto.LoginOutputDTO{ID: 1, Email: "user1@example.com", Token: "token"}
+
+	input := &dto.OidcCallbackInputDTO{Code: "code", State: "state", Binding: "binding", IP: "192.0.2.1", UserAgent: "Mozilla/5.0"}
+	noBinding := ""
+	otherBinding := "other-binding"
+
+	mockOidcRepository := new(mockOidcRepository)
+	mockUserRepository := new(mockUserRepository)
+	mockAuthUseCase := new(mockAuthUseCase)
+	mockOidcProvider := new(mockOidcProvider)
+
+	testCases := []struct {
+		name                 string
+		binding              *string
+		mockStateReturn      *domain.OidcLoginState
+		mockStateError       error
+		mockIdentityReturn   *oidc.Identity
+		mockExchangeError    error
+		mockLinkReturn       *domain.UserIdentity
+		mockLinkError        error
+		mockUserByEmail      *domain.User
+		mockUserByEmailError error
+		mockUserById         *domain.User
+		expectCreateIdentity bool
+		expectedLoginUser    *domain.User
+		expectedError        error
+	}{
+		{
+			name:               "Linked identity",
+			mockStateReturn:    loginState,
+			mockIdentityReturn: identity,
+			mockLinkReturn:     &domain.UserIdentity{UserID: 1, Issuer: testOidcIssuer, Subject: "subject-1"},
+			mockUserById:       verifiedUser,
+			expectedLoginUser:  verifiedUser,
+		},
+		{
+			name:                 "Linked by verified email",
+			mockStateReturn:      loginState,
+			mockIdentityReturn:   identity,
+			mockLinkError:        gorm.ErrRecordNotFound,
+			mockUserByEmail:      verifiedUser,
+			expectCreateIdentity: true,
+			expectedLoginUser:    verifiedUser,
+		},
+		{
+			name:               "Unverified provider email",
+			mockStateReturn:    loginState,
+			mockIdentityReturn: unverifiedIdentity,
+			mockLinkError:      gorm.ErrRecordNotFound,
+			expectedError:      domain.ErrOidcAccountNotLinked,
+		},
+		{
+			name:               "Unverified local email",
+			mockStateReturn:    loginState,
+			mockIdentityReturn: identity,
+			mockLinkError:      gorm.ErrRecordNotFound,
+			mockUserByEmail:    unverifiedUser,
+			expectedError:      domain.ErrOidcAccountNotLinked,
+		},
+		{
+			name:                 "Unknown email",
+			mockStateReturn:      loginState,
+			mockIdentityReturn:   identity,
+			mockLinkError:        gorm.ErrRecordNotFound,
+			mockUserByEmail:      nil,
+			mockUserByEmailError: gorm.ErrRecordNotFound,
+			expectedError:        domain.ErrOidcAccountNotLinked,
+		},
+		{
+			name:                 "Explicit link",
+			mockStateReturn:      linkState,
+			mockIdentityReturn:   identity,
+			mockLinkError:        gorm.ErrRecordNotFound,
+			mockUserById:         linkUser,
+			expectCreateIdentity: true,
+			expectedLoginUser:    linkUser,
+		},
+		{
+			name:               "Explicit link of an identity used by another user",
+			mockStateReturn:    linkState,
+			mockIdentityReturn: identity,
+			mockLinkReturn:     &domain.UserIdentity{UserID: 1, Issuer: testOidcIssuer, Subject: "subject-1"},
+			expectedError:      domain.ErrOidcIdentityLinked,
+		},
+		{
+			name:            "Unknown state",
+			mockStateReturn: nil,
+			mockStateError:  gorm.ErrRecordNotFound,
+			expectedError:   domain.ErrOidcStateInvalid,
+		},
+		{
+			name:            "Expired state",
+			mockStateReturn: expiredState,
+			expectedError:   domain.ErrOidcStateInvalid,
+		},
+		{
+			name:            "Missing binding",
+			binding:         &noBinding,
+			mockStateReturn: loginState,
+			expectedError:   domain.ErrOidcStateInvalid,
+		},
+		{
+			name:            "Binding of another browser",
+			binding:         &otherBinding,
+			mockStateReturn: loginState,
+			expectedError:   domain.ErrOidcStateInvalid,
+		},
+		{
+			name:               "Exchange failure",
+			mockStateReturn:    loginState,
+			mockIdentityReturn: nil,
+			mockExchangeError:  oidc.ErrNonceMismatch,
+			expectedError:      domain.ErrOidcLoginFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockOidcRepository.ExpectedCalls = nil
+			mockUserRepository.ExpectedCalls = nil
+			mockAuthUseCase.ExpectedCalls = nil
+			mockOidcProvider.ExpectedCalls = nil
+
+			mockOidcRepository.On("ConsumeLoginState", mock.Anything, util.HashOpaqueToken(input.State)).Return(tc.mockStateReturn, tc.mockStateError)
+			if tc.mockIdentityReturn != nil || tc.mockExchangeError != nil {
+				mockOidcProvider.On("Exchange", input.Code, "verifier", "nonce").Return(tc.mockIdentityReturn, tc.mockExchangeError)
+			}
+			if tc.mockLinkReturn != nil || tc.mockLinkError != nil {
+				mockOidcRepository.On("FindIdentity", mock.Anything, testOidcIssuer, "subject-1").Return(tc.mockLinkReturn, tc.mockLinkError)
+			}
+			if tc.mockUserByEmail != nil || tc.mockUserByEmailError != nil {
+				mockUserRepository.On("FindUserByEmail", mock.Anything, identity.Email).Return(tc.mockUserByEmail, tc.mockUserByEmailError)
+			}
+			if tc.mockUserById != nil {
+				mockUserRepository.On("FindUserById", mock.Anything, tc.mockUserById.ID).Return(tc.mockUserById, nil)
+			}
+			if tc.expectCreateIdentity {
+				mockOidcRepository.On("CreateIdentity", mock.Anything, mock.MatchedBy(func(i *domain.UserIdentity) bool {
+					return i.UserID == tc.expectedLoginUser.ID && i.Issuer == testOidcIssuer && i.Subject == "subject-1"
+				})).Return(&domain.UserIdentity{}, nil)
+			}
+			if tc.expectedLoginUser != nil {
+				mockAuthUseCase.On("LoginExternalUser", mock.Anything, tc.expectedLoginUser, input.IP, input.UserAgent).Return(loginOutput, nil)
+			}
+
+			callbackInput := *input
+			if tc.binding != nil {
+				callbackInput.Binding = *tc.binding
+			}
+
+			uc := newTestOidcUseCase(mockOidcRepository, mockUserRepository, mockAuthUseCase, mockOidcProvider, now)
+
+			output, err := uc.Callback(context.Background(), &callbackInput)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err, "Expected Callback error to match.")
+				assert.Nil(t, output, "Expected output to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, loginOutput, output, "Expected Callback output to match.")
+			}
+
+			mockOidcRepository.AssertExpectations(t)
+			mockUserRepository.AssertExpectations(t)
+			mockAuthUseCase.AssertExpectations(t)
+			mockOidcProvider.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+type PreferenceUseCase interface {
+	LoadPreferences(ctx context.Context, userId uint) (*domain.UserPreferences, error)
+	GetPreferences(ctx context.Context, userId uint) (*dto.PreferencesOutputDTO, error)
+	UpdatePreferences(ctx context.Context, userId uint, input *dto.PreferencesInputDTO) (*dto.PreferencesOutputDTO, error)
+}
+
+type preferenceUseCase struct {
+	preferenceRepository repository.PreferenceRepository
+}
+
+func NewPreferenceUseCase(preferenceRepository repository.PreferenceRepository) PreferenceUseCase {
+	return &preferenceUseCase{preferenceRepository: preferenceRepository}
+}
+
+// LoadPreferences returns the preferences of a user, or the defaults when they
+// never set any.
+func (uc *preferenceUseCase) LoadPreferences(ctx context.Context, userId uint) (*domain.UserPreferences, error) {
+	p, err := uc.preferenceRepository.FindPreferencesByUserId(ctx, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.DefaultUserPreferences(userId), nil
+		default:
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (uc *preferenceUseCase) GetPreferences(ctx context.Context, userId uint) (*dto.PreferencesOutputDTO, error) {
+	p, err := uc.LoadPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPreferencesOutputDTO(p), nil
+}
+
+// UpdatePreferences changes the preferences present in input, keeping the
+// current value of the others.
+func (uc *preferenceUseCase) UpdatePreferences(ctx context.Context, userId uint, input *dto.PreferencesInputDTO) (*dto.PreferencesOutputDTO, error) {
+	p, err := uc.LoadPreferences(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Locale != nil {
+		p.Locale = strings.TrimSpace(*input.Locale)
+	}
+	if input.Timezone != nil {
+		p.Timezone = strings.TrimSpace(*input.Timezone)
+	}
+	if input.Currency != nil {
+		p.Currency = strings.ToUpper(strings.TrimSpace(*input.Currency))
+	}
+	if input.PageSize != nil {
+		p.PageSize = *input.PageSize
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	err = uc.preferenceRepository.SavePreferences(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPreferencesOutputDTO(p), nil
+}
+
+func newPreferencesOutputDTO(p *domain.UserPreferences) *dto.PreferencesOutputDTO {
+	return &dto.PreferencesOutputDTO{
+		Locale:   p.Locale,
+		Timezone: p.Timezone,
+		Currency: p.Currency,
+		PageSize: p.PageSize,
+	}
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestLoadPreferences(t *testing.T) {
+
+	mockPreferenceRepository := new(mockPreferenceRepository)
+	uc := NewPreferenceUseCase(mockPreferenceRepository)
+
+	saved := &domain.UserPreferences{UserID: 1, Locale: "pt-BR", Timezone: "America/Sao_Paulo", Currency: "BRL", PageSize: 50}
+
+	testCases := []struct {
+		name           string
+		mockReturn     *domain.UserPreferences
+		mockError      error
+		expectedOutput *domain.UserPreferences
+		expectedError  error
+	}{
+		{
+			name:           "Saved",
+			mockReturn:     saved,
+			expectedOutput: saved,
+		},
+		{
+			name:           "Defaults",
+			mockReturn:     (*domain.UserPreferences)(nil),
+			mockError:      gorm.ErrRecordNotFound,
+			expectedOutput: domain.DefaultUserPreferences(1),
+		},
+		{
+			name:          "Find error",
+			mockReturn:    (*domain.UserPreferences)(nil),
+			mockError:     gorm.ErrInvalidDB,
+			expectedError: gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPreferenceRepository.ExpectedCalls = nil
+			mockPreferenceRepository.On("FindPreferencesByUserId", mock.Anything, uint(1)).Return(tc.mockReturn, tc.mockError)
+
+			output, err := uc.LoadPreferences(context.Background(), 1)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError, "Expected error to match")
+				assert.Nil(t, output, "Expected no preferences")
+				return
+			}
+
+			assert.NoError(t, err, "Expected no error")
+			assert.Equal(t, tc.expectedOutput, output, "Expected preferences to match")
+		})
+	}
+}
+
+func TestUpdatePreferences(t *testing.T) {
+
+	mockPreferenceRepository := new(mockPreferenceRepository)
+	uc := NewPreferenceUseCase(mockPreferenceRepository)
+
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+
+	testCases := []struct {
+		name           string
+		input          *dto.PreferencesInputDTO
+		mockSaveError  error
+		expectSave     bool
+		expectedOutput *dto.PreferencesOutputDTO
+		expectedError  error
+	}{
+		{
+			name:           "Success",
+			input:          &dto.PreferencesInputDTO{Timezone: str(" America/Sao_Paulo "), Currency: str("brl")},
+			expectSave:     true,
+			expectedOutput: &dto.PreferencesOutputDTO{Locale: domain.DefaultLocale, Timezone: "America/Sao_Paulo", Currency: "BRL", PageSize: domain.UserListDefaultLimit},
+		},
+		{
+			name:          "Invalid locale",
+			input:         &dto.PreferencesInputDTO{Locale: str("portuguese")},
+			expectedError: domain.ErrPreferenceLocaleInvalid,
+		},
+		{
+			name:          "Unknown timezone",
+			input:         &dto.PreferencesInputDTO{Timezone: str("Mars/Olympus_Mons")},
+			expectedError: domain.ErrPreferenceTimezoneInvalid,
+		},
+		{
+			name:          "Server timezone",
+			input:         &dto.PreferencesInputDTO{Timezone: str("Local")},
+			expectedError: domain.ErrPreferenceTimezoneInvalid,
+		},
+		{
+			name:          "Invalid currency",
+			input:         &dto.PreferencesInputDTO{Currency: str("R$")},
+			expectedError: domain.ErrPreferenceCurrencyInvalid,
+		},
+		{
+			name:          "Invalid page size",
+			input:         &dto.PreferencesInputDTO{PageSize: num(domain.UserListMaxLimit + 1)},
+			expectedError: domain.ErrPreferencePageSizeInvalid,
+		},
+		{
+			name:          "Save error",
+			input:         &dto.PreferencesInputDTO{PageSize: num(50)},
+			mockSaveError: gorm.ErrInvalidDB,
+			expectSave:    true,
+			expectedError: gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPreferenceRepository.ExpectedCalls = nil
+			mockPreferenceRepository.Calls = nil
+			mockPreferenceRepository.On("FindPreferencesByUserId", mock.Anything, uint(1)).Return((*domain.UserPreferences)(nil), gorm.ErrRecordNotFound)
+			mockPreferenceRepository.On("SavePreferences", mock.Anything, mock.Anything).Return(tc.mockSaveError)
+
+			output, err := uc.UpdatePreferences(context.Background(), 1, tc.input)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError, "Expected error to match")
+				assert.Nil(t, output, "Expected no output")
+			} else {
+				assert.NoError(t, err, "Expected no error")
+				assert.Equal(t, tc.expectedOutput, output, "Expected preferences to match")
+			}
+
+			if tc.expectSave {
+				mockPreferenceRepository.AssertCalled(t, "SavePreferences", mock.Anything, mock.MatchedBy(func(p *domain.UserPreferences) bool {
+					return p.UserID == 1
+				}))
+			} else {
+				mockPreferenceRepository.AssertNotCalled(t, "SavePreferences", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
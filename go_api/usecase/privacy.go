@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+)
+
+// ErasedUserName replaces the name of erased users.
+const ErasedUserName = "Erased user"
+
+// PrivacyUseCase answers data subject requests: exporting what is held about
+// a user, erasing it, and keeping track of what they consented to.
+type PrivacyUseCase interface {
+	ExportUserData(ctx context.Context, tenantId uint, userId uint) (*dto.UserDataExportDTO, error)
+	WriteUserDataBundle(ctx context.Context, tenantId uint, userId uint, w io.Writer) error
+	EraseUser(ctx context.Context, tenantId uint, userId uint) error
+	RecordConsent(ctx context.Context, userId uint, input *dto.ConsentInputDTO) (*dto.ConsentOutputDTO, error)
+	ListConsents(ctx context.Context, userId uint) ([]*dto.ConsentOutputDTO, error)
+}
+
+type privacyUseCase struct {
+	userRepository       repository.UserRepository
+	consentRepository    repository.ConsentRepository
+	loginThrottleUseCase LoginThrottleUseCase
+	now                  func() time.Time
+}
+
+func NewPrivacyUseCase(userRepository repository.UserRepository, consentRepository repository.ConsentRepository, loginThrottleUseCase LoginThrottleUseCase) PrivacyUseCase {
+	return &privacyUseCase{
+		userRepository:       userRepository,
+		consentRepository:    consentRepository,
+		loginThrottleUseCase: loginThrottleUseCase,
+		now:                  time.Now,
+	}
+}
+
+// ExportUserData returns everything held about a user of the organization,
+// deleted users included.
+func (uc *privacyUseCase) ExportUserData(ctx context.Context, tenantId uint, userId uint) (*dto.UserDataExportDTO, error) {
+	d, err := uc.userRepository.ForTenant(tenantId).FindUserData(ctx, userId)
+	if err != nil {
+		return nil, userNotFound(err)
+	}
+
+	return uc.newUserDataExportDTO(d), nil
+}
+
+// WriteUserDataBundle writes the export of a user to w as a ZIP archive with
+// a JSON file per kind of data.
+func (uc *privacyUseCase) WriteUserDataBundle(ctx context.Context, tenantId uint, userId uint, w io.Writer) error {
+	e, err := uc.ExportUserData(ctx, tenantId, userId)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteJSONZip(w, []util.JSONFile{
+		{Name: "user.json", Value: e.User},
+		{Name: "organization.json", Value: e.Organization},
+		{Name: "sessions.json", Value: e.Sessions},
+		{Name: "api_keys.json", Value: e.ApiKeys},
+		{Name: "identities.json", Value: e.Identities},
+		{Name: "preferences.json", Value: e.Preferences},
+		{Name: "security.json", Value: map[string]interface{}{
+			"totp_enabled_at":  e.TotpEnabledAt,
+			"password_changes": e.PasswordChanges,
+		}},
+		{Name: "invitations_sent.json", Value: e.InvitationsSent},
+		{Name: "email_changes.json", Value: e.EmailChanges},
+		{Name: "consents.json", Value: e.Consents},
+		{Name: "export.json", Value: map[string]interface{}{"exported_at": e.ExportedAt}},
+	})
+}
+
+// EraseUser anonymizes a user of the organization in place. Their name, email
+// and password are overwritten and the account is left erased, while the row
+// stays so records pointing to it remain valid. Every consent still granted is
+// withdrawn in the consent history.
+func (uc *privacyUseCase) EraseUser(ctx context.Context, tenantId uint, userId uint) error {
+	users := uc.userRepository.ForTenant(tenantId)
+
+	d, err := users.FindUserData(ctx, userId)
+	if err != nil {
+		return userNotFound(err)
+	}
+
+	withdrawals := []*domain.Consent{}
+	for _, purpose := range grantedConsentPurposes(d.Consents) {
+		withdrawals = append(withdrawals, &domain.Consent{
+			Purpose: purpose,
+			Granted: false,
+			Source:  domain.ConsentSourceErasure,
+		})
+	}
+
+	user := d.User
+	email := user.Email
+	user.Name = ErasedUserName
+	user.Email = fmt.Sprintf("erased-%d@erased.invalid", user.ID)
+	user.Password = ""
+
+	err = users.AnonymizeUser(ctx, user, withdrawals)
+	if err != nil {
+		return userNotFound(err)
+	}
+
+	// Account lockouts are kept by email, clear the one of the erased
+	// address too.
+	return uc.loginThrottleUseCase.RegisterSuccess(ctx, email)
+}
+
+// RecordConsent appends a consent decision of a user to their history.
+func (uc *privacyUseCase) RecordConsent(ctx context.Context, userId uint, input *dto.ConsentInputDTO) (*dto.ConsentOutputDTO, error) {
+	c := &domain.Consent{
+		UserID:  userId,
+		Purpose: input.Purpose,
+		Granted: input.Granted,
+		Source:  domain.ConsentSourceUser,
+	}
+
+	if err := c.ValidatePurpose(); err != nil {
+		return nil, err
+	}
+
+	c, err := uc.consentRepository.CreateConsent(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConsentOutputDTO(c), nil
+}
+
+// ListConsents returns the consent history of a user, oldest first.
+func (uc *privacyUseCase) ListConsents(ctx context.Context, userId uint) ([]*dto.ConsentOutputDTO, error) {
+	cs, err := uc.consentRepository.ListConsentsByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*dto.ConsentOutputDTO, len(cs))
+	for i, c := range cs {
+		output[i] = newConsentOutputDTO(c)
+	}
+
+	return output, nil
+}
+
+// grantedConsentPurposes returns the purposes whose latest decision in the
+// history cs is a grant, in the order they were first decided on.
+func grantedConsentPurposes(cs []*domain.Consent) []string {
+	purposes := []string{}
+	granted := map[string]bool{}
+
+	for _, c := range cs {
+		if _, ok := granted[c.Purpose]; !ok {
+			purposes = append(purposes, c.Purpose)
+		}
+		granted[c.Purpose] = c.Granted
+	}
+
+	output := []string{}
+	for _, p := range purposes {
+		if granted[p] {
+			output = append(output, p)
+		}
+	}
+
+	return output
+}
+
+func (uc *privacyUseCase) newUserDataExportDTO(d *domain.UserData) *dto.UserDataExportDTO {
+	e := &dto.UserDataExportDTO{
+		User: *newUserOutputDTO(d.User),
+		Organization: dto.OrganizationOutputDTO{
+			ID:   d.Organization.ID,
+			Name: d.Organization.Name,
+		},
+		Sessions:        make([]*dto.SessionOutputDTO, len(d.Sessions)),
+		ApiKeys:         make([]*dto.ApiKeyOutputDTO, len(d.ApiKeys)),
+		Identities:      make([]*dto.IdentityOutputDTO, len(d.Identities)),
+		PasswordChanges: make([]time.Time, len(d.PasswordHistory)),
+		InvitationsSent: make([]*dto.InvitationOutputDTO, len(d.InvitationsSent)),
+		EmailChanges:    make([]*dto.EmailChangeOutputDTO, len(d.EmailChanges)),
+		Consents:        make([]*dto.ConsentOutputDTO, len(d.Consents)),
+		ExportedAt:      uc.now(),
+	}
+
+	for i, s := range d.Sessions {
+		e.Sessions[i] = &dto.SessionOutputDTO{
+			ID:           s.ID,
+			IP:           s.IP,
+			UserAgent:    s.UserAgent,
+			CreatedAt:    s.CreatedAt,
+			LastSeenAt:   s.LastSeenAt,
+			ExpiresAt:    s.ExpiresAt,
+			Impersonated: s.ActorID != nil,
+		}
+	}
+	for i, k := range d.ApiKeys {
+		e.ApiKeys[i] = newApiKeyOutputDTO(k)
+	}
+	for i, id := range d.Identities {
+		e.Identities[i] = &dto.IdentityOutputDTO{
+			Issuer:    id.Issuer,
+			Subject:   id.Subject,
+			Email:     id.Email,
+			CreatedAt: id.CreatedAt,
+		}
+	}
+	if d.Preferences != nil {
+		e.Preferences = newPreferencesOutputDTO(d.Preferences)
+	}
+	if d.TotpCredential != nil {
+		e.TotpEnabledAt = d.TotpCredential.ConfirmedAt
+	}
+	for i, h := range d.PasswordHistory {
+		e.PasswordChanges[i] = h.CreatedAt
+	}
+	for i, inv := range d.InvitationsSent {
+		e.InvitationsSent[i] = newInvitationOutputDTO(inv)
+	}
+	for i, t := range d.EmailChanges {
+		e.EmailChanges[i] = &dto.EmailChangeOutputDTO{
+			NewEmail:  t.NewEmail,
+			ExpiresAt: t.ExpiresAt,
+			CreatedAt: t.CreatedAt,
+		}
+	}
+	for i, c := range d.Consents {
+		e.Consents[i] = newConsentOutputDTO(c)
+	}
+
+	return e
+}
+
+func newConsentOutputDTO(c *domain.Consent) *dto.ConsentOutputDTO {
+	return &dto.ConsentOutputDTO{
+		ID:        c.ID,
+		Purpose:   c.Purpose,
+		Granted:   c.Granted,
+		Source:    c.Source,
+		CreatedAt: c.CreatedAt,
+	}
+}
@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newTestPrivacyUseCase(userRepository *mockUserRepository, consentRepository *mockConsentRepository, loginThrottleUseCase *mockLoginThrottleUseCase, now time.Time) *privacyUseCase {
+	uc := NewPrivacyUseCase(userRepository, consentRepository, loginThrottleUseCase).(*privacyUseCase)
+	uc.now = func() time.Time { return now }
+	return uc
+}
+
+func newTestUserData(now time.Time) *domain.UserData {
+	return &domain.UserData{
+		User:         &domain.User{ID: 1, OrganizationID: 2, Name: "User1", Email: "user1@example.com", Password: "hash", Status: domain.UserStatusActive},
+		Organization: &domain.Organization{ID: 2, Name: "Org"},
+		Sessions:     []*domain.Session{{ID: 3, UserID: 1, TokenID: "jti", IP: "127.0.0.1"}},
+		ApiKeys:      []*domain.ApiKey{{ID: 4, UserID: 1, Name: "ci", KeyHash: "secret", Scopes: "users:read"}},
+		Identities:   []*domain.UserIdentity{{ID: 5, UserID: 1, Issuer: "https://idp", Subject: "sub"}},
+		TotpCredential: &domain.TotpCredential{
+			UserID:      1,
+			Secret:      "seed",
+			ConfirmedAt: &now,
+		},
+		Preferences:     &domain.UserPreferences{UserID: 1, Locale: "pt-BR", Timezone: "America/Sao_Paulo", Currency: "BRL", PageSize: 50},
+		PasswordHistory: []*domain.PasswordHistory{{ID: 6, UserID: 1, Password: "old", CreatedAt: now}},
+		Consents: []*domain.Consent{
+			{ID: 7, UserID: 1, Purpose: domain.ConsentPurposeTermsOfService, Granted: true, Source: domain.ConsentSourceUser},
+			{ID: 8, UserID: 1, Purpose: domain.ConsentPurposeMarketing, Granted: true, Source: domain.ConsentSourceUser},
+			{ID: 9, UserID: 1, Purpose: domain.ConsentPurposeMarketing, Granted: false, Source: domain.ConsentSourceUser},
+		},
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockUserRepository := new(mockUserRepository)
+	uc := newTestPrivacyUseCase(mockUserRepository, new(mockConsentRepository), new(mockLoginThrottleUseCase), now)
+
+	testCases := []struct {
+		name          string
+		mockData      *domain.UserData
+		mockError     error
+		expectedError error
+	}{
+		{
+			name:     "Success",
+			mockData: newTestUserData(now),
+		},
+		{
+			name:          "User not found",
+			mockData:      (*domain.UserData)(nil),
+			mockError:     gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockUserRepository.On("ForTenant", uint(2))
+			mockUserRepository.On("FindUserData", mock.Anything, uint(1)).Return(tc.mockData, tc.mockError)
+
+			output, err := uc.ExportUserData(context.Background(), 2, 1)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError, "Expected error to match")
+				assert.Nil(t, output, "Expected no output")
+				return
+			}
+
+			assert.NoError(t, err, "Expected no error")
+			assert.Equal(t, "user1@example.com", output.User.Email, "Expected the user to be exported")
+			assert.Equal(t, "Org", output.Organization.Name, "Expected the organization to be exported")
+			assert.Len(t, output.Sessions, 1, "Expected the sessions to be exported")
+			assert.Len(t, output.ApiKeys, 1, "Expected the api keys to be exported")
+			assert.Len(t, output.Identities, 1, "Expected the identities to be exported")
+			assert.Equal(t, "America/Sao_Paulo", output.Preferences.Timezone, "Expected the preferences to be exported")
+			assert.Equal(t, &now, output.TotpEnabledAt, "Expected when TOTP was enabled to be exported")
+			assert.Equal(t, []time.Time{now}, output.PasswordChanges, "Expected only the dates of password changes")
+			assert.Len(t, output.Consents, 3, "Expected the whole consent history")
+			assert.Equal(t, now, output.ExportedAt, "Expected the export time")
+		})
+	}
+}
+
+func TestWriteUserDataBundle(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockUserRepository := new(mockUserRepository)
+	uc := newTestPrivacyUseCase(mockUserRepository, new(mockConsentRepository), new(mockLoginThrottleUseCase), now)
+
+	mockUserRepository.On("ForTenant", uint(2))
+	mockUserRepository.On("FindUserData", mock.Anything, uint(1)).Return(newTestUserData(now), nil)
+
+	buf := &bytes.Buffer{}
+	err := uc.WriteUserDataBundle(context.Background(), 2, 1, buf)
+	if !assert.NoError(t, err, "Expected no error") {
+		return
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !assert.NoError(t, err, "Expected a ZIP archive") {
+		return
+	}
+
+	names := []string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.Contains(t, names, "user.json", "Expected the user file")
+	assert.Contains(t, names, "consents.json", "Expected the consents file")
+	assert.NotContains(t, buf.String(), "secret", "Expected no secret in the bundle")
+}
+
+func TestEraseUser(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockUserRepository := new(mockUserRepository)
+	mockLoginThrottleUseCase := new(mockLoginThrottleUseCase)
+	uc := newTestPrivacyUseCase(mockUserRepository, new(mockConsentRepository), mockLoginThrottleUseCase, now)
+
+	testCases := []struct {
+		name            string
+		mockFindError   error
+		mockEraseError  error
+		expectAnonymize bool
+		expectedError   error
+	}{
+		{
+			name:            "Success",
+			expectAnonymize: true,
+		},
+		{
+			name:          "User not found",
+			mockFindError: gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+		{
+			name:            "Anonymize error",
+			mockEraseError:  gorm.ErrInvalidDB,
+			expectAnonymize: true,
+			expectedError:   gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockLoginThrottleUseCase.ExpectedCalls = nil
+			mockLoginThrottleUseCase.Calls = nil
+
+			var data *domain.UserData
+			if tc.mockFindError == nil {
+				data = newTestUserData(now)
+			}
+
+			mockUserRepository.On("ForTenant", uint(2))
+			mockUserRepository.On("FindUserData", mock.Anything, uint(1)).Return(data, tc.mockFindError)
+			if tc.expectAnonymize {
+				mockUserRepository.On("AnonymizeUser", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+					return u.ID == 1 && u.Name == ErasedUserName && u.Email == "erased-1@erased.invalid" && u.Password == ""
+				}), mock.MatchedBy(func(ws []*domain.Consent) bool {
+					// Marketing was already withdrawn, only the terms are left.
+					return len(ws) == 1 && ws[0].Purpose == domain.ConsentPurposeTermsOfService && !ws[0].Granted && ws[0].Source == domain.ConsentSourceErasure
+				})).Return(tc.mockEraseError)
+			}
+			mockLoginThrottleUseCase.On("RegisterSuccess", mock.Anything, "user1@example.com").Return(nil)
+
+			err := uc.EraseUser(context.Background(), 2, 1)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError, "Expected error to match")
+				mockLoginThrottleUseCase.AssertNotCalled(t, "RegisterSuccess", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err, "Expected no error")
+				mockLoginThrottleUseCase.AssertCalled(t, "RegisterSuccess", mock.Anything, "user1@example.com")
+			}
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecordConsent(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockConsentRepository := new(mockConsentRepository)
+	uc := newTestPrivacyUseCase(new(mockUserRepository), mockConsentRepository, new(mockLoginThrottleUseCase), now)
+
+	testCases := []struct {
+		name          string
+		input         *dto.ConsentInputDTO
+		mockError     error
+		expectCreate  bool
+		expectedError error
+	}{
+		{
+			name:         "Success",
+			input:        &dto.ConsentInputDTO{Purpose: domain.ConsentPurposeMarketing, Granted: true},
+			expectCreate: true,
+		},
+		{
+			name:          "Invalid purpose",
+			input:         &dto.ConsentInputDTO{Purpose: "profiling", Granted: true},
+			expectedError: domain.ErrConsentPurposeInvalid,
+		},
+		{
+			name:          "Create error",
+			input:         &dto.ConsentInputDTO{Purpose: domain.ConsentPurposeMarketing},
+			mockError:     gorm.ErrInvalidDB,
+			expectCreate:  true,
+			expectedError: gorm.ErrInvalidDB,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockConsentRepository.ExpectedCalls = nil
+			mockConsentRepository.Calls = nil
+
+			if tc.expectCreate {
+				mockConsentRepository.On("CreateConsent", mock.Anything, mock.MatchedBy(func(c *domain.Consent) bool {
+					return c.UserID == 1 && c.Purpose == tc.input.Purpose && c.Granted == tc.input.Granted && c.Source == domain.ConsentSourceUser
+				})).Return(&domain.Consent{ID: 3, UserID: 1, Purpose: tc.input.Purpose, Granted: tc.input.Granted, Source: domain.ConsentSourceUser}, tc.mockError)
+			}
+
+			output, err := uc.RecordConsent(context.Background(), 1, tc.input)
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "Expected error to match")
+				assert.Nil(t, output, "Expected no output")
+			} else {
+				assert.NoError(t, err, "Expected no error")
+				assert.Equal(t, uint(3), output.ID, "Expected the recorded consent")
+			}
+			if !tc.expectCreate {
+				mockConsentRepository.AssertNotCalled(t, "CreateConsent", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestListConsents(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	mockConsentRepository := new(mockConsentRepository)
+	uc := newTestPrivacyUseCase(new(mockUserRepository), mockConsentRepository, new(mockLoginThrottleUseCase), now)
+
+	mockConsentRepository.On("ListConsentsByUserId", mock.Anything, uint(1)).Return(newTestUserData(now).Consents, nil)
+
+	output, err := uc.ListConsents(context.Background(), 1)
+	assert.NoError(t, err, "Expected no error")
+	if assert.Len(t, output, 3, "Expected the whole history") {
+		assert.Equal(t, uint(7), output[0].ID, "Expected oldest first")
+	}
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/repository"
+)
+
+// rateLimitPurgeInterval is how often buckets back to full are dropped.
+const rateLimitPurgeInterval = time.Minute
+
+type RateLimitUseCase interface {
+	Allow(ctx context.Context, key string, policy domain.RateLimitPolicy) (*domain.RateLimitResult, error)
+}
+
+type rateLimitUseCase struct {
+	rateLimitRepository repository.RateLimitRepository
+	now                 func() time.Time
+
+	mu       sync.Mutex
+	purgedAt time.Time
+}
+
+func NewRateLimitUseCase(rateLimitRepository repository.RateLimitRepository) RateLimitUseCase {
+	return &rateLimitUseCase{
+		rateLimitRepository: rateLimitRepository,
+		now:                 time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key, filled according to policy.
+// The request is to be refused when the result does not allow it.
+func (uc *rateLimitUseCase) Allow(ctx context.Context, key string, policy domain.RateLimitPolicy) (*domain.RateLimitResult, error) {
+	now := uc.now()
+
+	if err := uc.purge(ctx, now); err != nil {
+		return nil, err
+	}
+
+	var res domain.RateLimitResult
+	err := uc.rateLimitRepository.UpdateBucket(ctx, key, func(b *domain.RateLimitBucket) {
+		res = b.Take(policy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// purge drops the buckets that are full again, at most once per
+// rateLimitPurgeInterval, so they do not pile up.
+func (uc *rateLimitUseCase) purge(ctx context.Context, now time.Time) error {
+	uc.mu.Lock()
+	if now.Sub(uc.purgedAt) < rateLimitPurgeInterval {
+		uc.mu.Unlock()
+		return nil
+	}
+	uc.purgedAt = now
+	uc.mu.Unlock()
+
+	return uc.rateLimitRepository.DeleteExpiredBuckets(ctx, now)
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitAllow(t *testing.T) {
+
+	start := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	policy := domain.RateLimitPolicy{Limit: 3, Period: 3 * time.Second, Key: domain.RateLimitKeyIP}
+
+	uc := NewRateLimitUseCase(repository.NewMemoryRateLimitRepository()).(*rateLimitUseCase)
+
+	steps := []struct {
+		name     string
+		key      string
+		elapsed  time.Duration
+		expected domain.RateLimitResult
+	}{
+		{
+			name:     "First request",
+			key:      "POST /login|ip:192.0.2.1",
+			expected: domain.RateLimitResult{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second},
+		},
+		{
+			name:     "Burst",
+			key:      "POST /login|ip:192.0.2.1",
+			expected: domain.RateLimitResult{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second},
+		},
+		{
+			name:     "Last token",
+			key:      "POST /login|ip:192.0.2.1",
+			expected: domain.RateLimitResult{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second},
+		},
+		{
+			name:     "Bucket empty",
+			key:      "POST /login|ip:192.0.2.1",
+			elapsed:  500 * time.Millisecond,
+			expected: domain.RateLimitResult{Allowed: false, Limit: 3, Remaining: 0, Reset: 2500 * time.Millisecond, RetryAfter: 500 * time.Millisecond},
+		},
+		{
+			name:     "Other client",
+			key:      "POST /login|ip:192.0.2.2",
+			expected: domain.RateLimitResult{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second},
+		},
+		{
+			name:     "Refilled",
+			key:      "POST /login|ip:192.0.2.1",
+			elapsed:  time.Second,
+			expected: domain.RateLimitResult{Allowed: true, Limit: 3, Remaining: 0, Reset: 2500 * time.Millisecond},
+		},
+		{
+			name:     "Refill capped at the limit",
+			key:      "POST /login|ip:192.0.2.1",
+			elapsed:  time.Hour,
+			expected: domain.RateLimitResult{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second},
+		},
+	}
+
+	now := start
+	uc.now = func() time.Time { return now }
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			now = now.Add(step.elapsed)
+
+			res, err := uc.Allow(context.Background(), step.key, policy)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &step.expected, res)
+		})
+	}
+}
+
+func TestRateLimitPurge(t *testing.T) {
+
+	start := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	policy := domain.RateLimitPolicy{Limit: 10, Period: 10 * time.Second, Key: domain.RateLimitKeyIP}
+
+	rateLimitRepository := repository.NewMemoryRateLimitRepository()
+	uc := NewRateLimitUseCase(rateLimitRepository).(*rateLimitUseCase)
+
+	now := start
+	uc.now = func() time.Time { return now }
+
+	_, err := uc.Allow(context.Background(), "a", policy)
+	assert.NoError(t, err)
+
+	// The bucket of "a" is full again after a second, but no purge is due
+	// before a minute.
+	now = start.Add(30 * time.Second)
+	_, err = uc.Allow(context.Background(), "b", policy)
+	assert.NoError(t, err)
+
+	count := 0
+	rateLimitRepository.UpdateBucket(context.Background(), "a", func(b *domain.RateLimitBucket) {
+		if !b.RefilledAt.IsZero() {
+			count++
+		}
+	})
+	assert.Equal(t, 1, count, "Expected the bucket of a to be kept")
+
+	now = start.Add(time.Minute + 30*time.Second)
+	_, err = uc.Allow(context.Background(), "c", policy)
+	assert.NoError(t, err)
+
+	for _, key := range []string{"a", "b"} {
+		rateLimitRepository.UpdateBucket(context.Background(), key, func(b *domain.RateLimitBucket) {
+			assert.True(t, b.RefilledAt.IsZero(), "Expected the bucket of %s to be purged", key)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/Daffc/GO-Sales/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	sessionTokenIdSize   = 24
+	sessionUserAgentSize = 255
+	// sessionLastSeenResolution limits how often an active session writes
+	// its last-seen timestamp.
+	sessionLastSeenResolution = time.Minute
+)
+
+type SessionUseCase interface {
+	CreateSession(ctx context.Context, userId uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error)
+	CreateImpersonationSession(ctx context.Context, userId uint, actorId uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error)
+	ListSessions(ctx context.Context, userId uint, currentSessionId uint) ([]*dto.SessionOutputDTO, error)
+	RevokeSession(ctx context.Context, userId uint, sessionId uint) error
+	RevokeOtherSessions(ctx context.Context, userId uint, currentSessionId uint) error
+	RevokeImpersonationSessions(ctx context.Context, actorId uint) error
+	ValidateSession(ctx context.Context, userId uint, tokenId string) (*domain.Session, error)
+}
+
+type sessionUseCase struct {
+	sessionRepository repository.SessionRepository
+	now               func() time.Time
+}
+
+func NewSessionUseCase(sessionRepository repository.SessionRepository) SessionUseCase {
+	return &sessionUseCase{
+		sessionRepository: sessionRepository,
+		now:               time.Now,
+	}
+}
+
+// CreateSession records a new login. The returned session TokenID must be
+// embedded in the access token issued for it.
+func (uc *sessionUseCase) CreateSession(ctx context.Context, userId uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error) {
+	return uc.createSession(ctx, userId, nil, ip, userAgent, duration)
+}
+
+// CreateImpersonationSession records actorId acting as userId.
+func (uc *sessionUseCase) CreateImpersonationSession(ctx context.Context, userId uint, actorId uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error) {
+	return uc.createSession(ctx, userId, &actorId, ip, userAgent, duration)
+}
+
+func (uc *sessionUseCase) createSession(ctx context.Context, userId uint, actorId *uint, ip string, userAgent string, duration time.Duration) (*domain.Session, error) {
+	tokenId, err := util.NewOpaqueToken(sessionTokenIdSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userAgent) > sessionUserAgentSize {
+		// Cut on a character boundary, half a character is not valid UTF-8.
+		end := sessionUserAgentSize
+		for end > 0 && !utf8.RuneStart(userAgent[end]) {
+			end--
+		}
+		userAgent = userAgent[:end]
+	}
+
+	now := uc.now()
+
+	return uc.sessionRepository.CreateSession(ctx, &domain.Session{
+		UserID:     userId,
+		TokenID:    tokenId,
+		IP:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(duration),
+		ActorID:    actorId,
+	})
+}
+
+// ListSessions returns the sessions of the user that are neither revoked nor
+// expired, flagging the one with id currentSessionId.
+func (uc *sessionUseCase) ListSessions(ctx context.Context, userId uint, currentSessionId uint) ([]*dto.SessionOutputDTO, error) {
+	ss, err := uc.sessionRepository.ListActiveSessionsByUserId(ctx, userId, uc.now())
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*dto.SessionOutputDTO, len(ss))
+	for i, s := range ss {
+		output[i] = &dto.SessionOutputDTO{
+			ID:           s.ID,
+			IP:           s.IP,
+			UserAgent:    s.UserAgent,
+			CreatedAt:    s.CreatedAt,
+			LastSeenAt:   s.LastSeenAt,
+			ExpiresAt:    s.ExpiresAt,
+			Current:      s.ID == currentSessionId,
+			Impersonated: s.ActorID != nil,
+		}
+	}
+
+	return output, nil
+}
+
+func (uc *sessionUseCase) RevokeSession(ctx context.Context, userId uint, sessionId uint) error {
+	err := uc.sessionRepository.RevokeSession(ctx, userId, sessionId, uc.now())
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return domain.ErrSessionNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions logs the user out everywhere but in currentSessionId.
+func (uc *sessionUseCase) RevokeOtherSessions(ctx context.Context, userId uint, currentSessionId uint) error {
+	return uc.sessionRepository.RevokeOtherSessions(ctx, userId, currentSessionId, uc.now())
+}
+
+// RevokeImpersonationSessions ends every impersonation started by actorId.
+func (uc *sessionUseCase) RevokeImpersonationSessions(ctx context.Context, actorId uint) error {
+	return uc.sessionRepository.RevokeImpersonationSessions(ctx, actorId, uc.now())
+}
+
+// ValidateSession checks that the session linked to an access token is still
+// active. Tokens issued without a session are refused, so every accepted token
+// can be revoked.
+func (uc *sessionUseCase) ValidateSession(ctx context.Context, userId uint, tokenId string) (*domain.Session, error) {
+	if len(tokenId) == 0 {
+		return nil, domain.ErrSessionInvalid
+	}
+
+	s, err := uc.sessionRepository.FindSessionByTokenId(ctx, tokenId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, domain.ErrSessionInvalid
+		default:
+			return nil, err
+		}
+	}
+
+	now := uc.now()
+	if s.UserID != userId || !s.IsActive(now) {
+		return nil, domain.ErrSessionInvalid
+	}
+
+	if now.Sub(s.LastSeenAt) >= sessionLastSeenResolution {
+		s.LastSeenAt = now
+		err = uc.sessionRepository.UpdateSessionLastSeenAt(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newTestSessionUseCase(sessionRepository *mockSessionRepository, now time.Time) *sessionUseCase {
+	uc := NewSessionUseCase(sessionRepository).(*sessionUseCase)
+	uc.now = func() time.Time { return now }
+	return uc
+}
+
+func TestCreateSession(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	mockSessionRepository := new(mockSessionRepository)
+
+	created := &domain.Session{}
+	mockSessionRepository.On("CreateSession", mock.Anything, mock.AnythingOfType("*domain.Session")).Run(func(args mock.Arguments) {
+		*created = *args.Get(1).(*domain.Session)
+		created.ID = 3
+	}).Return(created, nil)
+
+	uc := newTestSessionUseCase(mockSessionRepository, now)
+
+	s, err := uc.CreateSession(context.Background(), 1, "192.0.2.1", strings.Repeat("a", 300), 2*time.Hour)
+
+	assert.NoError(t, err, "Did not expect an error but got one")
+	assert.Equal(t, uint(3), s.ID, "Expected session ID to match.")
+	assert.Equal(t, uint(1), s.UserID, "Expected user ID to match.")
+	assert.Equal(t, "192.0.2.1", s.IP, "Expected IP to match.")
+	assert.Len(t, s.UserAgent, 255, "Expected user agent to be truncated.")
+	assert.Equal(t, now, s.CreatedAt, "Expected creation time to match.")
+	assert.Equal(t, now, s.LastSeenAt, "Expected last seen time to match.")
+	assert.Equal(t, now.Add(2*time.Hour), s.ExpiresAt, "Expected expiration to match.")
+	assert.GreaterOrEqual(t, len(s.TokenID), 32, "Expected a random token ID.")
+
+	tokenId := s.TokenID
+	other, err := uc.CreateSession(context.Background(), 1, "192.0.2.1", "Mozilla/5.0", 2*time.Hour)
+	assert.NoError(t, err, "Did not expect an error but got one")
+	assert.NotEqual(t, tokenId, other.TokenID, "Expected token IDs to be unique.")
+
+	// The 255th byte is in the middle of the "é".
+	s, err = uc.CreateSession(context.Background(), 1, "192.0.2.1", strings.Repeat("a", 254)+"éé", 2*time.Hour)
+	assert.NoError(t, err, "Did not expect an error but got one")
+	assert.Equal(t, strings.Repeat("a", 254), s.UserAgent, "Expected user agent to be truncated before the split character.")
+	assert.True(t, utf8.ValidString(s.UserAgent), "Expected user agent to stay valid UTF-8.")
+
+	mockSessionRepository.AssertExpectations(t)
+}
+
+func TestListSessions(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	mockSessionRepository := new(mockSessionRepository)
+	mockSessionRepository.On("ListActiveSessionsByUserId", mock.Anything, uint(1), now).Return([]*domain.Session{
+		{ID: 2, UserID: 1, TokenID: "token-2", IP: "192.0.2.2", UserAgent: "curl/8.0", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: 1, UserID: 1, TokenID: "token-1", IP: "192.0.2.1", UserAgent: "Mozilla/5.0", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)},
+	}, nil)
+
+	uc := newTestSessionUseCase(mockSessionRepository, now)
+
+	output, err := uc.ListSessions(context.Background(), 1, 1)
+
+	assert.NoError(t, err, "Did not expect an error but got one")
+	assert.Equal(t, []*dto.SessionOutputDTO{
+		{ID: 2, IP: "192.0.2.2", UserAgent: "curl/8.0", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour), Current: false},
+		{ID: 1, IP: "192.0.2.1", UserAgent: "Mozilla/5.0", CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour), Current: true},
+	}, output, "Expected sessions to match.")
+
+	mockSessionRepository.AssertExpectations(t)
+}
+
+func TestRevokeSession(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	mockSessionRepository := new(mockSessionRepository)
+
+	testCases := []struct {
+		name          string
+		sessionId     uint
+		mockError     error
+		expectedError error
+	}{
+		{
+			name:          "Success",
+			sessionId:     1,
+			mockError:     nil,
+			expectedError: nil,
+		},
+		{
+			name:          "Not found",
+			sessionId:     100,
+			mockError:     gorm.ErrRecordNotFound,
+			expectedError: domain.ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSessionRepository.ExpectedCalls = nil
+			mockSessionRepository.On("RevokeSession", mock.Anything, uint(1), tc.sessionId, now).Return(tc.mockError)
+
+			uc := newTestSessionUseCase(mockSessionRepository, now)
+
+			err := uc.RevokeSession(context.Background(), 1, tc.sessionId)
+
+			assert.Equal(t, tc.expectedError, err, "Expected RevokeSession error to match.")
+			mockSessionRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestValidateSession(t *testing.T) {
+
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Minute)
+
+	active := domain.Session{ID: 1, UserID: 1, TokenID: "token-id", LastSeenAt: now.Add(-10 * time.Second), ExpiresAt: now.Add(time.Hour)}
+	idle := domain.Session{ID: 1, UserID: 1, TokenID: "token-id", LastSeenAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}
+	revoked := domain.Session{ID: 1, UserID: 1, TokenID: "token-id", LastSeenAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}
+	expired := domain.Session{ID: 1, UserID: 1, TokenID: "token-id", LastSeenAt: now.Add(-time.Hour), ExpiresAt: now}
+
+	mockSessionRepository := new(mockSessionRepository)
+
+	testCases := []struct {
+		name             string
+		userId           uint
+		tokenId          string
+		mockReturn       *domain.Session
+		mockError        error
+		expectLastSeen   bool
+		expectedLastSeen time.Time
+		expectedError    error
+	}{
+		{
+			name:             "Recently seen",
+			userId:           1,
+			tokenId:          "token-id",
+			mockReturn:       &active,
+			expectedLastSeen: active.LastSeenAt,
+		},
+		{
+			name:             "Last seen updated",
+			userId:           1,
+			tokenId:          "token-id",
+			mockReturn:       &idle,
+			expectLastSeen:   true,
+			expectedLastSeen: now,
+		},
+		{
+			name:          "Revoked",
+			userId:        1,
+			tokenId:       "token-id",
+			mockReturn:    &revoked,
+			expectedError: domain.ErrSessionInvalid,
+		},
+		{
+			name:          "Expired",
+			userId:        1,
+			tokenId:       "token-id",
+			mockReturn:    &expired,
+			expectedError: domain.ErrSessionInvalid,
+		},
+		{
+			name:          "Other user",
+			userId:        2,
+			tokenId:       "token-id",
+			mockReturn:    &active,
+			expectedError: domain.ErrSessionInvalid,
+		},
+		{
+			name:          "Unknown token",
+			userId:        1,
+			tokenId:       "unknown",
+			mockReturn:    nil,
+			mockError:     gorm.ErrRecordNotFound,
+			expectedError: domain.ErrSessionInvalid,
+		},
+		{
+			name:          "Token without session",
+			userId:        1,
+			tokenId:       "",
+			expectedError: domain.ErrSessionInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSessionRepository.ExpectedCalls = nil
+
+			// The use case updates the session in place, hand it a copy.
+			var mockReturn *domain.Session
+			if tc.mockReturn != nil {
+				s := *tc.mockReturn
+				mockReturn = &s
+			}
+			if len(tc.tokenId) > 0 {
+				mockSessionRepository.On("FindSessionByTokenId", mock.Anything, tc.tokenId).Return(mockReturn, tc.mockError)
+			}
+			if tc.expectLastSeen {
+				mockSessionRepository.On("UpdateSessionLastSeenAt", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+			}
+
+			uc := newTestSessionUseCase(mockSessionRepository, now)
+
+			s, err := uc.ValidateSession(context.Background(), tc.userId, tc.tokenId)
+
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err, "Expected ValidateSession error to match.")
+				assert.Nil(t, s, "Expected session to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, tc.mockReturn.ID, s.ID, "Expected session to match.")
+				assert.Equal(t, tc.expectedLastSeen, s.LastSeenAt, "Expected last seen time to match.")
+			}
+
+			mockSessionRepository.AssertExpectations(t)
+		})
+	}
+}
@@ -1,122 +1,403 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+
 	"github.com/Daffc/GO-Sales/domain"
 	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
 	"github.com/Daffc/GO-Sales/repository"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type UserUseCase interface {
-	CreateUser(input *dto.UserInputDTO) (*dto.UserOutputDTO, error)
-	ListUsers() ([]*dto.UserOutputDTO, error)
-	FindUserById(input uint) (*dto.UserOutputDTO, error)
-	UpdateUserPassword(input dto.UpdateUserPasswordInputDTO) error
+	CreateUser(ctx context.Context, input *dto.UserInputDTO) (*dto.UserOutputDTO, error)
+	ListUsers(ctx context.Context, tenantId uint, input *dto.ListUsersInputDTO) (*dto.UserListOutputDTO, error)
+	FindUserById(ctx context.Context, tenantId uint, input uint) (*dto.UserOutputDTO, error)
+	UpdateUser(ctx context.Context, tenantId uint, userId uint, input *dto.UpdateUserInputDTO) (*dto.UpdateUserOutputDTO, error)
+	UpdateUserPassword(ctx context.Context, userId uint, currentSessionId uint, input *dto.UpdateUserPasswordInputDTO) error
+	UpdateUserStatus(ctx context.Context, tenantId uint, userId uint, input *dto.UpdateUserStatusInputDTO) (*dto.UserOutputDTO, error)
+	DeleteUser(ctx context.Context, tenantId uint, userId uint) error
+	RestoreUser(ctx context.Context, tenantId uint, userId uint) (*dto.UserOutputDTO, error)
+	PurgeUser(ctx context.Context, tenantId uint, userId uint) error
+	ImportUsers(ctx context.Context, importer *domain.User, file io.Reader, dryRun bool) (*dto.UserImportOutputDTO, error)
 }
 type userUseCase struct {
-	repository repository.UserRepository
+	repository               repository.UserRepository
+	emailVerificationUseCase EmailVerificationUseCase
+	emailChangeUseCase       EmailChangeUseCase
+	sessionUseCase           SessionUseCase
+	passwordHasher           util.PasswordHasher
+	passwordPolicy           *domain.PasswordPolicy
+	// PasswordHistorySize is how many of the latest passwords, the current
+	// one included, cannot be chosen again.
+	PasswordHistorySize uint
 }
 
-func NewUserUseCase(repository repository.UserRepository) UserUseCase {
-	return &userUseCase{repository: repository}
+func NewUserUseCase(repository repository.UserRepository, emailVerificationUseCase EmailVerificationUseCase, emailChangeUseCase EmailChangeUseCase, sessionUseCase SessionUseCase, passwordHasher util.PasswordHasher, passwordPolicy *domain.PasswordPolicy, passwordHistorySize uint) UserUseCase {
+	return &userUseCase{
+		repository:               repository,
+		emailVerificationUseCase: emailVerificationUseCase,
+		emailChangeUseCase:       emailChangeUseCase,
+		sessionUseCase:           sessionUseCase,
+		passwordHasher:           passwordHasher,
+		passwordPolicy:           passwordPolicy,
+		PasswordHistorySize:      passwordHistorySize,
+	}
 }
 
-func (uc *userUseCase) CreateUser(input *dto.UserInputDTO) (*dto.UserOutputDTO, error) {
+func (uc *userUseCase) CreateUser(ctx context.Context, input *dto.UserInputDTO) (*dto.UserOutputDTO, error) {
+	// Open signup has no organization to join but the default one.
 	u := domain.User{
-		Name:     input.Name,
-		Email:    input.Email,
-		Password: input.Password,
+		OrganizationID: domain.DefaultOrganizationID,
+		Name:           input.Name,
+		Email:          input.Email,
+		Password:       input.Password,
+		Role:           domain.RoleUser,
+		Status:         domain.UserStatusActive,
 	}
 
-	err := u.ValidateAll()
+	err := u.ValidateAll(uc.passwordPolicy)
 	if err != nil {
 		return nil, err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), 0)
+	hashedPassword, err := uc.passwordHasher.Hash(u.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 
-	user, err := uc.repository.CreateUser(&u)
+	user, err := uc.repository.ForTenant(u.OrganizationID).CreateUser(ctx, &u)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return nil, domain.ErrUserEmailTaken
+		default:
+			return nil, err
+		}
 	}
 
-	userDTO := dto.UserOutputDTO{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+	// The account already exists at this point, a failed delivery can be
+	// recovered through the resend endpoint.
+	err = uc.emailVerificationUseCase.SendVerificationEmail(ctx, user)
+	if err != nil {
+		log.Println(err)
 	}
 
-	return &userDTO, nil
+	return newUserOutputDTO(user), nil
 }
 
-func (uc *userUseCase) ListUsers() ([]*dto.UserOutputDTO, error) {
-	us, err := uc.repository.ListUsers()
+// ListUsers returns a page of the users of the organization. The cursor of
+// the next page is only set when there are users left.
+func (uc *userUseCase) ListUsers(ctx context.Context, tenantId uint, input *dto.ListUsersInputDTO) (*dto.UserListOutputDTO, error) {
+	q := domain.UserListQuery{
+		NameContains: input.Name,
+		Email:        strings.TrimSpace(input.Email),
+		Status:       input.Status,
+		CreatedFrom:  input.CreatedFrom,
+		CreatedTo:    input.CreatedTo,
+		Sort:         strings.TrimPrefix(input.Sort, "-"),
+		Desc:         strings.HasPrefix(input.Sort, "-"),
+		Limit:        input.Limit,
+	}
+	if len(q.Sort) == 0 {
+		q.Sort = domain.UserSortID
+	}
+	if q.Limit == 0 {
+		q.Limit = domain.UserListDefaultLimit
+	}
+
+	if len(q.Status) > 0 {
+		u := domain.User{Status: q.Status}
+		if err := u.ValidateStatus(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(input.Cursor) > 0 {
+		cursor, err := domain.DecodeUserCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.After = cursor
+	}
+
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Asking for one more user than the page holds tells whether there is a
+	// next page.
+	page := q
+	page.Limit++
+
+	us, err := uc.repository.ForTenant(tenantId).ListUsers(ctx, &page)
 	if err != nil {
 		return nil, err
 	}
 
-	usersDTO := make([]*dto.UserOutputDTO, len(us))
+	output := &dto.UserListOutputDTO{}
+	if len(us) > q.Limit {
+		us = us[:q.Limit]
+		output.NextCursor = domain.NewUserCursor(us[len(us)-1], q.Sort, q.Desc).Encode()
+	}
 
+	output.Data = make([]*dto.UserOutputDTO, len(us))
 	for i, u := range us {
-		usersDTO[i] = &dto.UserOutputDTO{
-			ID:        u.ID,
-			Name:      u.Name,
-			Email:     u.Email,
-			CreatedAt: u.CreatedAt,
-			UpdatedAt: u.UpdatedAt,
-		}
+		output.Data[i] = newUserOutputDTO(u)
 	}
 
-	return usersDTO, nil
+	return output, nil
 }
 
-func (uc *userUseCase) FindUserById(input uint) (*dto.UserOutputDTO, error) {
-	user, err := uc.repository.FindUserById(input)
+func (uc *userUseCase) FindUserById(ctx context.Context, tenantId uint, input uint) (*dto.UserOutputDTO, error) {
+	user, err := uc.repository.ForTenant(tenantId).FindUserById(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, userNotFound(err)
 	}
 
-	userDTO := dto.UserOutputDTO{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+	return newUserOutputDTO(user), nil
+}
+
+// UpdateUser changes the profile of a user of the organization. A new email is
+// only requested, it replaces the current one once confirmed.
+func (uc *userUseCase) UpdateUser(ctx context.Context, tenantId uint, userId uint, input *dto.UpdateUserInputDTO) (*dto.UpdateUserOutputDTO, error) {
+	users := uc.repository.ForTenant(tenantId)
+
+	user, err := users.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, userNotFound(err)
 	}
 
-	return &userDTO, nil
+	// Every field is checked before any is written, so a refused update
+	// leaves the user as it was.
+	if input.Name != nil {
+		user.Name = strings.TrimSpace(*input.Name)
+
+		err = user.ValidateName()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var newEmail string
+	if input.Email != nil && strings.TrimSpace(*input.Email) != user.Email {
+		newEmail, err = uc.emailChangeUseCase.CheckNewEmail(ctx, *input.Email)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if input.Name != nil {
+		err = users.UpdateUserProfile(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	output := &dto.UpdateUserOutputDTO{}
+
+	if len(newEmail) > 0 {
+		err = uc.emailChangeUseCase.RequestEmailChange(ctx, user, newEmail)
+		if err != nil {
+			return nil, err
+		}
+
+		output.PendingEmail = newEmail
+	}
+
+	output.UserOutputDTO = *newUserOutputDTO(user)
+
+	return output, nil
 }
 
-func (uc *userUseCase) UpdateUserPassword(input dto.UpdateUserPasswordInputDTO) error {
+// UpdateUserPassword changes the password of the user after checking the
+// current one, then logs the user out of every session but currentSessionId.
+func (uc *userUseCase) UpdateUserPassword(ctx context.Context, userId uint, currentSessionId uint, input *dto.UpdateUserPasswordInputDTO) error {
+
+	user, err := uc.repository.AllTenants().FindUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	users := uc.repository.ForTenant(user.OrganizationID)
+
+	ok, err := uc.passwordHasher.Verify(input.CurrentPassword, user.Password)
+	if err != nil || !ok {
+		return domain.ErrUserPasswordIncorrect
+	}
 
 	u := &domain.User{
-		ID:       uint(input.ID),
-		Password: input.Password,
+		ID:       user.ID,
+		Name:     user.Name,
+		Email:    user.Email,
+		Password: input.NewPassword,
+	}
+
+	err = u.ValidatePassword(uc.passwordPolicy)
+	if err != nil {
+		return domain.NewFieldError("new_password", err)
 	}
 
-	err := u.ValidatePassword()
+	err = uc.checkPasswordReuse(ctx, users, user, input.NewPassword)
+	if errors.Is(err, domain.ErrUserPasswordReused) {
+		return domain.NewFieldError("new_password", err)
+	}
 	if err != nil {
 		return err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), 0)
+	hashedPassword, err := uc.passwordHasher.Hash(input.NewPassword)
 	if err != nil {
 		return err
 	}
 
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 
-	err = uc.repository.UpdateUserPassword(u)
+	err = users.UpdateUserPassword(ctx, u)
 	if err != nil {
 		return err
 	}
 
+	return uc.sessionUseCase.RevokeOtherSessions(ctx, user.ID, currentSessionId)
+}
+
+// UpdateUserStatus suspends or reactivates a user of the organization.
+func (uc *userUseCase) UpdateUserStatus(ctx context.Context, tenantId uint, userId uint, input *dto.UpdateUserStatusInputDTO) (*dto.UserOutputDTO, error) {
+	users := uc.repository.ForTenant(tenantId)
+
+	user, err := users.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, userNotFound(err)
+	}
+
+	user.Status = input.Status
+
+	err = user.ValidateStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	err = users.UpdateUserStatus(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// A suspended admin loses the impersonations they had started.
+	if user.IsSuspended() {
+		err = uc.sessionUseCase.RevokeImpersonationSessions(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newUserOutputDTO(user), nil
+}
+
+// DeleteUser soft deletes a user of the organization. The account stops
+// authenticating right away and can be restored until purged.
+func (uc *userUseCase) DeleteUser(ctx context.Context, tenantId uint, userId uint) error {
+	err := uc.repository.ForTenant(tenantId).DeleteUser(ctx, userId)
+	if err != nil {
+		return userNotFound(err)
+	}
+
+	return uc.sessionUseCase.RevokeImpersonationSessions(ctx, userId)
+}
+
+// RestoreUser brings back a deleted user of the organization, unless their
+// email was taken by another account in the meantime. Erased users cannot be
+// brought back.
+func (uc *userUseCase) RestoreUser(ctx context.Context, tenantId uint, userId uint) (*dto.UserOutputDTO, error) {
+	users := uc.repository.ForTenant(tenantId)
+
+	err := users.RestoreUser(ctx, userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			return nil, domain.ErrUserEmailTaken
+		case errors.Is(err, repository.ErrUserErased):
+			return nil, domain.ErrUserErased
+		default:
+			return nil, userNotFound(err)
+		}
+	}
+
+	user, err := users.FindUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return newUserOutputDTO(user), nil
+}
+
+// PurgeUser permanently removes a deleted user of the organization. Erased
+// users are kept for their consent history.
+func (uc *userUseCase) PurgeUser(ctx context.Context, tenantId uint, userId uint) error {
+	err := uc.repository.ForTenant(tenantId).PurgeUser(ctx, userId)
+	if errors.Is(err, repository.ErrUserErased) {
+		return domain.ErrUserErased
+	}
+	if err != nil {
+		return userNotFound(err)
+	}
+
+	return nil
+}
+
+// userNotFound turns a missing row into domain.ErrUserNotFound.
+func userNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrUserNotFound
+	}
+
+	return err
+}
+
+// checkPasswordReuse refuses the current password and the ones it replaced
+// within the configured history size.
+func (uc *userUseCase) checkPasswordReuse(ctx context.Context, users repository.UserRepository, user *domain.User, password string) error {
+	if uc.PasswordHistorySize == 0 {
+		return nil
+	}
+
+	hashes := []string{user.Password}
+
+	if uc.PasswordHistorySize > 1 {
+		history, err := users.ListPasswordHistory(ctx, user.ID, int(uc.PasswordHistorySize-1))
+		if err != nil {
+			return err
+		}
+		for _, h := range history {
+			hashes = append(hashes, h.Password)
+		}
+	}
+
+	for _, hash := range hashes {
+		if ok, _ := uc.passwordHasher.Verify(password, hash); ok {
+			return domain.ErrUserPasswordReused
+		}
+	}
+
 	return nil
 }
+
+func newUserOutputDTO(u *domain.User) *dto.UserOutputDTO {
+	return &dto.UserOutputDTO{
+		ID:             u.ID,
+		OrganizationID: u.OrganizationID,
+		Name:           u.Name,
+		Email:          u.Email,
+		Role:           u.Role,
+		Status:         u.Status,
+		EmailVerified:  u.IsEmailVerified(),
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+	}
+}
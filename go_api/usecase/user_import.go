@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// userImportHashWorkers bounds the passwords hashed at once during an import,
+// each argon2id hash holds its whole memory cost while it runs.
+const userImportHashWorkers = 4
+
+// userImportRow is a data row of an imported CSV file. err is set when the row
+// could not be read, its fields are then empty.
+type userImportRow struct {
+	line     int
+	name     string
+	email    string
+	password string
+	err      error
+}
+
+// ImportUsers creates a user in the organization of importer for every valid
+// row of a CSV file with name, email and password columns. Rows failing
+// validation are reported with the reason, and rows whose email belongs to an
+// existing account or an earlier row are skipped. The valid rows are created
+// together, or not at all, and nothing is written on a dry run.
+func (uc *userUseCase) ImportUsers(ctx context.Context, importer *domain.User, file io.Reader, dryRun bool) (*dto.UserImportOutputDTO, error) {
+	rows, err := readUserImportRows(file)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := []string{}
+	for _, row := range rows {
+		if row.err == nil && len(row.email) > 0 {
+			emails = append(emails, row.email)
+		}
+	}
+
+	// The email is unique across organizations.
+	taken, err := uc.repository.AllTenants().FindTakenEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+
+	takenEmails := map[string]bool{}
+	for _, email := range taken {
+		takenEmails[strings.ToLower(email)] = true
+	}
+	seenEmails := map[string]bool{}
+
+	output := &dto.UserImportOutputDTO{DryRun: dryRun, Rows: make([]*dto.UserImportRowDTO, len(rows))}
+	users := []*domain.User{}
+	created := []*dto.UserImportRowDTO{}
+
+	for i, row := range rows {
+		report := &dto.UserImportRowDTO{Row: row.line, Email: row.email}
+		output.Rows[i] = report
+
+		if row.err != nil {
+			report.Status, report.Reason = domain.UserImportRowFailed, row.err.Error()
+			continue
+		}
+
+		u := &domain.User{
+			OrganizationID: importer.OrganizationID,
+			Name:           row.name,
+			Email:          row.email,
+			Password:       row.password,
+			Role:           domain.RoleUser,
+			Status:         domain.UserStatusActive,
+		}
+
+		if err := u.ValidateAll(uc.passwordPolicy); err != nil {
+			report.Status, report.Reason = domain.UserImportRowFailed, err.Error()
+			continue
+		}
+
+		key := strings.ToLower(u.Email)
+		switch {
+		case takenEmails[key]:
+			report.Status, report.Reason = domain.UserImportRowSkipped, domain.ErrUserEmailTaken.Error()
+			continue
+		case seenEmails[key]:
+			report.Status, report.Reason = domain.UserImportRowSkipped, domain.ErrUserImportDuplicateRow.Error()
+			continue
+		}
+		seenEmails[key] = true
+
+		if dryRun {
+			report.Status = domain.UserImportRowValid
+			continue
+		}
+
+		users = append(users, u)
+		created = append(created, report)
+	}
+
+	if len(users) > 0 {
+		err = uc.hashImportedPasswords(ctx, users)
+		if err != nil {
+			return nil, err
+		}
+
+		// An account may have taken one of the emails since they were
+		// checked, the unique constraint is what settles it.
+		err = uc.repository.ForTenant(importer.OrganizationID).CreateUsers(ctx, users, domain.UserImportBatchSize)
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrDuplicatedKey):
+				return nil, domain.ErrUserEmailTaken
+			default:
+				return nil, err
+			}
+		}
+
+		for i, u := range users {
+			created[i].Status = domain.UserImportRowCreated
+			created[i].UserID = u.ID
+
+			err = uc.emailVerificationUseCase.SendVerificationEmail(ctx, u)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+
+	for _, report := range output.Rows {
+		switch report.Status {
+		case domain.UserImportRowCreated:
+			output.Created++
+		case domain.UserImportRowValid:
+			output.Valid++
+		case domain.UserImportRowSkipped:
+			output.Skipped++
+		case domain.UserImportRowFailed:
+			output.Failed++
+		}
+	}
+
+	return output, nil
+}
+
+// hashImportedPasswords replaces the passwords of users with their hashes. It
+// stops handing out passwords once ctx is done, so that an import too large for
+// the deadline of the request fails instead of hashing on for a gone client.
+func (uc *userUseCase) hashImportedPasswords(ctx context.Context, users []*domain.User) error {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(userImportHashWorkers)
+
+	for _, u := range users {
+		if gctx.Err() != nil {
+			break
+		}
+
+		g.Go(func() error {
+			// The slot may only free up once ctx is done.
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+
+			hash, err := uc.passwordHasher.Hash(u.Password)
+			if err != nil {
+				return err
+			}
+
+			u.Password = hash
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	if err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// readUserImportRows reads the data rows of a CSV file, locating the name,
+// email and password columns through the header. A row with the wrong number
+// of fields is returned with its error, any other syntax error fails the whole
+// file.
+func readUserImportRows(file io.Reader) ([]*userImportRow, error) {
+	r := csv.NewReader(file)
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil, domain.ErrUserImportEmpty
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", domain.ErrUserImportMalformed, err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// Spreadsheets often start their exports with a byte order mark.
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"name", "email", "password"} {
+		if _, ok := columns[name]; !ok {
+			return nil, domain.ErrUserImportHeaderInvalid
+		}
+	}
+
+	rows := []*userImportRow{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows) == domain.UserImportMaxRows {
+			return nil, domain.ErrUserImportTooLarge
+		}
+
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: %v", domain.ErrUserImportMalformed, err)
+		}
+
+		line, _ := r.FieldPos(0)
+		row := &userImportRow{line: line}
+		rows = append(rows, row)
+
+		if err != nil {
+			row.err = csv.ErrFieldCount
+			continue
+		}
+
+		row.name = strings.TrimSpace(record[columns["name"]])
+		row.email = strings.TrimSpace(record[columns["email"]])
+		row.password = record[columns["password"]]
+	}
+
+	if len(rows) == 0 {
+		return nil, domain.ErrUserImportEmpty
+	}
+
+	return rows, nil
+}
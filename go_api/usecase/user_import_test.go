@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Daffc/GO-Sales/domain"
+	"github.com/Daffc/GO-Sales/domain/dto"
+	"github.com/Daffc/GO-Sales/internal/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestImportUsers(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	mockEmailVerificationUseCase := new(mockEmailVerificationUseCase)
+
+	importer := &domain.User{ID: 1, OrganizationID: 2, Role: domain.RoleAdmin}
+	header := "Name,Email,Password\n"
+
+	testCases := []struct {
+		name              string
+		file              string
+		dryRun            bool
+		expectedEmails    []string
+		mockTakenEmails   []string
+		expectedCreated   []string
+		mockCreateError   error
+		expectedOutput    *dto.UserImportOutputDTO
+		expectedError     error
+		expectedErrorText string
+	}{
+		{
+			name:            "Success",
+			file:            "\ufeff" + header + "User1,user1@example.com,Password@1\nUser2, user2@example.com ,Password@2\n",
+			expectedEmails:  []string{"user1@example.com", "user2@example.com"},
+			expectedCreated: []string{"user1@example.com", "user2@example.com"},
+			expectedOutput: &dto.UserImportOutputDTO{
+				Created: 2,
+				Rows: []*dto.UserImportRowDTO{
+					{Row: 2, Email: "user1@example.com", Status: domain.UserImportRowCreated, UserID: 10},
+					{Row: 3, Email: "user2@example.com", Status: domain.UserImportRowCreated, UserID: 11},
+				},
+			},
+		},
+		{
+			name:           "Dry run",
+			file:           header + "User1,user1@example.com,Password@1\n",
+			dryRun:         true,
+			expectedEmails: []string{"user1@example.com"},
+			expectedOutput: &dto.UserImportOutputDTO{
+				DryRun: true,
+				Valid:  1,
+				Rows: []*dto.UserImportRowDTO{
+					{Row: 2, Email: "user1@example.com", Status: domain.UserImportRowValid},
+				},
+			},
+		},
+		{
+			name:            "Skipped and failed rows",
+			file:            header + "User1,user1@example.com,Password@1\nUser2,USER2@example.com,Password@2\nUser3,user1@example.com,Password@3\nUser4,not an email,Password@4\nUser5,user5@example.com\nUser6,user6@example.com,short\n",
+			expectedEmails:  []string{"user1@example.com", "USER2@example.com", "user1@example.com", "not an email", "user6@example.com"},
+			mockTakenEmails: []string{"user2@example.com"},
+			expectedCreated: []string{"user1@example.com"},
+			expectedOutput: &dto.UserImportOutputDTO{
+				Created: 1,
+				Skipped: 2,
+				Failed:  3,
+				Rows: []*dto.UserImportRowDTO{
+					{Row: 2, Email: "user1@example.com", Status: domain.UserImportRowCreated, UserID: 10},
+					{Row: 3, Email: "USER2@example.com", Status: domain.UserImportRowSkipped, Reason: domain.ErrUserEmailTaken.Error()},
+					{Row: 4, Email: "user1@example.com", Status: domain.UserImportRowSkipped, Reason: domain.ErrUserImportDuplicateRow.Error()},
+					{Row: 5, Email: "not an email", Status: domain.UserImportRowFailed, Reason: "email: " + domain.ErrUserEmailRequired.Error()},
+					{Row: 6, Status: domain.UserImportRowFailed, Reason: "wrong number of fields"},
+					{Row: 7, Email: "user6@example.com", Status: domain.UserImportRowFailed, Reason: "<password>"},
+				},
+			},
+		},
+		{
+			name:            "Email taken while importing",
+			file:            header + "User1,user1@example.com,Password@1\n",
+			expectedEmails:  []string{"user1@example.com"},
+			expectedCreated: []string{"user1@example.com"},
+			mockCreateError: gorm.ErrDuplicatedKey,
+			expectedError:   domain.ErrUserEmailTaken,
+		},
+		{
+			name:            "Create users error",
+			file:            header + "User1,user1@example.com,Password@1\n",
+			expectedEmails:  []string{"user1@example.com"},
+			expectedCreated: []string{"user1@example.com"},
+			mockCreateError: gorm.ErrInvalidDB,
+			expectedError:   gorm.ErrInvalidDB,
+		},
+		{
+			name:          "Empty file",
+			file:          "",
+			expectedError: domain.ErrUserImportEmpty,
+		},
+		{
+			name:          "Header only",
+			file:          header,
+			expectedError: domain.ErrUserImportEmpty,
+		},
+		{
+			name:          "Missing column",
+			file:          "Name,Email\nUser1,user1@example.com\n",
+			expectedError: domain.ErrUserImportHeaderInvalid,
+		},
+		{
+			name:          "Malformed file",
+			file:          header + "User1,\"user1@example.com,Password@1\n",
+			expectedError: domain.ErrUserImportMalformed,
+		},
+		{
+			name:          "Too many rows",
+			file:          header + strings.Repeat("User1,user1@example.com,Password@1\n", domain.UserImportMaxRows+1),
+			expectedError: domain.ErrUserImportTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockUserRepository.Calls = nil
+			mockEmailVerificationUseCase.ExpectedCalls = nil
+			mockEmailVerificationUseCase.Calls = nil
+
+			if tc.expectedEmails != nil {
+				mockUserRepository.On("FindTakenEmails", mock.Anything, tc.expectedEmails).Return(tc.mockTakenEmails, nil)
+			}
+			if tc.expectedCreated != nil {
+				mockUserRepository.On("ForTenant", importer.OrganizationID)
+				mockUserRepository.On("CreateUsers", mock.Anything, mock.MatchedBy(func(us []*domain.User) bool {
+					if len(us) != len(tc.expectedCreated) {
+						return false
+					}
+					for i, u := range us {
+						if u.Email != tc.expectedCreated[i] || u.OrganizationID != importer.OrganizationID || u.Role != domain.RoleUser || strings.HasPrefix(u.Password, "Password@") {
+							return false
+						}
+					}
+					return true
+				}), domain.UserImportBatchSize).Run(func(args mock.Arguments) {
+					for i, u := range args.Get(1).([]*domain.User) {
+						u.ID = uint(10 + i)
+					}
+				}).Return(tc.mockCreateError)
+				if tc.mockCreateError == nil {
+					mockEmailVerificationUseCase.On("SendVerificationEmail", mock.Anything, mock.Anything).Return(nil).Times(len(tc.expectedCreated))
+				}
+			}
+
+			userUseCase := NewUserUseCase(mockUserRepository, mockEmailVerificationUseCase, new(mockEmailChangeUseCase), new(mockSessionUseCase), testPasswordHasher, testPasswordPolicy, 5)
+
+			output, err := userUseCase.ImportUsers(context.Background(), importer, strings.NewReader(tc.file), tc.dryRun)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError, "Expected ImportUsers error to match.")
+				assert.Nil(t, output, "Expected import report to be nil")
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				if assert.Len(t, output.Rows, len(tc.expectedOutput.Rows)) {
+					// The policy reason depends on the policy, only its
+					// presence is checked.
+					for i, row := range tc.expectedOutput.Rows {
+						if row.Reason == "<password>" {
+							assert.NotEmpty(t, output.Rows[i].Reason, "Expected a password policy reason.")
+							row.Reason = output.Rows[i].Reason
+						}
+					}
+				}
+				assert.Equal(t, tc.expectedOutput, output, "Expected ImportUsers output to match.")
+			}
+			mockUserRepository.AssertExpectations(t)
+			mockEmailVerificationUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportUsersFindTakenEmailsError(t *testing.T) {
+	mockUserRepository := new(mockUserRepository)
+	mockUserRepository.On("FindTakenEmails", mock.Anything, []string{"user1@example.com"}).Return([]string(nil), gorm.ErrInvalidDB)
+
+	userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), new(mockSessionUseCase), testPasswordHasher, testPasswordPolicy, 5)
+
+	output, err := userUseCase.ImportUsers(context.Background(), &domain.User{OrganizationID: 2}, strings.NewReader("name,email,password\nUser1,user1@example.com,Password@1\n"), false)
+
+	assert.True(t, errors.Is(err, gorm.ErrInvalidDB), "Expected the repository error.")
+	assert.Nil(t, output)
+	mockUserRepository.AssertExpectations(t)
+}
+
+// deadlinePasswordHasher only returns once ctx is done, as if every hash
+// outlasted the deadline of the request.
+type deadlinePasswordHasher struct {
+	util.PasswordHasher
+	ctx   context.Context
+	calls atomic.Int32
+}
+
+func (h *deadlinePasswordHasher) Hash(password string) (string, error) {
+	h.calls.Add(1)
+	<-h.ctx.Done()
+	return h.PasswordHasher.Hash(password)
+}
+
+func TestImportUsersPastDeadline(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	rows := 20
+	file := strings.Builder{}
+	file.WriteString("name,email,password\n")
+	emails := []string{}
+	for i := 0; i < rows; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		fmt.Fprintf(&file, "User%d,%s,Password@1\n", i, email)
+		emails = append(emails, email)
+	}
+
+	mockUserRepository := new(mockUserRepository)
+	mockUserRepository.On("FindTakenEmails", mock.Anything, emails).Return([]string{}, nil)
+	hasher := &deadlinePasswordHasher{PasswordHasher: testPasswordHasher, ctx: ctx}
+
+	userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), new(mockSessionUseCase), hasher, testPasswordPolicy, 5)
+
+	output, err := userUseCase.ImportUsers(ctx, &domain.User{OrganizationID: 2}, strings.NewReader(file.String()), false)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Expected the import to stop at the deadline.")
+	assert.Nil(t, output)
+	assert.LessOrEqual(t, int(hasher.calls.Load()), userImportHashWorkers, "Expected no password to be hashed past the deadline.")
+	mockUserRepository.AssertNotCalled(t, "CreateUsers", mock.Anything, mock.Anything, mock.Anything)
+	mockUserRepository.AssertExpectations(t)
+}
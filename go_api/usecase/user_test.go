@@ -1,7 +1,9 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"github.com/Daffc/GO-Sales/repository"
 	"testing"
 	"time"
 
@@ -9,13 +11,23 @@ import (
 	"github.com/Daffc/GO-Sales/domain/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var testPasswordPolicy = &domain.PasswordPolicy{
+	MinLength:          6,
+	MaxLength:          64,
+	RequireLowercase:   true,
+	RequireUppercase:   true,
+	RequireDigit:       true,
+	RequireSpecial:     true,
+	ForbidPersonalInfo: true,
+}
+
 func TestCreateUser(t *testing.T) {
 
 	mockUserRepository := new(mockUserRepository)
+	mockEmailVerificationUseCase := new(mockEmailVerificationUseCase)
 
 	testCases := []struct {
 		name                     string
@@ -23,6 +35,7 @@ func TestCreateUser(t *testing.T) {
 		mockUserRepositoryInput  *domain.User
 		mockUserRepositoryReturn *domain.User
 		mockUserRepositoryError  error
+		mockSendVerificationErr  error
 		expectedOutput           interface{}
 		expectedError            interface{}
 	}{
@@ -69,7 +82,22 @@ func TestCreateUser(t *testing.T) {
 			mockUserRepositoryReturn: nil,
 			mockUserRepositoryError:  nil,
 			expectedOutput:           nil,
-			expectedError:            errors.New("invalid user name"),
+			expectedError: &domain.ValidationError{Violations: []domain.FieldViolation{
+				{Field: "name", Err: domain.ErrUserNameRequired},
+			}},
+		},
+		{
+			name: "Every field invalid",
+			input: &dto.UserInputDTO{
+				Name:     "",
+				Email:    "user1",
+				Password: "password@1",
+			},
+			expectedError: &domain.ValidationError{Violations: []domain.FieldViolation{
+				{Field: "name", Err: domain.ErrUserNameRequired},
+				{Field: "email", Err: domain.ErrUserEmailRequired},
+				{Field: "password", Err: domain.ErrUserPasswordFormatUpperCase},
+			}},
 		},
 		{
 			name: "Invalid user data error",
@@ -91,21 +119,68 @@ func TestCreateUser(t *testing.T) {
 			expectedOutput:           nil,
 			expectedError:            gorm.ErrInvalidData,
 		},
+		{
+			name: "Email taken",
+			input: &dto.UserInputDTO{
+				Name:     "User1",
+				Email:    "user1@example.com",
+				Password: "Password@1",
+			},
+			mockUserRepositoryInput: &domain.User{
+				Name:     "User1",
+				Email:    "user1@example.com",
+				Password: "Password@1",
+			},
+			mockUserRepositoryError: gorm.ErrDuplicatedKey,
+			expectedError:           domain.ErrUserEmailTaken,
+		},
+		{
+			name: "Verification email failure",
+			input: &dto.UserInputDTO{
+				ID:       0,
+				Name:     "User1",
+				Email:    "user1@example.com",
+				Password: "Password@1",
+			},
+			mockUserRepositoryInput: &domain.User{
+				Name:     "User1",
+				Email:    "user1@example.com",
+				Password: "Password@1",
+			},
+			mockUserRepositoryReturn: &domain.User{
+				ID:    1,
+				Name:  "User1",
+				Email: "user1@example.com",
+			},
+			mockUserRepositoryError: nil,
+			mockSendVerificationErr: errors.New("smtp unavailable"),
+			expectedOutput: &dto.UserOutputDTO{
+				ID:    1,
+				Name:  "User1",
+				Email: "user1@example.com",
+			},
+			expectedError: nil,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserRepository.ExpectedCalls = nil
 
-			mockUserRepository.On("CreateUser", mock.MatchedBy(func(u *domain.User) bool {
-				return u.Name == tc.mockUserRepositoryInput.Name &&
+			mockUserRepository.On("ForTenant", uint(domain.DefaultOrganizationID))
+			mockUserRepository.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+				return u.OrganizationID == domain.DefaultOrganizationID &&
+					u.Name == tc.mockUserRepositoryInput.Name &&
 					u.Email == tc.mockUserRepositoryInput.Email &&
-					bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(tc.mockUserRepositoryInput.Password)) == nil
+					verifyTestPassword(tc.mockUserRepositoryInput.Password, u.Password)
 			})).Return(tc.mockUserRepositoryReturn, tc.mockUserRepositoryError)
 
-			userUseCase := NewUserUseCase(mockUserRepository)
+			mockEmailVerificationUseCase.ExpectedCalls = nil
+			mockEmailVerificationUseCase.On("SendVerificationEmail", mock.Anything, tc.mockUserRepositoryReturn).Return(tc.mockSendVerificationErr)
+
+			userUseCase := NewUserUseCase(mockUserRepository, mockEmailVerificationUseCase, new(mockEmailChangeUseCase), new(mockSessionUseCase), testPasswordHasher, testPasswordPolicy, 5)
 
-			uo, err := userUseCase.CreateUser(tc.input)
+			uo, err := userUseCase.CreateUser(context.Background(), tc.input)
 
 			if tc.expectedError != nil {
 				assert.Error(t, err, "Expected error")
@@ -121,85 +196,250 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func TestCreateUserPasswordPolicy(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	mockEmailVerificationUseCase := new(mockEmailVerificationUseCase)
+	mockBreachedPasswordChecker := new(mockBreachedPasswordChecker)
+
+	policy := &domain.PasswordPolicy{
+		MinLength:          8,
+		MaxLength:          16,
+		RequireLowercase:   true,
+		RequireUppercase:   true,
+		RequireDigit:       true,
+		RequireSpecial:     true,
+		ForbidPersonalInfo: true,
+		BreachedPasswords:  mockBreachedPasswordChecker,
+	}
+
+	testCases := []struct {
+		name               string
+		password           string
+		breached           bool
+		expectedViolations []error
+	}{
+		{
+			name:     "Only digit is zero",
+			password: "Passw0rd!",
+		},
+		{
+			name:               "Every class missing",
+			password:           "        ",
+			expectedViolations: []error{domain.ErrUserPasswordFormatLowCase, domain.ErrUserPasswordFormatUpperCase, domain.ErrUserPasswordFormatNumber, domain.ErrUserPasswordFormatSpecialCharacter},
+		},
+		{
+			name:               "Too short and too simple",
+			password:           "abc",
+			expectedViolations: []error{domain.ErrUserPasswordTooShort, domain.ErrUserPasswordFormatUpperCase, domain.ErrUserPasswordFormatNumber, domain.ErrUserPasswordFormatSpecialCharacter},
+		},
+		{
+			name:               "Too long",
+			password:           "Password@1Password@1",
+			expectedViolations: []error{domain.ErrUserPasswordTooLong},
+		},
+		{
+			name:               "Contains name",
+			password:           "Mariana@2024",
+			expectedViolations: []error{domain.ErrUserPasswordPersonalInfo},
+		},
+		{
+			name:               "Contains email",
+			password:           "Msouza#99",
+			expectedViolations: []error{domain.ErrUserPasswordPersonalInfo},
+		},
+		{
+			name:               "Breached",
+			password:           "Qwerty@123",
+			breached:           true,
+			expectedViolations: []error{domain.ErrUserPasswordBreached},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockEmailVerificationUseCase.ExpectedCalls = nil
+			mockBreachedPasswordChecker.ExpectedCalls = nil
+
+			input := &dto.UserInputDTO{Name: "Mariana Lima", Email: "m.souza@example.com", Password: tc.password}
+			created := &domain.User{ID: 1, Name: input.Name, Email: input.Email}
+
+			mockBreachedPasswordChecker.On("IsBreached", tc.password).Return(tc.breached, nil)
+			if tc.expectedViolations == nil {
+				mockUserRepository.On("ForTenant", uint(domain.DefaultOrganizationID))
+				mockUserRepository.On("CreateUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(created, nil)
+				mockEmailVerificationUseCase.On("SendVerificationEmail", mock.Anything, created).Return(nil)
+			}
+
+			userUseCase := NewUserUseCase(mockUserRepository, mockEmailVerificationUseCase, new(mockEmailChangeUseCase), new(mockSessionUseCase), testPasswordHasher, policy, 5)
+
+			uo, err := userUseCase.CreateUser(context.Background(), input)
+
+			if tc.expectedViolations != nil {
+				var validationErr *domain.ValidationError
+				assert.ErrorAs(t, err, &validationErr, "Expected a validation error")
+				assert.Nil(t, uo, "Expected User to be nil")
+				assert.Len(t, validationErr.Violations, len(tc.expectedViolations), "Expected every unmet rule to be reported.")
+				for _, v := range validationErr.Violations {
+					assert.Equal(t, "password", v.Field, "Expected the violation to be on the password.")
+				}
+				for _, v := range tc.expectedViolations {
+					assert.ErrorIs(t, err, v, "Expected violation to be reported.")
+				}
+			} else {
+				assert.NoError(t, err, "Did not expect an error but got one")
+				assert.Equal(t, created.ID, uo.ID, "Expected CreateUser output to match.")
+			}
+
+			mockUserRepository.AssertExpectations(t)
+			mockBreachedPasswordChecker.AssertExpectations(t)
+		})
+	}
+}
+
 func TestListUsers(t *testing.T) {
 
 	mockUserRepository := new(mockUserRepository)
 
+	users := []*domain.User{
+		&domain.User{
+			ID:        1,
+			Name:      "User1",
+			Email:     "user1@example.com",
+			Password:  "Password@1",
+			CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		&domain.User{
+			ID:        2,
+			Name:      "User2",
+			Email:     "user2@example.com",
+			Password:  "Password@2",
+			CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	outputs := []*dto.UserOutputDTO{
+		&dto.UserOutputDTO{
+			ID:        1,
+			Name:      "User1",
+			Email:     "user1@example.com",
+			CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		&dto.UserOutputDTO{
+			ID:        2,
+			Name:      "User2",
+			Email:     "user2@example.com",
+			CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	nameCursor := domain.NewUserCursor(users[0], domain.UserSortName, true)
+
 	testCases := []struct {
 		name                     string
+		input                    *dto.ListUsersInputDTO
+		expectedQuery            *domain.UserListQuery
 		mockUserRepositoryReturn []*domain.User
 		mockUserRepositoryError  error
-		expectedOutput           interface{}
-		expectedError            interface{}
+		expectedOutput           *dto.UserListOutputDTO
+		expectedError            error
 	}{
 		{
-			name: "Success",
-			mockUserRepositoryReturn: []*domain.User{
-				&domain.User{
-					ID:        1,
-					Name:      "User1",
-					Email:     "user1@example.com",
-					Password:  "Password@1",
-					CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
-					UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
-				},
-				&domain.User{
-					ID:        2,
-					Name:      "User2",
-					Email:     "user2@example.com",
-					Password:  "Password@2",
-					CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
-					UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
-				},
-			},
-			mockUserRepositoryError: nil,
-			expectedOutput: []*dto.UserOutputDTO{
-				&dto.UserOutputDTO{
-					ID:        1,
-					Name:      "User1",
-					Email:     "user1@example.com",
-					CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
-					UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
-				},
-				&dto.UserOutputDTO{
-					ID:        2,
-					Name:      "User2",
-					Email:     "user2@example.com",
-					CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
-					UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC),
-				},
-			},
-			expectedError: nil,
+			name:                     "Success",
+			input:                    &dto.ListUsersInputDTO{},
+			expectedQuery:            &domain.UserListQuery{Sort: domain.UserSortID, Limit: domain.UserListDefaultLimit + 1},
+			mockUserRepositoryReturn: users,
+			expectedOutput:           &dto.UserListOutputDTO{Data: outputs},
 		},
 		{
-			name:                     "Recover users error",
-			mockUserRepositoryReturn: nil,
-			mockUserRepositoryError:  gorm.ErrInvalidDB,
-			expectedOutput:           nil,
-			expectedError:            gorm.ErrInvalidDB,
+			name:                     "Next page",
+			input:                    &dto.ListUsersInputDTO{Limit: 1, Name: "User", Email: " user1@example.com ", Status: domain.UserStatusActive, Sort: "-name"},
+			expectedQuery:            &domain.UserListQuery{NameContains: "User", Email: "user1@example.com", Status: domain.UserStatusActive, Sort: domain.UserSortName, Desc: true, Limit: 2},
+			mockUserRepositoryReturn: users,
+			expectedOutput:           &dto.UserListOutputDTO{Data: outputs[:1], NextCursor: nameCursor.Encode()},
+		},
+		{
+			name:                     "After cursor",
+			input:                    &dto.ListUsersInputDTO{Limit: 1, Sort: "-name", Cursor: nameCursor.Encode()},
+			expectedQuery:            &domain.UserListQuery{Sort: domain.UserSortName, Desc: true, Limit: 2, After: nameCursor},
+			mockUserRepositoryReturn: users[1:],
+			expectedOutput:           &dto.UserListOutputDTO{Data: outputs[1:]},
+		},
+		{
+			name:          "Cursor of another sort",
+			input:         &dto.ListUsersInputDTO{Sort: "email", Cursor: nameCursor.Encode()},
+			expectedError: domain.ErrUserListCursorInvalid,
+		},
+		{
+			name:          "Malformed cursor",
+			input:         &dto.ListUsersInputDTO{Cursor: "not a cursor"},
+			expectedError: domain.ErrUserListCursorInvalid,
+		},
+		{
+			name:          "Invalid sort",
+			input:         &dto.ListUsersInputDTO{Sort: "password"},
+			expectedError: domain.ErrUserListSortInvalid,
+		},
+		{
+			name:          "Limit too large",
+			input:         &dto.ListUsersInputDTO{Limit: domain.UserListMaxLimit + 1},
+			expectedError: domain.ErrUserListLimitInvalid,
+		},
+		{
+			name:          "Invalid status",
+			input:         &dto.ListUsersInputDTO{Status: domain.UserStatusDeleted},
+			expectedError: domain.ErrUserStatusInvalid,
+		},
+		{
+			name:                    "Recover users error",
+			input:                   &dto.ListUsersInputDTO{},
+			expectedQuery:           &domain.UserListQuery{Sort: domain.UserSortID, Limit: domain.UserListDefaultLimit + 1},
+			mockUserRepositoryError: gorm.ErrInvalidDB,
+			expectedError:           gorm.ErrInvalidDB,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserRepository.ExpectedCalls = nil
+			mockUserRepository.Calls = nil
 
-			mockUserRepository.On("ListUsers").Return(tc.mockUserRepositoryReturn, tc.mockUserRepositoryError)
+			if tc.expectedQuery != nil {
+				mockUserRepository.On("ForTenant", uint(2))
+				mockUserRepository.On("ListUsers", mock.Anything, tc.expectedQuery).Return(tc.mockUserRepositoryReturn, tc.mockUserRepositoryError)
+			}
 
-			userUseCase := NewUserUseCase(mockUserRepository)
+			userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), new(mockSessionUseCase), testPasswordHasher, testPasswordPolicy, 5)
 
-			ulo, err := userUseCase.ListUsers()
+			ulo, err := userUseCase.ListUsers(context.Background(), 2, tc.input)
 
 			if tc.expectedError != nil {
-				assert.Error(t, err, "Expected error")
-				assert.Empty(t, ulo, "Expected Users List to be nil")
-				assert.Equal(t, err, tc.expectedError, "Expected ListUsers error to match.")
-
+				assert.ErrorIs(t, err, tc.expectedError, "Expected ListUsers error to match.")
+				assert.Nil(t, ulo, "Expected Users List to be nil")
 			} else {
 				assert.NoError(t, err, "Did not expect an error but got one")
-				assert.NotEmpty(t, ulo, "Expected Users list not to be nil")
-				assert.Equal(t, ulo, tc.expectedOutput, "Expected ListUsers output to match.")
+				assert.Equal(t, tc.expectedOutput, ulo, "Expected ListUsers output to match.")
 			}
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserCursor(t *testing.T) {
+
+	createdAt := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+	user := &domain.User{ID: 7, Name: "User7", Email: "user7@example.com", CreatedAt: createdAt}
+
+	for _, sort := range domain.UserSortFields {
+		t.Run(sort, func(t *testing.T) {
+			cursor := domain.NewUserCursor(user, sort, true)
+
+			decoded, err := domain.DecodeUserCursor(cursor.Encode())
+			assert.NoError(t, err, "Expected the cursor to decode")
+			assert.Equal(t, cursor, decoded, "Expected the cursor to round trip")
 		})
 	}
 }
@@ -246,7 +486,7 @@ func TestFindUserById(t *testing.T) {
 			mockUserRepositoryReturn: nil,
 			mockUserRepositoryError:  gorm.ErrRecordNotFound,
 			expectedOutput:           nil,
-			expectedError:            gorm.ErrRecordNotFound,
+			expectedError:            domain.ErrUserNotFound,
 		},
 	}
 
@@ -254,11 +494,12 @@ func TestFindUserById(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserRepository.ExpectedCalls = nil
 
-			mockUserRepository.On("FindUserById", tc.mockUserRepositoryInput).Return(tc.mockUserRepositoryReturn, tc.mockUserRepositoryError)
+			mockUserRepository.On("ForTenant", uint(2))
+			mockUserRepository.On("FindUserById", mock.Anything, tc.mockUserRepositoryInput).Return(tc.mockUserRepositoryReturn, tc.mockUserRepositoryError)
 
-			userUseCase := NewUserUseCase(mockUserRepository)
+			userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), new(mockSessionUseCase), testPasswordHasher, testPasswordPolicy, 5)
 
-			uo, err := userUseCase.FindUserById(tc.mockUserRepositoryInput)
+			uo, err := userUseCase.FindUserById(context.Background(), 2, tc.mockUserRepositoryInput)
 
 			if tc.expectedError != nil {
 				assert.Error(t, err, "Expected error")
@@ -273,3 +514,442 @@ func TestFindUserById(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateUserPassword(t *testing.T) {
+
+	hash := func(password string) string {
+		h, err := testPasswordHasher.Hash(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	user := &domain.User{ID: 1, OrganizationID: 2, Email: "user1@example.com", Password: hash("Password@1")}
+	history := []*domain.PasswordHistory{
+		{ID: 2, UserID: 1, Password: hash("Password@2")},
+		{ID: 1, UserID: 1, Password: hash("Password@3")},
+	}
+
+	mockUserRepository := new(mockUserRepository)
+	mockSessionUseCase := new(mockSessionUseCase)
+
+	testCases := []struct {
+		name          string
+		input         *dto.UpdateUserPasswordInputDTO
+		expectHistory bool
+		expectUpdate  bool
+		expectedError error
+	}{
+		{
+			name:          "Success",
+			input:         &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@1", NewPassword: "Password@4"},
+			expectHistory: true,
+			expectUpdate:  true,
+		},
+		{
+			name:          "Wrong current password",
+			input:         &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@0", NewPassword: "Password@4"},
+			expectedError: domain.ErrUserPasswordIncorrect,
+		},
+		{
+			name:  "Weak password",
+			input: &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@1", NewPassword: "password"},
+			expectedError: &domain.ValidationError{Violations: []domain.FieldViolation{
+				{Field: "new_password", Err: domain.ErrUserPasswordFormatUpperCase},
+				{Field: "new_password", Err: domain.ErrUserPasswordFormatNumber},
+				{Field: "new_password", Err: domain.ErrUserPasswordFormatSpecialCharacter},
+			}},
+		},
+		{
+			name:          "Current password reused",
+			input:         &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@1", NewPassword: "Password@1"},
+			expectHistory: true,
+			expectedError: &domain.ValidationError{Violations: []domain.FieldViolation{{Field: "new_password", Err: domain.ErrUserPasswordReused}}},
+		},
+		{
+			name:          "Previous password reused",
+			input:         &dto.UpdateUserPasswordInputDTO{CurrentPassword: "Password@1", NewPassword: "Password@3"},
+			expectHistory: true,
+			expectedError: &domain.ValidationError{Violations: []domain.FieldViolation{{Field: "new_password", Err: domain.ErrUserPasswordReused}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockSessionUseCase.ExpectedCalls = nil
+
+			mockUserRepository.On("FindUserById", mock.Anything, user.ID).Return(user, nil)
+			mockUserRepository.On("ForTenant", user.OrganizationID)
+			if tc.expectHistory {
+				mockUserRepository.On("ListPasswordHistory", mock.Anything, user.ID, 2).Return(history, nil)
+			}
+			if tc.expectUpdate {
+				mockUserRepository.On("UpdateUserPassword", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+					return u.ID == user.ID && verifyTestPassword(tc.input.NewPassword, u.Password)
+				})).Return(nil)
+				mockSessionUseCase.On("RevokeOtherSessions", mock.Anything, user.ID, uint(7)).Return(nil)
+			}
+
+			userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), mockSessionUseCase, testPasswordHasher, testPasswordPolicy, 3)
+
+			err := userUseCase.UpdateUserPassword(context.Background(), user.ID, 7, tc.input)
+
+			assert.Equal(t, tc.expectedError, err, "Expected UpdateUserPassword error to match.")
+
+			mockUserRepository.AssertExpectations(t)
+			mockSessionUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	mockEmailChangeUseCase := new(mockEmailChangeUseCase)
+
+	name := " User2 "
+	emptyName := ""
+	newEmail := "new@example.com"
+	sameEmail := "user1@example.com"
+
+	testCases := []struct {
+		name                string
+		input               *dto.UpdateUserInputDTO
+		mockFindError       error
+		expectProfileUpdate bool
+		expectEmailCheck    bool
+		mockCheckError      error
+		expectEmailChange   bool
+		mockEmailError      error
+		expectedOutput      *dto.UpdateUserOutputDTO
+		expectedError       error
+	}{
+		{
+			name:                "Name",
+			input:               &dto.UpdateUserInputDTO{Name: &name},
+			expectProfileUpdate: true,
+			expectedOutput: &dto.UpdateUserOutputDTO{
+				UserOutputDTO: dto.UserOutputDTO{ID: 1, OrganizationID: 2, Name: "User2", Email: "user1@example.com"},
+			},
+		},
+		{
+			name:              "Email",
+			input:             &dto.UpdateUserInputDTO{Email: &newEmail},
+			expectEmailCheck:  true,
+			expectEmailChange: true,
+			expectedOutput: &dto.UpdateUserOutputDTO{
+				UserOutputDTO: dto.UserOutputDTO{ID: 1, OrganizationID: 2, Name: "User1", Email: "user1@example.com"},
+				PendingEmail:  "new@example.com",
+			},
+		},
+		{
+			name:  "Same email",
+			input: &dto.UpdateUserInputDTO{Email: &sameEmail},
+			expectedOutput: &dto.UpdateUserOutputDTO{
+				UserOutputDTO: dto.UserOutputDTO{ID: 1, OrganizationID: 2, Name: "User1", Email: "user1@example.com"},
+			},
+		},
+		{
+			name:          "Empty name",
+			input:         &dto.UpdateUserInputDTO{Name: &emptyName},
+			expectedError: domain.ErrUserNameRequired,
+		},
+		{
+			name:             "Email taken",
+			input:            &dto.UpdateUserInputDTO{Email: &newEmail},
+			expectEmailCheck: true,
+			mockCheckError:   domain.ErrUserEmailTaken,
+			expectedError:    domain.ErrUserEmailTaken,
+		},
+		{
+			name:             "Name with a taken email",
+			input:            &dto.UpdateUserInputDTO{Name: &name, Email: &newEmail},
+			expectEmailCheck: true,
+			mockCheckError:   domain.ErrUserEmailTaken,
+			expectedError:    domain.ErrUserEmailTaken,
+		},
+		{
+			name:             "Name with an invalid email",
+			input:            &dto.UpdateUserInputDTO{Name: &name, Email: &newEmail},
+			expectEmailCheck: true,
+			mockCheckError:   domain.ErrUserEmailRequired,
+			expectedError:    domain.ErrUserEmailRequired,
+		},
+		{
+			name:                "Name and email",
+			input:               &dto.UpdateUserInputDTO{Name: &name, Email: &newEmail},
+			expectProfileUpdate: true,
+			expectEmailCheck:    true,
+			expectEmailChange:   true,
+			expectedOutput: &dto.UpdateUserOutputDTO{
+				UserOutputDTO: dto.UserOutputDTO{ID: 1, OrganizationID: 2, Name: "User2", Email: "user1@example.com"},
+				PendingEmail:  "new@example.com",
+			},
+		},
+		{
+			name:          "User of another organization",
+			input:         &dto.UpdateUserInputDTO{Name: &name},
+			mockFindError: gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockUserRepository.Calls = nil
+			mockEmailChangeUseCase.ExpectedCalls = nil
+
+			user := &domain.User{ID: 1, OrganizationID: 2, Name: "User1", Email: "user1@example.com"}
+
+			mockUserRepository.On("ForTenant", uint(2))
+			mockUserRepository.On("FindUserById", mock.Anything, uint(1)).Return(user, tc.mockFindError)
+			if tc.expectProfileUpdate {
+				mockUserRepository.On("UpdateUserProfile", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+					return u.ID == 1 && u.Name == "User2"
+				})).Return(nil)
+			}
+			if tc.expectEmailCheck {
+				mockEmailChangeUseCase.On("CheckNewEmail", mock.Anything, newEmail).Return(newEmail, tc.mockCheckError)
+			}
+			if tc.expectEmailChange {
+				mockEmailChangeUseCase.On("RequestEmailChange", mock.Anything, user, newEmail).Return(tc.mockEmailError)
+			}
+
+			userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), mockEmailChangeUseCase, new(mockSessionUseCase), testPasswordHasher, testPasswordPolicy, 5)
+
+			output, err := userUseCase.UpdateUser(context.Background(), 2, 1, tc.input)
+
+			assert.Equal(t, tc.expectedError, err, "Expected UpdateUser error to match.")
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.expectedOutput, output, "Expected UpdateUser output to match.")
+			}
+			if !tc.expectProfileUpdate {
+				mockUserRepository.AssertNotCalled(t, "UpdateUserProfile", mock.Anything, mock.Anything)
+			}
+
+			mockUserRepository.AssertExpectations(t)
+			mockEmailChangeUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	mockSessionUseCase := new(mockSessionUseCase)
+
+	testCases := []struct {
+		name          string
+		status        string
+		mockFindError error
+		expectUpdate  bool
+		expectRevoke  bool
+		expectedError error
+	}{
+		{
+			name:         "Suspend",
+			status:       domain.UserStatusSuspended,
+			expectUpdate: true,
+			expectRevoke: true,
+		},
+		{
+			name:         "Reactivate",
+			status:       domain.UserStatusActive,
+			expectUpdate: true,
+		},
+		{
+			name:          "Deleted is not a status to set",
+			status:        domain.UserStatusDeleted,
+			expectedError: domain.ErrUserStatusInvalid,
+		},
+		{
+			name:          "User not found",
+			status:        domain.UserStatusSuspended,
+			mockFindError: gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockSessionUseCase.ExpectedCalls = nil
+			mockSessionUseCase.Calls = nil
+
+			user := &domain.User{ID: 1, OrganizationID: 2, Name: "User1", Status: domain.UserStatusActive}
+
+			mockUserRepository.On("ForTenant", uint(2))
+			mockUserRepository.On("FindUserById", mock.Anything, uint(1)).Return(user, tc.mockFindError)
+			if tc.expectUpdate {
+				mockUserRepository.On("UpdateUserStatus", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+					return u.ID == 1 && u.Status == tc.status
+				})).Return(nil)
+			}
+			if tc.expectRevoke {
+				mockSessionUseCase.On("RevokeImpersonationSessions", mock.Anything, uint(1)).Return(nil)
+			}
+
+			userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), mockSessionUseCase, testPasswordHasher, testPasswordPolicy, 5)
+
+			output, err := userUseCase.UpdateUserStatus(context.Background(), 2, 1, &dto.UpdateUserStatusInputDTO{Status: tc.status})
+
+			assert.Equal(t, tc.expectedError, err, "Expected UpdateUserStatus error to match.")
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.status, output.Status, "Expected status to match.")
+			}
+			if !tc.expectRevoke {
+				mockSessionUseCase.AssertNotCalled(t, "RevokeImpersonationSessions", mock.Anything, mock.Anything)
+			}
+
+			mockUserRepository.AssertExpectations(t)
+			mockSessionUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	mockSessionUseCase := new(mockSessionUseCase)
+
+	testCases := []struct {
+		name          string
+		mockError     error
+		expectRevoke  bool
+		expectedError error
+	}{
+		{
+			name:         "Success",
+			expectRevoke: true,
+		},
+		{
+			name:          "User not found",
+			mockError:     gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+			mockSessionUseCase.ExpectedCalls = nil
+			mockSessionUseCase.Calls = nil
+
+			mockUserRepository.On("ForTenant", uint(2))
+			mockUserRepository.On("DeleteUser", mock.Anything, uint(1)).Return(tc.mockError)
+			if tc.expectRevoke {
+				mockSessionUseCase.On("RevokeImpersonationSessions", mock.Anything, uint(1)).Return(nil)
+			}
+
+			userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), mockSessionUseCase, testPasswordHasher, testPasswordPolicy, 5)
+
+			err := userUseCase.DeleteUser(context.Background(), 2, 1)
+
+			assert.Equal(t, tc.expectedError, err, "Expected DeleteUser error to match.")
+			if !tc.expectRevoke {
+				mockSessionUseCase.AssertNotCalled(t, "RevokeImpersonationSessions", mock.Anything, mock.Anything)
+			}
+			mockUserRepository.AssertExpectations(t)
+			mockSessionUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+	restored := &domain.User{ID: 1, OrganizationID: 2, Name: "User1", Email: "user1@example.com", Status: domain.UserStatusActive}
+
+	testCases := []struct {
+		name           string
+		mockError      error
+		expectFind     bool
+		expectedOutput *dto.UserOutputDTO
+		expectedError  error
+	}{
+		{
+			name:           "Success",
+			expectFind:     true,
+			expectedOutput: &dto.UserOutputDTO{ID: 1, OrganizationID: 2, Name: "User1", Email: "user1@example.com", Status: domain.UserStatusActive},
+		},
+		{
+			name:          "Email taken by a live user",
+			mockError:     gorm.ErrDuplicatedKey,
+			expectedError: domain.ErrUserEmailTaken,
+		},
+		{
+			name:          "No deleted user",
+			mockError:     gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+		{
+			name:          "Erased user",
+			mockError:     repository.ErrUserErased,
+			expectedError: domain.ErrUserErased,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+
+			mockUserRepository.On("ForTenant", uint(2))
+			mockUserRepository.On("RestoreUser", mock.Anything, uint(1)).Return(tc.mockError)
+			if tc.expectFind {
+				mockUserRepository.On("FindUserById", mock.Anything, uint(1)).Return(restored, nil)
+			}
+
+			userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), new(mockSessionUseCase), testPasswordHasher, testPasswordPolicy, 5)
+
+			output, err := userUseCase.RestoreUser(context.Background(), 2, 1)
+
+			assert.Equal(t, tc.expectedError, err, "Expected RestoreUser error to match.")
+			assert.Equal(t, tc.expectedOutput, output, "Expected RestoreUser output to match.")
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeUser(t *testing.T) {
+
+	mockUserRepository := new(mockUserRepository)
+
+	testCases := []struct {
+		name          string
+		mockError     error
+		expectedError error
+	}{
+		{
+			name: "Success",
+		},
+		{
+			name:          "Live or missing user",
+			mockError:     gorm.ErrRecordNotFound,
+			expectedError: domain.ErrUserNotFound,
+		},
+		{
+			name:          "Erased user",
+			mockError:     repository.ErrUserErased,
+			expectedError: domain.ErrUserErased,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepository.ExpectedCalls = nil
+
+			mockUserRepository.On("ForTenant", uint(2))
+			mockUserRepository.On("PurgeUser", mock.Anything, uint(1)).Return(tc.mockError)
+
+			userUseCase := NewUserUseCase(mockUserRepository, new(mockEmailVerificationUseCase), new(mockEmailChangeUseCase), new(mockSessionUseCase), testPasswordHasher, testPasswordPolicy, 5)
+
+			err := userUseCase.PurgeUser(context.Background(), 2, 1)
+
+			assert.Equal(t, tc.expectedError, err, "Expected PurgeUser error to match.")
+			mockUserRepository.AssertExpectations(t)
+		})
+	}
+}